@@ -0,0 +1,370 @@
+package lexorank
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+)
+
+// An Allocator hands out ranks for appending to the end of many
+// independent lists (boards, tenants, ...) at once.  It remembers the
+// last rank issued for each list, so that concurrent appends to the
+// same list never collide, and each list has its own lock, so that
+// busy lists don't hold each other up.  Lists that haven't been
+// touched for a while are forgotten.
+//
+// An Allocator only knows what it has been told or has issued itself,
+// so Seed a list with its current last rank before appending to an
+// existing list.
+type Allocator struct {
+	gen    Generator
+	idle   time.Duration
+	keyTTL time.Duration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	lists     map[string]*allocList
+	lastSweep time.Time
+}
+
+type allocList struct {
+	mu   sync.Mutex
+	last *Posn
+
+	// keys remembers what was handed out for idempotency keys
+	keys     map[string]keyed
+	keySweep time.Time
+
+	// these are guarded by the Allocator's mu
+	used   time.Time
+	active int
+}
+
+// NewAllocator creates an allocator generating ranks with g, which
+// forgets lists that have been idle for longer than idle (zero means
+// never forget).
+func NewAllocator(g Generator, idle time.Duration) *Allocator {
+	return &Allocator{
+		gen:    g,
+		idle:   idle,
+		keyTTL: DefaultKeyTTL,
+		now:    time.Now,
+		lists:  make(map[string]*allocList),
+	}
+}
+
+// DefaultKeyTTL is how long an Allocator remembers idempotency keys,
+// unless told otherwise with SetKeyTTL.
+const DefaultKeyTTL = 10 * time.Minute
+
+// SetKeyTTL sets how long idempotency keys are remembered.  (A list
+// that goes idle is forgotten along with its keys, so the allocator's
+// idle timeout should be longer.)
+func (a *Allocator) SetKeyTTL(ttl time.Duration) {
+	a.mu.Lock()
+	a.keyTTL = ttl
+	a.mu.Unlock()
+}
+
+type keyed struct {
+	ranks   []Posn
+	expires time.Time
+}
+
+// Seed tells the allocator the current last rank of a list.
+func (a *Allocator) Seed(list string, last Posn) {
+	l := a.acquire(list)
+	defer a.release(l)
+
+	l.mu.Lock()
+	l.last = &last
+	l.mu.Unlock()
+}
+
+// Next returns a new rank after the last one in the list, and
+// remembers it as the new last rank.
+func (a *Allocator) Next(list string) (Posn, bool) {
+	l := a.acquire(list)
+	defer a.release(l)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	g := a.gen
+	g.list = list
+	p, ok := g.Ranks(1, l.last, nil)
+	if !ok {
+		return Posn{}, false
+	}
+	l.last = &p[0]
+	return p[0], true
+}
+
+// NextBlock returns k new ranks after the last one in the list, for
+// a batch import, and remembers the last of them as the new last
+// rank, so that ranks handed out afterwards come after the block.
+func (a *Allocator) NextBlock(list string, k int) ([]Posn, bool) {
+	l := a.acquire(list)
+	defer a.release(l)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	g := a.gen
+	g.list = list
+	p, ok := g.AllocateBlock(l.last, nil, k)
+	if !ok {
+		return nil, false
+	}
+	if k > 0 {
+		l.last = &p[k-1]
+	}
+	return p, true
+}
+
+// NextKeyed is like Next, but with an idempotency key: asking again
+// with the same key (as a retried request would) returns the same
+// rank, rather than using up another one, for as long as the key is
+// remembered.
+func (a *Allocator) NextKeyed(list, key string) (Posn, bool) {
+	p, ok := a.keyed(list, key, 1, func(g Generator, last *Posn) ([]Posn, bool) {
+		return g.Ranks(1, last, nil)
+	})
+	if !ok {
+		return Posn{}, false
+	}
+	return p[0], true
+}
+
+// NextBlockKeyed is like NextBlock, but with an idempotency key, as
+// for NextKeyed.  Reusing a key for a block of a different size
+// fails.
+func (a *Allocator) NextBlockKeyed(list, key string, k int) ([]Posn, bool) {
+	return a.keyed(list, key, k, func(g Generator, last *Posn) ([]Posn, bool) {
+		return g.AllocateBlock(last, nil, k)
+	})
+}
+
+// keyed hands out k ranks made by gen, or the ones handed out before
+// for the same key
+func (a *Allocator) keyed(list, key string, k int, gen func(Generator, *Posn) ([]Posn, bool)) ([]Posn, bool) {
+	l := a.acquire(list)
+	defer a.release(l)
+
+	a.mu.Lock()
+	ttl := a.keyTTL
+	a.mu.Unlock()
+	now := a.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.keySweep) > ttl {
+		for key, kr := range l.keys {
+			if now.After(kr.expires) {
+				delete(l.keys, key)
+			}
+		}
+		l.keySweep = now
+	}
+	if kr, ok := l.keys[key]; ok && !now.After(kr.expires) {
+		if len(kr.ranks) != k {
+			return nil, false
+		}
+		return append([]Posn(nil), kr.ranks...), true
+	}
+
+	g := a.gen
+	g.list = list
+	p, ok := gen(g, l.last)
+	if !ok {
+		return nil, false
+	}
+	if k > 0 {
+		l.last = &p[k-1]
+	}
+	if l.keys == nil {
+		l.keys = make(map[string]keyed)
+	}
+	l.keys[key] = keyed{ranks: append([]Posn(nil), p...), expires: now.Add(ttl)}
+	return p, true
+}
+
+// Last returns the last rank issued for (or seeded into) a list, if
+// the allocator knows it.
+func (a *Allocator) Last(list string) (Posn, bool) {
+	a.mu.Lock()
+	l := a.lists[list]
+	a.mu.Unlock()
+	if l == nil {
+		return Posn{}, false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.last == nil {
+		return Posn{}, false
+	}
+	return *l.last, true
+}
+
+// Forget drops what the allocator knows about a list, and reports
+// whether it did.  A list that is in use (say, by a Next that hasn't
+// returned yet) is left alone, as forgetting it then would let the
+// next rank handed out for it collide with the one being worked out.
+func (a *Allocator) Forget(list string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	l := a.lists[list]
+	if l == nil || !l.idle() {
+		return false
+	}
+	delete(a.lists, list)
+	return true
+}
+
+// Len returns the number of lists the allocator is keeping track of.
+func (a *Allocator) Len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.lists)
+}
+
+// EvictIdle forgets every list that has been idle for longer than the
+// allocator's idle timeout, and returns how many were dropped.  This
+// also happens as a matter of course as the allocator is used.
+func (a *Allocator) EvictIdle() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.sweep(a.now())
+}
+
+func (a *Allocator) acquire(list string) *allocList {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	if a.idle > 0 && now.Sub(a.lastSweep) > a.idle {
+		a.sweep(now)
+	}
+	l := a.lists[list]
+	if l == nil {
+		l = &allocList{}
+		a.lists[list] = l
+	}
+	l.used = now
+	l.active++
+	return l
+}
+
+func (a *Allocator) release(l *allocList) {
+	a.mu.Lock()
+	l.active--
+	a.mu.Unlock()
+}
+
+// sweep must be called with mu held
+func (a *Allocator) sweep(now time.Time) int {
+	a.lastSweep = now
+	if a.idle <= 0 {
+		return 0
+	}
+	n := 0
+	for id, l := range a.lists {
+		if now.Sub(l.used) > a.idle && l.idle() {
+			delete(a.lists, id)
+			n++
+		}
+	}
+	return n
+}
+
+// idle reports whether nothing is using the list, so that it can be
+// forgotten; it must be called with the Allocator's mu held
+func (l *allocList) idle() bool {
+	if l.active > 0 || !l.mu.TryLock() {
+		return false
+	}
+	l.mu.Unlock()
+	return true
+}
+
+// snapshotVersion is the first byte of a snapshot, so that the
+// encoding can change later
+const snapshotVersion = 1
+
+// Snapshot encodes the allocator's knowledge of the last rank of each
+// list, so that it can be persisted and restored after a restart
+// rather than every list having to be seeded afresh.
+func (a *Allocator) Snapshot() []byte {
+	a.mu.Lock()
+	lists := make(map[string]*allocList, len(a.lists))
+	for id, l := range a.lists {
+		lists[id] = l
+	}
+	a.mu.Unlock()
+
+	b := []byte{snapshotVersion}
+	var entries []byte
+	n := 0
+	for id, l := range lists {
+		l.mu.Lock()
+		last := l.last
+		l.mu.Unlock()
+		if last == nil {
+			continue
+		}
+		entries = appendString(entries, id)
+		entries = append(entries, last.Bucket)
+		entries = appendString(entries, last.Major)
+		entries = appendString(entries, last.MinorValue())
+		n++
+	}
+	b = binary.AppendUvarint(b, uint64(n))
+	return append(b, entries...)
+}
+
+// Restore seeds the allocator with the lists in a snapshot made by
+// Snapshot.  Lists the allocator already knows about are overwritten
+// by the ones in the snapshot, and the rest left alone.  Nothing is
+// restored if the snapshot is corrupt.
+func (a *Allocator) Restore(snapshot []byte) error {
+	if len(snapshot) == 0 || snapshot[0] != snapshotVersion {
+		return newError("not an allocator snapshot")
+	}
+	b := snapshot[1:]
+	n, k := binary.Uvarint(b)
+	if k <= 0 || n > uint64(len(b)) {
+		return newError("truncated allocator snapshot")
+	}
+	b = b[k:]
+	type seed struct {
+		list string
+		last Posn
+	}
+	seeds := make([]seed, n)
+	for i := range seeds {
+		var s seed
+		var err error
+		if s.list, b, err = readString(b); err != nil {
+			return newError("truncated allocator snapshot")
+		}
+		if len(b) == 0 {
+			return newError("truncated allocator snapshot")
+		}
+		s.last.Bucket, b = b[0], b[1:]
+		if s.last.Major, b, err = readString(b); err != nil {
+			return newError("truncated allocator snapshot")
+		}
+		if s.last.Minor, b, err = readString(b); err != nil {
+			return newError("truncated allocator snapshot")
+		}
+		seeds[i] = s
+	}
+	if len(b) != 0 {
+		return newError("junk at the end of allocator snapshot")
+	}
+	for _, s := range seeds {
+		a.Seed(s.list, s.last)
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package lexorank
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllocatorNext(t *testing.T) {
+	a := NewAllocator(Generator{}, 0)
+	a.Seed("board1", Posn{Major: "a00000"})
+
+	p, ok := a.Next("board1")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 1, p.Compare(Posn{Major: "a00000"}))
+
+	q, ok := a.Next("board1")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 1, q.Compare(p))
+
+	last, ok := a.Last("board1")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, q, last)
+
+	// an unseeded list starts from scratch
+	r, ok := a.Next("board2")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "UUUUUU", r.Major)
+}
+
+func TestAllocatorConcurrent(t *testing.T) {
+	a := NewAllocator(Generator{}, 0)
+	var mu sync.Mutex
+	seen := map[string]bool{}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			list := fmt.Sprintf("list%d", i%2)
+			for j := 0; j < 5; j++ {
+				p, ok := a.Next(list)
+				assert.Equal(t, true, ok)
+				mu.Lock()
+				key := list + " " + p.String()
+				assert.False(t, seen[key], key)
+				seen[key] = true
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 40, len(seen))
+}
+
+func TestAllocatorEviction(t *testing.T) {
+	now := time.Unix(1000, 0)
+	a := NewAllocator(Generator{}, time.Minute)
+	a.now = func() time.Time { return now }
+
+	a.Seed("old", Posn{Major: "a00000"})
+	now = now.Add(30 * time.Second)
+	a.Seed("new", Posn{Major: "a00000"})
+	assert.Equal(t, 2, a.Len())
+
+	now = now.Add(45 * time.Second)
+	assert.Equal(t, 1, a.EvictIdle())
+	_, ok := a.Last("old")
+	assert.Equal(t, false, ok)
+	_, ok = a.Last("new")
+	assert.Equal(t, true, ok)
+
+	assert.Equal(t, true, a.Forget("new"))
+	assert.Equal(t, false, a.Forget("new"))
+	assert.Equal(t, 0, a.Len())
+}
+
+func TestAllocatorEvictionInUse(t *testing.T) {
+	now := time.Unix(1000, 0)
+	a := NewAllocator(Generator{}, time.Minute)
+	a.now = func() time.Time { return now }
+	a.Seed("board", Posn{Major: "a00000"})
+
+	// a list that's been handed out, or is locked, stays put however
+	// long it's been idle
+	l := a.acquire("board")
+	now = now.Add(time.Hour)
+	assert.Equal(t, 0, a.EvictIdle())
+	assert.Equal(t, false, a.Forget("board"))
+	a.release(l)
+
+	l.mu.Lock()
+	assert.Equal(t, 0, a.EvictIdle())
+	assert.Equal(t, false, a.Forget("board"))
+	l.mu.Unlock()
+
+	assert.Equal(t, 1, a.EvictIdle())
+	assert.Equal(t, 0, a.Len())
+}
+
+func TestAllocatorSnapshot(t *testing.T) {
+	a := NewAllocator(Generator{}, 0)
+	a.Seed("board", Posn{Major: "a00000"})
+	a.Seed("tenant", Posn{Bucket: 1, Major: "zzzzzz", Minor: "U"})
+	_, ok := a.Next("board")
+	assert.Equal(t, true, ok)
+	snap := a.Snapshot()
+
+	b := NewAllocator(Generator{}, 0)
+	b.Seed("board", Posn{Major: "000000"})
+	b.Seed("other", Posn{Major: "100000"})
+	assert.NoError(t, b.Restore(snap))
+	assert.Equal(t, 3, b.Len())
+	for _, list := range []string{"board", "tenant"} {
+		want, _ := a.Last(list)
+		got, ok := b.Last(list)
+		assert.Equal(t, true, ok)
+		assert.True(t, want.Equal(got), list)
+	}
+	p, ok := b.Next("board")
+	assert.Equal(t, true, ok)
+	q, _ := a.Next("board")
+	assert.Equal(t, q, p)
+
+	for i := 0; i < len(snap); i++ {
+		assert.Error(t, b.Restore(snap[:i]))
+	}
+	assert.Error(t, b.Restore(append(snap, 0)))
+}
+
+func TestAllocatorNextBlock(t *testing.T) {
+	a := NewAllocator(Generator{}, 0)
+	a.Seed("board", Posn{Major: "a00000"})
+	block, ok := a.NextBlock("board", 100)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 100, len(block))
+	last, _ := a.Last("board")
+	assert.Equal(t, block[99], last)
+
+	p, ok := a.Next("board")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 1, p.Compare(block[99]))
+}
+
+func TestAllocatorKeyed(t *testing.T) {
+	a := NewAllocator(Generator{}, 0)
+	now := time.Unix(1000, 0)
+	a.now = func() time.Time { return now }
+	a.SetKeyTTL(time.Minute)
+
+	p1, ok := a.NextKeyed("board", "req-1")
+	assert.Equal(t, true, ok)
+	plain, _ := Generator{}.Ranks(1, nil, nil)
+	assert.Equal(t, plain[0], p1)
+
+	// a retry gets the same rank, and doesn't use up another
+	again, ok := a.NextKeyed("board", "req-1")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, p1, again)
+	last, _ := a.Last("board")
+	assert.Equal(t, p1, last)
+
+	// keys are per list
+	other, ok := a.NextKeyed("other", "req-1")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, p1, other)
+
+	p2, ok := a.NextKeyed("board", "req-2")
+	assert.Equal(t, true, ok)
+	assert.True(t, p1.Compare(p2) < 0)
+
+	// once the key expires, it's a new request
+	now = now.Add(2 * time.Minute)
+	p3, ok := a.NextKeyed("board", "req-1")
+	assert.Equal(t, true, ok)
+	assert.True(t, p2.Compare(p3) < 0)
+}
+
+func TestAllocatorBlockKeyed(t *testing.T) {
+	a := NewAllocator(Generator{}, 0)
+	b1, ok := a.NextBlockKeyed("board", "import", 3)
+	assert.Equal(t, true, ok)
+	b2, ok := a.NextBlockKeyed("board", "import", 3)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, b1, b2)
+
+	_, ok = a.NextBlockKeyed("board", "import", 4)
+	assert.Equal(t, false, ok)
+}
@@ -0,0 +1,251 @@
+package lexorank
+
+import (
+	"errors"
+	"strconv"
+)
+
+// An Alphabet is the numeral system ranks are written in: an ordered
+// set of digits, each of which sorts (bytewise) after the one before
+// it, so that comparing rank strings compares their values.
+//
+// The zero Alphabet is not usable directly; a Generator whose
+// Alphabet is the zero value uses Base62.
+type Alphabet struct {
+	digits string
+	// value of each byte as a digit, or -1 if it isn't one.  Aliases
+	// (such as lower case letters in a case-insensitive alphabet)
+	// map to the value of the digit they stand for.
+	values *[256]int8
+}
+
+var (
+	// Base62 is the default alphabet, 0-9A-Za-z
+	Base62 = mustAlphabet(orderToByte, nil)
+
+	// Base36 is the alphabet Jira uses, 0-9a-z
+	Base36 = mustAlphabet("0123456789abcdefghijklmnopqrstuvwxyz", nil)
+
+	// Crockford32 is Douglas Crockford's base32, which leaves out the
+	// easily confused I, L, O and U.  It is case-insensitive on input
+	// (and reads I and L as 1 and O as 0), and always produces upper
+	// case, which makes it pleasant when ranks end up in URLs or
+	// support tickets.
+	Crockford32 = mustAlphabet("0123456789ABCDEFGHJKMNPQRSTVWXYZ", map[byte]byte{
+		'I': '1', 'i': '1',
+		'L': '1', 'l': '1',
+		'O': '0', 'o': '0',
+	})
+
+	// Base64URL is the URL-safe base64 digit set rearranged into
+	// ascending byte order, -0-9A-Z_a-z.  It makes for denser keys,
+	// but like Base62 needs case-sensitive storage and comparison.
+	Base64URL = mustAlphabet("-0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ_abcdefghijklmnopqrstuvwxyz", nil)
+)
+
+// NewAlphabet creates a custom alphabet whose digits, in order, are
+// the bytes of chars.  They must be printable ASCII and strictly
+// ascending (hence unique), so that ranks sort the same way as
+// strings as they do as numbers.
+func NewAlphabet(chars string) (Alphabet, error) {
+	if len(chars) < 2 {
+		return Alphabet{}, newError("alphabet " + strconv.Quote(chars) + " needs at least two digits")
+	}
+	for i := 0; i < len(chars); i++ {
+		c := chars[i]
+		if c <= ' ' || c > '~' {
+			return Alphabet{}, newError("alphabet digit " + quoteByte(c) + " is not printable ASCII")
+		}
+		if i > 0 && c <= chars[i-1] {
+			if c == chars[i-1] {
+				return Alphabet{}, newError("alphabet digit " + quoteByte(c) + " is repeated")
+			}
+			return Alphabet{}, newError("alphabet digit " + quoteByte(c) + " is out of order after " + quoteByte(chars[i-1]))
+		}
+	}
+	return mustAlphabet(chars, nil), nil
+}
+
+func mustAlphabet(digits string, aliases map[byte]byte) Alphabet {
+	a := Alphabet{
+		digits: digits,
+		values: new([256]int8),
+	}
+	for i := range a.values {
+		a.values[i] = -1
+	}
+	for i := 0; i < len(digits); i++ {
+		a.values[digits[i]] = int8(i)
+	}
+	for alias, digit := range aliases {
+		a.values[alias] = a.values[digit]
+	}
+	// case-insensitive alphabets accept lower case for any upper case
+	// letter that isn't also a digit in its own right
+	if aliases != nil {
+		for c := byte('a'); c <= 'z'; c++ {
+			if a.values[c] < 0 {
+				a.values[c] = a.values[c-'a'+'A']
+			}
+		}
+	}
+	return a
+}
+
+func (a Alphabet) orDefault() Alphabet {
+	if a.values == nil {
+		return Base62
+	}
+	return a
+}
+
+// Digits returns the alphabet's digits, in ascending order.  (Like
+// the other exported methods, on the zero Alphabet it describes
+// Base62.)
+func (a Alphabet) Digits() string {
+	return a.orDefault().digits
+}
+
+// Base returns the number of digits in the alphabet.
+func (a Alphabet) Base() int {
+	return a.orDefault().base()
+}
+
+// Min returns the smallest digit, which is the lowest possible rank
+// and the digit that open-ended ranks are padded with below.
+func (a Alphabet) Min() byte {
+	return a.orDefault().min()
+}
+
+// Max returns the largest digit, which is the highest possible rank.
+func (a Alphabet) Max() byte {
+	return a.orDefault().max()
+}
+
+// MaxMultiRank returns the most ranks that can be made at once
+// between two others with the alphabet, which is as many digits as
+// there are strictly between its smallest and largest: 60 for Base62,
+// but 34 for Base36.
+func (a Alphabet) MaxMultiRank() int {
+	return a.Base() - 2
+}
+
+// Valid reports whether s is made only of the alphabet's digits (or
+// aliases for them).
+func (a Alphabet) Valid(s string) bool {
+	return a.orDefault().valid(s)
+}
+
+func (a Alphabet) base() int {
+	return len(a.digits)
+}
+
+func (a Alphabet) min() byte {
+	return a.digits[0]
+}
+
+func (a Alphabet) max() byte {
+	return a.digits[len(a.digits)-1]
+}
+
+// mid is the digit halfway through the alphabet
+func (a Alphabet) mid() byte {
+	return a.digits[(len(a.digits)-1)/2]
+}
+
+func (a Alphabet) digit(v int) byte {
+	return a.digits[v]
+}
+
+// ErrInvalidDigit is returned (wrapped) when a byte isn't a digit in
+// the alphabet being used.
+var ErrInvalidDigit = errors.New("lexorank: invalid digit")
+
+// OrderOf returns the value of b as a digit in the default (Base62)
+// alphabet.
+func OrderOf(b byte) (int, error) {
+	return Base62.OrderOf(b)
+}
+
+// OrderOf returns the value of b as a digit in the alphabet, or an
+// error wrapping ErrInvalidDigit if it isn't one.
+func (a Alphabet) OrderOf(b byte) (int, error) {
+	a = a.orDefault()
+	v := a.values[b]
+	if v < 0 {
+		return 0, invalidDigit(b, "")
+	}
+	return int(v), nil
+}
+
+// order returns the value of a digit, which the caller must already
+// have validated; it panics if b isn't one
+func (a Alphabet) order(b byte) int {
+	v := a.values[b]
+	if v < 0 {
+		panic("bad value")
+	}
+	return int(v)
+}
+
+func (a Alphabet) valid(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if a.values[s[i]] < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (a Alphabet) validBytes(s []byte) bool {
+	for _, b := range s {
+		if a.values[b] < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// compare compares two valid digit strings by value
+func (a Alphabet) compare(x, y []byte) int {
+	for i := 0; i < len(x) && i < len(y); i++ {
+		vx, vy := a.values[x[i]], a.values[y[i]]
+		if vx != vy {
+			if vx < vy {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case len(x) < len(y):
+		return -1
+	case len(x) > len(y):
+		return 1
+	}
+	return 0
+}
+
+// appendCanonical appends the valid digits s to dst, replacing any
+// aliases with the digits they stand for
+func (a Alphabet) appendCanonical(dst, s []byte) []byte {
+	for _, b := range s {
+		dst = append(dst, a.digits[a.values[b]])
+	}
+	return dst
+}
+
+// canonical rewrites s using the alphabet's own digits, so that
+// aliases compare correctly
+func (a Alphabet) canonical(s string) string {
+	for i := 0; i < len(s); i++ {
+		if a.digits[a.values[s[i]]] != s[i] {
+			buf := []byte(s)
+			for j := i; j < len(buf); j++ {
+				buf[j] = a.digits[a.values[buf[j]]]
+			}
+			return string(buf)
+		}
+	}
+	return s
+}
@@ -0,0 +1,170 @@
+package lexorank
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCrockfordRank(t *testing.T) {
+	g := Generator{Alphabet: Crockford32}
+	rank, ok := g.Rank("", "")
+	assert.Equal(t, "F", rank)
+	assert.Equal(t, true, ok)
+
+	// lower case and the ambiguous letters are accepted on input,
+	// but the output is always canonical
+	rank, ok = g.Rank("ab", "aD")
+	assert.Equal(t, "AC", rank)
+	assert.Equal(t, true, ok)
+
+	rank, ok = g.Rank("o", "i")
+	assert.Equal(t, "0F", rank)
+	assert.Equal(t, true, ok)
+}
+
+func TestCrockfordRejectsU(t *testing.T) {
+	_, ok := Generator{Alphabet: Crockford32}.Rank("T", "U")
+	assert.Equal(t, false, ok)
+}
+
+func TestCrockfordRanks(t *testing.T) {
+	g := Generator{Alphabet: Crockford32}
+	ranks, ok := g.Ranks(1, nil, nil)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "FFFFFF", ranks[0].Major)
+	for _, c := range ranks[0].Major {
+		assert.True(t, Crockford32.valid(string(c)))
+	}
+}
+
+func TestBase36Rank(t *testing.T) {
+	rank, ok := Generator{Alphabet: Base36}.Rank("", "")
+	assert.Equal(t, "h", rank)
+	assert.Equal(t, true, ok)
+
+	_, ok = Generator{Alphabet: Base36}.Rank("A", "B")
+	assert.Equal(t, false, ok)
+}
+
+func TestBase64URLRank(t *testing.T) {
+	g := Generator{Alphabet: Base64URL}
+	rank, ok := g.Rank("", "")
+	assert.Equal(t, "U", rank)
+	assert.Equal(t, true, ok)
+
+	rank, ok = g.Rank("Z", "a")
+	assert.Equal(t, "_", rank)
+	assert.Equal(t, true, ok)
+
+	rank, ok = g.Rank("", "0")
+	assert.Equal(t, "-U", rank)
+	assert.Equal(t, true, ok)
+}
+
+func TestBase64URLValid(t *testing.T) {
+	assert.True(t, Base64URL.valid("-_az09AZ"))
+	assert.False(t, Base64URL.valid("a+b"))
+	assert.False(t, Base64URL.valid("a/b"))
+	assert.False(t, Base62.valid("a_b"))
+}
+
+func TestAlphabetsAscending(t *testing.T) {
+	for _, a := range []Alphabet{Base62, Base36, Crockford32, Base64URL} {
+		for i := 1; i < len(a.digits); i++ {
+			assert.True(t, a.digits[i-1] < a.digits[i], a.digits)
+		}
+	}
+}
+
+func TestNewAlphabet(t *testing.T) {
+	a, err := NewAlphabet("0123456789")
+	assert.NoError(t, err)
+	rank, ok := Generator{Alphabet: a}.Rank("1", "5")
+	assert.Equal(t, "3", rank)
+	assert.Equal(t, true, ok)
+
+	_, ok = Generator{Alphabet: a}.Rank("1", "a")
+	assert.Equal(t, false, ok)
+}
+
+func TestNewAlphabetErrors(t *testing.T) {
+	for _, chars := range []string{
+		"",
+		"0",
+		"10",
+		"0012",
+		"01 2",
+		"01\x7f",
+		"01é",
+	} {
+		_, err := NewAlphabet(chars)
+		assert.Error(t, err, chars)
+	}
+}
+
+func TestOrderOf(t *testing.T) {
+	v, err := OrderOf('0')
+	assert.NoError(t, err)
+	assert.Equal(t, 0, v)
+	v, err = OrderOf('z')
+	assert.NoError(t, err)
+	assert.Equal(t, 61, v)
+
+	_, err = OrderOf('-')
+	assert.True(t, errors.Is(err, ErrInvalidDigit))
+
+	v, err = Crockford32.OrderOf('o')
+	assert.NoError(t, err)
+	assert.Equal(t, 0, v)
+	_, err = Crockford32.OrderOf('U')
+	assert.True(t, errors.Is(err, ErrInvalidDigit))
+}
+
+func TestRanksRejectInvalidBounds(t *testing.T) {
+	prev := Posn{Major: "a-0000"}
+	next := Posn{Major: "b00000"}
+	assert.NotPanics(t, func() {
+		_, ok := Ranks(1, &prev, &next)
+		assert.Equal(t, false, ok)
+	})
+}
+
+func TestAlphabetAccessors(t *testing.T) {
+	assert.Equal(t, "0123456789abcdefghijklmnopqrstuvwxyz", Base36.Digits())
+	assert.Equal(t, 36, Base36.Base())
+	assert.Equal(t, byte('0'), Base36.Min())
+	assert.Equal(t, byte('z'), Base36.Max())
+
+	// the zero alphabet is Base62, as for a Generator
+	var zero Alphabet
+	assert.Equal(t, Base62.Digits(), zero.Digits())
+	assert.Equal(t, 62, zero.Base())
+	assert.Equal(t, byte('-'), Base64URL.Min())
+
+	assert.True(t, Crockford32.Valid("o1l"))
+	assert.False(t, Crockford32.Valid("U"))
+	assert.True(t, zero.Valid("aZ"))
+}
+
+func TestAlphabetMaxMultiRank(t *testing.T) {
+	assert.Equal(t, MaxMultiRank, Base62.MaxMultiRank())
+	assert.Equal(t, 34, Base36.MaxMultiRank())
+
+	g := Generator{Alphabet: Base36}
+	assert.Equal(t, 34, g.MaxMultiRank())
+	assert.Equal(t, byte('0'), g.Min())
+	assert.Equal(t, byte('z'), g.Max())
+
+	r, ok := g.Ranks(34, nil, nil)
+	assert.True(t, ok)
+	assert.Len(t, r, 34)
+	_, ok = g.Ranks(35, nil, nil)
+	assert.False(t, ok)
+
+	g = Generator{Alphabet: Base64URL}
+	r, ok = g.Ranks(g.MaxMultiRank(), nil, nil)
+	assert.True(t, ok)
+	assert.Len(t, r, 62)
+}
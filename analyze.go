@@ -0,0 +1,138 @@
+package lexorank
+
+import (
+	"math"
+	"slices"
+	"sort"
+)
+
+// A Report describes the health of a list's keyspace, for keeping an
+// eye on big lists before inserts into them start to fail or keys get
+// unwieldy.
+type Report struct {
+	// Count is the number of ranks
+	Count int
+
+	// Lengths counts the ranks by length (major and minor together),
+	// and MaxLen and MeanLen summarize them
+	Lengths map[int]int
+	MaxLen  int
+	MeanLen float64
+
+	// Gaps is a histogram of the gaps between neighbouring ranks (and
+	// before the first and after the last), by the length of the rank
+	// Rank would put in each one.  The longer that is, the tighter
+	// the gap; gaps with no room at all (between duplicates) are
+	// counted under 0.
+	Gaps map[int]int
+
+	// Tightest holds the tightest gaps (at most TightestGaps of them),
+	// tightest first
+	Tightest []Gap
+
+	// Skew measures how unevenly the ranks are spread through the
+	// keyspace: it's the standard deviation of the gap sizes divided
+	// by their mean, which is 0 for perfectly even spacing and grows
+	// as ranks bunch up
+	Skew float64
+
+	// Duplicates is the number of ranks equal to the one before
+	Duplicates int
+}
+
+// A Gap is the space between two neighbouring ranks; Prev or Next is
+// nil at the ends of the list.
+type Gap struct {
+	Prev, Next *Posn
+
+	// Len is the length of the rank Rank would put in the gap (0 if
+	// there's no room), and Size is the fraction of the keyspace the
+	// gap covers
+	Len  int
+	Size float64
+}
+
+// TightestGaps is how many gaps a Report lists in Tightest.
+const TightestGaps = 10
+
+// Analyze reports on the keyspace used by ranks, which needn't be
+// sorted.  Buckets are ignored, so the ranks should all be in the
+// same one.
+func Analyze(ranks []Posn) Report {
+	return Generator{}.Analyze(ranks)
+}
+
+// Analyze is like the package-level Analyze, but works in the
+// generator's alphabet.
+func (g Generator) Analyze(ranks []Posn) Report {
+	a := g.alphabet()
+	plain := Generator{Alphabet: a}
+	sorted := slices.Clone(ranks)
+	slices.SortFunc(sorted, Posn.Compare)
+
+	r := Report{
+		Count:   len(sorted),
+		Lengths: map[int]int{},
+		Gaps:    map[int]int{},
+	}
+	total := 0
+	for i, p := range sorted {
+		l := len(p.digits())
+		r.Lengths[l]++
+		total += l
+		r.MaxLen = max(r.MaxLen, l)
+		if i > 0 && sorted[i-1].Equal(p) {
+			r.Duplicates++
+		}
+	}
+	if len(sorted) > 0 {
+		r.MeanLen = float64(total) / float64(len(sorted))
+	}
+
+	gaps := make([]Gap, 0, len(sorted)+1)
+	for i := 0; i <= len(sorted); i++ {
+		var gap Gap
+		lo, hi := "", ""
+		loF, hiF := 0.0, 1.0
+		if i > 0 {
+			gap.Prev = &sorted[i-1]
+			lo = gap.Prev.digits()
+			loF = a.fraction(lo)
+		}
+		if i < len(sorted) {
+			gap.Next = &sorted[i]
+			hi = gap.Next.digits()
+			hiF = a.fraction(hi)
+		}
+		if rank, ok := plain.Rank(lo, hi); ok {
+			gap.Len = len(rank)
+		}
+		gap.Size = hiF - loF
+		r.Gaps[gap.Len]++
+		gaps = append(gaps, gap)
+	}
+
+	mean := 1 / float64(len(gaps))
+	variance := 0.0
+	for _, gap := range gaps {
+		variance += (gap.Size - mean) * (gap.Size - mean)
+	}
+	r.Skew = math.Sqrt(variance/float64(len(gaps))) / mean
+
+	// gaps with no room at all are the tightest of all
+	tightness := func(gap Gap) int {
+		if gap.Len == 0 {
+			return math.MaxInt
+		}
+		return gap.Len
+	}
+	sort.SliceStable(gaps, func(i, j int) bool {
+		ti, tj := tightness(gaps[i]), tightness(gaps[j])
+		if ti != tj {
+			return ti > tj
+		}
+		return gaps[i].Size < gaps[j].Size
+	})
+	r.Tightest = gaps[:min(len(gaps), TightestGaps)]
+	return r
+}
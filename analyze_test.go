@@ -0,0 +1,56 @@
+package lexorank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyzeEven(t *testing.T) {
+	ranks, err := Rebalance(61, 0)
+	assert.NoError(t, err)
+	r := Analyze(ranks)
+	assert.Equal(t, 61, r.Count)
+	assert.Equal(t, map[int]int{6: 61}, r.Lengths)
+	assert.Equal(t, 6, r.MaxLen)
+	assert.Equal(t, 0, r.Duplicates)
+	assert.Equal(t, TightestGaps, len(r.Tightest))
+	assert.Less(t, r.Skew, 0.1)
+}
+
+func TestAnalyze(t *testing.T) {
+	ranks := []Posn{
+		{Major: "U"},
+		{Major: "a", Minor: "1"},
+		{Major: "a"},
+		{Major: "a", Minor: "1"},
+		{Major: "a", Minor: "2"},
+	}
+	r := Analyze(ranks)
+	assert.Equal(t, 5, r.Count)
+	assert.Equal(t, map[int]int{1: 2, 2: 3}, r.Lengths)
+	assert.Equal(t, 2, r.MaxLen)
+	assert.InDelta(t, 1.6, r.MeanLen, 1e-9)
+	assert.Equal(t, 1, r.Duplicates)
+
+	// the duplicates leave no room, and "a1" to "a2" and "a" to "a1"
+	// both need 3 digits (as "a0" would end in the smallest digit)
+	assert.Equal(t, map[int]int{0: 1, 1: 3, 3: 2}, r.Gaps)
+	assert.Equal(t, 0, r.Tightest[0].Len)
+	assert.Equal(t, *r.Tightest[0].Prev, *r.Tightest[0].Next)
+	assert.Equal(t, 3, r.Tightest[1].Len)
+	assert.Equal(t, Posn{Major: "a", Minor: "1"}, *r.Tightest[1].Prev)
+	assert.Equal(t, Posn{Major: "a", Minor: "2"}, *r.Tightest[1].Next)
+	assert.Greater(t, r.Skew, 1.0)
+
+	// the open ends are gaps too
+	assert.Nil(t, r.Tightest[len(r.Tightest)-1].Prev)
+}
+
+func TestAnalyzeEmpty(t *testing.T) {
+	r := Analyze(nil)
+	assert.Equal(t, 0, r.Count)
+	assert.Equal(t, map[int]int{1: 1}, r.Gaps)
+	assert.Equal(t, 1, len(r.Tightest))
+	assert.Equal(t, 0.0, r.Skew)
+}
@@ -0,0 +1,142 @@
+package lexorank
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// An AuditRecord describes a change to the rank of an item, for
+// compliance logging of changes to the order of lists.  A Generator
+// with an Audit function makes one for every item the operations that
+// work through a Store write to.
+type AuditRecord struct {
+	Time time.Time `json:"time"`
+
+	// Actor is who made the change, as given to WithActor; it's
+	// empty for changes made with a context that doesn't say
+	Actor string `json:"actor,omitempty"`
+
+	List string `json:"list"`
+	ID   string `json:"id"`
+
+	// Old is the rank the item had (nil for an insert), and New the
+	// rank it got
+	Old *Posn `json:"old,omitempty"`
+	New Posn  `json:"new"`
+
+	Reason AuditReason `json:"reason"`
+}
+
+// An AuditReason says why a rank changed.
+type AuditReason byte
+
+const (
+	// AuditInsert is a new item being added to a list
+	AuditInsert AuditReason = iota + 1
+
+	// AuditMove is a user moving an item.  Nothing in this package
+	// moves items on its own, but applications that do can log their
+	// moves with the rest.
+	AuditMove
+
+	// AuditRebalance is an item given a fresh rank to make room,
+	// whether by a rebalance or by a collision strategy
+	AuditRebalance
+
+	// AuditMigrate is an item moved to another bucket, by a
+	// RebalanceJob or RebalanceIfNeeded
+	AuditMigrate
+)
+
+var auditReasons = []string{AuditInsert: "insert", AuditMove: "move", AuditRebalance: "rebalance", AuditMigrate: "migrate"}
+
+func (r AuditReason) String() string {
+	if int(r) < len(auditReasons) && auditReasons[r] != "" {
+		return auditReasons[r]
+	}
+	return "AuditReason(" + strconv.Itoa(int(r)) + ")"
+}
+
+// MarshalText implements encoding.TextMarshaler, so that reasons are
+// logged by name.
+func (r AuditReason) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (r *AuditReason) UnmarshalText(b []byte) error {
+	for i, name := range auditReasons {
+		if name != "" && name == string(b) {
+			*r = AuditReason(i)
+			return nil
+		}
+	}
+	return newError("unknown audit reason " + strconv.Quote(string(b)))
+}
+
+type actorKey struct{}
+
+// WithActor returns a context that says who is making changes, for
+// the Actor of audit records.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Actor returns who WithActor says is making changes.
+func Actor(ctx context.Context) string {
+	a, _ := ctx.Value(actorKey{}).(string)
+	return a
+}
+
+// audit reports that the items in updates, which had the ranks in
+// old (or none, if old is nil), were written to a list
+func (g Generator) audit(ctx context.Context, list string, reason AuditReason, old []Posn, updates []Item) {
+	if g.Audit == nil {
+		return
+	}
+	now, actor := time.Now(), Actor(ctx)
+	for i, it := range updates {
+		r := AuditRecord{Time: now, Actor: actor, List: list, ID: it.ID, New: it.Rank, Reason: reason}
+		if old != nil {
+			r.Old = &old[i]
+		}
+		g.Audit(r)
+	}
+}
+
+// An AuditLog writes audit records to W as JSON, a line each.  Its
+// Record method can be a Generator's Audit.  It is safe for concurrent
+// use.
+type AuditLog struct {
+	W io.Writer
+
+	mu  sync.Mutex
+	err error
+}
+
+// Record writes r to the log.  Once a write fails, the log stops
+// writing, and Err says why.
+func (l *AuditLog) Record(r AuditRecord) {
+	b, err := json.Marshal(r)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return
+	}
+	if err != nil {
+		l.err = err
+		return
+	}
+	_, l.err = l.W.Write(append(b, '\n'))
+}
+
+// Err returns the error that stopped the log, if any.
+func (l *AuditLog) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
@@ -0,0 +1,81 @@
+package lexorank
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAudit(t *testing.T) {
+	ctx := WithActor(context.Background(), "alice")
+	m := &MemStore{}
+	var recs []AuditRecord
+	g := Generator{Audit: func(r AuditRecord) { recs = append(recs, r) }}
+
+	x, err := g.InsertStore(ctx, m, "a", "x", nil, nil)
+	assert.NoError(t, err)
+	_, err = g.AllocateBetween(context.Background(), m, "a", "x", "y")
+	assert.NoError(t, err)
+	if assert.Len(t, recs, 2) {
+		assert.Equal(t, AuditRecord{Time: recs[0].Time, Actor: "alice", List: "a", ID: "x", New: x, Reason: AuditInsert}, recs[0])
+		assert.False(t, recs[0].Time.IsZero())
+		assert.Empty(t, recs[1].Actor)
+		assert.Equal(t, "y", recs[1].ID)
+	}
+
+	// a rebalance logs the items it changed, with where they were
+	recs = nil
+	m.Put("b", Item{"p", Posn{Major: "a"}}, Item{"q", Posn{Major: "a0"}})
+	assert.NoError(t, g.RebalanceStore(ctx, m, "b"))
+	assert.Len(t, recs, 2)
+	for _, r := range recs {
+		assert.Equal(t, AuditRebalance, r.Reason)
+		assert.NotNil(t, r.Old)
+		assert.Contains(t, m.List("b"), Item{r.ID, r.New})
+	}
+	assert.Equal(t, Posn{Major: "a"}, *recs[0].Old)
+
+	recs = nil
+	job := RebalanceJob{Store: m, List: "b", Generator: g}
+	assert.NoError(t, job.Run(ctx))
+	assert.Len(t, recs, 2)
+	for _, r := range recs {
+		assert.Equal(t, AuditMigrate, r.Reason)
+		assert.Equal(t, byte(1), r.New.Bucket)
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	var buf bytes.Buffer
+	l := &AuditLog{W: &buf}
+	old := Posn{Major: "a"}
+	l.Record(AuditRecord{Actor: "bob", List: "l", ID: "x", Old: &old, New: Posn{Major: "b", Minor: "U"}, Reason: AuditMove})
+	l.Record(AuditRecord{List: "l", ID: "y", New: Posn{Bucket: 1, Major: "c"}, Reason: AuditInsert})
+	assert.NoError(t, l.Err())
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.Equal(t, []string{
+		`{"time":"0001-01-01T00:00:00Z","actor":"bob","list":"l","id":"x","old":"0|a:","new":"0|b:U","reason":"move"}`,
+		`{"time":"0001-01-01T00:00:00Z","list":"l","id":"y","new":"1|c:","reason":"insert"}`,
+	}, lines)
+
+	var r AuditRecord
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &r))
+	assert.Equal(t, AuditMove, r.Reason)
+	assert.Equal(t, old, *r.Old)
+	assert.Error(t, json.Unmarshal([]byte(`{"reason":"shuffle"}`), &r))
+	assert.Equal(t, "AuditReason(9)", AuditReason(9).String())
+
+	l = &AuditLog{W: failWriter{}}
+	l.Record(AuditRecord{})
+	assert.Error(t, l.Err())
+}
+
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
@@ -0,0 +1,76 @@
+package lexorank
+
+import (
+	"math/big"
+	"strconv"
+)
+
+// ToBigInt reads a position's digits (major and minor run together)
+// as a width-digit base-62 integer, padding on the right with zeros,
+// so that callers can do their own arithmetic on ranks (averaging,
+// bucketing, statistics) with arbitrary precision.  Positions with
+// more than width digits are rejected rather than truncated.  The
+// integers only keep to the order of Compare for positions whose
+// majors are the same length (as Jira's are): "a" with minor "z"
+// comes before "a0", but reads as the bigger number.
+func ToBigInt(p Posn, width int) (*big.Int, error) {
+	return Generator{}.ToBigInt(p, width)
+}
+
+// FromBigInt is the inverse of ToBigInt: it writes v as a position
+// whose major has exactly width digits.  v must be in [0, 62^width).
+func FromBigInt(v *big.Int, width int) (Posn, error) {
+	return Generator{}.FromBigInt(v, width)
+}
+
+// ToBigInt is like the package-level ToBigInt, but reads digits in
+// the generator's alphabet.
+func (g Generator) ToBigInt(p Posn, width int) (*big.Int, error) {
+	a := g.alphabet()
+	s := p.digits()
+	if len(s) > width {
+		return nil, newError(p.String() + " has more than " + strconv.Itoa(width) + " digits")
+	}
+	if !a.valid(s) {
+		return nil, newError(p.String() + " is not valid in the alphabet")
+	}
+	v := digitsValue(a, s, width)
+	return v, nil
+}
+
+// FromBigInt is like the package-level FromBigInt, but writes digits
+// in the generator's alphabet.
+func (g Generator) FromBigInt(v *big.Int, width int) (Posn, error) {
+	a := g.alphabet()
+	base := big.NewInt(int64(a.base()))
+	if v.Sign() < 0 || v.Cmp(new(big.Int).Exp(base, big.NewInt(int64(width)), nil)) >= 0 {
+		return Posn{}, newError(v.String() + " does not fit in " + strconv.Itoa(width) + " digits")
+	}
+	return Posn{Major: digitsString(a, v, width)}, nil
+}
+
+// digitsValue reads valid digits as a width-digit number, padding on
+// the right with zeros
+func digitsValue(a Alphabet, s string, width int) *big.Int {
+	base := big.NewInt(int64(a.base()))
+	v := new(big.Int)
+	for i := 0; i < width; i++ {
+		v.Mul(v, base)
+		if i < len(s) {
+			v.Add(v, big.NewInt(int64(a.order(s[i]))))
+		}
+	}
+	return v
+}
+
+// digitsString writes v as exactly width digits
+func digitsString(a Alphabet, v *big.Int, width int) string {
+	base := big.NewInt(int64(a.base()))
+	out := make([]byte, width)
+	q, r := new(big.Int).Set(v), new(big.Int)
+	for i := width - 1; i >= 0; i-- {
+		q.QuoRem(q, base, r)
+		out[i] = a.digit(int(r.Int64()))
+	}
+	return string(out)
+}
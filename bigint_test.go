@@ -0,0 +1,71 @@
+package lexorank
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToBigInt(t *testing.T) {
+	v, err := ToBigInt(Posn{Major: "10"}, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(62), v)
+
+	// narrower positions are padded on the right
+	v, err = ToBigInt(Posn{Major: "1"}, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(62*62), v)
+
+	// the minor is part of the value
+	v, err = ToBigInt(Posn{Major: "1", Minor: "1"}, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(63), v)
+
+	_, err = ToBigInt(Posn{Major: "123"}, 2)
+	assert.Error(t, err)
+	_, err = ToBigInt(Posn{Major: "1-"}, 2)
+	assert.Error(t, err)
+}
+
+func TestToBigIntOrder(t *testing.T) {
+	// with majors of the same length, the integers sort like the
+	// positions
+	ranks := []Posn{{Major: "a0"}, {Major: "a0", Minor: "z"}, {Major: "a1"}, {Major: "b0", Minor: "1"}}
+	var last *big.Int
+	for _, p := range ranks {
+		v, err := ToBigInt(p, 4)
+		assert.NoError(t, err)
+		if last != nil {
+			assert.Equal(t, 1, v.Cmp(last), p)
+		}
+		last = v
+	}
+
+	// but not when they're different lengths
+	x, _ := ToBigInt(Posn{Major: "a", Minor: "z"}, 2)
+	y, _ := ToBigInt(Posn{Major: "a0"}, 2)
+	assert.Equal(t, -1, Posn{Major: "a", Minor: "z"}.Compare(Posn{Major: "a0"}))
+	assert.Equal(t, 1, x.Cmp(y))
+}
+
+func TestFromBigInt(t *testing.T) {
+	p, err := FromBigInt(big.NewInt(63), 3)
+	assert.NoError(t, err)
+	assert.Equal(t, Posn{Major: "011"}, p)
+
+	_, err = FromBigInt(big.NewInt(62*62), 2)
+	assert.Error(t, err)
+	_, err = FromBigInt(big.NewInt(-1), 2)
+	assert.Error(t, err)
+}
+
+func TestBigIntAverage(t *testing.T) {
+	lo, _ := ToBigInt(Posn{Major: "a0"}, 6)
+	hi, _ := ToBigInt(Posn{Major: "a2"}, 6)
+	mid := new(big.Int).Add(lo, hi)
+	mid.Rsh(mid, 1)
+	p, err := FromBigInt(mid, 6)
+	assert.NoError(t, err)
+	assert.Equal(t, "a10000", p.Major)
+}
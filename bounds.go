@@ -0,0 +1,52 @@
+package lexorank
+
+import "errors"
+
+// ErrInvertedBounds is returned (wrapped in a *BoundsError) when the
+// bounds ranks are wanted between are out of order or equal, which is
+// always a bug in the caller.
+var ErrInvertedBounds = errors.New("lexorank: bounds out of order")
+
+// ErrNotBetween is returned if a rank worked out for a gap doesn't
+// sort strictly between the ranks either side, instead of handing it
+// out.  It means a bug in the library rather than the caller, but the
+// ranks are checked so that such a bug can't corrupt a list.
+var ErrNotBetween = errors.New("lexorank: generated rank not between its bounds")
+
+// A BoundsError reports bounds that are out of order, giving both so
+// that the bug behind them can be tracked down.
+type BoundsError struct {
+	Prev, Next string
+}
+
+func (e *BoundsError) Error() string {
+	return ErrInvertedBounds.Error() + ": " + e.Prev + " is not before " + e.Next
+}
+
+func (e *BoundsError) Unwrap() error {
+	return ErrInvertedBounds
+}
+
+// CheckBounds returns a *BoundsError if prev doesn't sort strictly
+// before next.  Ranks and friends only report failure for such
+// bounds; this says why.
+func CheckBounds(prev, next Posn) error {
+	if prev.Compare(next) >= 0 {
+		return &BoundsError{Prev: prev.String(), Next: next.String()}
+	}
+	return nil
+}
+
+// checkBetween returns an error matching ErrNotBetween unless ranks
+// are in order strictly between prev and next, for the ways of
+// working out ranks that don't go through Ranks or ranksFunc
+func checkBetween(prev Posn, ranks []Posn, next Posn) error {
+	last := prev
+	for _, p := range append(ranks[:len(ranks):len(ranks)], next) {
+		if last.Compare(p) >= 0 {
+			return &wrapError{ErrNotBetween.Error() + ": " + p.String(), ErrNotBetween}
+		}
+		last = p
+	}
+	return nil
+}
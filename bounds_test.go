@@ -0,0 +1,164 @@
+package lexorank
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckBounds(t *testing.T) {
+	a := Posn{Major: "a00000"}
+	b := Posn{Major: "b00000"}
+	assert.NoError(t, CheckBounds(a, b))
+
+	err := CheckBounds(b, a)
+	assert.True(t, errors.Is(err, ErrInvertedBounds))
+	var berr *BoundsError
+	assert.True(t, errors.As(err, &berr))
+	assert.Equal(t, "0|b00000:", berr.Prev)
+	assert.Equal(t, "0|a00000:", berr.Next)
+	assert.Equal(t, "lexorank: bounds out of order: 0|b00000: is not before 0|a00000:", err.Error())
+
+	assert.Error(t, CheckBounds(a, a))
+}
+
+func TestRanksInvertedBounds(t *testing.T) {
+	a := Posn{Major: "a00000"}
+	b := Posn{Major: "b00000"}
+	_, ok := Ranks(1, &b, &a)
+	assert.Equal(t, false, ok)
+	_, ok = Ranks(1, &a, &a)
+	assert.Equal(t, false, ok)
+}
+
+func TestSpreadFixedInvertedBounds(t *testing.T) {
+	_, err := SpreadFixed("b", "a", 1, 3)
+	assert.True(t, errors.Is(err, ErrInvertedBounds))
+}
+
+// requireBetween checks that ranks are in order strictly between prev
+// and next
+func requireBetween(t *testing.T, prev Posn, ranks []Posn, next Posn) {
+	t.Helper()
+	last := prev
+	for _, p := range append(ranks, next) {
+		if last.Compare(p) >= 0 {
+			t.Fatalf("%v not strictly between %v and %v", ranks, prev, next)
+		}
+		last = p
+	}
+}
+
+func TestRanksStrictlyBetween(t *testing.T) {
+	// bounds that used to get ranks past next, when next's major
+	// ended (or had nothing but zeros left) where the bounds forked,
+	// or prev's major was shorter or a prefix of next's
+	for _, c := range [][2]Posn{
+		{{Major: "0A"}, {Major: "1"}},
+		{{Major: "yZ1", Minor: "y"}, {Major: "ya"}},
+		{{Major: "0z9a", Minor: "z1Az"}, {Major: "10"}},
+		{{Major: "yZZZ1"}, {Major: "z", Minor: "z1"}},
+		{{Major: "Z0y", Minor: "9z911"}, {Major: "a", Minor: "1"}},
+		{{Major: "a", Minor: "y"}, {Major: "a0"}},
+		{{Major: "yzz", Minor: "z"}, {Bucket: 1, Major: "0"}},
+	} {
+		prev, next := c[0], c[1]
+		for n := 1; n <= 5; n++ {
+			if rs, ok := Ranks(n, &prev, &next); ok {
+				requireBetween(t, prev, rs, next)
+			}
+			rs, ok := AllocateBlock(&prev, &next, n)
+			assert.True(t, ok, "%v %v", prev, next)
+			requireBetween(t, prev, rs, next)
+		}
+	}
+	head := Posn{Major: "1", Minor: "a"}
+	rs, ok := Generator{HeadStep: 3}.Ranks(5, nil, &head)
+	assert.True(t, ok)
+	requireBetween(t, Posn{Major: "0"}, rs, head)
+}
+
+func TestSplitStrictlyBetween(t *testing.T) {
+	// EvenSplit and PlaceAtFraction never hand out ranks outside the
+	// bounds, even when they can't find any inside them
+	for _, c := range [][2]Posn{
+		{{Major: "a"}, {Major: "a", Minor: "5"}},
+		{{Major: "a", Minor: "1"}, {Major: "a", Minor: "5"}},
+		{{Major: "a00000"}, {Major: "b00000"}},
+	} {
+		prev, next := c[0], c[1]
+		if rs, err := EvenSplit(prev, next, 3); err == nil {
+			requireBetween(t, prev, rs, next)
+		}
+		if p, err := PlaceAtFraction(prev, next, 0.5); err == nil {
+			requireBetween(t, prev, []Posn{p}, next)
+		}
+	}
+	_, err := EvenSplit(Posn{Major: "a"}, Posn{Major: "a", Minor: "5"}, 1)
+	assert.Error(t, err)
+	_, err = PlaceAtFraction(Posn{Major: "a"}, Posn{Major: "a", Minor: "5"}, 0.5)
+	assert.Error(t, err)
+}
+
+func TestRanksAdversarial(t *testing.T) {
+	// bounds made of the digits at the edges of the alphabet, with
+	// long shared prefixes, differing only at the end, or with majors
+	// of different lengths
+	rng := rand.New(rand.NewSource(1))
+	const digits = "01yzaAZ9"
+	random := func() string {
+		b := make([]byte, 1+rng.Intn(7))
+		for i := range b {
+			b[i] = digits[rng.Intn(len(digits))]
+		}
+		return string(b)
+	}
+	for k := 0; k < 20000; k++ {
+		prev, next := Posn{Major: random()}, Posn{Major: random()}
+		switch rng.Intn(3) {
+		case 0:
+			next.Major = prev.Major[:len(prev.Major)-1] + random()[:1]
+		case 1:
+			next.Major = prev.Major
+		}
+		if rng.Intn(3) == 0 {
+			prev.Minor = random()
+		}
+		if rng.Intn(3) == 0 {
+			next.Minor = random()
+		}
+		switch prev.Compare(next) {
+		case 0:
+			continue
+		case 1:
+			prev, next = next, prev
+		}
+		n := 1 + rng.Intn(5)
+		if rs, ok := Ranks(n, &prev, &next); ok {
+			requireBetween(t, prev, rs, next)
+		}
+		if rs, ok := AllocateBlock(&prev, &next, n); ok {
+			requireBetween(t, prev, rs, next)
+		}
+		if r, ok := Rank(prev.digits(), next.digits()); ok {
+			assert.True(t, prev.digits() < r && r < next.digits(), "%v %v %v", prev, next, r)
+		}
+	}
+}
+
+func FuzzRanksBetween(f *testing.F) {
+	f.Add("0A", "", "1", "", 2)
+	f.Add("a", "y", "a0", "", 3)
+	f.Fuzz(func(t *testing.T, pmaj, pmin, nmaj, nmin string, n int) {
+		prev, next := Posn{Major: pmaj, Minor: pmin}, Posn{Major: nmaj, Minor: nmin}
+		n = 1 + n&7
+		if rs, ok := Ranks(n, &prev, &next); ok {
+			requireBetween(t, prev, rs, next)
+		}
+		if rs, ok := AllocateBlock(&prev, &next, n); ok {
+			requireBetween(t, prev, rs, next)
+		}
+	})
+}
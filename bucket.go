@@ -0,0 +1,208 @@
+package lexorank
+
+import (
+	"errors"
+	"strings"
+)
+
+// NextBucket returns the bucket that Jira rebalances bucket b into:
+// they are used in rotation, 0 to 1 to 2 and back to 0.
+func NextBucket(b byte) byte {
+	return (b + 1) % (MaxBucket + 1)
+}
+
+// crossBucket handles bounds in different buckets, as happens in the
+// middle of a rebalance, when some of a list has been moved into the
+// next bucket and the rest hasn't yet.  New ranks go in the bucket
+// being rebalanced into, since the other one is on its way out: for
+// prev in bucket 0 and next in 1, that's 1, between the start of the
+// bucket and next; but for prev in 0 and next in 2 (where 2 is being
+// rebalanced into 0) it's 0, between prev and the end of the bucket.
+func (g Generator) crossBucket(prev, next Posn) (*Posn, *Posn, bool) {
+	if prev.Bucket > MaxBucket || next.Bucket > MaxBucket {
+		return nil, nil, false
+	}
+	a := g.alphabet()
+	if NextBucket(prev.Bucket) == next.Bucket {
+		prev = Posn{
+			Bucket: next.Bucket,
+			Major:  strings.Repeat(string(a.min()), max(len(next.Major), 6)),
+		}
+	} else {
+		next = Posn{
+			Bucket: prev.Bucket,
+			Major:  strings.Repeat(string(a.max()), max(len(prev.Major), 6)),
+		}
+	}
+	if prev.Compare(next) >= 0 {
+		return nil, nil, false
+	}
+	return &prev, &next, true
+}
+
+// BucketStats describes how a list is using the buckets.
+type BucketStats struct {
+	// Counts is the number of items in each bucket
+	Counts [MaxBucket + 1]int
+
+	// MaxLen and MeanLen describe the lengths of the keys (major and
+	// minor together)
+	MaxLen  int
+	MeanLen float64
+
+	// Migrating is true if more than one bucket is in use, i.e., a
+	// migration to the next bucket was started but not finished
+	Migrating bool
+
+	// Advisable is true if a migration is called for: either one is
+	// in progress and should be finished, or the keys have grown to
+	// more than twice as long as a freshly spread list would need.
+	Advisable bool
+}
+
+// BucketUsage reports on the buckets used by a list.  Ranks with an
+// out of range bucket are ignored.
+func BucketUsage(ranks []Posn) BucketStats {
+	return Generator{}.BucketUsage(ranks)
+}
+
+// BucketUsage is like the package-level BucketUsage, but uses the
+// generator's configuration.
+func (g Generator) BucketUsage(ranks []Posn) BucketStats {
+	var st BucketStats
+	total, n := 0, 0
+	for _, p := range ranks {
+		if p.Bucket > MaxBucket {
+			continue
+		}
+		st.Counts[p.Bucket]++
+		l := len(p.digits())
+		total += l
+		n++
+		if l > st.MaxLen {
+			st.MaxLen = l
+		}
+	}
+	if n == 0 {
+		return st
+	}
+	st.MeanLen = float64(total) / float64(n)
+	used := 0
+	for _, c := range st.Counts {
+		if c > 0 {
+			used++
+		}
+	}
+	st.Migrating = used > 1
+	st.Advisable = st.Migrating || st.MaxLen > 2*g.freshLen(n)
+	return st
+}
+
+// freshLen is the length of key needed to spread n items out with
+// plenty of room, which is never less than Jira's six digits
+func (g Generator) freshLen(n int) int {
+	l := 1
+	for room := g.alphabet().base(); room < 2*(n+1); room *= g.alphabet().base() {
+		l++
+	}
+	return max(l, 6)
+}
+
+// A MigrationPlan moves a list from one bucket to the next.  The
+// updates are in the order they should be written so that the list
+// stays in order throughout, even if the writes are spread over time
+// (or interrupted): the items closest to the part of the list that
+// is already in the new bucket go first.
+type MigrationPlan struct {
+	From, To byte
+	Updates  []Update
+}
+
+// PlanMigration works out how to move a list, given by its ranks in
+// order, into the next bucket, or how to finish moving it if that was
+// already under way.  It returns false if the list is in no state to
+// be migrated (it's out of order, or uses all three buckets).
+func PlanMigration(ranks []Posn) (MigrationPlan, bool) {
+	return Generator{}.PlanMigration(ranks)
+}
+
+// PlanMigration is like the package-level PlanMigration, but uses the
+// generator's configuration.
+func (g Generator) PlanMigration(ranks []Posn) (MigrationPlan, bool) {
+	for i := 1; i < len(ranks); i++ {
+		if ranks[i-1].Compare(ranks[i]) >= 0 {
+			return MigrationPlan{}, false
+		}
+	}
+	st := g.BucketUsage(ranks)
+	var plan MigrationPlan
+	switch used := st.Counts[0] + st.Counts[1] + st.Counts[2]; {
+	case used != len(ranks):
+		return MigrationPlan{}, false
+	case used == 0:
+		return plan, true
+	case !st.Migrating:
+		plan.From = ranks[0].Bucket
+		plan.To = NextBucket(plan.From)
+	default:
+		// the bucket being moved into is the one that follows the
+		// other in the rotation
+		first, last := ranks[0].Bucket, ranks[len(ranks)-1].Bucket
+		for b := byte(0); b <= MaxBucket; b++ {
+			if st.Counts[b] > 0 && b != first && b != last {
+				return MigrationPlan{}, false
+			}
+		}
+		plan.From, plan.To = first, last
+		if NextBucket(last) == first {
+			plan.From, plan.To = last, first
+		}
+	}
+
+	// the items still to move, and the bound they have to stay on
+	// the right side of
+	var moving []int
+	var lo, hi string
+	for i, p := range ranks {
+		switch {
+		case p.Bucket == plan.From:
+			moving = append(moving, i)
+		case plan.To > plan.From && hi == "":
+			hi = p.digits()
+		case plan.To < plan.From:
+			lo = p.digits()
+		}
+	}
+	keys, err := g.spreadFresh(lo, hi, len(moving))
+	if err != nil {
+		return MigrationPlan{}, false
+	}
+	for k, i := range moving {
+		plan.Updates = append(plan.Updates, Update{
+			Index: i,
+			Rank:  Posn{Bucket: plan.To, Major: keys[k]},
+		})
+	}
+	if plan.To > plan.From {
+		// the new bucket sorts after the old one, so start at the
+		// end of the list
+		for i, j := 0, len(plan.Updates)-1; i < j; i, j = i+1, j-1 {
+			plan.Updates[i], plan.Updates[j] = plan.Updates[j], plan.Updates[i]
+		}
+	}
+	return plan, true
+}
+
+// spreadFresh spreads n fixed-length keys between lo and hi, making
+// them long enough to leave plenty of room
+func (g Generator) spreadFresh(lo, hi string, n int) ([]string, error) {
+	l := max(g.freshLen(n), max(len(lo), len(hi)))
+	for {
+		keys, err := g.quiet().SpreadFixed(lo, hi, n, l)
+		var lerr *FixedLengthError
+		if !errors.As(err, &lerr) {
+			return keys, err
+		}
+		l++
+	}
+}
@@ -0,0 +1,129 @@
+package lexorank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextBucket(t *testing.T) {
+	assert.Equal(t, byte(1), NextBucket(0))
+	assert.Equal(t, byte(2), NextBucket(1))
+	assert.Equal(t, byte(0), NextBucket(2))
+}
+
+func TestRanksCrossBucket(t *testing.T) {
+	// rebalancing 0 into 1: the new rank goes in bucket 1
+	prev := Posn{Bucket: 0, Major: "x00000"}
+	next := Posn{Bucket: 1, Major: "a00000"}
+	ranks, ok := Ranks(1, &prev, &next)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, byte(1), ranks[0].Bucket)
+	assert.Equal(t, -1, ranks[0].Compare(next))
+
+	// rebalancing 2 into 0: the new rank goes in bucket 0
+	prev = Posn{Bucket: 0, Major: "x00000"}
+	next = Posn{Bucket: 2, Major: "a00000"}
+	ranks, ok = Ranks(1, &prev, &next)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, byte(0), ranks[0].Bucket)
+	assert.Equal(t, 1, ranks[0].Compare(prev))
+
+	prev = Posn{Bucket: 1, Major: "x00000"}
+	next = Posn{Bucket: 5, Major: "a00000"}
+	_, ok = Ranks(1, &prev, &next)
+	assert.Equal(t, false, ok)
+}
+
+func TestBucketUsage(t *testing.T) {
+	st := BucketUsage([]Posn{
+		{Bucket: 0, Major: "a00000"},
+		{Bucket: 0, Major: "b00000"},
+		{Bucket: 1, Major: "c00000"},
+	})
+	assert.Equal(t, [3]int{2, 1, 0}, st.Counts)
+	assert.Equal(t, 6, st.MaxLen)
+	assert.Equal(t, true, st.Migrating)
+	assert.Equal(t, true, st.Advisable)
+
+	st = BucketUsage([]Posn{{Major: "a00000"}, {Major: "a00000", Minor: "0000001"}})
+	assert.Equal(t, false, st.Migrating)
+	assert.Equal(t, true, st.Advisable)
+
+	st = BucketUsage([]Posn{{Major: "a00000"}, {Major: "b00000"}})
+	assert.Equal(t, false, st.Advisable)
+	assert.Equal(t, BucketStats{}, BucketUsage(nil))
+}
+
+// applyPlan writes the plan's updates one at a time, checking that
+// the list stays in order after each
+func applyPlan(t *testing.T, ranks []Posn, plan MigrationPlan) {
+	for _, u := range plan.Updates {
+		ranks[u.Index] = u.Rank
+		for i := 1; i < len(ranks); i++ {
+			assert.Equal(t, -1, ranks[i-1].Compare(ranks[i]), "%v", ranks)
+		}
+	}
+	for _, p := range ranks {
+		assert.Equal(t, plan.To, p.Bucket)
+	}
+}
+
+func TestPlanMigration(t *testing.T) {
+	ranks := []Posn{
+		{Major: "a00000"},
+		{Major: "a00000", Minor: "1"},
+		{Major: "b00000"},
+	}
+	plan, ok := PlanMigration(ranks)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, byte(0), plan.From)
+	assert.Equal(t, byte(1), plan.To)
+	assert.Equal(t, 2, plan.Updates[0].Index)
+	applyPlan(t, ranks, plan)
+
+	ranks = []Posn{
+		{Bucket: 2, Major: "a00000"},
+		{Bucket: 2, Major: "b00000"},
+	}
+	plan, ok = PlanMigration(ranks)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, byte(0), plan.To)
+	assert.Equal(t, 0, plan.Updates[0].Index)
+	applyPlan(t, ranks, plan)
+}
+
+func TestPlanMigrationInProgress(t *testing.T) {
+	// 0 to 1, started from the end
+	ranks := []Posn{
+		{Bucket: 0, Major: "a00000"},
+		{Bucket: 0, Major: "b00000"},
+		{Bucket: 1, Major: "000001"},
+		{Bucket: 1, Major: "U00000"},
+	}
+	plan, ok := PlanMigration(ranks)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, byte(1), plan.To)
+	assert.Equal(t, 2, len(plan.Updates))
+	applyPlan(t, ranks, plan)
+
+	// 2 to 0, started from the beginning
+	ranks = []Posn{
+		{Bucket: 0, Major: "zzzzzy"},
+		{Bucket: 2, Major: "a00000"},
+		{Bucket: 2, Major: "b00000"},
+	}
+	plan, ok = PlanMigration(ranks)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, byte(2), plan.From)
+	assert.Equal(t, byte(0), plan.To)
+	applyPlan(t, ranks, plan)
+	assert.Equal(t, 7, len(ranks[2].Major))
+}
+
+func TestPlanMigrationBad(t *testing.T) {
+	_, ok := PlanMigration([]Posn{{Major: "b"}, {Major: "a"}})
+	assert.Equal(t, false, ok)
+	_, ok = PlanMigration([]Posn{{Bucket: 0, Major: "a"}, {Bucket: 1, Major: "a"}, {Bucket: 2, Major: "a"}})
+	assert.Equal(t, false, ok)
+}
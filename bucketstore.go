@@ -0,0 +1,101 @@
+package lexorank
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// A BucketStore keeps track of the active bucket of each list: the one
+// new ranks go in, which moves on to the next bucket when a migration
+// starts.  Keeping it in one place, rather than working it out from
+// the ranks, lets instances of a service agree on it, and lets only
+// one of them start a migration.  Implementations must be safe for
+// concurrent use.
+type BucketStore interface {
+	// ActiveBucket returns the list's active bucket, which is 0 if
+	// it has never been set.
+	ActiveBucket(ctx context.Context, list string) (byte, error)
+
+	// SetActiveBucket sets the list's active bucket to to, as long
+	// as it is still from; if it isn't (because another instance
+	// got there first), it fails with an error wrapping
+	// ErrBucketChanged, and leaves it alone.
+	SetActiveBucket(ctx context.Context, list string, from, to byte) error
+}
+
+// ErrBucketChanged is returned (perhaps wrapped) by a BucketStore when
+// a list's active bucket isn't the one it was expected to be.
+var ErrBucketChanged = errors.New("lexorank: active bucket changed")
+
+// MemBuckets is a BucketStore that keeps the buckets in memory, for
+// tests and single-instance programs.  The zero value is ready to
+// use.
+type MemBuckets struct {
+	mu      sync.Mutex
+	buckets map[string]byte
+}
+
+var _ BucketStore = (*MemBuckets)(nil)
+
+func (m *MemBuckets) ActiveBucket(ctx context.Context, list string) (byte, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.buckets[list], nil
+}
+
+func (m *MemBuckets) SetActiveBucket(ctx context.Context, list string, from, to byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if to > MaxBucket {
+		return badBucket(to)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.buckets[list] != from {
+		return ErrBucketChanged
+	}
+	if m.buckets == nil {
+		m.buckets = make(map[string]byte)
+	}
+	m.buckets[list] = to
+	return nil
+}
+
+// RanksFor is like Ranks, but for a list whose active bucket is kept
+// in bs: if the list is empty (prev and next are both nil), the ranks
+// go in its active bucket, rather than bucket 0.  Otherwise they go
+// where the neighbours say, as for Ranks; in the middle of a
+// migration, that's the bucket being moved into.
+func RanksFor(ctx context.Context, bs BucketStore, list string, n int, prev, next *Posn) ([]Posn, error) {
+	return Generator{}.RanksFor(ctx, bs, list, n, prev, next)
+}
+
+// RanksFor is like the package-level RanksFor, but uses the
+// generator's configuration.
+func (g Generator) RanksFor(ctx context.Context, bs BucketStore, list string, n int, prev, next *Posn) ([]Posn, error) {
+	var bucket byte
+	if prev == nil && next == nil {
+		var err error
+		if bucket, err = bs.ActiveBucket(ctx, list); err != nil {
+			return nil, err
+		}
+		if bucket > MaxBucket {
+			return nil, badBucket(bucket)
+		}
+	}
+	ranks, ok := g.Ranks(n, prev, next)
+	if !ok {
+		return nil, ErrNoRoom
+	}
+	if prev == nil && next == nil {
+		for i := range ranks {
+			ranks[i].Bucket = bucket
+		}
+	}
+	return ranks, nil
+}
@@ -0,0 +1,50 @@
+package lexorank
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemBuckets(t *testing.T) {
+	ctx := context.Background()
+	var m MemBuckets
+	b, err := m.ActiveBucket(ctx, "list")
+	assert.NoError(t, err)
+	assert.Equal(t, byte(0), b)
+
+	assert.NoError(t, m.SetActiveBucket(ctx, "list", 0, 1))
+	assert.ErrorIs(t, m.SetActiveBucket(ctx, "list", 0, 1), ErrBucketChanged)
+	assert.Error(t, m.SetActiveBucket(ctx, "list", 1, 3))
+	b, err = m.ActiveBucket(ctx, "list")
+	assert.NoError(t, err)
+	assert.Equal(t, byte(1), b)
+
+	b, err = m.ActiveBucket(ctx, "other")
+	assert.NoError(t, err)
+	assert.Equal(t, byte(0), b)
+}
+
+func TestRanksFor(t *testing.T) {
+	ctx := context.Background()
+	var m MemBuckets
+	assert.NoError(t, m.SetActiveBucket(ctx, "list", 0, 2))
+
+	// an empty list starts in its active bucket
+	ranks, err := RanksFor(ctx, &m, "list", 3, nil, nil)
+	assert.NoError(t, err)
+	assert.Len(t, ranks, 3)
+	for _, p := range ranks {
+		assert.Equal(t, byte(2), p.Bucket)
+	}
+
+	// otherwise the neighbours decide
+	prev, next := Posn{Bucket: 0, Major: "a"}, Posn{Bucket: 1, Major: "a"}
+	ranks, err = RanksFor(ctx, &m, "list", 1, &prev, &next)
+	assert.NoError(t, err)
+	assert.Equal(t, byte(1), ranks[0].Bucket)
+
+	_, err = RanksFor(ctx, &m, "list", 1, &next, &prev)
+	assert.ErrorIs(t, err, ErrNoRoom)
+}
@@ -0,0 +1,66 @@
+package lexorank
+
+import (
+	"cmp"
+	"slices"
+)
+
+// An Insertion asks for Count new items to go in at Index of a list,
+// that is, between the items at Index-1 and Index.
+type Insertion struct {
+	Index, Count int
+}
+
+// RanksForIndices works out the ranks for many insertions into a list
+// at once, as when merging a batch of new items into it.  list holds
+// the ranks of the list in order, and the ranks for at[k] are in
+// out[k], in order.  Insertions at the same index go one after the
+// other, in the order given, and share a single spread of ranks, so
+// there's no need to insert them one at a time, looking up neighbours
+// as you go.  It returns false if an index is out of range, or there's
+// no room for the ranks at one of them.
+func RanksForIndices(list []Posn, at []Insertion) ([][]Posn, bool) {
+	return Generator{}.RanksForIndices(list, at)
+}
+
+// RanksForIndices is like the package-level RanksForIndices, but uses
+// the generator's configuration.
+func (g Generator) RanksForIndices(list []Posn, at []Insertion) ([][]Posn, bool) {
+	order := make([]int, len(at))
+	for k, ins := range at {
+		if ins.Index < 0 || ins.Index > len(list) || ins.Count < 0 {
+			return nil, false
+		}
+		order[k] = k
+	}
+	slices.SortStableFunc(order, func(x, y int) int {
+		return cmp.Compare(at[x].Index, at[y].Index)
+	})
+
+	out := make([][]Posn, len(at))
+	for len(order) > 0 {
+		// the insertions at the next index along
+		i := at[order[0]].Index
+		n, total := 0, 0
+		for n < len(order) && at[order[n]].Index == i {
+			total += at[order[n]].Count
+			n++
+		}
+		var prev, next *Posn
+		if i > 0 {
+			prev = &list[i-1]
+		}
+		if i < len(list) {
+			next = &list[i]
+		}
+		ranks, ok := g.AllocateBlock(prev, next, total)
+		if !ok {
+			return nil, false
+		}
+		for _, k := range order[:n] {
+			out[k], ranks = ranks[:at[k].Count:at[k].Count], ranks[at[k].Count:]
+		}
+		order = order[n:]
+	}
+	return out, true
+}
@@ -0,0 +1,44 @@
+package lexorank
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRanksForIndices(t *testing.T) {
+	list, err := Rebalance(5, 0)
+	assert.NoError(t, err)
+	at := []Insertion{{5, 2}, {0, 1}, {2, 3}, {2, 1}, {3, 0}}
+	out, ok := RanksForIndices(list, at)
+	assert.True(t, ok)
+	assert.Len(t, out, len(at))
+	for k, ins := range at {
+		assert.Len(t, out[k], ins.Count)
+	}
+
+	// merged, everything is in order, with the insertions at the
+	// same index in the order given
+	var merged []Posn
+	merged = append(merged, out[1]...)
+	merged = append(merged, list[:2]...)
+	merged = append(merged, out[2]...)
+	merged = append(merged, out[3]...)
+	merged = append(merged, list[2:]...)
+	merged = append(merged, out[0]...)
+	assert.Len(t, merged, 12)
+	assert.True(t, slices.IsSortedFunc(merged, Posn.Compare))
+	assert.Equal(t, len(merged), len(slices.CompactFunc(slices.Clone(merged), Posn.Equal)))
+
+	out, ok = RanksForIndices(nil, []Insertion{{0, 3}})
+	assert.True(t, ok)
+	assert.True(t, slices.IsSortedFunc(out[0], Posn.Compare))
+
+	_, ok = RanksForIndices(list, []Insertion{{6, 1}})
+	assert.False(t, ok)
+	_, ok = RanksForIndices(list, []Insertion{{1, -1}})
+	assert.False(t, ok)
+	_, ok = Generator{MaxLength: 6}.RanksForIndices([]Posn{{Major: "a00000"}, {Major: "a00001"}}, []Insertion{{1, 1}})
+	assert.False(t, ok)
+}
@@ -0,0 +1,95 @@
+package lexorank
+
+import (
+	"math/big"
+	"strconv"
+)
+
+// Capacity returns exactly how many distinct ranks of at most maxLen
+// digits (major and minor together) there are strictly between prev
+// and next.  Buckets are not considered.  This is useful for raising
+// an alarm before a hot spot in a list runs out of room.  When the
+// majors of prev and next differ, it's the majors between theirs that
+// are counted; only when they're the same are the minors between
+// theirs counted instead.
+func Capacity(prev, next Posn, maxLen int) (*big.Int, error) {
+	return Generator{}.Capacity(prev, next, maxLen)
+}
+
+// Capacity64 is like Capacity, but returns the count as a uint64,
+// with ok false if it doesn't fit (or the positions are invalid).
+func Capacity64(prev, next Posn, maxLen int) (count uint64, ok bool) {
+	c, err := Capacity(prev, next, maxLen)
+	if err != nil || !c.IsUint64() {
+		return 0, false
+	}
+	return c.Uint64(), true
+}
+
+// Capacity is like the package-level Capacity, but counts ranks in
+// the generator's alphabet.
+func (g Generator) Capacity(prev, next Posn, maxLen int) (*big.Int, error) {
+	if maxLen < 0 {
+		return nil, newError("negative length " + strconv.Itoa(maxLen))
+	}
+	a := g.alphabet()
+	lo, hi := prev.digits(), next.digits()
+	for _, s := range []string{lo, hi} {
+		if !a.valid(s) {
+			return nil, newError(strconv.Quote(s) + " is not valid in the alphabet")
+		}
+	}
+	if prev.Major != next.Major {
+		// the digits run together don't sort like the positions when
+		// the majors are different lengths, but the majors alone do
+		lo, hi = prev.Major, next.Major
+	}
+	return capacity(a, a.canonical(lo), a.canonical(hi), maxLen), nil
+}
+
+// capacity counts the strings of at most maxLen digits strictly
+// between the canonical, valid digit strings lo and hi
+func capacity(a Alphabet, lo, hi string, maxLen int) *big.Int {
+	c := new(big.Int).Sub(countBelow(a, hi, maxLen), countBelow(a, lo, maxLen))
+	if len(lo) <= maxLen {
+		// lo itself isn't strictly between
+		c.Sub(c, big.NewInt(1))
+	}
+	if c.Sign() < 0 {
+		c.SetInt64(0)
+	}
+	return c
+}
+
+// countBelow counts the non-empty strings of at most maxLen digits
+// that sort strictly before x.
+func countBelow(a Alphabet, x string, maxLen int) *big.Int {
+	base := big.NewInt(int64(a.base()))
+
+	// upTo[k] is the number of strings of length 0..k, i.e., the
+	// number of ways to finish a string with k digits to spare
+	upTo := make([]*big.Int, maxLen+1)
+	pow := big.NewInt(1)
+	sum := new(big.Int)
+	for k := 0; k <= maxLen; k++ {
+		sum = new(big.Int).Add(sum, pow)
+		upTo[k] = sum
+		pow = new(big.Int).Mul(pow, base)
+	}
+
+	count := new(big.Int)
+	for i := 0; i < len(x) && i < maxLen; i++ {
+		// strings sharing x[:i] whose next digit is smaller
+		d := big.NewInt(int64(a.order(x[i])))
+		count.Add(count, d.Mul(d, upTo[maxLen-i-1]))
+	}
+	// ...plus the non-empty proper prefixes of x
+	prefixes := len(x) - 1
+	if prefixes > maxLen {
+		prefixes = maxLen
+	}
+	if prefixes > 0 {
+		count.Add(count, big.NewInt(int64(prefixes)))
+	}
+	return count
+}
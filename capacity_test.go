@@ -0,0 +1,97 @@
+package lexorank
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// bruteCapacity enumerates every string up to maxLen in a small
+// alphabet and counts the ones strictly between lo and hi
+func bruteCapacity(a Alphabet, lo, hi string, maxLen int) int64 {
+	var count int64
+	var walk func(s string)
+	walk = func(s string) {
+		if s != "" && s > lo && s < hi {
+			count++
+		}
+		if len(s) == maxLen {
+			return
+		}
+		for i := 0; i < a.base(); i++ {
+			walk(s + string(a.digit(i)))
+		}
+	}
+	walk("")
+	return count
+}
+
+func TestCapacityMatchesBruteForce(t *testing.T) {
+	a, err := NewAlphabet("0123")
+	assert.NoError(t, err)
+	g := Generator{Alphabet: a}
+	cases := [][2]string{
+		{"0", "3"},
+		{"1", "2"},
+		{"1", "10"},
+		{"1", "11"},
+		{"12", "2"},
+		{"0003", "3333"},
+		{"21", "213"},
+		{"3", "3"},
+		{"2", "1"},
+	}
+	for _, c := range cases {
+		for maxLen := 1; maxLen <= 4; maxLen++ {
+			got, err := g.Capacity(Posn{Major: c[0]}, Posn{Major: c[1]}, maxLen)
+			assert.NoError(t, err)
+			assert.Equal(t, bruteCapacity(a, c[0], c[1], maxLen), got.Int64(), "%v len %d", c, maxLen)
+		}
+	}
+}
+
+func TestCapacityIncludesMinor(t *testing.T) {
+	prev := Posn{Major: "hzzzzz"}
+	next := Posn{Major: "hzzzzz", Minor: "i"}
+	c, err := Capacity(prev, next, 7)
+	assert.NoError(t, err)
+	// hzzzzz0 .. hzzzzzh
+	assert.Equal(t, big.NewInt(int64(Base62.order('i'))), c)
+}
+
+func TestCapacity64(t *testing.T) {
+	c, ok := Capacity64(Posn{Major: "0"}, Posn{Major: "z"}, 2)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, uint64(60+62+60*62), c)
+
+	_, ok = Capacity64(Posn{Major: "0"}, Posn{Major: "z"}, 20)
+	assert.Equal(t, false, ok)
+
+	_, ok = Capacity64(Posn{Major: "0"}, Posn{Major: "!"}, 2)
+	assert.Equal(t, false, ok)
+}
+
+func TestCapacityNegative(t *testing.T) {
+	_, err := Capacity(Posn{Major: "0"}, Posn{Major: "z"}, -2)
+	assert.Error(t, err)
+	c, err := Capacity(Posn{Major: "0"}, Posn{Major: "z"}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), c.Int64())
+}
+
+func TestCapacityMajors(t *testing.T) {
+	// run together, the digits of these sort the other way round, but
+	// there's plenty of room between the majors
+	prev := Posn{Major: "0", Minor: "q0"}
+	next := Posn{Major: "0Iz", Minor: "z0"}
+	c, err := Capacity(prev, next, 3)
+	assert.NoError(t, err)
+	want, err := Capacity(Posn{Major: "0"}, Posn{Major: "0Iz"}, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, want, c)
+	assert.Positive(t, c.Sign())
+	r, ok := Ranks(1, &prev, &next)
+	assert.Equal(t, true, ok)
+	requireBetween(t, prev, r, next)
+}
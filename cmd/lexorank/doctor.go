@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dkolbly/lexorank"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// A dumped rank is a rank string read by doctor, and where it came
+// from, for reporting
+type dumped struct {
+	where string
+	text  string
+	rank  lexorank.Posn
+}
+
+// doctor reads ranks and reports what's wrong with them: the first
+// thing to run when someone says cards are jumping around
+func doctor(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), `usage: lexorank doctor [flags] [file]
+
+Reads ranks, one per line, from file (or stdin), or a column of a CSV
+file, or the first column of the results of a database query, and
+reports invalid ranks, duplicates, ranks out of order, overly long
+keys, and whether the list needs rebalancing.  The ranks should be in
+list order, as from a query with ORDER BY on the rank column, unless
+-unordered is given.  The exit status is 1 if there are problems.
+
+flags:`)
+		fs.PrintDefaults()
+	}
+	csvColumn := fs.String("csv", "", "read `column` (a name from the header, or a number from 1) of CSV input")
+	driver := fs.String("driver", "pgx", "database/sql `driver` for -dsn: pgx or sqlite3")
+	dsn := fs.String("dsn", "", "read ranks from a database with this data source name")
+	query := fs.String("query", "", "`SQL` query for -dsn, whose first column is the rank")
+	unordered := fs.Bool("unordered", false, "the input isn't in list order, so don't check its order")
+	maxLen := fs.Int("maxlen", lexorank.DefaultPolicy.MaxLen, "report keys longer than `n` digits")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	var ranks []dumped
+	var err error
+	switch {
+	case *dsn != "":
+		if *query == "" {
+			return errors.New("-dsn needs a -query")
+		}
+		ranks, err = readQuery(*driver, *dsn, *query)
+	default:
+		in := stdin
+		if fs.NArg() > 0 {
+			f, err := os.Open(fs.Arg(0))
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			in = f
+		}
+		if *csvColumn != "" {
+			ranks, err = readCSV(in, *csvColumn)
+		} else {
+			ranks, err = readLines(in)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if !diagnose(stdout, ranks, !*unordered, *maxLen) {
+		return errProblems
+	}
+	return nil
+}
+
+func readLines(r io.Reader) ([]dumped, error) {
+	var out []dumped
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		s := strings.TrimSpace(sc.Text())
+		if s != "" {
+			out = append(out, dumped{where: "line " + strconv.Itoa(n), text: s})
+		}
+	}
+	return out, sc.Err()
+}
+
+func readCSV(r io.Reader, column string) ([]dumped, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	col, err := strconv.Atoi(column)
+	if err == nil {
+		if col < 1 {
+			return nil, fmt.Errorf("bad column %d", col)
+		}
+		col--
+	} else {
+		header, err := cr.Read()
+		if err != nil {
+			return nil, err
+		}
+		col = slices.Index(header, column)
+		if col < 0 {
+			return nil, fmt.Errorf("no column %q in header", column)
+		}
+	}
+	var out []dumped
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		if col >= len(rec) {
+			return nil, fmt.Errorf("line %d has no column %d", line, col+1)
+		}
+		out = append(out, dumped{where: "line " + strconv.Itoa(line), text: strings.TrimSpace(rec[col])})
+	}
+}
+
+func readQuery(driver, dsn, query string) ([]dumped, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var out []dumped
+	dest := make([]any, len(cols))
+	for i := range dest {
+		dest[i] = new(any)
+	}
+	var s sql.NullString
+	dest[0] = &s
+	for n := 1; rows.Next(); n++ {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		out = append(out, dumped{where: "row " + strconv.Itoa(n), text: s.String})
+	}
+	return out, rows.Err()
+}
+
+// diagnose writes a report on ranks to w, and returns whether they're
+// healthy.  If ordered is set, ranks are in list order, and ranks out
+// of order are reported.
+func diagnose(w io.Writer, ranks []dumped, ordered bool, maxLen int) bool {
+	ok := true
+	problem := func(heading string, n int) {
+		fmt.Fprintf(w, "\n%s: %d\n", heading, n)
+		ok = false
+	}
+	fmt.Fprintf(w, "read %d ranks\n", len(ranks))
+
+	// invalid values are left out of everything after
+	var valid []dumped
+	var invalid []string
+	for _, d := range ranks {
+		p, err := lexorank.Parse(d.text)
+		if err != nil {
+			invalid = append(invalid, fmt.Sprintf("%s: %v", d.where, err))
+			continue
+		}
+		d.rank = p
+		valid = append(valid, d)
+	}
+	if len(invalid) > 0 {
+		problem("invalid", len(invalid))
+		list(w, invalid)
+	}
+
+	seen := map[lexorank.Posn][]string{}
+	var dups []lexorank.Posn
+	for _, d := range valid {
+		if len(seen[d.rank]) == 1 {
+			dups = append(dups, d.rank)
+		}
+		seen[d.rank] = append(seen[d.rank], d.where)
+	}
+	if len(dups) > 0 {
+		problem("duplicates", len(dups))
+		var lines []string
+		for _, p := range dups {
+			lines = append(lines, p.String()+" at "+strings.Join(seen[p], ", "))
+		}
+		list(w, lines)
+	}
+
+	if ordered {
+		var lines []string
+		for i := 1; i < len(valid); i++ {
+			prev, d := valid[i-1], valid[i]
+			if prev.rank.Compare(d.rank) > 0 {
+				lines = append(lines, fmt.Sprintf("%s: %s comes after %s at %s", d.where, d.text, prev.text, prev.where))
+			}
+		}
+		if len(lines) > 0 {
+			problem("out of order", len(lines))
+			list(w, lines)
+			fmt.Fprintln(w, "  (if the dump was sorted by the database, check the rank column's collation)")
+		}
+	}
+
+	sorted := make([]lexorank.Posn, len(valid))
+	for i, d := range valid {
+		sorted[i] = d.rank
+	}
+	slices.SortFunc(sorted, lexorank.Posn.Compare)
+	if vs := lexorank.RawOrder(sorted); len(vs) > 0 {
+		problem("sort differently as strings", len(vs))
+		var lines []string
+		for _, v := range vs {
+			lines = append(lines, v.Rank.String()+": "+v.Detail)
+		}
+		list(w, lines)
+	}
+
+	if maxLen > 0 {
+		var lines []string
+		for _, d := range valid {
+			if n := len(d.rank.Major) + len(d.rank.MinorValue()); n > maxLen {
+				lines = append(lines, fmt.Sprintf("%s: %d digits", d.where, n))
+			}
+		}
+		if len(lines) > 0 {
+			problem("longer than "+strconv.Itoa(maxLen)+" digits", len(lines))
+			list(w, lines)
+		}
+	}
+
+	if len(sorted) > 0 {
+		r := lexorank.Analyze(sorted)
+		fmt.Fprintf(w, "\nkeys: longest %d digits, mean %.1f; skew %.2f\n", r.MaxLen, r.MeanLen, r.Skew)
+	}
+	pol := lexorank.DefaultPolicy
+	pol.MaxLen = maxLen
+	d := pol.Decide(sorted)
+	switch d.Action {
+	case lexorank.NoAction:
+		fmt.Fprintln(w, "\nrecommendation: none needed")
+	case lexorank.RebalanceLocal:
+		ok = false
+		lo, hi := max(d.Center-d.Radius, 0), min(d.Center+d.Radius, len(sorted)-1)
+		fmt.Fprintf(w, "\nrecommendation: rebalance items %d to %d, in rank order from 0 (%s)\n", lo, hi, d.Reason)
+	default:
+		ok = false
+		fmt.Fprintf(w, "\nrecommendation: %s rebalance (%s)\n", d.Action, d.Reason)
+	}
+	return ok
+}
+
+// maxListed is how many of each kind of problem diagnose lists
+const maxListed = 20
+
+func list(w io.Writer, lines []string) {
+	for i, l := range lines {
+		if i == maxListed {
+			fmt.Fprintf(w, "  ... and %d more\n", len(lines)-i)
+			break
+		}
+		fmt.Fprintln(w, "  "+l)
+	}
+}
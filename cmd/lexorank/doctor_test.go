@@ -0,0 +1,73 @@
+package main
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dkolbly/lexorank"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDoctorHealthy(t *testing.T) {
+	ranks, err := lexorank.Rebalance(100, 0)
+	assert.NoError(t, err)
+	var in strings.Builder
+	for _, p := range ranks {
+		in.WriteString(p.String() + "\n")
+	}
+	var out strings.Builder
+	assert.NoError(t, doctor(nil, strings.NewReader(in.String()), &out))
+	assert.Contains(t, out.String(), "read 100 ranks")
+	assert.Contains(t, out.String(), "recommendation: none needed")
+}
+
+func TestDoctorProblems(t *testing.T) {
+	in := "0|a:\n0|c:\nnope\n0|b:\n0|c:\n0|d" + strings.Repeat("0", 40) + ":\n"
+	var out strings.Builder
+	err := doctor(nil, strings.NewReader(in), &out)
+	assert.ErrorIs(t, err, errProblems)
+	s := out.String()
+	assert.Contains(t, s, "invalid: 1\n  line 3:")
+	assert.Contains(t, s, "duplicates: 1\n  0|c: at line 2, line 5")
+	assert.Contains(t, s, "out of order: 1\n  line 4: 0|b: comes after 0|c: at line 2")
+	assert.Contains(t, s, "longer than 32 digits: 1\n  line 6: 41 digits")
+	assert.Contains(t, s, "recommendation: rebalance")
+
+	// without the order check, the order doesn't matter
+	out.Reset()
+	err = doctor([]string{"-unordered"}, strings.NewReader("0|b:\n0|a:\n"), &out)
+	assert.NoError(t, err)
+	assert.NotContains(t, out.String(), "out of order")
+}
+
+func TestDoctorCSV(t *testing.T) {
+	in := "id,rank\n1,0|a:\n2,0|a:\n"
+	var out strings.Builder
+	assert.ErrorIs(t, doctor([]string{"-csv", "rank"}, strings.NewReader(in), &out), errProblems)
+	assert.Contains(t, out.String(), "0|a: at line 2, line 3")
+
+	out.Reset()
+	assert.ErrorIs(t, doctor([]string{"-csv", "2"}, strings.NewReader(in), &out), errProblems)
+	assert.Contains(t, out.String(), "invalid: 1\n  line 1:")
+
+	assert.Error(t, doctor([]string{"-csv", "nope"}, strings.NewReader(in), &out))
+}
+
+func TestDoctorQuery(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "db")
+	db, err := sql.Open("sqlite3", dsn)
+	assert.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE cards (id INTEGER, rank TEXT); INSERT INTO cards VALUES (1, '0|a:'), (2, '0|b:'), (3, NULL)`)
+	assert.NoError(t, err)
+	db.Close()
+
+	var out strings.Builder
+	err = doctor([]string{"-driver", "sqlite3", "-dsn", dsn, "-query", "SELECT rank, id FROM cards ORDER BY id"}, nil, &out)
+	assert.ErrorIs(t, err, errProblems)
+	assert.Contains(t, out.String(), "read 3 ranks")
+	assert.Contains(t, out.String(), "invalid: 1\n  row 3:")
+
+	assert.Error(t, doctor([]string{"-dsn", dsn}, nil, &out))
+}
@@ -0,0 +1,75 @@
+// Command lexorank is a toolbox for looking after ranked lists.
+//
+// Usage:
+//
+//	lexorank <command> [flags] [args]
+//
+// The commands are:
+//
+//	doctor    analyze a dump of ranks and report problems
+//	rebalance re-spread a dump of a list, however big
+//	shell     try out inserts and moves interactively
+//	vectors   write test vectors for other implementations
+//
+// Run "lexorank <command> -h" for a command's flags.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// A command is a subcommand, which reads from stdin and writes its
+// output to stdout
+type command struct {
+	run  func(args []string, stdin io.Reader, stdout io.Writer) error
+	help string
+}
+
+var commands = map[string]command{
+	"doctor":    {doctor, "analyze a dump of ranks and report problems"},
+	"rebalance": {rebalance, "re-spread a dump of a list, however big"},
+	"shell":     {shell, "try out inserts and moves interactively"},
+	"vectors":   {vectors, "write test vectors for other implementations"},
+}
+
+// errProblems is returned by commands that ran fine but found
+// something wrong, which they've already reported, for a non-zero
+// exit status
+var errProblems = errors.New("problems found")
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "lexorank: unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	err := cmd.run(os.Args[2:], os.Stdin, os.Stdout)
+	switch {
+	case errors.Is(err, errProblems):
+		os.Exit(1)
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "lexorank %s: %v\n", os.Args[1], err)
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: lexorank <command> [flags] [args]\n\ncommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-9s %s\n", name, commands[name].help)
+	}
+}
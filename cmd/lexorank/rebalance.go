@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dkolbly/lexorank"
+)
+
+// rebalance re-spreads a dump of a list, however big, sorting it on
+// disk
+func rebalance(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("rebalance", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), `usage: lexorank rebalance [flags] [file]
+
+Reads a list from file (or stdin), one item per line as an ID and its
+rank separated by a tab, in any order, and writes each item's ID and
+new rank, in list order, for loading back into the database.  The list
+is sorted in runs spilled to temporary files, so it can be much bigger
+than memory.
+
+flags:`)
+		fs.PrintDefaults()
+	}
+	bucket := fs.Uint("bucket", 0, "`bucket` for the new ranks")
+	tmp := fs.String("tmp", "", "`dir`ectory for temporary files (default the system's)")
+	run := fs.Int("run", 0, "sort `n` items at a time in memory (default a million)")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if *bucket > lexorank.MaxBucket {
+		return fmt.Errorf("bucket %d out of range", *bucket)
+	}
+	in := stdin
+	if fs.NArg() > 0 {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	x := lexorank.ExternalRebalance{Bucket: byte(*bucket), TempDir: *tmp, RunSize: *run}
+	_, err := x.Run(context.Background(), in, stdout)
+	return err
+}
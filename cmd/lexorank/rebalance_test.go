@@ -0,0 +1,22 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRebalance(t *testing.T) {
+	in := "c\t0|c:\na\t0|a:\nb\t0|b0000:z\n"
+	var out strings.Builder
+	assert.NoError(t, rebalance([]string{"-bucket", "2", "-run", "2", "-tmp", t.TempDir()}, strings.NewReader(in), &out))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 3)
+	for i, id := range []string{"a", "b", "c"} {
+		assert.True(t, strings.HasPrefix(lines[i], id+"\t2|"), lines[i])
+	}
+
+	assert.Error(t, rebalance([]string{"-bucket", "3"}, strings.NewReader(in), &out))
+	assert.Error(t, rebalance(nil, strings.NewReader("nope\n"), &out))
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dkolbly/lexorank"
+)
+
+// A shellItem is an item of the list being played with in the shell
+type shellItem struct {
+	id   string
+	rank lexorank.Posn
+}
+
+// A session is the state of the shell: the list, and where output
+// goes
+type session struct {
+	out    io.Writer
+	items  []shellItem
+	nextID int
+}
+
+const shellHelp = `commands:
+  new N              start a fresh list of N items, spread evenly
+  load FILE          load a list of ranks, one per line, in list order
+  insert I [N]       insert N items (1 if not given), one after the
+                     other, at index I (0 for the top, "end" for the
+                     bottom)
+  move FROM TO       move the item at index FROM to index TO
+  delete I           delete the item at index I
+  rebalance [C R]    re-spread the whole list, or just the items within
+                     R of index C
+  list               show the list
+  stats              show the gaps and key lengths
+  help               show this
+  quit               leave`
+
+// shell is an interactive playground for inserting into and moving
+// things around a list, and seeing what happens to the keys
+func shell(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("shell", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), `usage: lexorank shell [file]
+
+Starts an interactive shell for trying out inserts, moves and
+rebalances on a list, showing the new keys and how the gaps are doing
+after each one.  The list starts empty, or with the ranks in file, one
+per line.  Type "help" in the shell for its commands.`)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	s := &session{out: stdout}
+	if fs.NArg() > 0 {
+		if err := s.load(fs.Arg(0)); err != nil {
+			return err
+		}
+		s.summary()
+	}
+
+	sc := bufio.NewScanner(stdin)
+	for {
+		fmt.Fprint(stdout, "> ")
+		if !sc.Scan() {
+			fmt.Fprintln(stdout)
+			return sc.Err()
+		}
+		f := strings.Fields(sc.Text())
+		if len(f) == 0 {
+			continue
+		}
+		if f[0] == "quit" || f[0] == "exit" {
+			return nil
+		}
+		if err := s.run(f[0], f[1:]); err != nil {
+			fmt.Fprintln(stdout, "error:", err)
+		}
+	}
+}
+
+// run runs a command of the shell
+func (s *session) run(cmd string, args []string) error {
+	// the numbers the command takes, as indexes into the list (where
+	// "end" is the end of it)
+	ints := func(lo, hi int) ([]int, error) {
+		if len(args) < lo || len(args) > hi {
+			return nil, fmt.Errorf("wrong number of arguments to %s; try help", cmd)
+		}
+		out := make([]int, len(args))
+		for i, a := range args {
+			if a == "end" {
+				out[i] = len(s.items)
+				continue
+			}
+			n, err := strconv.Atoi(a)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("bad number %q", a)
+			}
+			out[i] = n
+		}
+		return out, nil
+	}
+
+	switch cmd {
+	case "new":
+		n, err := ints(1, 1)
+		if err != nil {
+			return err
+		}
+		ranks, err := lexorank.Rebalance(n[0], 0)
+		if err != nil {
+			return err
+		}
+		s.items = s.items[:0]
+		for _, p := range ranks {
+			s.items = append(s.items, s.item(p))
+		}
+		s.summary()
+	case "load":
+		if len(args) != 1 {
+			return errors.New("load takes a file name")
+		}
+		if err := s.load(args[0]); err != nil {
+			return err
+		}
+		s.summary()
+	case "insert":
+		n, err := ints(1, 2)
+		if err != nil {
+			return err
+		}
+		count := 1
+		if len(n) == 2 {
+			count = n[1]
+		}
+		for k := 0; k < count; k++ {
+			it, err := s.insert(n[0] + k)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(s.out, "inserted %s at %d: %s\n", it.id, n[0]+k, it.rank)
+		}
+		s.summary()
+	case "move":
+		n, err := ints(2, 2)
+		if err != nil {
+			return err
+		}
+		from, to := n[0], n[1]
+		if from >= len(s.items) || to >= len(s.items) {
+			return fmt.Errorf("index out of range")
+		}
+		it := s.items[from]
+		s.items = slices.Delete(s.items, from, from+1)
+		moved, err := s.place(to, it.id)
+		if err != nil {
+			s.items = slices.Insert(s.items, from, it)
+			return err
+		}
+		fmt.Fprintf(s.out, "moved %s from %d to %d: %s -> %s\n", it.id, from, to, it.rank, moved.rank)
+		s.summary()
+	case "delete":
+		n, err := ints(1, 1)
+		if err != nil {
+			return err
+		}
+		if n[0] >= len(s.items) {
+			return fmt.Errorf("index out of range")
+		}
+		fmt.Fprintf(s.out, "deleted %s: %s\n", s.items[n[0]].id, s.items[n[0]].rank)
+		s.items = slices.Delete(s.items, n[0], n[0]+1)
+		s.summary()
+	case "rebalance":
+		n, err := ints(0, 2)
+		if err != nil {
+			return err
+		}
+		if len(n) == 1 {
+			return errors.New("rebalance takes no arguments, or a center and a radius")
+		}
+		if err := s.rebalance(n); err != nil {
+			return err
+		}
+		s.summary()
+	case "list":
+		for i, it := range s.items {
+			fmt.Fprintf(s.out, "%4d  %-8s %s\n", i, it.id, it.rank)
+		}
+		if len(s.items) == 0 {
+			fmt.Fprintln(s.out, "(empty)")
+		}
+	case "stats":
+		s.stats()
+	case "help":
+		fmt.Fprintln(s.out, shellHelp)
+	default:
+		return fmt.Errorf("unknown command %q; try help", cmd)
+	}
+	return nil
+}
+
+// item makes a new item with rank p
+func (s *session) item(p lexorank.Posn) shellItem {
+	s.nextID++
+	return shellItem{"item" + strconv.Itoa(s.nextID), p}
+}
+
+func (s *session) load(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	lines, err := readLines(f)
+	if err != nil {
+		return err
+	}
+	items := make([]shellItem, 0, len(lines))
+	for _, d := range lines {
+		p, err := lexorank.Parse(d.text)
+		if err != nil {
+			return fmt.Errorf("%s: %v", d.where, err)
+		}
+		items = append(items, s.item(p))
+	}
+	s.items = items
+	return nil
+}
+
+// insert puts a new item at index i
+func (s *session) insert(i int) (shellItem, error) {
+	if i > len(s.items) {
+		return shellItem{}, fmt.Errorf("index out of range")
+	}
+	s.nextID++
+	it, err := s.place(i, "item"+strconv.Itoa(s.nextID))
+	if err != nil {
+		s.nextID--
+	}
+	return it, err
+}
+
+// place puts the item id at index i, between the items either side
+func (s *session) place(i int, id string) (shellItem, error) {
+	var prev, next *lexorank.Posn
+	if i > 0 {
+		prev = &s.items[i-1].rank
+	}
+	if i < len(s.items) {
+		next = &s.items[i].rank
+	}
+	r, ok := lexorank.Ranks(1, prev, next)
+	if !ok {
+		return shellItem{}, lexorank.ErrNoRoom
+	}
+	it := shellItem{id, r[0]}
+	s.items = slices.Insert(s.items, i, it)
+	return it, nil
+}
+
+// rebalance re-spreads the list, or the window n gives
+func (s *session) rebalance(n []int) error {
+	ranks := s.ranks()
+	if len(n) == 0 {
+		bucket := byte(0)
+		if len(ranks) > 0 {
+			bucket = ranks[0].Bucket
+		}
+		fresh, err := lexorank.Rebalance(len(ranks), bucket)
+		if err != nil {
+			return err
+		}
+		for i := range s.items {
+			s.items[i].rank = fresh[i]
+		}
+		fmt.Fprintf(s.out, "rebalanced %d items\n", len(s.items))
+		return nil
+	}
+	ups, ok := lexorank.RebalanceWindow(ranks, n[0], n[1])
+	if !ok {
+		return lexorank.ErrNoRoom
+	}
+	for _, u := range ups {
+		it := &s.items[u.Index]
+		fmt.Fprintf(s.out, "%4d  %-8s %s -> %s\n", u.Index, it.id, it.rank, u.Rank)
+		it.rank = u.Rank
+	}
+	return nil
+}
+
+func (s *session) ranks() []lexorank.Posn {
+	ranks := make([]lexorank.Posn, len(s.items))
+	for i, it := range s.items {
+		ranks[i] = it.rank
+	}
+	return ranks
+}
+
+// summary writes a line on the state of the list
+func (s *session) summary() {
+	if len(s.items) == 0 {
+		fmt.Fprintln(s.out, "0 items")
+		return
+	}
+	r := lexorank.Analyze(s.ranks())
+	tightest := 0
+	if len(r.Tightest) > 0 {
+		tightest = r.Tightest[0].Len
+	}
+	fmt.Fprintf(s.out, "%d items; keys longest %d, mean %.1f; skew %.2f; tightest gap takes %d digits\n",
+		r.Count, r.MaxLen, r.MeanLen, r.Skew, tightest)
+}
+
+// stats writes out the gaps and key lengths in full
+func (s *session) stats() {
+	s.summary()
+	if len(s.items) == 0 {
+		return
+	}
+	r := lexorank.Analyze(s.ranks())
+	hist := func(title string, m map[int]int) {
+		fmt.Fprintln(s.out, title)
+		keys := make([]int, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			fmt.Fprintf(s.out, "  %3d: %d\n", k, m[k])
+		}
+	}
+	hist("key lengths:", r.Lengths)
+	hist("gaps, by the length of key that goes in them:", r.Gaps)
+	fmt.Fprintln(s.out, "tightest gaps:")
+	for _, g := range r.Tightest {
+		fmt.Fprintf(s.out, "  %s .. %s: %d digits\n", posnOrEnd(g.Prev), posnOrEnd(g.Next), g.Len)
+	}
+}
+
+func posnOrEnd(p *lexorank.Posn) string {
+	if p == nil {
+		return "(end)"
+	}
+	return p.String()
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShell(t *testing.T) {
+	in := `new 3
+insert 1 2
+insert end
+move 0 4
+delete 2
+rebalance 1 1
+rebalance
+bogus
+insert 99
+list
+stats
+quit
+insert 0
+`
+	var out strings.Builder
+	assert.NoError(t, shell(nil, strings.NewReader(in), &out))
+	s := out.String()
+	assert.Contains(t, s, "3 items;")
+	assert.Contains(t, s, "inserted item4 at 1: ")
+	assert.Contains(t, s, "inserted item5 at 2: ")
+	assert.Contains(t, s, "inserted item6 at 5: ")
+	assert.Contains(t, s, "moved item1 from 0 to 4: ")
+	assert.Contains(t, s, "deleted ")
+	assert.Contains(t, s, "rebalanced 5 items")
+	assert.Contains(t, s, `error: unknown command "bogus"`)
+	assert.Contains(t, s, "error: index out of range")
+	assert.Contains(t, s, "tightest gaps:")
+	assert.NotContains(t, s, "item7", "nothing runs after quit")
+
+	// the list ends up in order
+	ranks := []string{}
+	for _, line := range strings.Split(s, "\n") {
+		if f := strings.Fields(strings.TrimPrefix(line, "> ")); len(f) == 3 && strings.HasPrefix(f[1], "item") && !strings.HasSuffix(f[1], ":") {
+			ranks = append(ranks, f[2])
+		}
+	}
+	assert.Len(t, ranks, 5)
+	assert.IsIncreasing(t, ranks)
+}
+
+func TestShellLoad(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "ranks")
+	assert.NoError(t, os.WriteFile(name, []byte("0|a:\n0|b:\n\n0|c:\n"), 0o644))
+
+	var out strings.Builder
+	assert.NoError(t, shell([]string{name}, strings.NewReader("insert 1\n"), &out))
+	assert.Contains(t, out.String(), "3 items;")
+	assert.Contains(t, out.String(), "inserted item4 at 1: 0|a")
+
+	out.Reset()
+	assert.NoError(t, os.WriteFile(name, []byte("0|a:\nnope\n"), 0o644))
+	assert.NoError(t, shell(nil, strings.NewReader("load "+name+"\n"), &out))
+	assert.Contains(t, out.String(), "error: line 2: ")
+	assert.Error(t, shell([]string{name}, strings.NewReader(""), &out))
+}
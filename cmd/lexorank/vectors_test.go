@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/dkolbly/lexorank"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVectors(t *testing.T) {
+	var out strings.Builder
+	assert.NoError(t, vectors([]string{"-n", "50"}, nil, &out))
+	var v Vectors
+	assert.NoError(t, json.Unmarshal([]byte(out.String()), &v))
+	assert.Equal(t, lexorank.Base62.Digits(), v.Alphabet)
+	assert.Len(t, v.Compare, 54)
+
+	// the vectors agree with the package
+	for _, c := range v.Between {
+		r, ok := lexorank.Rank(c.Prev, c.Next)
+		assert.Equal(t, c.OK, ok)
+		if ok {
+			assert.Equal(t, c.Rank, r)
+		}
+	}
+	for _, c := range v.Ranks {
+		if c.OK {
+			assert.Len(t, c.Ranks, c.N)
+		}
+	}
+	var good, bad int
+	for _, c := range v.Parse {
+		_, err := lexorank.Parse(c.Input)
+		assert.Equal(t, c.OK, err == nil, c.Input)
+		if c.OK {
+			good++
+		} else {
+			bad++
+		}
+	}
+	assert.True(t, good > 0 && bad > 0)
+
+	// and are the same every time
+	var again strings.Builder
+	assert.NoError(t, vectors([]string{"-n", "50"}, nil, &again))
+	assert.Equal(t, out.String(), again.String())
+
+	again.Reset()
+	assert.NoError(t, vectors([]string{"-n", "50", "-seed", "2"}, nil, &again))
+	assert.NotEqual(t, out.String(), again.String())
+
+	assert.NoError(t, vectors([]string{"-alphabet", "crockford32", "-n", "5"}, nil, &again))
+	assert.Error(t, vectors([]string{"-alphabet", "nope"}, nil, &again))
+}
@@ -0,0 +1,308 @@
+package lexorank
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strconv"
+	"time"
+)
+
+// A CollisionStrategy decides what to do when inserting an item into
+// a Store fails because another item got the rank first, as happens
+// when two writers insert at the same spot at the same time.  Retry,
+// ShiftRight and Escalate are the usual choices.
+type CollisionStrategy interface {
+	// Resolve returns the bounds to try inserting between next, or
+	// an error to give up with.
+	Resolve(ctx context.Context, c Collision) (prev, next *Posn, err error)
+}
+
+// A Collision describes a failed insert.
+type Collision struct {
+	Store Store
+	List  string
+
+	// Prev and Next are the bounds the insert was between, and Rank
+	// the rank it tried to take
+	Prev, Next *Posn
+	Rank       Posn
+
+	// Attempt counts the collisions so far, starting from 1
+	Attempt int
+
+	// Err is what the Store returned
+	Err error
+}
+
+// after returns the rank of the first item after p in the list
+func (c Collision) after(ctx context.Context, p *Posn) (*Posn, error) {
+	items, err := c.Store.Items(ctx, c.List, p, 1)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+	return &items[0].Rank, nil
+}
+
+// Retry tries again between the same item and whatever now follows
+// it, after a random pause of up to Jitter (times the number of
+// attempts so far), so that writers that collided don't just collide
+// again.
+type Retry struct {
+	// Attempts is how many collisions to put up with (3 if zero)
+	Attempts int
+	Jitter   time.Duration
+}
+
+func (r Retry) Resolve(ctx context.Context, c Collision) (*Posn, *Posn, error) {
+	if c.Attempt > orDefault(r.Attempts, 3) {
+		return nil, nil, c.Err
+	}
+	if r.Jitter > 0 {
+		t := time.NewTimer(time.Duration(rand.Int63n(int64(r.Jitter) * int64(c.Attempt))))
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return nil, nil, ctx.Err()
+		}
+	}
+	next, err := c.after(ctx, c.Prev)
+	return c.Prev, next, err
+}
+
+// ShiftRight goes straight after the item that took the rank, without
+// waiting: it gives up the exact spot, but settles quickly when many
+// writers are appending in the same place.
+type ShiftRight struct {
+	// Attempts is how many collisions to put up with (3 if zero)
+	Attempts int
+}
+
+func (s ShiftRight) Resolve(ctx context.Context, c Collision) (*Posn, *Posn, error) {
+	if c.Attempt > orDefault(s.Attempts, 3) {
+		return nil, nil, c.Err
+	}
+	next, err := c.after(ctx, &c.Rank)
+	return &c.Rank, next, err
+}
+
+// Escalate re-spreads the items around the collision (Radius of them
+// either side) through the Store, as RebalanceWindow would, leaving a
+// gap where the new item goes, for spots so busy that the ranks there
+// have run short of room.
+type Escalate struct {
+	// Generator makes the new ranks for the window
+	Generator Generator
+
+	// Radius is how many items either side to re-spread (8 if zero),
+	// and Attempts how many collisions to put up with (3 if zero)
+	Radius, Attempts int
+}
+
+func (e Escalate) Resolve(ctx context.Context, c Collision) (*Posn, *Posn, error) {
+	if c.Attempt > orDefault(e.Attempts, 3) {
+		return nil, nil, c.Err
+	}
+	radius := orDefault(e.Radius, 8)
+	// the window, and the items either side of it as bounds
+	left, err := c.Store.ItemsBefore(ctx, c.List, &c.Rank, radius+1)
+	if err != nil {
+		return nil, nil, err
+	}
+	var prev, next *Posn
+	if len(left) > radius {
+		prev = &left[0].Rank
+		left = left[1:]
+	}
+	want := len(left) + radius + 2
+	window, err := c.Store.Items(ctx, c.List, prev, want)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(window) == want {
+		next = &window[want-1].Rank
+		window = window[:want-1]
+	}
+
+	// the new item goes after the items up to c.Prev
+	at := 0
+	for at < len(window) && c.Prev != nil && window[at].Rank.Compare(*c.Prev) <= 0 {
+		at++
+	}
+	ranks, ok := e.Generator.quiet().AllocateBlock(prev, next, len(window)+1)
+	if !ok {
+		return nil, nil, ErrNoRoom
+	}
+	var (
+		updates []Item
+		old     []Posn
+	)
+	for i, it := range window {
+		r := ranks[i]
+		if i >= at {
+			r = ranks[i+1]
+		}
+		if !it.Rank.Equal(r) {
+			updates = append(updates, Item{ID: it.ID, Rank: r})
+			old = append(old, it.Rank)
+		}
+	}
+	if err := c.Store.Update(ctx, c.List, updates); err != nil {
+		return nil, nil, err
+	}
+	e.Generator.audit(ctx, c.List, AuditRebalance, old, updates)
+	if at > 0 {
+		prev = &ranks[at-1]
+	}
+	if at+1 < len(ranks) {
+		next = &ranks[at+1]
+	}
+	return prev, next, nil
+}
+
+func orDefault(n, def int) int {
+	if n <= 0 {
+		return def
+	}
+	return n
+}
+
+// InsertStore adds an item to a list in s, with a rank between prev
+// and next (either of which may be nil, for the start or end of the
+// list), and returns its rank.  If another writer takes the rank
+// first, the generator's Collisions strategy (Retry if it has none)
+// decides what to do.
+func InsertStore(ctx context.Context, s Store, list, id string, prev, next *Posn) (Posn, error) {
+	return Generator{}.InsertStore(ctx, s, list, id, prev, next)
+}
+
+// InsertStore is like the package-level InsertStore, but uses the
+// generator's configuration.
+func (g Generator) InsertStore(ctx context.Context, s Store, list, id string, prev, next *Posn) (_ Posn, err error) {
+	ctx, end := g.startSpan(ctx, "InsertStore", 1)
+	touched := 0
+	defer func() { end(touched, err) }()
+	strategy := g.Collisions
+	if strategy == nil {
+		strategy = Retry{}
+	}
+	for attempt := 1; ; attempt++ {
+		ranks, ok := g.Ranks(1, prev, next)
+		if !ok {
+			return Posn{}, ErrNoRoom
+		}
+		item := Item{ID: id, Rank: ranks[0]}
+		err := s.Insert(ctx, list, item)
+		if err == nil {
+			g.audit(ctx, list, AuditInsert, nil, []Item{item})
+			touched = 1
+			return ranks[0], nil
+		}
+		if !errors.Is(err, ErrCollision) {
+			return Posn{}, err
+		}
+		prev, next, err = strategy.Resolve(ctx, Collision{
+			Store:   s,
+			List:    list,
+			Prev:    prev,
+			Next:    next,
+			Rank:    ranks[0],
+			Attempt: attempt,
+			Err:     err,
+		})
+		if err != nil {
+			return Posn{}, err
+		}
+	}
+}
+
+// AllocateBetween adds an item to a list in s straight after the item
+// with ID afterID (or at the top of the list, if afterID is empty),
+// and returns its rank.  This is the whole of inserting safely
+// alongside other writers: the neighbours are looked up, a rank is
+// picked between them and written, and if another writer got there
+// first, the generator's Collisions strategy (Retry, with a little
+// backoff, if it has none) decides whether to go again, with the
+// neighbours looked up afresh in case afterID has moved in the
+// meantime.  It fails with ErrNotFound if afterID isn't in the list.
+// (A Generator can be told to look past soft-deleted items that follow
+// afterID; see TombstoneMode.)
+func AllocateBetween(ctx context.Context, s Store, list, afterID, id string) (Posn, error) {
+	return Generator{}.AllocateBetween(ctx, s, list, afterID, id)
+}
+
+// AllocateBetween is like the package-level AllocateBetween, but uses
+// the generator's configuration.
+func (g Generator) AllocateBetween(ctx context.Context, s Store, list, afterID, id string) (Posn, error) {
+	prev, next, err := g.neighboursAfter(ctx, s, list, afterID)
+	if err != nil {
+		return Posn{}, err
+	}
+	strategy := g.Collisions
+	if strategy == nil {
+		strategy = Retry{Jitter: 10 * time.Millisecond}
+	}
+	g.Collisions = refetch{strategy, afterID, g}
+	return g.InsertStore(ctx, s, list, id, prev, next)
+}
+
+// refetch waits (or gives up) as another strategy says, but then
+// tries again after the item it's inserting after, wherever that is
+// now
+type refetch struct {
+	strategy CollisionStrategy
+	after    string
+	g        Generator
+}
+
+func (r refetch) Resolve(ctx context.Context, c Collision) (*Posn, *Posn, error) {
+	if _, _, err := r.strategy.Resolve(ctx, c); err != nil {
+		return nil, nil, err
+	}
+	return r.g.neighboursAfter(ctx, c.Store, c.List, r.after)
+}
+
+// neighboursAfter returns the bounds for an item going after the item
+// with the given ID (or at the top, if id is empty), looking past
+// soft-deleted items if the generator is told to
+func (g Generator) neighboursAfter(ctx context.Context, s Store, list, id string) (*Posn, *Posn, error) {
+	prev, next, err := neighboursAfter(ctx, s, list, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return g.skipTombstones(ctx, s, list, prev, next)
+}
+
+// neighboursAfter returns the ranks of the item with the given ID and
+// the one after it (or of nothing and the first item, if id is empty)
+func neighboursAfter(ctx context.Context, s Store, list, id string) (prev, next *Posn, err error) {
+	var after *Posn
+	for {
+		items, err := s.Items(ctx, list, after, 1000)
+		if err != nil {
+			return nil, nil, err
+		}
+		if id == "" {
+			if len(items) > 0 {
+				next = &items[0].Rank
+			}
+			return nil, next, nil
+		}
+		if len(items) == 0 {
+			return nil, nil, &wrapError{ErrNotFound.Error() + ": " + strconv.Quote(id), ErrNotFound}
+		}
+		for i, it := range items {
+			if it.ID != id {
+				continue
+			}
+			if i+1 < len(items) {
+				return &it.Rank, &items[i+1].Rank, nil
+			}
+			c := Collision{Store: s, List: list}
+			next, err := c.after(ctx, &it.Rank)
+			return &it.Rank, next, err
+		}
+		after = &items[len(items)-1].Rank
+	}
+}
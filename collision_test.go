@@ -0,0 +1,148 @@
+package lexorank
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// racingStore is a store in which a rival writer takes the rank of
+// each of the first few inserts just before it happens
+type racingStore struct {
+	*MemStore
+	races int
+}
+
+func (s *racingStore) Insert(ctx context.Context, list string, item Item) error {
+	if s.races > 0 {
+		s.races--
+		s.MemStore.Put(list, Item{"rival" + string(rune('0'+s.races)), item.Rank})
+	}
+	return s.MemStore.Insert(ctx, list, item)
+}
+
+func racing(races int) (*racingStore, Posn, Posn) {
+	m := &MemStore{}
+	a, c := Posn{Major: "a"}, Posn{Major: "c"}
+	m.Put("list", Item{"a", a}, Item{"c", c})
+	return &racingStore{m, races}, a, c
+}
+
+func ids(items []Item) []string {
+	var out []string
+	for _, it := range items {
+		out = append(out, it.ID)
+	}
+	return out
+}
+
+func TestInsertStore(t *testing.T) {
+	ctx := context.Background()
+	s, a, c := racing(0)
+	p, err := InsertStore(ctx, s, "list", "b", &a, &c)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", p.Major)
+	assert.Equal(t, []string{"a", "b", "c"}, ids(s.List("list")))
+}
+
+func TestCollisionStrategies(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		strategy CollisionStrategy
+		want     []string
+	}{
+		{Retry{Jitter: time.Millisecond}, []string{"a", "new", "rival0", "c"}},
+		{ShiftRight{}, []string{"a", "rival0", "new", "c"}},
+		{Escalate{Radius: 1}, []string{"a", "new", "rival0", "c"}},
+	} {
+		s, a, c := racing(1)
+		g := Generator{Collisions: tc.strategy}
+		_, err := g.InsertStore(ctx, s, "list", "new", &a, &c)
+		assert.NoError(t, err)
+		items := s.List("list")
+		assert.Equal(t, tc.want, ids(items))
+		for i := 1; i < len(items); i++ {
+			assert.True(t, items[i-1].Rank.Compare(items[i].Rank) < 0)
+		}
+	}
+}
+
+func TestCollisionGiveUp(t *testing.T) {
+	ctx := context.Background()
+	s, a, c := racing(10)
+	_, err := Generator{Collisions: ShiftRight{Attempts: 2}}.InsertStore(ctx, s, "list", "new", &a, &c)
+	assert.True(t, errors.Is(err, ErrCollision))
+	assert.Equal(t, 7, s.races)
+}
+
+func TestEscalate(t *testing.T) {
+	ctx := context.Background()
+	m := &MemStore{}
+	var ranks []Posn
+	for _, major := range []string{"a", "a1", "a2", "a3", "a4", "a5", "a6"} {
+		ranks = append(ranks, Posn{Major: major})
+		m.Put("list", Item{major, Posn{Major: major}})
+	}
+	// the new item was going between a2 and a3, where a rival got in
+	// first
+	prev, next, err := Escalate{Radius: 2}.Resolve(ctx, Collision{
+		Store:   m,
+		List:    "list",
+		Prev:    &ranks[2],
+		Next:    &ranks[3],
+		Rank:    Posn{Major: "a2V"},
+		Attempt: 1,
+	})
+	assert.NoError(t, err)
+	items := m.List("list")
+	assert.Equal(t, []string{"a", "a1", "a2", "a3", "a4", "a5", "a6"}, ids(items))
+	// a and a6 are outside the window and stay put
+	assert.Equal(t, ranks[0], items[0].Rank)
+	assert.Equal(t, ranks[6], items[6].Rank)
+	assert.Equal(t, items[2].Rank, *prev)
+	assert.Equal(t, items[3].Rank, *next)
+}
+
+// movingStore is a racing store where the rival also moves item "a"
+// to the end of the list, as a concurrent drag would
+type movingStore struct {
+	*racingStore
+}
+
+func (s movingStore) Insert(ctx context.Context, list string, item Item) error {
+	if s.races > 0 {
+		s.MemStore.Put(list, Item{"a", Posn{Major: "x"}})
+	}
+	return s.racingStore.Insert(ctx, list, item)
+}
+
+func TestAllocateBetween(t *testing.T) {
+	ctx := context.Background()
+	s, _, _ := racing(0)
+	p, err := AllocateBetween(ctx, s, "list", "a", "b")
+	assert.NoError(t, err)
+	assert.Equal(t, "b", p.Major)
+	_, err = AllocateBetween(ctx, s, "list", "", "top")
+	assert.NoError(t, err)
+	_, err = AllocateBetween(ctx, s, "list", "c", "bottom")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"top", "a", "b", "c", "bottom"}, ids(s.List("list")))
+
+	_, err = AllocateBetween(ctx, s, "list", "nope", "new")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// after a collision the neighbours are looked up again, so the
+	// new item follows "a" to where it has moved
+	m := movingStore{&racingStore{&MemStore{}, 1}}
+	m.Put("list", Item{"a", Posn{Major: "a"}}, Item{"c", Posn{Major: "c"}})
+	_, err = Generator{Collisions: Retry{}}.AllocateBetween(ctx, m, "list", "a", "new")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"rival0", "c", "a", "new"}, ids(m.List("list")))
+
+	s, _, _ = racing(10)
+	_, err = AllocateBetween(ctx, s, "list", "a", "new")
+	assert.ErrorIs(t, err, ErrCollision)
+}
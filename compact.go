@@ -0,0 +1,92 @@
+package lexorank
+
+// Compact proposes shorter ranks for a list whose keys have grown
+// long, as happens after many inserts at the same spot.  ranks holds
+// the list in order.  Items whose keys are already about as short as
+// a list of that size allows are left alone, and the runs of long
+// keys between them are re-spread through the gaps they occupy; a run
+// is only changed if that saves space overall, and then only the
+// items whose rank actually changes get an update.  Order, and
+// bucket, is preserved.  If ranks isn't in order, or isn't valid,
+// there is nothing to propose.
+func Compact(ranks []Posn) []Update {
+	return Generator{}.Compact(ranks)
+}
+
+// Compact is like the package-level Compact, but uses the generator's
+// configuration.
+func (g Generator) Compact(ranks []Posn) []Update {
+	a := g.alphabet()
+	for i, p := range ranks {
+		if !a.valid(p.digits()) || (i > 0 && ranks[i-1].Compare(p) >= 0) {
+			return nil
+		}
+	}
+
+	// a list of n items fits in keys of this length
+	short := 1
+	for room := a.base() - 1; room < len(ranks); room *= a.base() {
+		short++
+	}
+
+	var out []Update
+	for start := 0; start < len(ranks); {
+		// buckets are compacted separately
+		end := start
+		for end < len(ranks) && ranks[end].Bucket == ranks[start].Bucket {
+			end++
+		}
+		out = g.compactRuns(out, ranks, start, end, short)
+		start = end
+	}
+	return out
+}
+
+// compactRuns re-spreads the runs of keys longer than short in
+// ranks[start:end] between the short keys around them
+func (g Generator) compactRuns(out []Update, ranks []Posn, start, end, short int) []Update {
+	var prev *Posn
+	for i := start; i < end; {
+		if len(ranks[i].digits()) <= short {
+			prev = &ranks[i]
+			i++
+			continue
+		}
+		j := i
+		for j < end && len(ranks[j].digits()) > short {
+			j++
+		}
+		var next *Posn
+		if j < end {
+			next = &ranks[j]
+		}
+		out = g.compactRun(out, ranks, i, j, prev, next)
+		i = j
+	}
+	return out
+}
+
+// compactRun re-spreads ranks[i:j] between prev and next (nil for the
+// ends of the bucket); the gap is worked out from the positions, as
+// their digits run together don't sort like them when the majors are
+// different lengths
+func (g Generator) compactRun(out []Update, ranks []Posn, i, j int, prev, next *Posn) []Update {
+	keys, ok := g.block(prev, next, j-i, ranks[i])
+	if !ok {
+		return out
+	}
+	before, after := 0, 0
+	for k, key := range keys {
+		before += len(ranks[i+k].digits())
+		after += len(key.digits())
+	}
+	if after >= before {
+		return out
+	}
+	for k, key := range keys {
+		if !key.Equal(ranks[i+k]) {
+			out = append(out, Update{Index: i + k, Rank: key})
+		}
+	}
+	return out
+}
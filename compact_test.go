@@ -0,0 +1,75 @@
+package lexorank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompact(t *testing.T) {
+	// keep inserting at the same spot
+	digits := []string{"a"}
+	lo := "a"
+	for i := 0; i < 20; i++ {
+		r, ok := Rank(lo, "b")
+		assert.Equal(t, true, ok)
+		digits = append(digits, r)
+		lo = r
+	}
+	digits = append(digits, "b", "c")
+	ranks := make([]Posn, len(digits))
+	before := 0
+	for i, d := range digits {
+		ranks[i] = Posn{Bucket: 1, Major: d}
+		before += len(d)
+	}
+
+	up := Compact(ranks)
+	assert.NotEmpty(t, up)
+	for _, u := range up {
+		assert.NotEqual(t, "a", ranks[u.Index].Major)
+		assert.NotEqual(t, "b", ranks[u.Index].Major)
+		assert.NotEqual(t, "c", ranks[u.Index].Major)
+		ranks[u.Index] = u.Rank
+	}
+	after := 0
+	for i, p := range ranks {
+		assert.Equal(t, byte(1), p.Bucket)
+		after += len(p.digits())
+		if i > 0 {
+			assert.Equal(t, -1, ranks[i-1].Compare(p))
+		}
+	}
+	assert.True(t, after < before, "%d >= %d", after, before)
+}
+
+func TestCompactNothingToDo(t *testing.T) {
+	ranks := []Posn{{Major: "a"}, {Major: "b"}, {Major: "c"}}
+	assert.Empty(t, Compact(ranks))
+
+	// out of order
+	ranks = []Posn{{Major: "b"}, {Major: "azzzzzz"}}
+	assert.Empty(t, Compact(ranks))
+}
+
+func TestCompactMajors(t *testing.T) {
+	// majors of different lengths, whose digits run together don't
+	// sort like the positions
+	ranks := []Posn{
+		{Major: "b"},
+		{Major: "b", Minor: "4w"},
+		{Major: "b", Minor: "7f"},
+		{Major: "ba", Minor: "y2"},
+		{Major: "c", Minor: "n"},
+		{Major: "d"},
+		{Major: "da", Minor: "c"},
+	}
+	up := Compact(ranks)
+	assert.NotEmpty(t, up)
+	for _, u := range up {
+		ranks[u.Index] = u.Rank
+	}
+	for i := 1; i < len(ranks); i++ {
+		assert.Equal(t, -1, ranks[i-1].Compare(ranks[i]), ranks[i])
+	}
+}
@@ -0,0 +1,99 @@
+package lexorank
+
+import "strings"
+
+// A SiteRanker makes ranks for lists edited at several sites (devices,
+// replicas) at once and merged later, as local-first apps do.  Every
+// rank it makes ends with its site's ID, so when two sites insert at
+// the same spot concurrently they still get different ranks, which
+// sort in the order of their site IDs.  Since that is just the order
+// of the keys, every replica ends up with the same list however the
+// edits are merged.
+//
+// Site IDs must all be the same length, for the ranks to be told
+// apart reliably.
+type SiteRanker struct {
+	gen  Generator
+	site string
+}
+
+// NewSiteRanker returns a SiteRanker for the site with the given ID,
+// which must be made of digits in g's alphabet and must not end with
+// its smallest digit.
+func NewSiteRanker(g Generator, site string) (SiteRanker, error) {
+	a := g.alphabet()
+	if site == "" {
+		return SiteRanker{}, newError("empty site ID")
+	}
+	if !a.valid(site) {
+		return SiteRanker{}, newError("site ID " + site + " is not valid in the alphabet")
+	}
+	if site[len(site)-1] == a.min() {
+		return SiteRanker{}, newError("site ID " + site + " ends with the smallest digit")
+	}
+	return SiteRanker{g, site}, nil
+}
+
+// Site returns the site's ID.
+func (s SiteRanker) Site() string {
+	return s.site
+}
+
+// SiteOf returns the ID of the site that made p (assuming it was made
+// by a SiteRanker with an ID of the same length as s's).
+func (s SiteRanker) SiteOf(p Posn) string {
+	d := p.digits()
+	return d[max(len(d)-len(s.site), 0):]
+}
+
+// Rank returns a rank between prev and next (either of which may be
+// nil, for the start or end of the list), ending in the site's ID.
+// The result is in prev's bucket (or next's).
+func (s SiteRanker) Rank(prev, next *Posn) (Posn, bool) {
+	var lo, hi string
+	var shape Posn
+	if next != nil {
+		hi = next.digits()
+		shape.Bucket = next.Bucket
+	}
+	if prev != nil {
+		lo = prev.digits()
+		shape.Bucket = prev.Bucket
+	}
+	if prev != nil && next != nil && prev.Bucket != next.Bucket {
+		return Posn{}, false
+	}
+	pos, ok := s.position(lo, hi)
+	if !ok {
+		return Posn{}, false
+	}
+	return Posn{Bucket: shape.Bucket, Major: pos + s.site}, true
+}
+
+// Ranks returns n ranks between prev and next, in order, each ending
+// in the site's ID.
+func (s SiteRanker) Ranks(n int, prev, next *Posn) ([]Posn, bool) {
+	out := make([]Posn, 0, n)
+	for range n {
+		p, ok := s.Rank(prev, next)
+		if !ok {
+			return nil, false
+		}
+		out = append(out, p)
+		prev = &out[len(out)-1]
+	}
+	return out, true
+}
+
+// position finds a position between lo and hi to put the site ID
+// after.  It mustn't be a prefix of hi, or the site ID could take the
+// rank past it.
+func (s SiteRanker) position(lo, hi string) (string, bool) {
+	pos, ok := s.gen.Rank(lo, hi)
+	for ok && strings.HasPrefix(hi, pos) {
+		// each time round pos gets longer, so this stops by the time
+		// it's as long as hi
+		pos, ok = s.gen.Rank(pos, hi)
+	}
+	return pos, ok
+}
@@ -0,0 +1,89 @@
+package lexorank
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSiteRanker(t *testing.T) {
+	a, err := NewSiteRanker(Generator{}, "aa")
+	assert.NoError(t, err)
+	b, err := NewSiteRanker(Generator{}, "bb")
+	assert.NoError(t, err)
+
+	// both sites insert into the same empty list, then between the
+	// same pair
+	pa, ok := a.Rank(nil, nil)
+	assert.True(t, ok)
+	pb, ok := b.Rank(nil, nil)
+	assert.True(t, ok)
+	assert.Equal(t, "Uaa", pa.Major)
+	assert.Equal(t, "Ubb", pb.Major)
+	assert.Equal(t, "aa", a.SiteOf(pa))
+	assert.Equal(t, "bb", a.SiteOf(pb))
+
+	qa, ok := a.Rank(&pa, &pb)
+	assert.True(t, ok)
+	qb, ok := b.Rank(&pa, &pb)
+	assert.True(t, ok)
+	merged := []Posn{pb, qb, pa, qa}
+	slices.SortFunc(merged, Posn.Compare)
+	assert.Equal(t, []Posn{pa, qa, qb, pb}, merged)
+
+	_, err = NewSiteRanker(Generator{}, "")
+	assert.Error(t, err)
+	_, err = NewSiteRanker(Generator{}, "a-")
+	assert.Error(t, err)
+	_, err = NewSiteRanker(Generator{}, "a0")
+	assert.Error(t, err)
+}
+
+func TestSiteRankerPrefix(t *testing.T) {
+	s, err := NewSiteRanker(Generator{}, "zz")
+	assert.NoError(t, err)
+	// the midpoint of these is a prefix of next, and the site ID
+	// mustn't take the rank past it
+	prev, next := Posn{Major: "a"}, Posn{Major: "aV1"}
+	p, ok := s.Rank(&prev, &next)
+	assert.True(t, ok)
+	assert.Empty(t, StrictlyBetween(&prev, p, &next), p.Major)
+}
+
+func TestSiteRankerConverges(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var sites []SiteRanker
+	for _, id := range []string{"k1", "m2", "x3"} {
+		s, err := NewSiteRanker(Generator{}, id)
+		assert.NoError(t, err)
+		sites = append(sites, s)
+	}
+	// each site makes inserts into its own copy of the list, which
+	// are then all merged
+	base, ok := sites[0].Ranks(5, nil, nil)
+	assert.True(t, ok)
+	var all []Posn
+	all = append(all, base...)
+	for _, s := range sites {
+		list := slices.Clone(base)
+		for range 200 {
+			i := rng.Intn(len(list) + 1)
+			var prev, next *Posn
+			if i > 0 {
+				prev = &list[i-1]
+			}
+			if i < len(list) {
+				next = &list[i]
+			}
+			p, ok := s.Rank(prev, next)
+			assert.True(t, ok)
+			list = slices.Insert(list, i, p)
+			all = append(all, p)
+		}
+		assert.True(t, slices.IsSortedFunc(list, Posn.Compare))
+	}
+	slices.SortFunc(all, Posn.Compare)
+	assert.Len(t, slices.CompactFunc(all, Posn.Equal), 5+3*200)
+}
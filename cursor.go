@@ -0,0 +1,59 @@
+package lexorank
+
+import (
+	"encoding/base64"
+	"errors"
+)
+
+// ErrBadCursor is returned by DecodeCursor for cursors EncodeCursor
+// can't have made.
+var ErrBadCursor = errors.New("lexorank: malformed cursor")
+
+// cursorEncoding is base64 with the URL-safe characters in ASCII
+// order, so that cursors sort the same way as what's in them
+var cursorEncoding = base64.NewEncoding("-0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ_abcdefghijklmnopqrstuvwxyz").
+	WithPadding(base64.NoPadding)
+
+// EncodeCursor returns an opaque, URL-safe cursor for paging through
+// a list ordered by rank and then by ID (to break ties, as there may
+// be when ranks aren't unique).  Cursors sort in the same order as
+// the items they point at.
+func EncodeCursor(rank Posn, id string) string {
+	b := []byte{rank.Bucket}
+	b = appendEscaped(b, rank.Major)
+	b = appendEscaped(b, rank.MinorValue())
+	return cursorEncoding.EncodeToString(append(b, id...))
+}
+
+// DecodeCursor is the inverse of EncodeCursor.
+func DecodeCursor(cursor string) (rank Posn, id string, err error) {
+	b, err := cursorEncoding.DecodeString(cursor)
+	if err != nil || len(b) == 0 {
+		return Posn{}, "", ErrBadCursor
+	}
+	rank.Bucket = b[0]
+	var ok bool
+	if rank.Major, b, ok = readEscaped(b[1:]); !ok || rank.Major == "" {
+		return Posn{}, "", ErrBadCursor
+	}
+	if rank.Minor, b, ok = readEscaped(b); !ok {
+		return Posn{}, "", ErrBadCursor
+	}
+	return rank, string(b), nil
+}
+
+// KeysetWhere returns a condition for a WHERE clause that selects the
+// rows after a cursor in (rankCol, idCol) order, or before it if
+// before is set, along with the arguments for its placeholders, for
+// keyset pagination.  rank is the cursor's rank as it is stored in
+// the rank column.  The placeholders are ?s; use sqlx's Rebind (or
+// similar) for databases that want something else.  The column names
+// are pasted in as they are, so they must come from the program, not
+// from users.
+func KeysetWhere(rankCol, idCol string, rank, id any, before bool) (string, []any) {
+	op := " > "
+	if before {
+		op = " < "
+	}
+	return "(" + rankCol + op + "? OR (" + rankCol + " = ? AND " + idCol + op + "?))", []any{rank, rank, id}
+}
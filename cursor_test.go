@@ -0,0 +1,54 @@
+package lexorank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursor(t *testing.T) {
+	// in order
+	items := []struct {
+		rank Posn
+		id   string
+	}{
+		{Posn{Major: "a"}, ""},
+		{Posn{Major: "a"}, "1"},
+		{Posn{Major: "a"}, "2"},
+		{Posn{Major: "a", Minor: "1"}, "1"},
+		{Posn{Major: "a0"}, "0"},
+		{Posn{Major: "b"}, "0"},
+		{Posn{Major: "b"}, "0\x00"},
+		{Posn{Bucket: 1, Major: "0"}, "0"},
+	}
+	for i, it := range items {
+		c := EncodeCursor(it.rank, it.id)
+		assert.Regexp(t, `^[-_0-9A-Za-z]+$`, c)
+		rank, id, err := DecodeCursor(c)
+		assert.NoError(t, err)
+		assert.Equal(t, it.rank, rank)
+		assert.Equal(t, it.id, id)
+		if i > 0 {
+			assert.Less(t, EncodeCursor(items[i-1].rank, items[i-1].id), c)
+		}
+	}
+
+	// an old-style minor comes back without its separator
+	rank, _, err := DecodeCursor(EncodeCursor(Posn{Major: "a", Minor: ":1"}, "x"))
+	assert.NoError(t, err)
+	assert.Equal(t, Posn{Major: "a", Minor: "1"}, rank)
+
+	for _, bad := range []string{"", "!!", "-", EncodeCursor(Posn{}, "x"), "0---"} {
+		_, _, err := DecodeCursor(bad)
+		assert.ErrorIs(t, err, ErrBadCursor, bad)
+	}
+}
+
+func TestKeysetWhere(t *testing.T) {
+	where, args := KeysetWhere("rank", "id", "0|a:", 7, false)
+	assert.Equal(t, "(rank > ? OR (rank = ? AND id > ?))", where)
+	assert.Equal(t, []any{"0|a:", "0|a:", 7}, args)
+
+	where, _ = KeysetWhere("r", "k", "a", "x", true)
+	assert.Equal(t, "(r < ? OR (r = ? AND k < ?))", where)
+}
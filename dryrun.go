@@ -0,0 +1,123 @@
+package lexorank
+
+import (
+	"context"
+	"sync"
+)
+
+// A DryRunStore wraps a Store, reading from it as usual but keeping
+// any changes to itself instead of writing them, so that rebalances
+// and the like (RebalanceStore, RebalanceIfNeeded, a RebalanceJob)
+// can be tried out first, to see how much they'd change:
+//
+//	dry := &lexorank.DryRunStore{Store: store}
+//	_, err := lexorank.RebalanceIfNeeded(ctx, dry, list, policy)
+//	fmt.Printf("%+v\n", dry.Report())
+//
+// Lists are read from the wrapped store as they were, so operations
+// that read back what they've written don't see their changes.
+type DryRunStore struct {
+	Store Store
+
+	mu      sync.Mutex
+	old     map[itemKey]Posn
+	changes map[itemKey]Posn
+}
+
+type itemKey struct {
+	list, id string
+}
+
+// A DryRunReport describes the changes a DryRunStore was asked to
+// make.
+type DryRunReport struct {
+	// Touched is the number of items that would have been written
+	Touched int
+
+	// OldLengths and NewLengths count the items touched by the
+	// length of their rank (in digits) before and after.  Items that
+	// would have been inserted aren't in OldLengths.
+	OldLengths, NewLengths map[int]int
+
+	// PayloadBytes estimates the size of the writes: the IDs and
+	// ranks (in String form) of the items touched
+	PayloadBytes int
+}
+
+func (d *DryRunStore) saw(list string, items []Item) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.old == nil {
+		d.old = make(map[itemKey]Posn)
+	}
+	for _, it := range items {
+		k := itemKey{list, it.ID}
+		if _, ok := d.old[k]; !ok {
+			d.old[k] = it.Rank
+		}
+	}
+}
+
+func (d *DryRunStore) change(list string, items []Item) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.changes == nil {
+		d.changes = make(map[itemKey]Posn)
+	}
+	for _, it := range items {
+		d.changes[itemKey{list, it.ID}] = it.Rank
+	}
+}
+
+// Items reads from the wrapped store.
+func (d *DryRunStore) Items(ctx context.Context, list string, after *Posn, limit int) ([]Item, error) {
+	items, err := d.Store.Items(ctx, list, after, limit)
+	d.saw(list, items)
+	return items, err
+}
+
+// ItemsBefore reads from the wrapped store.
+func (d *DryRunStore) ItemsBefore(ctx context.Context, list string, before *Posn, limit int) ([]Item, error) {
+	items, err := d.Store.ItemsBefore(ctx, list, before, limit)
+	d.saw(list, items)
+	return items, err
+}
+
+// Update records the new ranks without writing them.
+func (d *DryRunStore) Update(ctx context.Context, list string, items []Item) error {
+	d.change(list, items)
+	return ctx.Err()
+}
+
+// Insert records the new item without writing it.
+func (d *DryRunStore) Insert(ctx context.Context, list string, item Item) error {
+	d.change(list, []Item{item})
+	return ctx.Err()
+}
+
+// Report describes the changes so far.
+func (d *DryRunStore) Report() DryRunReport {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	r := DryRunReport{OldLengths: map[int]int{}, NewLengths: map[int]int{}}
+	for k, p := range d.changes {
+		old, ok := d.old[k]
+		if ok && old.Equal(p) {
+			continue
+		}
+		r.Touched++
+		if ok {
+			r.OldLengths[len(old.digits())]++
+		}
+		r.NewLengths[len(p.digits())]++
+		r.PayloadBytes += len(k.id) + len(p.String())
+	}
+	return r
+}
+
+// Reset forgets the changes so far, for trying something else.
+func (d *DryRunStore) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.old, d.changes = nil, nil
+}
@@ -0,0 +1,54 @@
+package lexorank
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDryRunStore(t *testing.T) {
+	ctx := context.Background()
+	m := &MemStore{}
+	for i := 0; i < 10; i++ {
+		m.Put("a", Item{strconv.Itoa(i), Posn{Major: "a" + strings.Repeat("0", i) + "1"}})
+	}
+	before := m.List("a")
+
+	dry := &DryRunStore{Store: m}
+	assert.NoError(t, RebalanceStore(ctx, dry, "a"))
+	assert.Equal(t, before, m.List("a"))
+
+	r := dry.Report()
+	assert.Equal(t, 10, r.Touched)
+	want := map[int]int{}
+	for i := 0; i < 10; i++ {
+		want[i+2]++
+	}
+	assert.Equal(t, want, r.OldLengths)
+	assert.Equal(t, map[int]int{6: 10}, r.NewLengths)
+	assert.Equal(t, 10*len("0|000000:")+10, r.PayloadBytes)
+
+	// the same goes for the other kinds of maintenance
+	dry.Reset()
+	assert.Equal(t, DryRunReport{OldLengths: map[int]int{}, NewLengths: map[int]int{}}, dry.Report())
+	job := RebalanceJob{Store: dry, List: "a", BatchSize: 3}
+	assert.NoError(t, job.Run(ctx))
+	assert.Equal(t, 10, dry.Report().Touched)
+	assert.Equal(t, before, m.List("a"))
+
+	// and writes that don't change anything don't count
+	dry.Reset()
+	items, err := dry.Items(ctx, "a", nil, 0)
+	assert.NoError(t, err)
+	assert.NoError(t, dry.Update(ctx, "a", items[:2]))
+	assert.NoError(t, dry.Insert(ctx, "a", Item{"new", Posn{Major: "b"}}))
+	assert.Equal(t, DryRunReport{
+		Touched:      1,
+		OldLengths:   map[int]int{},
+		NewLengths:   map[int]int{1: 1},
+		PayloadBytes: len("new") + len("0|b:"),
+	}, dry.Report())
+}
@@ -0,0 +1,62 @@
+package lexorank
+
+import "math/big"
+
+// maxStepDigits caps how much longer than the rank it steps from
+// stepRanks will go before giving up and leaving it to the usual
+// midpoints
+const maxStepDigits = 8
+
+// stepRanks returns n ranks between lo and hi, in order, stepping by
+// step at a time from one end of the gap: down from hi if down is set
+// (as for inserting at the top of a list whose first rank is hi), and
+// otherwise up from lo.  They're as long as the rank they step from,
+// unless there isn't room for them, in which case they're longer by
+// as little as will do.  The other bound is the edge of the keyspace,
+// and may be cut short to fit.
+func (g Generator) stepRanks(a Alphabet, lo, hi string, n, step int, down bool) ([]string, bool) {
+	from := lo
+	if down {
+		from = hi
+	}
+	if step <= 0 || g.Writers > 1 || n < 1 || from == "" {
+		return nil, false
+	}
+	total := big.NewInt(int64(step) * int64(n))
+	base, one := big.NewInt(int64(a.base())), big.NewInt(1)
+widths:
+	for width := len(from); width <= len(from)+maxStepDigits && !g.tooLong(width); width++ {
+		vlo, vhi := digitsValue(a, lo, width), digitsValue(a, hi, width)
+		first := new(big.Int)
+		if down {
+			first.Sub(vhi, total)
+			if first.Cmp(vlo) <= 0 {
+				continue
+			}
+		} else {
+			if new(big.Int).Add(vlo, total).Cmp(vhi) >= 0 {
+				continue
+			}
+			first.Add(vlo, big.NewInt(int64(step)))
+		}
+		out := make([]string, n)
+		v, r := new(big.Int), new(big.Int)
+		for i := range out {
+			v.Set(first)
+			first.Add(first, big.NewInt(int64(step)))
+			// ranks don't end in the smallest digit (see
+			// NoTrailingMin), so one that would is nudged up to the
+			// next value, as long as that doesn't run into the next
+			// rank or the end of the gap
+			if r.Rem(v, base).Sign() == 0 {
+				v.Add(v, one)
+				if (i < n-1 && v.Cmp(first) >= 0) || v.Cmp(vhi) >= 0 {
+					continue widths
+				}
+			}
+			out[i] = digitsString(a, v, width)
+		}
+		return out, true
+	}
+	return nil, false
+}
@@ -0,0 +1,126 @@
+package lexorank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeadStep(t *testing.T) {
+	g := Generator{HeadStep: 1000}
+	ranks, err := Rebalance(100, 0)
+	assert.NoError(t, err)
+
+	// keys stay the same length at the top of the list for far
+	// longer than with midpoints
+	plain := Forecast{Ranks: ranks, Pattern: AlwaysTop, MaxLen: 6}.Run()
+	stepped := Forecast{Ranks: ranks, Pattern: AlwaysTop, MaxLen: 6, Generator: g, Limit: 5000}.Run()
+	assert.Less(t, plain.Ops, 50)
+	assert.Equal(t, ForecastResult{Ops: 5000}, stepped)
+
+	first := ranks[0]
+	out, ok := g.Ranks(3, nil, &first)
+	assert.True(t, ok)
+	for i, p := range out {
+		assert.Len(t, p.Major, len(first.Major))
+		if i > 0 {
+			assert.Equal(t, -1, out[i-1].Compare(p))
+		}
+	}
+	assert.Equal(t, -1, out[2].Compare(first))
+
+	// when the step doesn't fit any more, keys grow by a digit
+	low := Posn{Major: "000002"}
+	out, ok = g.Ranks(1, nil, &low)
+	assert.True(t, ok)
+	assert.Len(t, out[0].Major, 8)
+	assert.Equal(t, -1, out[0].Compare(low))
+
+	r, ok := g.Rank("", "a")
+	assert.True(t, ok)
+	assert.Len(t, r, 2)
+	assert.Less(t, r, "a")
+	r, ok = g.Rank("", "1")
+	assert.True(t, ok)
+	assert.Less(t, "0", r)
+	assert.Less(t, r, "1")
+}
+
+func TestAppendStep(t *testing.T) {
+	g := Generator{AppendStep: 1000}
+	ranks, err := Rebalance(100, 0)
+	assert.NoError(t, err)
+
+	plain := Forecast{Ranks: ranks, Pattern: AlwaysBottom, MaxLen: 6}.Run()
+	stepped := Forecast{Ranks: ranks, Pattern: AlwaysBottom, MaxLen: 6, Generator: g, Limit: 5000}.Run()
+	assert.Less(t, plain.Ops, 50)
+	assert.Equal(t, ForecastResult{Ops: 5000}, stepped)
+
+	// appending one at a time steps up by exactly the increment
+	last := ranks[99]
+	out, ok := g.Ranks(1, &last, nil)
+	assert.True(t, ok)
+	v, err := ToBigInt(last, 6)
+	assert.NoError(t, err)
+	w, err := ToBigInt(out[0], 6)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), w.Sub(w, v).Int64())
+
+	// and grows by a digit once the end of the keyspace is near
+	high := Posn{Major: "zzzzzy"}
+	out, ok = g.Ranks(2, &high, nil)
+	assert.True(t, ok)
+	assert.Len(t, out[0].Major, 8)
+	assert.Equal(t, -1, high.Compare(out[0]))
+	assert.Equal(t, -1, out[1].Compare(Posn{Major: "zzzzzz"}))
+
+	r, ok := g.Rank("a", "")
+	assert.True(t, ok)
+	assert.Equal(t, "q8", r)
+	r, ok = g.Rank("y", "")
+	assert.True(t, ok)
+	assert.Less(t, "y", r)
+	assert.Less(t, r, "z")
+
+	// a HeadStep doesn't affect appends, nor AppendStep the top
+	assert.Equal(t, ranksOf(t, Generator{}, &last, nil), ranksOf(t, Generator{HeadStep: 1000}, &last, nil))
+	assert.Equal(t, ranksOf(t, Generator{}, nil, &ranks[0]), ranksOf(t, g, nil, &ranks[0]))
+}
+
+func TestStepNoTrailingMin(t *testing.T) {
+	r, ok := Generator{AppendStep: 1}.Rank("az", "")
+	assert.True(t, ok)
+	assert.Equal(t, "b1", r)
+	r, ok = Generator{HeadStep: 1}.Rank("", "b1")
+	assert.True(t, ok)
+	assert.Equal(t, "b0z", r)
+
+	for _, g := range []Generator{{AppendStep: 1}, {AppendStep: 2}, {HeadStep: 1}, {HeadStep: 62}} {
+		p := Posn{Major: "ax"}
+		for i := 0; i < 200; i++ {
+			var out []Posn
+			if g.AppendStep > 0 {
+				out, ok = g.Ranks(3, &p, nil)
+			} else {
+				out, ok = g.Ranks(3, nil, &p)
+			}
+			assert.True(t, ok)
+			for _, q := range out {
+				assert.Empty(t, NoTrailingMin(Base62, q), q)
+			}
+			if g.AppendStep > 0 {
+				assert.Equal(t, -1, p.Compare(out[0]))
+				p = out[2]
+			} else {
+				assert.Equal(t, -1, out[2].Compare(p))
+				p = out[0]
+			}
+		}
+	}
+}
+
+func ranksOf(t *testing.T, g Generator, prev, next *Posn) []Posn {
+	out, ok := g.Ranks(2, prev, next)
+	assert.True(t, ok)
+	return out
+}
@@ -0,0 +1,75 @@
+package lexorank
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Encode writes v as a number in the alphabet, padded on the left
+// with its smallest digit to at least width digits.  Numbers encoded
+// to the same width sort the same way as strings as they do as
+// numbers, which makes them handy for cursors and other keys.
+func (a Alphabet) Encode(v uint64, width int) string {
+	a = a.orDefault()
+	var buf [64]byte
+	i := len(buf)
+	base := uint64(a.base())
+	for v > 0 {
+		i--
+		buf[i] = a.digit(int(v % base))
+		v /= base
+	}
+	s := string(buf[i:])
+	if len(s) < width {
+		s = strings.Repeat(string(a.min()), width-len(s)) + s
+	}
+	return s
+}
+
+// Decode is the inverse of Encode: it reads s as a number in the
+// alphabet.  A byte that isn't a digit gives an error wrapping
+// ErrInvalidDigit; a number too big for a uint64 is an error too.
+func (a Alphabet) Decode(s string) (uint64, error) {
+	a = a.orDefault()
+	base := uint64(a.base())
+	var v uint64
+	for i := 0; i < len(s); i++ {
+		d, err := a.OrderOf(s[i])
+		if err != nil {
+			return 0, err
+		}
+		if v > (math.MaxUint64-uint64(d))/base {
+			return 0, newError(strconv.Quote(s) + " overflows a uint64")
+		}
+		v = v*base + uint64(d)
+	}
+	return v, nil
+}
+
+// EncodeBig is like Encode, for numbers of any size; v must not be
+// negative.
+func (a Alphabet) EncodeBig(v *big.Int, width int) (string, error) {
+	a = a.orDefault()
+	if v.Sign() < 0 {
+		return "", newError("cannot encode negative " + v.String())
+	}
+	base := big.NewInt(int64(a.base()))
+	n := 0
+	for q := new(big.Int).Set(v); q.Sign() > 0; q.Quo(q, base) {
+		n++
+	}
+	return digitsString(a, v, max(n, width)), nil
+}
+
+// DecodeBig is like Decode, for numbers of any size.
+func (a Alphabet) DecodeBig(s string) (*big.Int, error) {
+	a = a.orDefault()
+	for i := 0; i < len(s); i++ {
+		if _, err := a.OrderOf(s[i]); err != nil {
+			return nil, err
+		}
+	}
+	return digitsValue(a, s, len(s)), nil
+}
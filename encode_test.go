@@ -0,0 +1,63 @@
+package lexorank
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncode(t *testing.T) {
+	assert.Equal(t, "", Base62.Encode(0, 0))
+	assert.Equal(t, "000", Base62.Encode(0, 3))
+	assert.Equal(t, "z", Base62.Encode(61, 0))
+	assert.Equal(t, "10", Base62.Encode(62, 0))
+	assert.Equal(t, "0010", Base62.Encode(62, 4))
+	assert.Equal(t, "10", Base62.Encode(62, 1))
+	assert.Equal(t, "-", Base64URL.Encode(0, 1))
+
+	var zero Alphabet
+	assert.Equal(t, "10", zero.Encode(62, 0))
+}
+
+func TestDecode(t *testing.T) {
+	for _, a := range []Alphabet{Base62, Base36, Crockford32, Base64URL} {
+		for _, v := range []uint64{0, 1, 61, 62, 1e9, math.MaxUint64} {
+			got, err := a.Decode(a.Encode(v, 0))
+			assert.NoError(t, err)
+			assert.Equal(t, v, got)
+		}
+	}
+	v, err := Crockford32.Decode("o1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), v)
+
+	_, err = Base36.Decode("A")
+	assert.True(t, errors.Is(err, ErrInvalidDigit))
+	_, err = Base62.Decode(Base62.Encode(math.MaxUint64, 0) + "0")
+	assert.Error(t, err)
+}
+
+func TestEncodeBig(t *testing.T) {
+	v, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	s, err := Base62.EncodeBig(v, 0)
+	assert.NoError(t, err)
+	got, err := Base62.DecodeBig(s)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, v.Cmp(got))
+
+	s, err = Base62.EncodeBig(big.NewInt(62), 4)
+	assert.NoError(t, err)
+	assert.Equal(t, "0010", s)
+
+	s, err = Base62.EncodeBig(new(big.Int), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "", s)
+
+	_, err = Base62.EncodeBig(big.NewInt(-1), 0)
+	assert.Error(t, err)
+	_, err = Base62.DecodeBig("a-b")
+	assert.True(t, errors.Is(err, ErrInvalidDigit))
+}
@@ -0,0 +1,51 @@
+package lexorank
+
+import (
+	"errors"
+	"strconv"
+)
+
+// The core of the package doesn't use fmt (or regexp), which add a
+// lot to binaries for small targets such as TinyGo and WASM, so error
+// messages are put together by hand with these.
+
+// newError returns an error whose message is msg, prefixed with the
+// package name.
+func newError(msg string) error {
+	return errors.New("lexorank: " + msg)
+}
+
+// wrapError is an error with a message of its own that wraps another,
+// as fmt.Errorf with %w would make.
+type wrapError struct {
+	msg string
+	err error
+}
+
+func (e *wrapError) Error() string {
+	return e.msg
+}
+
+func (e *wrapError) Unwrap() error {
+	return e.err
+}
+
+// invalidDigit is the error for a byte b that isn't a digit, found in
+// s (if known).
+func invalidDigit(b byte, s string) error {
+	msg := ErrInvalidDigit.Error() + " " + quoteByte(b)
+	if s != "" {
+		msg += " in " + strconv.Quote(s)
+	}
+	return &wrapError{msg, ErrInvalidDigit}
+}
+
+// quoteByte quotes b as a character literal, as %q does for a byte.
+func quoteByte(b byte) string {
+	return strconv.QuoteRune(rune(b))
+}
+
+// badBucket is the error for a bucket number out of range.
+func badBucket(bucket byte) error {
+	return newError("bucket " + strconv.Itoa(int(bucket)) + " out of range")
+}
@@ -0,0 +1,28 @@
+package lexorank
+
+import (
+	"errors"
+	"go/build"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNoFmtInCore(t *testing.T) {
+	ctx := build.Default
+	ctx.BuildTags = append(ctx.BuildTags, "lexorank_nofmt")
+	pkg, err := ctx.ImportDir(".", 0)
+	assert.NoError(t, err)
+	for _, heavy := range []string{"fmt", "regexp"} {
+		assert.False(t, slices.Contains(pkg.Imports, heavy), heavy)
+	}
+}
+
+func TestErrorMessages(t *testing.T) {
+	err := invalidDigit('-', "a-b")
+	assert.Equal(t, `lexorank: invalid digit '-' in "a-b"`, err.Error())
+	assert.True(t, errors.Is(err, ErrInvalidDigit))
+	assert.Equal(t, `lexorank: invalid digit '-'`, invalidDigit('-', "").Error())
+	assert.Equal(t, "lexorank: bucket 3 out of range", badBucket(3).Error())
+}
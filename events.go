@@ -0,0 +1,81 @@
+package lexorank
+
+import "math/big"
+
+// An Event describes the circumstances in which a Generator decided
+// that a list needs attention, so that applications can page someone
+// (or schedule maintenance) before inserts start failing.
+type Event struct {
+	// List is the list concerned, if known (it is when the generator
+	// is being driven by an Allocator)
+	List string
+
+	// Prev and Next are the bounds (as digits, major and minor run
+	// together) between which ranks were wanted
+	Prev, Next string
+
+	// N is how many ranks were wanted
+	N int
+
+	// Room is, for OnLowGap, the number of ranks of the same length
+	// that are left in the smallest gap next to the new ranks
+	Room int
+}
+
+// PrevLen returns the length of the lower bound.
+func (e Event) PrevLen() int {
+	return len(e.Prev)
+}
+
+// NextLen returns the length of the upper bound.
+func (e Event) NextLen() int {
+	return len(e.Next)
+}
+
+func (g Generator) fire(cb func(Event), prev, next string, n int) {
+	if cb == nil {
+		return
+	}
+	cb(Event{
+		List: g.list,
+		Prev: prev,
+		Next: next,
+		N:    n,
+	})
+}
+
+// checkLowGap calls OnLowGap if the room left around a batch of newly
+// generated ranks (as digit strings) is below the LowGap threshold
+func (g Generator) checkLowGap(prev, next string, out []string) {
+	if g.LowGap <= 0 || g.OnLowGap == nil || len(out) == 0 {
+		return
+	}
+	a := g.alphabet()
+	if !a.valid(prev) || !a.valid(next) {
+		return
+	}
+	length := 0
+	for _, s := range out {
+		length = max(length, len(s))
+	}
+	threshold := big.NewInt(int64(g.LowGap))
+	smallest := (*big.Int)(nil)
+	last := a.canonical(prev)
+	for _, s := range append(out, a.canonical(next)) {
+		c := capacity(a, last, s, length)
+		if smallest == nil || c.Cmp(smallest) < 0 {
+			smallest = c
+		}
+		last = s
+	}
+	if smallest.Cmp(threshold) >= 0 {
+		return
+	}
+	g.OnLowGap(Event{
+		List: g.list,
+		Prev: prev,
+		Next: next,
+		N:    len(out),
+		Room: int(smallest.Int64()),
+	})
+}
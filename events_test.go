@@ -0,0 +1,70 @@
+package lexorank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnExhaustion(t *testing.T) {
+	var events []Event
+	g := Generator{OnExhaustion: func(e Event) { events = append(events, e) }}
+	_, ok := g.Rank("a", "a0")
+	assert.Equal(t, false, ok)
+	assert.Equal(t, []Event{{Prev: "a", Next: "a0", N: 1}}, events)
+	assert.Equal(t, 1, events[0].PrevLen())
+	assert.Equal(t, 2, events[0].NextLen())
+
+	_, ok = g.Rank("a", "b")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 1, len(events))
+}
+
+func TestOnRebalanceFromAllocator(t *testing.T) {
+	var events []Event
+	g := Generator{
+		OnRebalance: func(e Event) { events = append(events, e) },
+	}
+	a := NewAllocator(g, 0)
+	a.Seed("board", Posn{Major: "zzzzzy"})
+	p, ok := a.Next("board")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, Posn{Major: "zzzzzy", Minor: "U"}, p)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, "board", events[0].List)
+	assert.Equal(t, "zzzzzy", events[0].Prev)
+	assert.Equal(t, "zzzzzz", events[0].Next)
+}
+
+func TestOnLowGap(t *testing.T) {
+	var events []Event
+	g := Generator{
+		LowGap:   5,
+		OnLowGap: func(e Event) { events = append(events, e) },
+	}
+	// plenty of room
+	_, ok := g.Rank("a", "z")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 0, len(events))
+
+	// "b" goes between "a" and "d", leaving no other one-digit ranks
+	// below it and only "c" above
+	rank, ok := g.Rank("a", "d")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "b", rank)
+	assert.Equal(t, []Event{{Prev: "a", Next: "d", N: 1, Room: 0}}, events)
+}
+
+func TestOnLowGapRanks(t *testing.T) {
+	var events []Event
+	g := Generator{
+		LowGap:   3,
+		OnLowGap: func(e Event) { events = append(events, e) },
+	}
+	prev := Posn{Major: "a00000"}
+	next := Posn{Major: "a00005"}
+	_, ok := g.Ranks(1, &prev, &next)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, 1, events[0].Room)
+}
@@ -0,0 +1,85 @@
+package lexorank
+
+import (
+	"math/big"
+	"strings"
+)
+
+// The arithmetic for placing ranks at a given point in a gap (rather
+// than just somewhere in it) is done here exactly, on fractions of the
+// keyspace, rather than a digit at a time: a string of digits d1 d2 ...
+// dk is the fraction d1/B + d2/B² + ... + dk/B^k, for base B, which is
+// how the ranks sort.  Ranks of a given width are the multiples of
+// 1/B^width, so a point is turned into a rank by rounding it down to
+// one of them, and nothing is lost along the way.
+//
+// The midpoints that Rank and Ranks make are still worked out a digit
+// at a time (the fork and trailer of majorRanks, and minorRanks),
+// rather than on top of this.  That arithmetic is on whole digits, so
+// it's exact already; what the digit walk decides is which of the many
+// exact answers to give, which Explain reports step by step and which
+// stored ranks and test vectors depend on, and it does so without
+// allocating.  Moving it over would change every rank it makes, so it
+// isn't done here; TestRanksExact checks its results against the
+// fractions instead.
+
+// ExactFraction is like ApproxFraction, but returns exactly where in
+// [0,1) of the keyspace p lies, for arithmetic that mustn't be thrown
+// off by rounding.  It returns nil if p has digits outside the
+// alphabet.
+func ExactFraction(p Posn) *big.Rat {
+	return Generator{}.ExactFraction(p)
+}
+
+// ExactFraction is like the package-level ExactFraction, but reads
+// digits in the generator's alphabet.
+func (g Generator) ExactFraction(p Posn) *big.Rat {
+	a := g.alphabet()
+	s := p.digits()
+	if !a.valid(s) {
+		return nil
+	}
+	return fracOf(a, a.canonical(s))
+}
+
+// fracOf returns the fraction that the valid digits s stand for
+func fracOf(a Alphabet, s string) *big.Rat {
+	return new(big.Rat).SetFrac(digitsValue(a, s, len(s)), unitsOf(a, len(s)))
+}
+
+// unitsOf returns B^width, the number of ranks of the given width
+func unitsOf(a Alphabet, width int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(int64(a.base())), big.NewInt(int64(width)), nil)
+}
+
+// fracAt returns the point fraction f of the way from lo to hi
+func fracAt(lo, hi, f *big.Rat) *big.Rat {
+	r := new(big.Rat).Sub(hi, lo)
+	r.Mul(r, f)
+	return r.Add(r, lo)
+}
+
+// fracFloor returns x (in [0,1)) rounded down to a whole number of
+// units of width digits, as a number of them
+func fracFloor(a Alphabet, x *big.Rat, width int) *big.Int {
+	v := new(big.Int).Mul(x.Num(), unitsOf(a, width))
+	return v.Quo(v, x.Denom())
+}
+
+// fracUnits returns how many whole units of width digits there are
+// between lo and hi, which is how much room there is for ranks of that
+// width
+func fracUnits(a Alphabet, lo, hi *big.Rat, width int) *big.Int {
+	return fracFloor(a, new(big.Rat).Sub(hi, lo), width)
+}
+
+// fracRank returns the rank of width digits at x rounded down, and
+// whether it's strictly between lo and hi.  Any smallest digits it
+// ends in are trimmed off (see NoTrailingMin), which leaves it at the
+// same point.
+func fracRank(a Alphabet, lo, hi, x *big.Rat, width int) (string, bool) {
+	v := fracFloor(a, x, width)
+	s := strings.TrimRight(digitsString(a, v, width), string(a.min()))
+	f := fracOf(a, s)
+	return s, f.Cmp(lo) > 0 && f.Cmp(hi) < 0
+}
@@ -0,0 +1,132 @@
+package lexorank
+
+import (
+	"math/big"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExactFraction(t *testing.T) {
+	assert.Equal(t, big.NewRat(1, 2), ExactFraction(Posn{Major: "V"}))
+	assert.Equal(t, big.NewRat(31*62+1, 62*62*62), ExactFraction(Posn{Major: "0V", Minor: "1"}))
+	assert.Equal(t, ExactFraction(Posn{Major: "a"}), ExactFraction(Posn{Major: "a00"}))
+	assert.Equal(t, big.NewRat(1, 2), Generator{Alphabet: Crockford32}.ExactFraction(Posn{Major: "g"}))
+	assert.Nil(t, ExactFraction(Posn{Major: "a-b"}))
+}
+
+func TestPlaceAtFractionExact(t *testing.T) {
+	// bounds too long for a float64 to tell apart from each other
+	prev := Posn{Major: "a" + strings.Repeat("0", 30)}
+	next := Posn{Major: "a" + strings.Repeat("0", 25) + "z0000"}
+	for _, f := range []float64{1.0 / 3, 0.5, 0.9, 1e-3} {
+		p, err := PlaceAtFraction(prev, next, f)
+		assert.NoError(t, err)
+
+		// exactly where it should be, to the last digit
+		want := fracAt(ExactFraction(prev), ExactFraction(next), new(big.Rat).SetFloat64(f))
+		unit := new(big.Rat).SetFrac(big.NewInt(1), unitsOf(Base62, len(p.Major)))
+		got := ExactFraction(p)
+		assert.True(t, got.Cmp(want) <= 0, p.String())
+		assert.True(t, got.Add(got, unit).Cmp(want) > 0, p.String())
+	}
+
+	// which rounding the product in floating point got wrong
+	p, err := PlaceAtFraction(Posn{Major: strings.Repeat("0", 16)}, Posn{Major: "1" + strings.Repeat("0", 15)}, 0.8136399609900968)
+	assert.NoError(t, err)
+	assert.Equal(t, "0oRdBRgnEP7QvY7t", p.Major)
+}
+
+func TestEvenSplitExact(t *testing.T) {
+	prev := Posn{Major: "a" + strings.Repeat("z", 30)}
+	next := Posn{Major: "b" + strings.Repeat("0", 29) + "7"}
+	out, err := EvenSplit(prev, next, 6)
+	assert.NoError(t, err)
+	lo, hi := ExactFraction(prev), ExactFraction(next)
+	unit := new(big.Rat).SetFrac(big.NewInt(1), unitsOf(Base62, len(out[0].Major)))
+	for k, p := range out {
+		want := fracAt(lo, hi, big.NewRat(int64(k)+1, 7))
+		got := ExactFraction(p)
+		assert.True(t, got.Cmp(want) <= 0 && got.Add(got, unit).Cmp(want) > 0, p.String())
+	}
+}
+
+func TestRanksExact(t *testing.T) {
+	// the digit-at-a-time midpoints land strictly between their
+	// bounds, in order, as exact fractions
+	rng := rand.New(rand.NewSource(1))
+	major := func() string {
+		b := make([]byte, 1+rng.Intn(6))
+		for i := range b {
+			b[i] = "01yzaAZ9"[rng.Intn(8)]
+		}
+		if b[len(b)-1] == '0' {
+			b[len(b)-1] = '1'
+		}
+		return string(b)
+	}
+	checked := 0
+	for i := 0; i < 2000; i++ {
+		prev, next := Posn{Major: major()}, Posn{Major: major()}
+		if prev.Compare(next) > 0 {
+			prev, next = next, prev
+		}
+		out, ok := Ranks(1+rng.Intn(5), &prev, &next)
+		if !ok || out[0].Major == prev.Major {
+			// no room, or the minors were used
+			continue
+		}
+		last := ExactFraction(prev)
+		for _, p := range out {
+			f := ExactFraction(p)
+			assert.Equal(t, 1, f.Cmp(last), "%v after %v..%v", p, prev, next)
+			last = f
+		}
+		assert.Equal(t, -1, last.Cmp(ExactFraction(next)), "%v..%v", prev, next)
+		checked++
+	}
+	assert.Greater(t, checked, 1000)
+}
+
+func TestExactNoRoom(t *testing.T) {
+	// bounds whose digits run together sort the wrong way round, or
+	// that have no major between them, used to keep the exact
+	// arithmetic widening forever
+	for _, c := range [][2]Posn{
+		{{Major: "a", Minor: "y"}, {Major: "a0"}},
+		{{Major: "z"}, {Major: "z", Minor: "0"}},
+	} {
+		done := make(chan [2]error, 1)
+		go func() {
+			_, err1 := EvenSplit(c[0], c[1], 3)
+			_, err2 := PlaceAtFraction(c[0], c[1], 0.5)
+			done <- [2]error{err1, err2}
+		}()
+		select {
+		case errs := <-done:
+			assert.ErrorIs(t, errs[0], ErrNoRoom, c)
+			assert.ErrorIs(t, errs[1], ErrNoRoom, c)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no answer between %v and %v", c[0], c[1])
+		}
+	}
+}
+
+func TestExactNoTrailingMin(t *testing.T) {
+	// the middle of these is a round number, which used to come out
+	// with the smallest digit on the end
+	prev, next := Posn{Major: "a00000"}, Posn{Major: "c00000"}
+	out, err := EvenSplit(prev, next, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", out[1].Major)
+	p, err := PlaceAtFraction(prev, next, 0.5)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", p.Major)
+	for _, p := range append(out, p) {
+		assert.Empty(t, NoTrailingMin(Alphabet{}, p), p)
+	}
+	requireBetween(t, prev, out, next)
+}
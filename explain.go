@@ -0,0 +1,107 @@
+package lexorank
+
+import (
+	"strconv"
+	"strings"
+)
+
+// A StepKind identifies what happened at a step of a rank computation.
+type StepKind int
+
+const (
+	// StepCommonPrefix is a digit the bounds have in common, which
+	// is copied into the new ranks
+	StepCommonPrefix StepKind = iota
+	// StepFork is a position where the bounds differ, but not by
+	// enough to fit the new ranks, so the computation went forward
+	// with whichever bound left more room after it
+	StepFork
+	// StepMidpoints is where the new ranks' distinguishing digits
+	// were chosen
+	StepMidpoints
+	// StepMinor is where the majors had run out of room and the new
+	// ranks were placed using the minor
+	StepMinor
+)
+
+func (k StepKind) String() string {
+	switch k {
+	case StepCommonPrefix:
+		return "common prefix"
+	case StepFork:
+		return "fork"
+	case StepMidpoints:
+		return "midpoints"
+	case StepMinor:
+		return "minor"
+	default:
+		return "unknown"
+	}
+}
+
+// A Step is one decision made while computing ranks.
+type Step struct {
+	Kind StepKind
+
+	// Pos is the digit position the step was about
+	Pos int
+
+	// Prev and Next are the bounds' digits at Pos
+	Prev, Next byte
+
+	// Chose is, for a fork, the digit gone forward with
+	Chose byte
+
+	// Mids are, for StepMidpoints, the digits chosen
+	Mids []byte
+}
+
+func (s Step) String() string {
+	pos := strconv.Itoa(s.Pos)
+	prev, next := string(rune(s.Prev)), string(rune(s.Next))
+	switch s.Kind {
+	case StepFork:
+		return pos + ": fork at [" + prev + " <> " + next + "], went forward with [" + string(rune(s.Chose)) + "]"
+	case StepMidpoints:
+		return pos + ": midpoints of (" + prev + " ... " + next + ") are " + strconv.Quote(string(s.Mids))
+	case StepMinor:
+		return pos + ": no room in the major, using the minor"
+	default:
+		return pos + ": " + s.Kind.String() + " [" + prev + "]"
+	}
+}
+
+// An Explanation is the trace of how some ranks were computed, for
+// working out why a rank came out the way it did.
+type Explanation []Step
+
+func (e Explanation) String() string {
+	var b strings.Builder
+	for _, s := range e {
+		b.WriteString(s.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// ExplainRanks is like Ranks, but also returns a trace of the steps
+// taken to compute them.
+func ExplainRanks(n int, prev, next *Posn) ([]Posn, Explanation, bool) {
+	return Generator{}.ExplainRanks(n, prev, next)
+}
+
+// ExplainRanks is like the package-level ExplainRanks, but uses the
+// generator's configuration.
+func (g Generator) ExplainRanks(n int, prev, next *Posn) ([]Posn, Explanation, bool) {
+	var e Explanation
+	g.trace = &e
+	out, ok := g.Ranks(n, prev, next)
+	return out, e, ok
+}
+
+// explain records a step, if the computation is being traced
+func (g Generator) explain(s Step) {
+	if g.trace != nil {
+		*g.trace = append(*g.trace, s)
+	}
+}
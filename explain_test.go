@@ -0,0 +1,26 @@
+package lexorank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplainRanks(t *testing.T) {
+	prev := Posn{Major: "a5z000"}
+	next := Posn{Major: "a6b000"}
+	out, e, ok := ExplainRanks(1, &prev, &next)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 1, len(out))
+
+	kinds := make([]StepKind, len(e))
+	for i, s := range e {
+		kinds[i] = s.Kind
+	}
+	assert.Equal(t, []StepKind{StepCommonPrefix, StepFork, StepMidpoints}, kinds)
+	assert.Equal(t, byte('6'), e[1].Chose)
+	assert.Equal(t, []byte{out[0].Major[2]}, e[2].Mids)
+	assert.Equal(t, "0: common prefix [a]\n"+
+		"1: fork at [5 <> 6], went forward with [6]\n"+
+		"2: midpoints of (0 ... b) are \"I\"\n", e.String())
+}
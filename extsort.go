@@ -0,0 +1,232 @@
+package lexorank
+
+import (
+	"bufio"
+	"container/heap"
+	"context"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// An ExternalRebalance rebalances a list that is too big to hold in
+// memory, given as a file of items and their ranks in any order, as
+// dumped from a database: it sorts them a run at a time, spilling each
+// run to a temporary file, merges the runs, and gives each item a
+// fresh rank from Rebalance as it comes out of the merge, so memory
+// use is bounded by RunSize however big the list.
+//
+// Each line of the input is an item ID and its rank, separated by a
+// tab (so IDs can't have tabs in them), and each line of the output
+// the same, with the new rank, in list order.  Items with the same
+// rank are put in order of ID.
+type ExternalRebalance struct {
+	// Bucket is the bucket the new ranks go in
+	Bucket byte
+
+	// TempDir is where runs are spilled (os.TempDir() if empty)
+	TempDir string
+
+	// RunSize is how many items are sorted in memory at a time
+	// (a million if zero)
+	RunSize int
+
+	Generator Generator
+}
+
+func (x ExternalRebalance) runSize() int {
+	if x.RunSize <= 0 {
+		return 1000000
+	}
+	return x.RunSize
+}
+
+// maxLine is the longest line an ExternalRebalance reads
+const maxLine = 1 << 20
+
+// An extItem is a line of an ExternalRebalance's input
+type extItem struct {
+	id   string
+	rank Posn
+}
+
+func (e extItem) compare(f extItem) int {
+	if c := e.rank.Compare(f.rank); c != 0 {
+		return c
+	}
+	return strings.Compare(e.id, f.id)
+}
+
+// Run reads the list from r and writes it, rebalanced, to w, and
+// returns the number of items.  It fails if a line of the input isn't
+// an ID and a valid rank, or if ctx is cancelled.  The temporary files
+// are removed either way.
+func (x ExternalRebalance) Run(ctx context.Context, r io.Reader, w io.Writer) (n int, err error) {
+	var runs []*os.File
+	defer func() {
+		for _, f := range runs {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	// sort the input into runs, keeping the last one in memory
+	var buf []extItem
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, maxLine)
+	for line := 1; sc.Scan(); line++ {
+		if len(buf) == x.runSize() {
+			if err := ctx.Err(); err != nil {
+				return 0, err
+			}
+			f, err := x.spill(buf)
+			if f != nil {
+				runs = append(runs, f)
+			}
+			if err != nil {
+				return 0, err
+			}
+			buf = buf[:0]
+		}
+		e, err := x.parse(sc.Text())
+		if err != nil {
+			return 0, &wrapError{err.Error() + " (line " + strconv.Itoa(line) + ")", err}
+		}
+		buf = append(buf, e)
+		n++
+	}
+	if err := sc.Err(); err != nil {
+		return 0, err
+	}
+	slices.SortFunc(buf, extItem.compare)
+
+	// then merge them, handing out new ranks in order
+	m := &extMerge{}
+	for _, f := range runs {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return 0, err
+		}
+		sc := bufio.NewScanner(f)
+		sc.Buffer(nil, maxLine)
+		m.runs = append(m.runs, extRun{sc: sc})
+	}
+	m.runs = append(m.runs, extRun{mem: buf})
+	for i := range m.runs {
+		if err := m.runs[i].next(x); err != nil {
+			return 0, err
+		}
+		if m.runs[i].ok {
+			m.heap = append(m.heap, i)
+		}
+	}
+	heap.Init(m)
+
+	bw := bufio.NewWriter(w)
+	var line []byte
+	err = x.Generator.RebalanceContext(ctx, n, x.Bucket, func(_ int, p Posn) error {
+		run := &m.runs[m.heap[0]]
+		var err error
+		line, err = x.appendLine(line[:0], extItem{run.head.id, p})
+		if err == nil {
+			_, err = bw.Write(line)
+		}
+		if err != nil {
+			return err
+		}
+		if err := run.next(x); err != nil {
+			return err
+		}
+		if run.ok {
+			heap.Fix(m, 0)
+		} else {
+			heap.Pop(m)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, bw.Flush()
+}
+
+func (x ExternalRebalance) parse(line string) (extItem, error) {
+	id, rank, ok := strings.Cut(line, "\t")
+	if !ok {
+		return extItem{}, newError("no tab between ID and rank")
+	}
+	p, err := x.Generator.Parse(rank)
+	return extItem{id, p}, err
+}
+
+func (x ExternalRebalance) appendLine(b []byte, e extItem) ([]byte, error) {
+	b = append(b, e.id...)
+	b = append(b, '\t')
+	b, err := x.Generator.AppendText(b, e.rank)
+	return append(b, '\n'), err
+}
+
+// spill sorts a run and writes it to a temporary file
+func (x ExternalRebalance) spill(run []extItem) (*os.File, error) {
+	slices.SortFunc(run, extItem.compare)
+	f, err := os.CreateTemp(x.TempDir, "lexorank-run-*")
+	if err != nil {
+		return nil, err
+	}
+	bw := bufio.NewWriter(f)
+	var line []byte
+	for _, e := range run {
+		line, err = x.appendLine(line[:0], e)
+		if err == nil {
+			_, err = bw.Write(line)
+		}
+		if err != nil {
+			return f, err
+		}
+	}
+	return f, bw.Flush()
+}
+
+// An extRun is a sorted run being merged, from a file or memory, and
+// the item at its head
+type extRun struct {
+	sc   *bufio.Scanner
+	mem  []extItem
+	head extItem
+	ok   bool
+}
+
+func (r *extRun) next(x ExternalRebalance) error {
+	if r.sc == nil {
+		r.ok = len(r.mem) > 0
+		if r.ok {
+			r.head, r.mem = r.mem[0], r.mem[1:]
+		}
+		return nil
+	}
+	if r.ok = r.sc.Scan(); !r.ok {
+		return r.sc.Err()
+	}
+	var err error
+	r.head, err = x.parse(r.sc.Text())
+	return err
+}
+
+// extMerge is a heap of the runs with items left, by their heads
+type extMerge struct {
+	runs []extRun
+	heap []int
+}
+
+func (m *extMerge) Len() int { return len(m.heap) }
+func (m *extMerge) Less(i, j int) bool {
+	return m.runs[m.heap[i]].head.compare(m.runs[m.heap[j]].head) < 0
+}
+func (m *extMerge) Swap(i, j int) { m.heap[i], m.heap[j] = m.heap[j], m.heap[i] }
+func (m *extMerge) Push(v any)    { m.heap = append(m.heap, v.(int)) }
+func (m *extMerge) Pop() any {
+	v := m.heap[len(m.heap)-1]
+	m.heap = m.heap[:len(m.heap)-1]
+	return v
+}
@@ -0,0 +1,83 @@
+package lexorank
+
+import (
+	"context"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExternalRebalance(t *testing.T) {
+	// a shuffled list, with some duplicate ranks
+	rng := rand.New(rand.NewSource(1))
+	ranks, err := Rebalance(1000, 0)
+	assert.NoError(t, err)
+	var lines []string
+	for i, p := range ranks {
+		lines = append(lines, "item"+strconv.Itoa(i)+"\t"+p.String())
+	}
+	lines = append(lines, "dup\t"+ranks[500].String())
+	rng.Shuffle(len(lines), func(i, j int) { lines[i], lines[j] = lines[j], lines[i] })
+	in := strings.Join(lines, "\n") + "\n"
+
+	dir := t.TempDir()
+	for _, runSize := range []int{0, 7, 100, 1001} {
+		var out strings.Builder
+		x := ExternalRebalance{Bucket: 1, TempDir: dir, RunSize: runSize}
+		n, err := x.Run(context.Background(), strings.NewReader(in), &out)
+		assert.NoError(t, err)
+		assert.Equal(t, 1001, n)
+
+		got := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+		assert.Len(t, got, 1001)
+		var last Posn
+		for i, l := range got {
+			id, rank, _ := strings.Cut(l, "\t")
+			p, err := Parse(rank)
+			assert.NoError(t, err)
+			assert.Equal(t, byte(1), p.Bucket)
+			if i > 0 {
+				assert.Equal(t, -1, last.Compare(p))
+			}
+			last = p
+
+			// in the order they were in
+			want := "item" + strconv.Itoa(i)
+			switch {
+			case i == 500:
+				want = "dup"
+			case i > 500:
+				want = "item" + strconv.Itoa(i-1)
+			}
+			assert.Equal(t, want, id)
+		}
+	}
+
+	// the runs are cleaned up
+	files, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestExternalRebalanceErrors(t *testing.T) {
+	dir := t.TempDir()
+	x := ExternalRebalance{TempDir: dir, RunSize: 2}
+	var out strings.Builder
+	_, err := x.Run(context.Background(), strings.NewReader("a\t0|a:\nb\t0|b:\nc 0|c:\n"), &out)
+	assert.ErrorContains(t, err, "line 3")
+	_, err = x.Run(context.Background(), strings.NewReader("a\t0|a:\nb\tnope\n"), &out)
+	assert.ErrorContains(t, err, "line 2")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = x.Run(ctx, strings.NewReader("a\t0|a:\nb\t0|b:\nc\t0|c:\n"), &out)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	files, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+}
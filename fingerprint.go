@@ -0,0 +1,36 @@
+package lexorank
+
+import (
+	"errors"
+	"hash/crc32"
+	"strconv"
+)
+
+// ErrFingerprint is the error for a rank that doesn't match the
+// fingerprint it was sent out with.
+var ErrFingerprint = errors.New("lexorank: rank doesn't match its fingerprint")
+
+// Fingerprint returns a short checksum of p, for spotting ranks that
+// have been edited or truncated on the way through an ETL pipeline or
+// a spreadsheet: export it alongside the rank, and check it with
+// CheckFingerprint when the rank comes back.  It is the CRC-32 (IEEE)
+// of p's String form, so it's easy to compute in other languages, and
+// won't change.  Equal positions written differently (with a missing
+// minor, say) have the same fingerprint.
+func Fingerprint(p Posn) uint32 {
+	b, _ := p.AppendText(nil)
+	return crc32.ChecksumIEEE(b)
+}
+
+// CheckFingerprint parses s, as Parse does, and checks that it has
+// fingerprint fp, failing with ErrFingerprint if it doesn't.
+func CheckFingerprint(s string, fp uint32) (Posn, error) {
+	p, err := Parse(s)
+	if err != nil {
+		return Posn{}, err
+	}
+	if got := Fingerprint(p); got != fp {
+		return Posn{}, &wrapError{ErrFingerprint.Error() + ": " + strconv.Quote(s) + " has " + strconv.FormatUint(uint64(got), 16) + ", not " + strconv.FormatUint(uint64(fp), 16), ErrFingerprint}
+	}
+	return p, nil
+}
@@ -0,0 +1,30 @@
+package lexorank
+
+import (
+	"hash/crc32"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFingerprint(t *testing.T) {
+	p := Posn{Bucket: 1, Major: "hzzzzz", Minor: "i"}
+	assert.Equal(t, crc32.ChecksumIEEE([]byte("1|hzzzzz:i")), Fingerprint(p))
+	assert.Equal(t, uint32(0x366e35dd), Fingerprint(Posn{Major: "a"}))
+
+	// equal however they're written
+	assert.Equal(t, Fingerprint(Posn{Major: "a"}), Fingerprint(Posn{Major: "a", Minor: ":"}))
+	assert.NotEqual(t, Fingerprint(p), Fingerprint(Posn{Bucket: 1, Major: "hzzzzz"}))
+
+	got, err := CheckFingerprint("1|hzzzzz:i", Fingerprint(p))
+	assert.NoError(t, err)
+	assert.Equal(t, p, got)
+
+	// truncated on the way
+	_, err = CheckFingerprint("1|hzzzzz:", Fingerprint(p))
+	assert.ErrorIs(t, err, ErrFingerprint)
+
+	_, err = CheckFingerprint("nope", Fingerprint(p))
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrFingerprint)
+}
@@ -0,0 +1,101 @@
+package lexorank
+
+import (
+	"math/big"
+	"strconv"
+)
+
+// A FixedLengthError is returned when there is no room for a rank of
+// the required length between two bounds.  The way out is to
+// rebalance that part of the list (see SpreadFixed) or to make the
+// column wider.
+type FixedLengthError struct {
+	Length     int
+	Prev, Next string
+}
+
+func (e *FixedLengthError) Error() string {
+	return "lexorank: no room for a " + strconv.Itoa(e.Length) + "-digit rank between " +
+		strconv.Quote(e.Prev) + " and " + strconv.Quote(e.Next)
+}
+
+// RankFixed is like Rank, but always returns exactly length digits,
+// for storing in a fixed-width column such as CHAR(12).  The rank is
+// placed halfway between the bounds, which must be no longer than
+// length; an empty prev or next stands for the start or end of the
+// keyspace.  If no rank of that length fits, the error is a
+// *FixedLengthError.
+func RankFixed(prev, next string, length int) (string, error) {
+	return Generator{}.RankFixed(prev, next, length)
+}
+
+// SpreadFixed returns count ranks of exactly length digits, evenly
+// spaced between prev and next (which may be empty, as for
+// RankFixed).  It is the way to rebalance a fixed-length list, or a
+// stretch of one, once RankFixed has run out of room.
+func SpreadFixed(prev, next string, count, length int) ([]string, error) {
+	return Generator{}.SpreadFixed(prev, next, count, length)
+}
+
+// RankFixed is like the package-level RankFixed, but uses the
+// generator's configuration.
+func (g Generator) RankFixed(prev, next string, length int) (string, error) {
+	out, err := g.SpreadFixed(prev, next, 1, length)
+	if err != nil {
+		return "", err
+	}
+	return out[0], nil
+}
+
+// SpreadFixed is like the package-level SpreadFixed, but uses the
+// generator's configuration.
+func (g Generator) SpreadFixed(prev, next string, count, length int) ([]string, error) {
+	lo, hi, err := g.fixedBounds(prev, next, length)
+	if err != nil {
+		return nil, err
+	}
+	if lo.Cmp(hi) >= 0 {
+		return nil, &BoundsError{Prev: prev, Next: next}
+	}
+	step := new(big.Int).Sub(hi, lo)
+	step.Quo(step, big.NewInt(int64(count+1)))
+	if step.Sign() <= 0 {
+		g.fire(g.OnExhaustion, prev, next, count)
+		return nil, &FixedLengthError{Length: length, Prev: prev, Next: next}
+	}
+	out := make([]string, count)
+	v := lo
+	for i := range out {
+		v.Add(v, step)
+		p, err := g.FromBigInt(v, length)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = p.Major
+	}
+	if g.Metrics != nil || g.LowGap > 0 {
+		if g.Metrics != nil {
+			g.observe(prev, next, out)
+		}
+		g.checkLowGap(prev, next, out)
+	}
+	return out, nil
+}
+
+// fixedBounds returns the values of prev and next as length-digit
+// numbers, where an empty prev is one below the smallest and an empty
+// next is one above the largest
+func (g Generator) fixedBounds(prev, next string, length int) (lo, hi *big.Int, err error) {
+	if prev == "" {
+		lo = big.NewInt(-1)
+	} else if lo, err = g.ToBigInt(Posn{Major: prev}, length); err != nil {
+		return nil, nil, err
+	}
+	if next == "" {
+		base := big.NewInt(int64(g.alphabet().base()))
+		hi = base.Exp(base, big.NewInt(int64(length)), nil)
+	} else if hi, err = g.ToBigInt(Posn{Major: next}, length); err != nil {
+		return nil, nil, err
+	}
+	return lo, hi, nil
+}
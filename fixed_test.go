@@ -0,0 +1,45 @@
+package lexorank
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRankFixed(t *testing.T) {
+	rank, err := RankFixed("a000", "a010", 4)
+	assert.NoError(t, err)
+	assert.Equal(t, "a00V", rank)
+
+	// shorter bounds are padded
+	rank, err = RankFixed("a", "b", 4)
+	assert.NoError(t, err)
+	assert.Equal(t, "aV00", rank)
+
+	rank, err = RankFixed("", "", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(rank))
+}
+
+func TestRankFixedFull(t *testing.T) {
+	_, err := RankFixed("a000", "a001", 4)
+	var lerr *FixedLengthError
+	assert.True(t, errors.As(err, &lerr))
+	assert.Equal(t, 4, lerr.Length)
+	assert.Equal(t, "a000", lerr.Prev)
+
+	// too long to begin with
+	_, err = RankFixed("a0000", "b", 4)
+	assert.Error(t, err)
+	assert.False(t, errors.As(err, &lerr))
+}
+
+func TestSpreadFixed(t *testing.T) {
+	out, err := SpreadFixed("", "", 3, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"FU", "Uz", "kU"}, out)
+
+	_, err = SpreadFixed("a0", "a4", 4, 2)
+	assert.Error(t, err)
+}
@@ -0,0 +1,92 @@
+package lexorank
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+)
+
+// A Forecast estimates how many more inserts a list can take before
+// its keys grow past a length budget, or there's no room left and it
+// has to be rebalanced, for capacity planning.  It plays an insert
+// workload forward against a copy of the list, so its answer is only
+// as good as the workload is a guide to the future.
+type Forecast struct {
+	// Ranks is the list as it is now, in order
+	Ranks []Posn
+
+	// Pattern is the shape of the workload, unless Recorded is set,
+	// in which case inserts are made at positions drawn from it.
+	// Recorded positions are fractions of the way down the list
+	// (0 for the top, 1 for the bottom), as logged from real inserts.
+	Pattern      Pattern
+	Recorded     []float64
+	HotspotWidth int
+
+	// MaxLen is the length budget for keys (major and minor
+	// together); if zero, only running out of room counts
+	MaxLen int
+
+	// Limit is the most inserts to try (100000 if zero)
+	Limit int
+
+	Seed      int64
+	Generator Generator
+}
+
+// A ForecastResult is the outcome of a Forecast.
+type ForecastResult struct {
+	// Ops is the number of inserts the list took
+	Ops int
+
+	// TooLong is set if the next insert would have gone past the
+	// length budget, and NoRoom if there was no room for it.  If
+	// neither is set, the list was still fine after Limit inserts.
+	TooLong, NoRoom bool
+}
+
+// Run runs the forecast.
+func (f Forecast) Run() ForecastResult {
+	g := f.Generator.quiet()
+	rnd := rand.New(rand.NewSource(f.Seed))
+	width := f.HotspotWidth
+	if width <= 0 {
+		width = 4
+	}
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 100000
+	}
+	list := make([]Posn, len(f.Ranks), len(f.Ranks)+min(limit, 1<<16))
+	copy(list, f.Ranks)
+
+	var res ForecastResult
+	for res.Ops < limit {
+		var i int
+		if len(f.Recorded) > 0 {
+			frac := math.Min(math.Max(f.Recorded[rnd.Intn(len(f.Recorded))], 0), 1)
+			i = int(frac * float64(len(list)))
+		} else {
+			i = f.Pattern.index(rnd, len(list), width)
+		}
+		var prev, next *Posn
+		if i > 0 {
+			prev = &list[i-1]
+		}
+		if i < len(list) {
+			next = &list[i]
+		}
+		r, ok := g.Ranks(1, prev, next)
+		switch {
+		case !ok:
+			res.NoRoom = true
+			return res
+		case f.MaxLen > 0 && len(r[0].digits()) > f.MaxLen:
+			res.TooLong = true
+			return res
+		}
+		list = slices.Insert(list, i, r[0])
+		res.Ops++
+	}
+	return res
+}
@@ -0,0 +1,35 @@
+package lexorank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForecast(t *testing.T) {
+	ranks, err := Rebalance(100, 0)
+	assert.NoError(t, err)
+
+	// inserting at the top halves the room there each time, so the
+	// budget goes quickly
+	top := Forecast{Ranks: ranks, Pattern: AlwaysTop, MaxLen: 7}.Run()
+	assert.True(t, top.TooLong)
+	assert.False(t, top.NoRoom)
+
+	// while random inserts spread out and are still fine at the limit
+	random := Forecast{Ranks: ranks, Pattern: RandomInserts, MaxLen: 7, Seed: 1, Limit: 2000}.Run()
+	assert.Equal(t, ForecastResult{Ops: 2000}, random)
+
+	// a recorded workload that always lands at the top behaves like
+	// AlwaysTop
+	recorded := Forecast{Ranks: ranks, Recorded: []float64{0}, MaxLen: 7}.Run()
+	assert.Equal(t, top, recorded)
+
+	// with no budget, and a generator that can't grow keys, the list
+	// runs out of room instead
+	room := Forecast{Ranks: ranks, Pattern: Hotspot, Generator: Generator{MaxLength: 7}}.Run()
+	assert.True(t, room.NoRoom)
+
+	limited := Forecast{Ranks: ranks, Pattern: AlwaysBottom, Limit: 50}.Run()
+	assert.Equal(t, ForecastResult{Ops: 50}, limited)
+}
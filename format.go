@@ -0,0 +1,27 @@
+//go:build !tinygo && !lexorank_nofmt
+
+package lexorank
+
+import "fmt"
+
+// This is kept apart from the rest of the package, which doesn't use
+// fmt, so that it can be left out of builds for small targets (TinyGo
+// does so by itself; elsewhere, use the lexorank_nofmt build tag).
+// Without it, positions still print as String has them.
+
+// Format implements fmt.Formatter.  %v and %s print p the way String
+// does and %q quotes that, while %+v and %#v show the bucket, major
+// and minor separately (with the minor's value, whichever way it is
+// stored), which is easier to follow in debug output.
+func (p Posn) Format(f fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && f.Flag('#'):
+		fmt.Fprintf(f, "lexorank.Posn{Bucket:%d, Major:%q, Minor:%q}", p.Bucket, p.Major, p.MinorValue())
+	case verb == 'v' && f.Flag('+'):
+		fmt.Fprintf(f, "{Bucket:%d Major:%s Minor:%s}", p.Bucket, p.Major, p.MinorValue())
+	case verb == 'v' || verb == 's' || verb == 'q':
+		fmt.Fprintf(f, fmt.FormatString(f, verb), p.String())
+	default:
+		fmt.Fprintf(f, "%%!%c(lexorank.Posn=%s)", verb, p.String())
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !tinygo && !lexorank_nofmt
+
+package lexorank
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	p := Posn{Bucket: 1, Major: "aZ", Minor: ":U"}
+	assert.Equal(t, "1|aZ:U", fmt.Sprintf("%v", p))
+	assert.Equal(t, "1|aZ:U", fmt.Sprint(p))
+	assert.Equal(t, "1|aZ:U  ", fmt.Sprintf("%-8s", p))
+	assert.Equal(t, `"1|aZ:U"`, fmt.Sprintf("%q", p))
+	assert.Equal(t, "{Bucket:1 Major:aZ Minor:U}", fmt.Sprintf("%+v", p))
+	assert.Equal(t, `lexorank.Posn{Bucket:1, Major:"aZ", Minor:"U"}`, fmt.Sprintf("%#v", p))
+	assert.Equal(t, "[0|a: 0|b:]", fmt.Sprint([]Posn{{Major: "a"}, {Major: "b"}}))
+	assert.Equal(t, "%!d(lexorank.Posn=1|aZ:U)", fmt.Sprintf("%d", p))
+}
@@ -0,0 +1,140 @@
+package lexorank
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// ApproxFraction interprets a position's digits (major and minor run
+// together) as a base-62 fraction, i.e., where in [0,1) of the
+// keyspace it lies.  It's meant for progress bars and dashboards
+// showing how skewed a list's keys have become, not for arithmetic.
+// The bucket is ignored.  A position with digits outside the alphabet
+// yields NaN.  Running the digits together only keeps to the order of
+// Compare for majors of the same length ("a" with minor "z" comes
+// before "a0", but lies further along); PlaceAtFraction and EvenSplit
+// work on the majors alone so as not to depend on it.
+func ApproxFraction(p Posn) float64 {
+	return Generator{}.ApproxFraction(p)
+}
+
+// ApproxFraction is like the package-level ApproxFraction, but reads
+// digits in the generator's alphabet.
+func (g Generator) ApproxFraction(p Posn) float64 {
+	return g.alphabet().fraction(p.digits())
+}
+
+func (a Alphabet) fraction(s string) float64 {
+	if !a.valid(s) {
+		return math.NaN()
+	}
+	base := float64(a.base())
+	f := 0.0
+	scale := 1.0
+	for i := 0; i < len(s); i++ {
+		scale /= base
+		if scale == 0 {
+			// no more precision to be had
+			break
+		}
+		f += float64(a.order(s[i])) * scale
+	}
+	return f
+}
+
+// FromFraction is the inverse of ApproxFraction: it returns a
+// position whose major has exactly length digits and lies
+// approximately at fraction f of the keyspace.  Fractions outside
+// [0,1) are clamped.  This is handy for seeding a list from an
+// existing float-valued ordering column.  It fails if length isn't
+// positive.
+func FromFraction(f float64, length int) (Posn, error) {
+	return Generator{}.FromFraction(f, length)
+}
+
+// FromFraction is like the package-level FromFraction, but writes the
+// major in the generator's alphabet.
+func (g Generator) FromFraction(f float64, length int) (Posn, error) {
+	if length < 1 {
+		return Posn{}, newError("length " + strconv.Itoa(length) + " is not positive")
+	}
+	a := g.alphabet()
+	major := make([]byte, length)
+	if !(f > 0) {
+		// includes NaN
+		f = 0
+	}
+	if f >= 1 {
+		for i := range major {
+			major[i] = a.max()
+		}
+		return Posn{Major: string(major)}, nil
+	}
+	base := float64(a.base())
+	for i := range major {
+		f *= base
+		d := int(f)
+		if d >= a.base() {
+			d = a.base() - 1
+		}
+		major[i] = a.digit(d)
+		f -= float64(d)
+	}
+	return Posn{Major: string(major)}, nil
+}
+
+// placeUnits is how many steps in its last digit the gap a rank is
+// placed in by PlaceAtFraction is made to span (by making the rank
+// longer, if need be), which puts it within a tenth of a percent of
+// where it was asked to go
+const placeUnits = 500
+
+// PlaceAtFraction returns a rank that lies fraction f of the way from
+// prev to next (which must be in the same bucket), for when a rank
+// shouldn't go in the middle of its gap: 0.9, say, to leave most of
+// the room before it for inserts expected there.  f must be strictly
+// between 0 and 1.  The rank is a major, so the gap is the one between
+// the majors of prev and next; if there is none, the error matches
+// ErrNoRoom.  The point is worked out exactly (see ExactFraction),
+// however long the bounds, and the rank is that point rounded down to
+// its last digit, less any smallest digits it ends in (see
+// NoTrailingMin).  The rank is as long as the bounds, or longer if
+// need be to place it accurately, but no longer than the generator's
+// MaxLength, which limits how accurately it can be placed; if there's
+// no room at all within that, the error matches ErrMaxLength.
+func PlaceAtFraction(prev, next Posn, f float64) (Posn, error) {
+	return Generator{}.PlaceAtFraction(prev, next, f)
+}
+
+// PlaceAtFraction is like the package-level PlaceAtFraction, but uses
+// the generator's configuration.
+func (g Generator) PlaceAtFraction(prev, next Posn, f float64) (Posn, error) {
+	if !(f > 0 && f < 1) {
+		return Posn{}, newError("fraction " + strconv.FormatFloat(f, 'g', -1, 64) + " is not between 0 and 1")
+	}
+	flo, fhi, width, err := g.majorGap(prev, next, "place")
+	if err != nil {
+		return Posn{}, err
+	}
+
+	a := g.alphabet()
+	at := fracAt(flo, fhi, new(big.Rat).SetFloat64(f))
+	var best *Posn
+	// the gap isn't empty, so it spans placeUnits at some width
+	for ; !g.tooLong(width); width++ {
+		if s, ok := fracRank(a, flo, fhi, at, width); ok {
+			best = &Posn{Bucket: prev.Bucket, Major: s}
+		}
+		if best != nil && fracUnits(a, flo, fhi, width).Cmp(big.NewInt(placeUnits)) >= 0 {
+			break
+		}
+	}
+	if best == nil {
+		return Posn{}, ErrMaxLength
+	}
+	if err := checkBetween(prev, []Posn{*best}, next); err != nil {
+		return Posn{}, err
+	}
+	return *best, nil
+}
@@ -0,0 +1,105 @@
+package lexorank
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApproxFraction(t *testing.T) {
+	assert.Equal(t, 0.0, ApproxFraction(Posn{Major: "000000"}))
+	assert.InDelta(t, 0.5, ApproxFraction(Posn{Major: "V"}), 1e-9)
+	assert.InDelta(t, 1.0, ApproxFraction(Posn{Major: "zzzzzz", Minor: "zzzz"}), 1e-9)
+	assert.True(t, ApproxFraction(Posn{Major: "hzzzzz"}) < ApproxFraction(Posn{Major: "hzzzzz", Minor: "i"}))
+	assert.True(t, math.IsNaN(ApproxFraction(Posn{Major: "a-b"})))
+}
+
+func TestApproxFractionAlphabet(t *testing.T) {
+	assert.InDelta(t, 0.5, Generator{Alphabet: Base36}.ApproxFraction(Posn{Major: "i"}), 1e-9)
+}
+
+func TestFromFraction(t *testing.T) {
+	for _, c := range []struct {
+		f      float64
+		length int
+		want   string
+	}{
+		{0, 6, "000000"},
+		{0.5, 6, "V00000"},
+		{1, 4, "zzzz"},
+		{-3, 3, "000"},
+		{math.NaN(), 3, "000"},
+	} {
+		p, err := FromFraction(c.f, c.length)
+		assert.NoError(t, err)
+		assert.Equal(t, Posn{Major: c.want}, p)
+	}
+	for _, length := range []int{0, -1} {
+		_, err := FromFraction(0.5, length)
+		assert.Error(t, err)
+	}
+}
+
+func TestFromFractionRoundTrip(t *testing.T) {
+	prev := ""
+	for _, f := range []float64{0.001, 0.1, 0.25, 0.3333, 0.5, 0.75, 0.999} {
+		p, err := FromFraction(f, 6)
+		assert.NoError(t, err)
+		assert.InDelta(t, f, ApproxFraction(p), 1e-9)
+		assert.True(t, p.Major > prev)
+		prev = p.Major
+	}
+}
+
+func TestPlaceAtFraction(t *testing.T) {
+	prev, next := Posn{Major: "a00000"}, Posn{Major: "a00100"}
+	p, err := PlaceAtFraction(prev, next, 0.9)
+	assert.NoError(t, err)
+	assert.Equal(t, Posn{Major: "a000tn"}, p)
+
+	p, err = PlaceAtFraction(prev, next, 0.1)
+	assert.NoError(t, err)
+	assert.Equal(t, Posn{Major: "a0006C"}, p)
+
+	// a narrow gap makes for a longer rank, to place it accurately
+	p, err = PlaceAtFraction(Posn{Major: "a"}, Posn{Major: "a1"}, 0.25)
+	assert.NoError(t, err)
+	assert.Equal(t, "a0FV", p.Major)
+
+	// unless that would be too long, when it's placed as well as it
+	// can be
+	p, err = Generator{MaxLength: 2}.PlaceAtFraction(Posn{Major: "a0"}, Posn{Major: "a3"}, 0.5)
+	assert.NoError(t, err)
+	assert.Equal(t, "a1", p.Major)
+	_, err = Generator{MaxLength: 2}.PlaceAtFraction(Posn{Major: "a0"}, Posn{Major: "a1"}, 0.5)
+	assert.ErrorIs(t, err, ErrMaxLength)
+
+	for _, f := range []float64{0, 1, -1, math.NaN()} {
+		_, err = PlaceAtFraction(prev, next, f)
+		assert.Error(t, err)
+	}
+	_, err = PlaceAtFraction(next, prev, 0.5)
+	assert.ErrorIs(t, err, ErrInvertedBounds)
+}
+
+func TestPlaceAtFractionMajors(t *testing.T) {
+	// the rank goes between the majors, whatever the minors, and
+	// sorts between the bounds even when the majors are different
+	// lengths
+	for _, c := range [][2]Posn{
+		{{Major: "a", Minor: "5"}, {Major: "a1"}},
+		{{Major: "a", Minor: "zz"}, {Major: "b0"}},
+		{{Major: "ab", Minor: "1"}, {Major: "b", Minor: "1"}},
+	} {
+		p, err := PlaceAtFraction(c[0], c[1], 0.5)
+		assert.NoError(t, err, c)
+		requireBetween(t, c[0], []Posn{p}, c[1])
+	}
+
+	// with nothing between the majors, there's no room
+	_, err := PlaceAtFraction(Posn{Major: "a"}, Posn{Major: "a", Minor: "5"}, 0.5)
+	assert.ErrorIs(t, err, ErrNoRoom)
+	_, err = PlaceAtFraction(Posn{Major: "a", Minor: "1"}, Posn{Major: "a00"}, 0.5)
+	assert.ErrorIs(t, err, ErrNoRoom)
+}
@@ -0,0 +1,352 @@
+package lexorank
+
+import (
+	"strconv"
+	"strings"
+)
+
+// This file implements keys compatible with the fractional-indexing
+// scheme (https://observablehq.com/@dgreensp/implementing-fractional-indexing)
+// as popularized by the `fractional-indexing` npm package, so that a
+// Go backend and a JS frontend can generate interoperable keys.
+//
+// A key is an "integer part" followed by a fractional part.  The
+// integer part starts with a head character giving its length
+// (a-z for positive, A-Z for negative integers) followed by that
+// many base62 digits.  The fractional part never ends with "0".
+// An empty string plays the role of the JS null, i.e., an open
+// bound.
+
+const smallestInteger = "A00000000000000000000000000"
+
+// GenerateKeyBetween returns a key that sorts strictly between a and
+// b, exactly as the npm package's generateKeyBetween would.  Either
+// bound may be empty to mean "unbounded".
+func GenerateKeyBetween(a, b string) (string, error) {
+	if a != "" {
+		if err := validateOrderKey(a); err != nil {
+			return "", err
+		}
+	}
+	if b != "" {
+		if err := validateOrderKey(b); err != nil {
+			return "", err
+		}
+	}
+	if a != "" && b != "" && a >= b {
+		return "", newError(strconv.Quote(a) + " >= " + strconv.Quote(b))
+	}
+
+	if a == "" {
+		if b == "" {
+			return "a" + string(minChar), nil
+		}
+		ib, _ := integerPart(b)
+		fb := b[len(ib):]
+		if ib == smallestInteger {
+			m, err := fractionalMidpoint("", fb, true)
+			return ib + m, err
+		}
+		if ib < b {
+			return ib, nil
+		}
+		res, ok := decrementInteger(ib)
+		if !ok {
+			return "", newError("cannot decrement " + strconv.Quote(b) + " any more")
+		}
+		return res, nil
+	}
+
+	ia, _ := integerPart(a)
+	fa := a[len(ia):]
+
+	if b == "" {
+		i, ok := incrementInteger(ia)
+		if !ok {
+			m, err := fractionalMidpoint(fa, "", false)
+			return ia + m, err
+		}
+		return i, nil
+	}
+
+	ib, _ := integerPart(b)
+	fb := b[len(ib):]
+	if ia == ib {
+		m, err := fractionalMidpoint(fa, fb, true)
+		return ia + m, err
+	}
+	i, ok := incrementInteger(ia)
+	if !ok {
+		return "", newError("cannot increment " + strconv.Quote(a) + " any more")
+	}
+	if i < b {
+		return i, nil
+	}
+	m, err := fractionalMidpoint(fa, "", false)
+	return ia + m, err
+}
+
+// GenerateNKeysBetween returns n keys in ascending order between a
+// and b, matching the npm package's generateNKeysBetween.
+func GenerateNKeysBetween(a, b string, n int) ([]string, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	if n == 1 {
+		c, err := GenerateKeyBetween(a, b)
+		if err != nil {
+			return nil, err
+		}
+		return []string{c}, nil
+	}
+	if b == "" {
+		out := make([]string, 0, n)
+		c := a
+		for i := 0; i < n; i++ {
+			var err error
+			c, err = GenerateKeyBetween(c, b)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, c)
+		}
+		return out, nil
+	}
+	if a == "" {
+		out := make([]string, n)
+		c := b
+		for i := n - 1; i >= 0; i-- {
+			var err error
+			c, err = GenerateKeyBetween(a, c)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = c
+		}
+		return out, nil
+	}
+	mid := n / 2
+	c, err := GenerateKeyBetween(a, b)
+	if err != nil {
+		return nil, err
+	}
+	before, err := GenerateNKeysBetween(a, c, mid)
+	if err != nil {
+		return nil, err
+	}
+	after, err := GenerateNKeysBetween(c, b, n-mid-1)
+	if err != nil {
+		return nil, err
+	}
+	out := append(before, c)
+	return append(out, after...), nil
+}
+
+// fractionalMidpoint returns a fractional part strictly between a and
+// b; hasB is false when there is no upper bound.
+func fractionalMidpoint(a, b string, hasB bool) (string, error) {
+	if hasB && a >= b {
+		return "", newError(strconv.Quote(a) + " >= " + strconv.Quote(b))
+	}
+	if strings.HasSuffix(a, string(minChar)) || strings.HasSuffix(b, string(minChar)) {
+		return "", newError("trailing zero")
+	}
+	if hasB {
+		// remove the longest common prefix, padding a with zeros as
+		// we go (b can't end before a does while in the prefix)
+		n := 0
+		for getChar(a, n, minChar) == b[n] {
+			n++
+		}
+		if n > 0 {
+			aRest := ""
+			if n < len(a) {
+				aRest = a[n:]
+			}
+			m, err := fractionalMidpoint(aRest, b[n:], true)
+			return b[:n] + m, err
+		}
+	}
+
+	// the first digits (or lack of digit) are different
+	digitA := 0
+	if a != "" {
+		digitA = strings.IndexByte(orderToByte, a[0])
+	}
+	digitB := len(orderToByte)
+	if hasB {
+		digitB = strings.IndexByte(orderToByte, b[0])
+	}
+	if digitB-digitA > 1 {
+		return string(orderToByte[(digitA+digitB+1)/2]), nil
+	}
+	// the first digits are consecutive
+	if hasB && len(b) > 1 {
+		return b[:1], nil
+	}
+	rest := ""
+	if a != "" {
+		rest = a[1:]
+	}
+	m, err := fractionalMidpoint(rest, "", false)
+	return string(orderToByte[digitA]) + m, err
+}
+
+func integerLength(head byte) (int, bool) {
+	switch {
+	case head >= 'a' && head <= 'z':
+		return int(head-'a') + 2, true
+	case head >= 'A' && head <= 'Z':
+		return int('Z'-head) + 2, true
+	default:
+		return 0, false
+	}
+}
+
+func integerPart(key string) (string, error) {
+	if key == "" {
+		return "", newError("empty order key")
+	}
+	n, ok := integerLength(key[0])
+	if !ok {
+		return "", newError("invalid order key head " + quoteByte(key[0]))
+	}
+	if n > len(key) {
+		return "", newError("invalid order key " + strconv.Quote(key))
+	}
+	return key[:n], nil
+}
+
+func validateOrderKey(key string) error {
+	if key == smallestInteger {
+		return newError("invalid order key " + strconv.Quote(key))
+	}
+	i, err := integerPart(key)
+	if err != nil {
+		return err
+	}
+	if !Base62.valid(key[1:]) {
+		return newError("invalid order key " + strconv.Quote(key))
+	}
+	if f := key[len(i):]; strings.HasSuffix(f, string(minChar)) {
+		return newError("invalid order key " + strconv.Quote(key))
+	}
+	return nil
+}
+
+func incrementInteger(x string) (string, bool) {
+	head, digs := x[0], []byte(x[1:])
+	carry := true
+	for i := len(digs) - 1; carry && i >= 0; i-- {
+		d := strings.IndexByte(orderToByte, digs[i]) + 1
+		if d == len(orderToByte) {
+			digs[i] = minChar
+		} else {
+			digs[i] = orderToByte[d]
+			carry = false
+		}
+	}
+	if !carry {
+		return string(head) + string(digs), true
+	}
+	switch head {
+	case 'Z':
+		return "a" + string(minChar), true
+	case 'z':
+		return "", false
+	}
+	h := head + 1
+	if h > 'a' {
+		digs = append(digs, minChar)
+	} else {
+		digs = digs[:len(digs)-1]
+	}
+	return string(h) + string(digs), true
+}
+
+func decrementInteger(x string) (string, bool) {
+	head, digs := x[0], []byte(x[1:])
+	borrow := true
+	for i := len(digs) - 1; borrow && i >= 0; i-- {
+		d := strings.IndexByte(orderToByte, digs[i]) - 1
+		if d == -1 {
+			digs[i] = maxChar
+		} else {
+			digs[i] = orderToByte[d]
+			borrow = false
+		}
+	}
+	if !borrow {
+		return string(head) + string(digs), true
+	}
+	switch head {
+	case 'a':
+		return "Z" + string(maxChar), true
+	case 'A':
+		return "", false
+	}
+	h := head - 1
+	if h < 'Z' {
+		digs = append(digs, maxChar)
+	} else {
+		digs = digs[:len(digs)-1]
+	}
+	return string(h) + string(digs), true
+}
+
+// A FractionalImport says how ImportFractional converts keys.
+type FractionalImport int
+
+const (
+	// ImportRespread gives the items fresh ranks, spread out as for
+	// Rebalance.
+	ImportRespread FractionalImport = iota
+
+	// ImportNative keeps the keys as they are, as the majors of the
+	// ranks.  Fractional-indexing keys are made of base62 digits and
+	// sort the same way as ranks with those majors, so they can be
+	// used directly (and inserted between with this package) without
+	// rewriting every row.
+	ImportNative
+)
+
+// ImportFractional converts the keys of a list ordered with the
+// fractional-indexing package, given in list order, into ranks in
+// the given bucket, one for each key, in the same order.  Every key
+// is checked, as is their order (they must be strictly increasing),
+// and the first problem found is returned as an error.
+func ImportFractional(keys []string, mode FractionalImport, bucket byte) ([]Posn, error) {
+	return Generator{}.ImportFractional(keys, mode, bucket)
+}
+
+// ImportFractional is like the package-level ImportFractional, but
+// uses the generator's configuration for respreading.
+func (g Generator) ImportFractional(keys []string, mode FractionalImport, bucket byte) ([]Posn, error) {
+	if bucket > MaxBucket {
+		return nil, badBucket(bucket)
+	}
+	for i, k := range keys {
+		if err := validateOrderKey(k); err != nil {
+			return nil, newError("key " + strconv.Itoa(i) + ": " + strings.TrimPrefix(err.Error(), "lexorank: "))
+		}
+		if i > 0 && keys[i-1] >= k {
+			return nil, newError("keys " + strconv.Itoa(i-1) + " and " + strconv.Itoa(i) +
+				" out of order: " + strconv.Quote(keys[i-1]) + " >= " + strconv.Quote(k))
+		}
+	}
+	switch mode {
+	case ImportNative:
+		if g.Alphabet.orDefault().digits != Base62.digits {
+			return nil, newError("native fractional-indexing keys need the Base62 alphabet")
+		}
+		out := make([]Posn, len(keys))
+		for i, k := range keys {
+			out[i] = Posn{Bucket: bucket, Major: k}
+		}
+		return out, nil
+	case ImportRespread:
+		return g.Rebalance(len(keys), bucket)
+	default:
+		return nil, newError("unknown import mode " + strconv.Itoa(int(mode)))
+	}
+}
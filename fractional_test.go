@@ -0,0 +1,111 @@
+package lexorank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateKeyBetween(t *testing.T) {
+	cases := []struct {
+		a, b, want string
+	}{
+		{"", "", "a0"},
+		{"", "a0", "Zz"},
+		{"", "Zz", "Zy"},
+		{"a0", "", "a1"},
+		{"a1", "", "a2"},
+		{"a0", "a1", "a0V"},
+		{"a1", "a2", "a1V"},
+		{"a0V", "a1", "a0l"},
+		{"Zz", "a0", "ZzV"},
+		{"Zz", "a1", "a0"},
+		{"", "Y00", "Xzzz"},
+		{"bzz", "", "c000"},
+		{"a0", "a0V", "a0G"},
+		{"a0", "a0G", "a08"},
+		{"b125", "b129", "b127"},
+		{"a0", "a1V", "a1"},
+		{"Zz", "a01", "a0"},
+		{"", "a0V", "a0"},
+		{"", "b999", "b99"},
+		{"", "A00000000000000000000000000V", "A00000000000000000000000000G"},
+		{"zzzzzzzzzzzzzzzzzzzzzzzzzzy", "", "zzzzzzzzzzzzzzzzzzzzzzzzzzz"},
+		{"zzzzzzzzzzzzzzzzzzzzzzzzzzz", "", "zzzzzzzzzzzzzzzzzzzzzzzzzzzV"},
+	}
+	for _, c := range cases {
+		got, err := GenerateKeyBetween(c.a, c.b)
+		assert.NoError(t, err, "%q..%q", c.a, c.b)
+		assert.Equal(t, c.want, got, "%q..%q", c.a, c.b)
+	}
+}
+
+func TestGenerateKeyBetweenErrors(t *testing.T) {
+	cases := []struct {
+		a, b string
+	}{
+		{"", "A00000000000000000000000000"},
+		{"a00", ""},
+		{"a00", "a1"},
+		{"0", "1"},
+		{"a1", "a0"},
+		{"a0", "a0"},
+	}
+	for _, c := range cases {
+		_, err := GenerateKeyBetween(c.a, c.b)
+		assert.Error(t, err, "%q..%q", c.a, c.b)
+	}
+}
+
+func TestGenerateNKeysBetween(t *testing.T) {
+	keys, err := GenerateNKeysBetween("", "", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a0", "a1", "a2", "a3", "a4"}, keys)
+
+	keys, err = GenerateNKeysBetween("a4", "", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a5", "a6", "a7", "a8", "a9", "aA", "aB", "aC", "aD", "aE"}, keys)
+
+	keys, err = GenerateNKeysBetween("", "a0", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Zv", "Zw", "Zx", "Zy", "Zz"}, keys)
+
+	keys, err = GenerateNKeysBetween("a0", "a2", 20)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"a04", "a08", "a0G", "a0K", "a0O", "a0V", "a0Z", "a0d", "a0l", "a0t",
+		"a1", "a14", "a18", "a1G", "a1O", "a1V", "a1Z", "a1d", "a1l", "a1t",
+	}, keys)
+}
+
+func TestImportFractional(t *testing.T) {
+	keys, err := GenerateNKeysBetween("", "", 5)
+	assert.NoError(t, err)
+	keys = append([]string{"Zz"}, keys...)
+
+	ranks, err := ImportFractional(keys, ImportNative, 1)
+	assert.NoError(t, err)
+	for i, p := range ranks {
+		assert.Equal(t, Posn{Bucket: 1, Major: keys[i]}, p)
+		if i > 0 {
+			assert.True(t, ranks[i-1].Compare(p) < 0)
+		}
+	}
+	// and they can be inserted between natively
+	mid, ok := Ranks(1, &ranks[0], &ranks[1])
+	assert.True(t, ok)
+	assert.True(t, ranks[0].Compare(mid[0]) < 0 && mid[0].Compare(ranks[1]) < 0)
+
+	ranks, err = ImportFractional(keys, ImportRespread, 0)
+	assert.NoError(t, err)
+	want, err := Rebalance(len(keys), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, want, ranks)
+
+	_, err = ImportFractional([]string{"a0", "a1", "a1"}, ImportNative, 0)
+	assert.EqualError(t, err, `lexorank: keys 1 and 2 out of order: "a1" >= "a1"`)
+	_, err = ImportFractional([]string{"a0", "a10"}, ImportNative, 0)
+	assert.EqualError(t, err, `lexorank: key 1: invalid order key "a10"`)
+	_, err = Generator{Alphabet: Base36}.ImportFractional([]string{"a0"}, ImportNative, 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,258 @@
+package lexorank
+
+import "strings"
+
+// A Generator produces ranks according to its configuration.  The
+// zero value is ready to use and behaves like the package-level
+// functions.
+type Generator struct {
+	// Spacing controls where within a gap new ranks are placed
+	Spacing Spacing
+
+	// Alphabet is the numeral system ranks are written in (Base62 if
+	// left unset)
+	Alphabet Alphabet
+
+	// Profile is the form positions are written in as text, by
+	// Text and Parse
+	Profile Profile
+
+	// Metrics, if set, is told about the ranks generated
+	Metrics Metrics
+
+	// Tracer, if set, traces long-running operations
+	Tracer Tracer
+
+	// OnRebalance, if set, is called when the generator finds that
+	// the list needs rebalancing: the majors between two positions
+	// have run out, so it has to resort to the minor.
+	OnRebalance func(Event)
+
+	// OnExhaustion, if set, is called when a gap has run out of room
+	// entirely and no rank could be generated.
+	OnExhaustion func(Event)
+
+	// LowGap, if positive, is the number of ranks of the same length
+	// below which the room left next to a newly generated rank counts
+	// as running low, in which case OnLowGap is called.  This gives
+	// an early signal to schedule maintenance, rather than waiting
+	// for OnExhaustion.
+	LowGap   int
+	OnLowGap func(Event)
+
+	// HeadStep, if positive, makes Rank and Ranks place ranks at the
+	// top of a list (before its first item, with nothing before that)
+	// by stepping down from the first rank that much at a time,
+	// rather than halving the room left above it every time.  That
+	// keeps keys from growing for a long while in lists that are
+	// mostly prepended to, such as a triage queue.  A step that's a
+	// few digits' worth (say 1000) is about right.
+	HeadStep int
+
+	// AppendStep is the same thing for the bottom of a list: ranks
+	// after the last item step up from it that much at a time, so
+	// keys in a list that's only ever appended to (such as an event
+	// feed) stay the same length until the keyspace past the last
+	// item runs out, and only then get a digit longer.
+	AppendStep int
+
+	// Reserve, if positive, makes Rank leave room for at least that
+	// many further inserts on either side of each rank it generates
+	// before keys have to grow, at the cost of making the rank itself
+	// a little longer.  This helps lists that keep getting inserted
+	// into at the same spot (such as the top of a queue), where plain
+	// midpoints use up the room quickly.
+	Reserve int
+
+	// MaxLength, if positive, is the most digits a rank generated by
+	// Rank may have.  Rather than growing keys past it, Rank fails
+	// (and RankAt says which part of the list to rebalance), which
+	// keeps index sizes predictable.
+	MaxLength int
+
+	// Writer and Writers, if Writers is more than one, split every
+	// gap Rank is asked about into Writers equal parts and confine
+	// this generator to part number Writer (counting from zero).
+	// Giving each of a known set of writers (say, the two regions of
+	// an active-active deployment) its own number means their
+	// concurrent inserts can never collide, without resorting to
+	// randomness.
+	Writer, Writers int
+
+	// Collisions decides what InsertStore does when another writer
+	// takes the rank it wanted (Retry if nil)
+	Collisions CollisionStrategy
+
+	// Audit, if set, is given an AuditRecord for every rank written
+	// by the operations that work through a Store (InsertStore,
+	// AllocateBetween, RebalanceStore, RebalanceIfNeeded and
+	// RebalanceJob, and Escalate with its own Generator), once the
+	// write has succeeded.  An AuditLog's Record will do.
+	Audit func(AuditRecord)
+
+	// Tombstones says what AllocateBetween and RebalanceStore make of
+	// soft-deleted items.  An item is soft-deleted if Tombstoned says
+	// so, or (if it's nil) if the Store is a TombstoneStore that does.
+	Tombstones TombstoneMode
+	Tombstoned func(list string, it Item) bool
+
+	// Buckets, if set, has the active bucket of each list, which the
+	// migrations RebalanceIfNeeded does (whether called directly or
+	// from a Scheduler's Rebalance) move on, as a RebalanceJob with
+	// Buckets does.
+	Buckets BucketStore
+
+	// PromoteMinors lets RanksAt get out of running out of room
+	// between minors by proposing a Promotion, instead of failing.
+	PromoteMinors bool
+
+	// NoPool turns off the pooling of scratch buffers.  Pooling
+	// saves garbage when generating ranks in bulk, but costs a little
+	// for the odd small call, so latency-sensitive code that only
+	// ever generates a rank at a time may be better off without it.
+	NoPool bool
+
+	// list identifies the list being ranked in events, when known
+	list string
+
+	// trace, if set, collects the steps taken by ExplainRanks
+	trace *Explanation
+}
+
+func (g Generator) alphabet() Alphabet {
+	return g.Alphabet.orDefault()
+}
+
+// MaxMultiRank returns the most ranks the generator can make at once
+// with Ranks, which depends on its alphabet.
+func (g Generator) MaxMultiRank() int {
+	return g.alphabet().MaxMultiRank()
+}
+
+// Min and Max return the lowest and highest digits of the generator's
+// alphabet, which bound the ranks it makes.
+func (g Generator) Min() byte { return g.alphabet().Min() }
+func (g Generator) Max() byte { return g.alphabet().Max() }
+
+// quiet returns a copy of g that doesn't report on what it does, for
+// internal use where the caller does the reporting
+func (g Generator) quiet() Generator {
+	g.Metrics = nil
+	g.OnRebalance = nil
+	g.OnExhaustion = nil
+	g.LowGap = 0
+	g.OnLowGap = nil
+	g.trace = nil
+	return g
+}
+
+func (g Generator) tooLong(n int) bool {
+	return g.MaxLength > 0 && n > g.MaxLength
+}
+
+// Ranks is like the package-level Ranks, but uses the generator's
+// configuration.
+func (g Generator) Ranks(n int, prev, next *Posn) ([]Posn, bool) {
+	return g.AppendRanks(nil, n, prev, next)
+}
+
+// AppendRanks is like Ranks, but appends the new ranks to dst and
+// returns the extended slice, so that callers generating ranks in
+// bulk can reuse a buffer.  On failure, dst is returned unchanged.
+func (g Generator) AppendRanks(dst []Posn, n int, prev, next *Posn) ([]Posn, bool) {
+	a := g.alphabet()
+	if n > a.MaxMultiRank() {
+		// can't accommodate that many all at once
+		return dst, false
+	}
+
+	// ranks at the very top or bottom of a list may be stepped to,
+	// rather than placed in the middle of what's left
+	var step int
+	switch {
+	case prev == nil && next != nil:
+		step = g.HeadStep
+	case next == nil && prev != nil:
+		step = g.AppendStep
+	}
+	head := prev == nil
+	if prev == nil {
+		prev = &Posn{
+			Major: strings.Repeat(string(a.min()), 6),
+		}
+		// if there *is* a next, adopt its bucket
+		if next != nil {
+			prev.Bucket = next.Bucket
+		}
+	}
+
+	if next == nil {
+		next = &Posn{
+			Major: strings.Repeat(string(a.max()), 6),
+		}
+		// if there *is* a prev, adopt its bucket
+		if prev != nil {
+			next.Bucket = prev.Bucket
+		}
+	}
+
+	// check the bounds up front, rather than finding out halfway
+	// through generating ranks
+	if !g.validPosn(*prev) || !g.validPosn(*next) || CheckBounds(*prev, *next) != nil {
+		return dst, false
+	}
+	if prev.Bucket != next.Bucket {
+		var ok bool
+		if prev, next, ok = g.crossBucket(*prev, *next); !ok {
+			return dst, false
+		}
+	}
+
+	start := len(dst)
+	out := dst
+	ok := false
+	if step > 0 && !prev.HasMinor() && !next.HasMinor() {
+		var keys []string
+		if keys, ok = g.stepRanks(a, prev.Major, next.Major, n, step, head); ok {
+			for _, k := range keys {
+				out = append(out, Posn{Bucket: next.Bucket, Major: k})
+			}
+		}
+	}
+	if !ok && prev.Major != next.Major {
+		out, ok = g.majorRanks(dst, n, *prev, *next)
+	}
+	if !ok {
+		g.explain(Step{Kind: StepMinor, Pos: len(prev.Major)})
+		g.fire(g.OnRebalance, prev.digits(), next.digits(), n)
+		out, ok = g.minorRanks(dst, n, *prev, *next)
+	}
+	if !ok {
+		g.fire(g.OnExhaustion, prev.digits(), next.digits(), n)
+		return dst, false
+	}
+	// whatever went wrong, never hand out ranks that aren't strictly
+	// between the bounds
+	last := *prev
+	for _, p := range out[start:] {
+		if last.Compare(p) >= 0 {
+			return dst, false
+		}
+		last = p
+	}
+	if last.Compare(*next) >= 0 {
+		return dst, false
+	}
+	if g.Metrics != nil || g.LowGap > 0 {
+		out := out[start:]
+		digits := make([]string, len(out))
+		for i, p := range out {
+			digits[i] = p.digits()
+		}
+		if g.Metrics != nil {
+			g.observe(prev.digits(), next.digits(), digits)
+		}
+		g.checkLowGap(prev.digits(), next.digits(), digits)
+	}
+	return out, true
+}
@@ -0,0 +1,144 @@
+package lexorank
+
+// Assign gives ranks to the items of a slice that don't have one yet,
+// so that the ranks follow the order of the slice.  get returns an
+// item's rank, or nil if it hasn't got one, and set gives it one.
+// Items that already have a rank keep it; each run of items without
+// one is spread between the ranked items on either side.  This saves
+// converting user structs to and from []Posn.  It returns false,
+// having assigned nothing, if the existing ranks are out of order or
+// there is no room for a run.
+func Assign[T any](items []T, get func(T) *Posn, set func(*T, Posn)) bool {
+	return Accessor[T]{Get: get, Set: set}.Assign(items)
+}
+
+// InsertAt inserts item into items at index i, giving it a rank
+// between its new neighbours, and returns the extended slice.
+func InsertAt[T any](items []T, i int, item T, get func(T) *Posn, set func(*T, Posn)) ([]T, bool) {
+	return Accessor[T]{Get: get, Set: set}.InsertAt(items, i, item)
+}
+
+// Move moves the item at index from so that it ends up at index to,
+// shifting the items in between along, and gives it a rank between
+// its new neighbours.
+func Move[T any](items []T, from, to int, get func(T) *Posn, set func(*T, Posn)) bool {
+	return Accessor[T]{Get: get, Set: set}.Move(items, from, to)
+}
+
+// An Accessor gets at the ranks of items of type T, so that Assign,
+// InsertAt and Move can be used with a generator's configuration
+// (methods can't have type parameters, so the generator goes in here
+// instead).
+type Accessor[T any] struct {
+	Generator
+
+	// Get returns an item's rank, or nil if it hasn't got one
+	Get func(T) *Posn
+
+	// Set gives an item a rank
+	Set func(*T, Posn)
+}
+
+// Assign is like the package-level Assign, but uses the accessor's
+// generator.
+func (x Accessor[T]) Assign(items []T) bool {
+	get, set := x.Get, x.Set
+	var prev *Posn
+	type run struct {
+		start, end int
+		ranks      []Posn
+	}
+	var runs []run
+	for i := 0; i < len(items); {
+		if p := get(items[i]); p != nil {
+			if prev != nil && prev.Compare(*p) >= 0 {
+				return false
+			}
+			prev = p
+			i++
+			continue
+		}
+		j := i
+		var next *Posn
+		for ; j < len(items); j++ {
+			if next = get(items[j]); next != nil {
+				break
+			}
+		}
+		ranks, ok := x.AllocateBlock(prev, next, j-i)
+		if !ok {
+			return false
+		}
+		runs = append(runs, run{i, j, ranks})
+		i = j
+	}
+	for _, r := range runs {
+		for k := r.start; k < r.end; k++ {
+			set(&items[k], r.ranks[k-r.start])
+		}
+	}
+	return true
+}
+
+// InsertAt is like the package-level InsertAt, but uses the
+// accessor's generator.
+func (x Accessor[T]) InsertAt(items []T, i int, item T) ([]T, bool) {
+	get, set := x.Get, x.Set
+	if i < 0 || i > len(items) {
+		return items, false
+	}
+	var prev, next *Posn
+	if i > 0 {
+		prev = get(items[i-1])
+	}
+	if i < len(items) {
+		next = get(items[i])
+	}
+	p, ok := x.Between(prev, next)
+	if !ok {
+		return items, false
+	}
+	set(&item, p)
+	var zero T
+	items = append(items, zero)
+	copy(items[i+1:], items[i:])
+	items[i] = item
+	return items, true
+}
+
+// Move is like the package-level Move, but uses the accessor's
+// generator.
+func (x Accessor[T]) Move(items []T, from, to int) bool {
+	get, set := x.Get, x.Set
+	if from < 0 || from >= len(items) || to < 0 || to >= len(items) {
+		return false
+	}
+	if from == to {
+		return true
+	}
+	var prev, next *Posn
+	if from < to {
+		prev = get(items[to])
+		if to+1 < len(items) {
+			next = get(items[to+1])
+		}
+	} else {
+		next = get(items[to])
+		if to > 0 {
+			prev = get(items[to-1])
+		}
+	}
+	p, ok := x.Between(prev, next)
+	if !ok {
+		return false
+	}
+	item := items[from]
+	set(&item, p)
+	if from < to {
+		copy(items[from:to], items[from+1:to+1])
+	} else {
+		copy(items[to+1:from+1], items[to:from])
+	}
+	items[to] = item
+	return true
+}
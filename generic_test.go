@@ -0,0 +1,95 @@
+package lexorank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type card struct {
+	name string
+	rank *Posn
+}
+
+func cardRank(c card) *Posn { return c.rank }
+
+func setCardRank(c *card, p Posn) { c.rank = &p }
+
+func names(cards []card) []string {
+	var out []string
+	for _, c := range cards {
+		out = append(out, c.name)
+	}
+	return out
+}
+
+func checkOrder(t *testing.T, cards []card) {
+	for i := 1; i < len(cards); i++ {
+		assert.Equal(t, -1, cards[i-1].rank.Compare(*cards[i].rank), names(cards))
+	}
+}
+
+func TestAssign(t *testing.T) {
+	cards := []card{
+		{name: "a"},
+		{name: "b", rank: &Posn{Major: "c00000"}},
+		{name: "c"},
+		{name: "d"},
+		{name: "e", rank: &Posn{Major: "d00000"}},
+		{name: "f"},
+	}
+	assert.Equal(t, true, Assign(cards, cardRank, setCardRank))
+	checkOrder(t, cards)
+	assert.Equal(t, Posn{Major: "c00000"}, *cards[1].rank)
+
+	bad := []card{
+		{name: "a", rank: &Posn{Major: "d00000"}},
+		{name: "b"},
+		{name: "c", rank: &Posn{Major: "c00000"}},
+	}
+	assert.Equal(t, false, Assign(bad, cardRank, setCardRank))
+	assert.Nil(t, bad[1].rank)
+}
+
+func TestInsertAtAndMove(t *testing.T) {
+	var cards []card
+	for _, n := range []string{"a", "b", "c", "d"} {
+		var ok bool
+		cards, ok = InsertAt(cards, len(cards), card{name: n}, cardRank, setCardRank)
+		assert.Equal(t, true, ok)
+	}
+	cards, ok := InsertAt(cards, 1, card{name: "x"}, cardRank, setCardRank)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, []string{"a", "x", "b", "c", "d"}, names(cards))
+	checkOrder(t, cards)
+
+	assert.Equal(t, true, Move(cards, 1, 3, cardRank, setCardRank))
+	assert.Equal(t, []string{"a", "b", "c", "x", "d"}, names(cards))
+	checkOrder(t, cards)
+
+	assert.Equal(t, true, Move(cards, 4, 0, cardRank, setCardRank))
+	assert.Equal(t, []string{"d", "a", "b", "c", "x"}, names(cards))
+	checkOrder(t, cards)
+
+	assert.Equal(t, false, Move(cards, 0, 5, cardRank, setCardRank))
+	_, ok = InsertAt(cards, 7, card{}, cardRank, setCardRank)
+	assert.Equal(t, false, ok)
+}
+
+func TestAccessor(t *testing.T) {
+	x := Accessor[card]{
+		Generator: Generator{Alphabet: Crockford32},
+		Get:       cardRank,
+		Set:       setCardRank,
+	}
+	cards := []card{{name: "a"}, {name: "b"}, {name: "c"}}
+	assert.Equal(t, true, x.Assign(cards))
+	cards, ok := x.InsertAt(cards, 1, card{name: "x"})
+	assert.Equal(t, true, ok)
+	assert.Equal(t, true, x.Move(cards, 0, 3))
+	assert.Equal(t, []string{"x", "b", "c", "a"}, names(cards))
+	checkOrder(t, cards)
+	for _, c := range cards {
+		assert.Equal(t, true, Crockford32.Valid(c.rank.Major+c.rank.MinorValue()), c.rank)
+	}
+}
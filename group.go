@@ -0,0 +1,78 @@
+package lexorank
+
+import "strings"
+
+// A GroupRank is the place of an item in a list split into groups,
+// such as the cards on a kanban board, which are ordered column by
+// column and then by rank within their column.
+type GroupRank struct {
+	Group string
+	Rank  Posn
+}
+
+// Compare orders group ranks by group, and then by rank.
+func (r GroupRank) Compare(o GroupRank) int {
+	if c := strings.Compare(r.Group, o.Group); c != 0 {
+		return c
+	}
+	return r.Rank.Compare(o.Rank)
+}
+
+// Key returns a single key for r that sorts byte-wise as Compare
+// does, for storing in a column of its own (see AppendKey).
+func (r GroupRank) Key() string {
+	return string(AppendKey(nil, r.Group, r.Rank))
+}
+
+// ParseGroupRank is the inverse of GroupRank.Key.
+func ParseGroupRank(key string) (GroupRank, error) {
+	group, p, err := ParseKey([]byte(key))
+	if err != nil {
+		return GroupRank{}, err
+	}
+	return GroupRank{group, p}, nil
+}
+
+// MoveToGroup works out where an item ends up when it's dragged to
+// another group (or elsewhere in its own).  ranks holds the ranks of
+// the group it's dropped in, in order, and to is the index in ranks
+// of the item it's dropped in front of (len(ranks) meaning the end).
+// If the item was already in that group, its own rank is skipped
+// over, so a move within a group works too.
+func MoveToGroup(item GroupRank, group string, ranks []Posn, to int) (GroupRank, bool) {
+	return Generator{}.MoveToGroup(item, group, ranks, to)
+}
+
+// MoveToGroup is like the package-level MoveToGroup, but uses the
+// generator's configuration.
+func (g Generator) MoveToGroup(item GroupRank, group string, ranks []Posn, to int) (GroupRank, bool) {
+	if to < 0 || to > len(ranks) {
+		return GroupRank{}, false
+	}
+	self := func(i int) bool {
+		return item.Group == group && ranks[i].Equal(item.Rank)
+	}
+	var prev, next *Posn
+	for i := to - 1; i >= 0; i-- {
+		if !self(i) {
+			prev = &ranks[i]
+			break
+		}
+	}
+	for i := to; i < len(ranks); i++ {
+		if !self(i) {
+			next = &ranks[i]
+			break
+		}
+	}
+	if item.Group == group && (prev == nil || prev.Compare(item.Rank) < 0) &&
+		(next == nil || item.Rank.Compare(*next) < 0) {
+		// it's already there
+		return item, true
+	}
+	out, ok := g.Ranks(1, prev, next)
+	if !ok {
+		return GroupRank{}, false
+	}
+	return GroupRank{group, out[0]}, true
+}
@@ -0,0 +1,65 @@
+package lexorank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupRankKey(t *testing.T) {
+	ranks, err := Rebalance(3, 0)
+	assert.NoError(t, err)
+	// in order
+	items := []GroupRank{
+		{"doing", ranks[0]},
+		{"doing", ranks[2]},
+		{"done", ranks[1]},
+		{"todo", ranks[0]},
+	}
+	for i := 1; i < len(items); i++ {
+		assert.Equal(t, -1, items[i-1].Compare(items[i]))
+		assert.Less(t, items[i-1].Key(), items[i].Key())
+	}
+	for _, r := range items {
+		back, err := ParseGroupRank(r.Key())
+		assert.NoError(t, err)
+		assert.Equal(t, r, back)
+	}
+	_, err = ParseGroupRank("junk")
+	assert.ErrorIs(t, err, ErrBadKey)
+}
+
+func TestMoveToGroup(t *testing.T) {
+	todo, err := Rebalance(3, 0)
+	assert.NoError(t, err)
+	done, err := Rebalance(2, 0)
+	assert.NoError(t, err)
+
+	// across columns, between the two done items
+	card := GroupRank{"todo", todo[1]}
+	moved, ok := MoveToGroup(card, "done", done, 1)
+	assert.True(t, ok)
+	assert.Equal(t, "done", moved.Group)
+	assert.Equal(t, -1, done[0].Compare(moved.Rank))
+	assert.Equal(t, -1, moved.Rank.Compare(done[1]))
+
+	// to the top of an empty column
+	moved, ok = MoveToGroup(card, "blocked", nil, 0)
+	assert.True(t, ok)
+	assert.Equal(t, "blocked", moved.Group)
+
+	// within a column, to the end
+	moved, ok = MoveToGroup(card, "todo", todo, 3)
+	assert.True(t, ok)
+	assert.Equal(t, -1, todo[2].Compare(moved.Rank))
+
+	// dropping it where it already is changes nothing
+	for _, to := range []int{1, 2} {
+		moved, ok = MoveToGroup(card, "todo", todo, to)
+		assert.True(t, ok)
+		assert.Equal(t, card, moved)
+	}
+
+	_, ok = MoveToGroup(card, "done", done, 3)
+	assert.False(t, ok)
+}
@@ -0,0 +1,57 @@
+package lexorank
+
+import (
+	"strings"
+	"sync"
+)
+
+// An Interner makes equal strings share storage, for loading huge
+// numbers of ranks into memory, where the same majors (and minors)
+// turn up over and over.  It's safe for concurrent use, and the zero
+// value is ready to use.  Everything interned is kept until the
+// Interner itself is dropped.
+type Interner struct {
+	mu      sync.Mutex
+	strings map[string]string
+}
+
+// Intern returns a string equal to s, which is the same one each time
+// for equal strings.  The first time a string is seen, it's copied,
+// so that it doesn't keep whatever s was cut from alive.
+func (in *Interner) Intern(s string) string {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if t, ok := in.strings[s]; ok {
+		return t
+	}
+	if in.strings == nil {
+		in.strings = make(map[string]string)
+	}
+	t := strings.Clone(s)
+	in.strings[t] = t
+	return t
+}
+
+// Posn returns p with its major and minor interned.
+func (in *Interner) Posn(p Posn) Posn {
+	p.Major = in.Intern(p.Major)
+	p.Minor = in.Intern(p.MinorValue())
+	return p
+}
+
+// ParseJira is like the package-level ParseJira, but interns the
+// parts of the result.
+func (in *Interner) ParseJira(rank string) (Posn, bool) {
+	p, ok := ParseJira(rank)
+	if !ok {
+		return Posn{}, false
+	}
+	return in.Posn(p), true
+}
+
+// Len returns the number of distinct strings interned.
+func (in *Interner) Len() int {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return len(in.strings)
+}
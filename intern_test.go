@@ -0,0 +1,74 @@
+package lexorank
+
+import (
+	"runtime"
+	"strconv"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterner(t *testing.T) {
+	var in Interner
+	line1 := "0|hzzzzz:a,first"
+	line2 := "0|hzzzzz:b,second"
+	p1, ok := in.ParseJira(line1[:10])
+	assert.Equal(t, true, ok)
+	p2, ok := in.ParseJira(line2[:10])
+	assert.Equal(t, true, ok)
+	assert.Equal(t, Posn{Major: "hzzzzz", Minor: "a"}, p1)
+	assert.Equal(t, Posn{Major: "hzzzzz", Minor: "b"}, p2)
+
+	// the majors share storage, and it isn't the input's
+	assert.True(t, unsafe.StringData(p1.Major) == unsafe.StringData(p2.Major))
+	assert.True(t, unsafe.StringData(line1[2:]) != unsafe.StringData(p1.Major))
+	assert.Equal(t, 3, in.Len())
+
+	_, ok = in.ParseJira("junk")
+	assert.Equal(t, false, ok)
+
+	p := in.Posn(Posn{Major: "hzzzzz", Minor: ":a"})
+	assert.Equal(t, "a", p.Minor)
+	assert.Equal(t, 3, in.Len())
+}
+
+// BenchmarkLoad compares the memory held by a million ranks parsed
+// from lines of input, with and without interning, in a board where
+// items share a few thousand majors.
+func BenchmarkLoad(b *testing.B) {
+	const n = 1 << 20
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = "0|" + strconv.Itoa(100000+i%4096) + ":" + strconv.Itoa(i%16) + ",item " + strconv.Itoa(i)
+	}
+	for _, intern := range []bool{false, true} {
+		name := "plain"
+		if intern {
+			name = "interned"
+		}
+		b.Run(name, func(b *testing.B) {
+			for k := 0; k < b.N; k++ {
+				var before runtime.MemStats
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+				var in Interner
+				ranks := make([]Posn, n)
+				for i, line := range lines {
+					// copying the line stands in for reading it
+					rank := string([]byte(line[:10]))
+					if intern {
+						ranks[i], _ = in.ParseJira(rank)
+					} else {
+						ranks[i], _ = ParseJira(rank)
+					}
+				}
+				var m runtime.MemStats
+				runtime.GC()
+				runtime.ReadMemStats(&m)
+				b.ReportMetric(float64(m.HeapInuse-before.HeapInuse)/n, "heap-B/rank")
+				runtime.KeepAlive(ranks)
+			}
+		})
+	}
+}
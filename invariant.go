@@ -0,0 +1,110 @@
+package lexorank
+
+import (
+	"slices"
+	"strconv"
+)
+
+// A Violation describes a rank breaking one of the invariants checked
+// by StrictlyBetween, ValidCharset, NoBoundEquality, NoTrailingMin
+// and RawOrder,
+// which are the library's own definition of a correct rank.  They're
+// exported so that property tests of code built on the library can
+// check the same things.
+type Violation struct {
+	// Invariant is the name of the check that failed, such as
+	// "StrictlyBetween"
+	Invariant string
+	Rank      Posn
+	Detail    string
+}
+
+func (v Violation) Error() string {
+	return "lexorank: " + v.Invariant + " violated by " + v.Rank.String() + ": " + v.Detail
+}
+
+// StrictlyBetween checks that got sorts after prev and before next,
+// returning a violation for each bound it doesn't respect.  A nil
+// bound is open ended.
+func StrictlyBetween(prev *Posn, got Posn, next *Posn) []Violation {
+	var out []Violation
+	if prev != nil && prev.Compare(got) >= 0 {
+		out = append(out, Violation{"StrictlyBetween", got, "not after " + prev.String()})
+	}
+	if next != nil && got.Compare(*next) >= 0 {
+		out = append(out, Violation{"StrictlyBetween", got, "not before " + next.String()})
+	}
+	return out
+}
+
+// ValidCharset checks that p is made up of digits of the alphabet
+// (Base62 if a is the zero Alphabet), with a non-empty major and a
+// bucket in range, returning a violation for each problem.
+func ValidCharset(a Alphabet, p Posn) []Violation {
+	a = a.orDefault()
+	var out []Violation
+	if p.Bucket > MaxBucket {
+		out = append(out, Violation{"ValidCharset", p, "bucket " + strconv.Itoa(int(p.Bucket)) + " out of range"})
+	}
+	if p.Major == "" {
+		out = append(out, Violation{"ValidCharset", p, "empty major"})
+	}
+	s := p.digits()
+	for i := 0; i < len(s); i++ {
+		if _, err := a.OrderOf(s[i]); err != nil {
+			out = append(out, Violation{"ValidCharset", p, err.Error() + " at offset " + strconv.Itoa(i)})
+		}
+	}
+	return out
+}
+
+// NoBoundEquality checks that got isn't the same position as either
+// bound, which is the mistake that most often slips through when a
+// gap has run out of room.  StrictlyBetween implies it, but checking
+// it separately makes for a clearer diagnosis.
+func NoBoundEquality(prev *Posn, got Posn, next *Posn) []Violation {
+	var out []Violation
+	for _, b := range []*Posn{prev, next} {
+		if b != nil && b.Equal(got) {
+			out = append(out, Violation{"NoBoundEquality", got, "equal to bound " + b.String()})
+		}
+	}
+	return out
+}
+
+// NoTrailingMin checks that p doesn't end in the smallest digit of
+// the alphabet (Base62 if a is the zero Alphabet), as in "a0".
+// Nothing at all sorts between such a rank and the one it extends
+// ("a"), so it's a dead end for inserting just before it; with no
+// rank ending that way, there's always room between two ranks.  The
+// library doesn't make such ranks, except where the caller picks the
+// digits (RankFixed, SpreadFixed, FromBigInt and the like, whose
+// ranks are all the same length, so none extends another) or asks for
+// the very next rank (Posn.Next).
+func NoTrailingMin(a Alphabet, p Posn) []Violation {
+	a = a.orDefault()
+	s := p.digits()
+	if s != "" && a.values[s[len(s)-1]] == 0 {
+		return []Violation{{"NoTrailingMin", p, "ends in " + quoteByte(a.min())}}
+	}
+	return nil
+}
+
+// RawOrder is a lint for a set of ranks that are going to be sorted by
+// their String forms (as by a database index on a rank column): it
+// returns a violation for each pair of ranks, neighbours in Compare
+// order, that the raw strings put the other way round.  That happens
+// when majors of different lengths are mixed, since the ":" after
+// the shorter one sorts above some digits and below others.
+func RawOrder(ranks []Posn) []Violation {
+	sorted := slices.Clone(ranks)
+	slices.SortFunc(sorted, Posn.Compare)
+	var out []Violation
+	for i := 1; i < len(sorted); i++ {
+		prev, p := sorted[i-1], sorted[i]
+		if prev.Compare(p) < 0 && prev.String() >= p.String() {
+			out = append(out, Violation{"RawOrder", p, "sorts before " + prev.String() + " as a string"})
+		}
+	}
+	return out
+}
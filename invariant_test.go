@@ -0,0 +1,113 @@
+package lexorank
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStrictlyBetween(t *testing.T) {
+	prev := Posn{Major: "a"}
+	next := Posn{Major: "c"}
+	assert.Empty(t, StrictlyBetween(&prev, Posn{Major: "b"}, &next))
+	assert.Empty(t, StrictlyBetween(nil, Posn{Major: "b"}, nil))
+
+	v := StrictlyBetween(&next, Posn{Major: "b"}, &prev)
+	assert.Equal(t, 2, len(v))
+	assert.Equal(t, "StrictlyBetween", v[0].Invariant)
+	assert.Equal(t, "lexorank: StrictlyBetween violated by 0|b:: not after 0|c:", v[0].Error())
+}
+
+func TestValidCharset(t *testing.T) {
+	assert.Empty(t, ValidCharset(Alphabet{}, Posn{Major: "aZ", Minor: "0"}))
+	assert.Equal(t, 1, len(ValidCharset(Base36, Posn{Major: "aZ"})))
+
+	v := ValidCharset(Alphabet{}, Posn{Bucket: 5, Minor: "-_"})
+	assert.Equal(t, 4, len(v))
+}
+
+func TestNoBoundEquality(t *testing.T) {
+	prev := Posn{Major: "a"}
+	next := Posn{Major: "c"}
+	assert.Empty(t, NoBoundEquality(&prev, Posn{Major: "b"}, &next))
+
+	v := NoBoundEquality(&prev, Posn{Major: "a", Minor: ":"}, &next)
+	assert.Equal(t, 1, len(v))
+	assert.Equal(t, "NoBoundEquality", v[0].Invariant)
+}
+
+func TestNoTrailingMin(t *testing.T) {
+	assert.Empty(t, NoTrailingMin(Alphabet{}, Posn{Major: "a0", Minor: "1"}))
+	v := NoTrailingMin(Alphabet{}, Posn{Major: "a", Minor: "0"})
+	assert.Equal(t, 1, len(v))
+	assert.Equal(t, "lexorank: NoTrailingMin violated by 0|a:0: ends in '0'", v[0].Error())
+	assert.Equal(t, 1, len(NoTrailingMin(Base36, Posn{Major: "a0"})))
+
+	// where the shortest rank would end in "0", the rank goes on
+	for _, c := range [][3]string{
+		{"a", "a1", "a0U"},
+		{"a", "a01", "a00U"},
+		{"", "1", "0U"},
+		{"a", "a2", "a1"},
+	} {
+		r, ok := Rank(c[0], c[1])
+		assert.True(t, ok)
+		assert.Equal(t, c[2], r, c)
+	}
+	_, ok := Rank("a", "a00")
+	assert.False(t, ok)
+
+	bin, err := NewAlphabet("01")
+	assert.NoError(t, err)
+	r, ok := Generator{Alphabet: bin}.Rank("0", "01")
+	assert.True(t, ok)
+	assert.Equal(t, "001", r)
+	r, ok = Generator{Alphabet: bin}.Rank("", "1")
+	assert.True(t, ok)
+	assert.Equal(t, "01", r)
+
+	// nothing the generator makes ends in the smallest digit, so
+	// there's always room before it
+	rng := rand.New(rand.NewSource(1))
+	list := []Posn{{Major: "U"}}
+	for i := 0; i < 2000; i++ {
+		j := rng.Intn(len(list) + 1)
+		if rng.Intn(2) == 0 {
+			j = min(1, len(list))
+		}
+		var prev, next *Posn
+		if j > 0 {
+			prev = &list[j-1]
+		}
+		if j < len(list) {
+			next = &list[j]
+		}
+		rs, ok := Ranks(1+rng.Intn(3), prev, next)
+		assert.True(t, ok)
+		for _, p := range rs {
+			assert.Empty(t, NoTrailingMin(Alphabet{}, p))
+		}
+		list = slices.Insert(list, j, rs...)
+	}
+	ranks, err := Rebalance(5000, 0)
+	assert.NoError(t, err)
+	for _, p := range ranks {
+		assert.Empty(t, NoTrailingMin(Alphabet{}, p))
+	}
+}
+
+func TestRawOrder(t *testing.T) {
+	// same length majors sort the same either way
+	assert.Empty(t, RawOrder([]Posn{{Major: "b0"}, {Major: "a0", Minor: "U"}, {Major: "a0"}}))
+
+	// "a:" sorts after "a0:" as a string
+	v := RawOrder([]Posn{{Major: "b"}, {Major: "a0"}, {Major: "a"}})
+	assert.Equal(t, 1, len(v))
+	assert.Equal(t, "RawOrder", v[0].Invariant)
+	assert.Equal(t, Posn{Major: "a0"}, v[0].Rank)
+
+	// but "a:" is before "aa:"
+	assert.Empty(t, RawOrder([]Posn{{Major: "a"}, {Major: "aa"}}))
+}
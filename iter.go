@@ -0,0 +1,163 @@
+package lexorank
+
+import (
+	"iter"
+	"strings"
+)
+
+// IterateAfter returns an endless sequence of strictly increasing
+// ranks after p, for streaming ingestion that just keeps appending.
+// Each rank is the next one at the same length (see Posn.Next) that
+// doesn't end in the smallest digit, so keys only get longer when
+// every rank of the current length has been used up.  If p isn't
+// valid, the sequence is empty.
+func IterateAfter(p Posn) iter.Seq[Posn] {
+	return Generator{}.IterateAfter(p)
+}
+
+// IterateAfter is like the package-level IterateAfter, but in the
+// generator's alphabet.
+func (g Generator) IterateAfter(p Posn) iter.Seq[Posn] {
+	return func(yield func(Posn) bool) {
+		a := g.alphabet()
+		for {
+			var ok bool
+			p, ok = g.Next(p)
+			if !ok {
+				return
+			}
+			if s := p.digits(); a.order(s[len(s)-1]) == 0 {
+				continue
+			}
+			if !yield(p) {
+				return
+			}
+		}
+	}
+}
+
+// IterateBetween returns a sequence of ranks between prev and next,
+// for when the number of items to insert isn't known up front.  The
+// ranks come out in increasing order, each one the shortest rank
+// between the one before it and next.  The sequence ends if there is
+// no more room.
+func IterateBetween(prev, next Posn) iter.Seq[Posn] {
+	return Generator{}.IterateBetween(prev, next)
+}
+
+// SubdivideBetween is like IterateBetween, but yields the ranks in
+// binary-subdivision order: first the middle of the gap, then the
+// middles of each half, and so on.  Stopping at any point leaves the
+// ranks handed out spread evenly through the gap, which suits filling
+// in placeholders whose final order doesn't matter yet.
+func SubdivideBetween(prev, next Posn) iter.Seq[Posn] {
+	return Generator{}.SubdivideBetween(prev, next)
+}
+
+// IterateBetween is like the package-level IterateBetween, but uses
+// the generator's configuration.
+func (g Generator) IterateBetween(prev, next Posn) iter.Seq[Posn] {
+	return func(yield func(Posn) bool) {
+		for {
+			r, ok := g.between(prev, next)
+			if !ok || !yield(r) {
+				return
+			}
+			prev = r
+		}
+	}
+}
+
+// SubdivideBetween is like the package-level SubdivideBetween, but
+// uses the generator's configuration.
+func (g Generator) SubdivideBetween(prev, next Posn) iter.Seq[Posn] {
+	return func(yield func(Posn) bool) {
+		queue := [][2]Posn{{prev, next}}
+		for len(queue) > 0 {
+			gap := queue[0]
+			queue = queue[1:]
+			r, ok := g.between(gap[0], gap[1])
+			if !ok {
+				continue
+			}
+			if !yield(r) {
+				return
+			}
+			queue = append(queue, [2]Posn{gap[0], r}, [2]Posn{r, gap[1]})
+		}
+	}
+}
+
+// between returns the shortest rank between prev and next.  It works
+// on the positions rather than their digits run together, since
+// those don't sort like the positions when the majors are different
+// lengths.
+func (g Generator) between(prev, next Posn) (Posn, bool) {
+	r, ok := g.block(&prev, &next, 1, prev)
+	if !ok {
+		return Posn{}, false
+	}
+	return r[0], true
+}
+
+// Enumerate returns every digit string of at most maxLen digits that
+// sorts strictly between lo and hi, in order, for exhaustive tests
+// with small alphabets and for fixtures.  An empty bound is open
+// ended.  There are about base^maxLen of them, so keep both small.  If
+// either bound isn't valid, the sequence is empty.
+func Enumerate(lo, hi string, maxLen int) iter.Seq[string] {
+	return Generator{}.Enumerate(lo, hi, maxLen)
+}
+
+// Enumerate is like the package-level Enumerate, but in the
+// generator's alphabet.
+func (g Generator) Enumerate(lo, hi string, maxLen int) iter.Seq[string] {
+	a := g.alphabet()
+	return func(yield func(string) bool) {
+		if !a.valid(lo) || !a.valid(hi) {
+			return
+		}
+		lo, hi := a.canonical(lo), []byte(a.canonical(hi))
+		buf := make([]byte, 0, maxLen)
+
+		// walk visits the strings starting with buf, depth first,
+		// which is their order; it returns false once the rest are
+		// all too big (or the caller has had enough)
+		var walk func() bool
+		walk = func() bool {
+			for v := 0; v < a.base(); v++ {
+				buf = append(buf, a.digit(v))
+				if len(hi) > 0 && a.compare(buf, hi) >= 0 {
+					return false
+				}
+				if lo != "" && a.compare(buf, []byte(lo)) <= 0 {
+					// too small, but longer strings starting the
+					// same way may not be
+					if !strings.HasPrefix(lo, string(buf)) {
+						buf = buf[:len(buf)-1]
+						continue
+					}
+				} else if !yield(string(buf)) {
+					return false
+				}
+				if len(buf) < maxLen && !walk() {
+					return false
+				}
+				buf = buf[:len(buf)-1]
+			}
+			return true
+		}
+		walk()
+	}
+}
+
+// fromDigits turns digits into a position in the same bucket as p,
+// splitting them so that the major is no longer than p's
+func (p Posn) fromDigits(s string) Posn {
+	n := min(len(s), len(p.Major))
+	return Posn{
+		Bucket: p.Bucket,
+		Major:  s[:n],
+		Minor:  s[n:],
+	}
+}
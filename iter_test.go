@@ -0,0 +1,146 @@
+package lexorank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIterateAfter(t *testing.T) {
+	var got []Posn
+	for p := range IterateAfter(Posn{Major: "ay"}) {
+		got = append(got, p)
+		if len(got) == 3 {
+			break
+		}
+	}
+	// "b0" is skipped, as ranks don't end in the smallest digit
+	assert.Equal(t, []Posn{{Major: "az"}, {Major: "b1"}, {Major: "b2"}}, got)
+}
+
+func TestIterateAfterGrows(t *testing.T) {
+	prev := Posn{Major: "zy"}
+	n := 0
+	for p := range IterateAfter(prev) {
+		assert.Equal(t, 1, p.Compare(prev))
+		prev = p
+		if n++; n == 5 {
+			break
+		}
+	}
+	assert.Equal(t, Posn{Major: "zz", Minor: "4"}, prev)
+}
+
+func TestIterateAfterInvalid(t *testing.T) {
+	for range IterateAfter(Posn{Major: "a-"}) {
+		t.Fatal("unexpected rank")
+	}
+}
+
+func TestIterateBetween(t *testing.T) {
+	prev := Posn{Major: "a00000"}
+	next := Posn{Major: "a00001"}
+	last := prev
+	n := 0
+	for p := range IterateBetween(prev, next) {
+		assert.Equal(t, 1, p.Compare(last))
+		assert.Equal(t, -1, p.Compare(next))
+		assert.Equal(t, "a00000", p.Major)
+		last = p
+		if n++; n == 100 {
+			break
+		}
+	}
+	assert.Equal(t, 100, n)
+}
+
+func TestIterateBetweenEnds(t *testing.T) {
+	n := 0
+	for range IterateBetween(Posn{Major: "a"}, Posn{Major: "a", Minor: "0"}) {
+		n++
+	}
+	assert.Equal(t, 0, n)
+}
+
+func TestIterateBetweenMajors(t *testing.T) {
+	// run together, the digits of these sort the other way round
+	cases := [][2]Posn{
+		{{Major: "a", Minor: "y"}, {Major: "a0"}},
+		{{Major: "a"}, {Major: "a0"}},
+		{{Major: "ab"}, {Major: "b"}},
+	}
+	for _, c := range cases {
+		last := c[0]
+		n := 0
+		for p := range IterateBetween(c[0], c[1]) {
+			assert.Equal(t, 1, p.Compare(last), p)
+			assert.Equal(t, -1, p.Compare(c[1]), p)
+			last = p
+			if n++; n == 20 {
+				break
+			}
+		}
+		assert.Equal(t, 20, n, c)
+
+		n = 0
+		for p := range SubdivideBetween(c[0], c[1]) {
+			assert.Equal(t, 1, p.Compare(c[0]), p)
+			assert.Equal(t, -1, p.Compare(c[1]), p)
+			if n++; n == 20 {
+				break
+			}
+		}
+		assert.Equal(t, 20, n, c)
+	}
+}
+
+func TestSubdivideBetween(t *testing.T) {
+	prev := Posn{Major: "a"}
+	next := Posn{Major: "e"}
+	var got []string
+	for p := range SubdivideBetween(prev, next) {
+		got = append(got, p.Major+p.Minor)
+		if len(got) == 3 {
+			break
+		}
+	}
+	assert.Equal(t, []string{"c", "b", "d"}, got)
+}
+
+func TestEnumerate(t *testing.T) {
+	abc, err := NewAlphabet("abc")
+	assert.NoError(t, err)
+	g := Generator{Alphabet: abc}
+	var got []string
+	for s := range g.Enumerate("", "", 2) {
+		got = append(got, s)
+	}
+	assert.Equal(t, []string{"a", "aa", "ab", "ac", "b", "ba", "bb", "bc", "c", "ca", "cb", "cc"}, got)
+
+	got = got[:0]
+	for s := range g.Enumerate("ab", "bb", 3) {
+		got = append(got, s)
+	}
+	assert.Equal(t, []string{"aba", "abb", "abc", "ac", "aca", "acb", "acc", "b", "ba", "baa", "bab", "bac"}, got)
+
+	// every rank Rank makes between two bounds is among them
+	all := map[string]bool{}
+	for s := range g.Enumerate("b", "c", 4) {
+		all[s] = true
+	}
+	assert.Len(t, all, 3+9+27)
+	r, ok := g.Rank("b", "c")
+	assert.True(t, ok)
+	assert.True(t, all[r], r)
+
+	n := 0
+	for range Enumerate("", "", 3) {
+		if n++; n == 10 {
+			break
+		}
+	}
+	assert.Equal(t, 10, n)
+	for range Enumerate("!", "", 3) {
+		t.Fatal("enumerated with a bad bound")
+	}
+}
@@ -0,0 +1,183 @@
+package lexorank
+
+import (
+	"strconv"
+	"strings"
+)
+
+// A JiraMode says how fussy ParseJiraMode is.
+type JiraMode int
+
+const (
+	// JiraDefault accepts what ParseJira does: Jira's grammar, but
+	// with the ":" optional when there's no minor.
+	JiraDefault JiraMode = iota
+
+	// JiraStrict accepts exactly what Jira writes, which always
+	// includes the ":".
+	JiraStrict
+
+	// JiraLenient is for ranks that have been through spreadsheets
+	// and CSV exports: it also allows surrounding white space, upper
+	// case letters (which are lowered, since Jira's digits are base36)
+	// and buckets beyond 2 (which wrap round, as Jira's rotation
+	// does, so 3 is 0).  The result is normalized accordingly.
+	JiraLenient
+)
+
+// ParseJiraMode is like ParseJira, but in the given mode, and says
+// what is wrong with a rank it can't parse.
+func ParseJiraMode(rank string, mode JiraMode) (Posn, error) {
+	s := rank
+	if mode == JiraLenient {
+		s = strings.TrimSpace(s)
+		if len(s) > 0 && s[0] >= '0' && s[0] <= '9' {
+			s = string('0'+(s[0]-'0')%(MaxBucket+1)) + strings.ToLower(s[1:])
+		}
+	}
+	if len(s) < 2 || s[0] < '0' || s[0] > '0'+MaxBucket || s[1] != '|' {
+		return Posn{}, newError(strconv.Quote(rank) + " doesn't start with a bucket")
+	}
+	major, minor, found := strings.Cut(s[2:], ":")
+	if !found && mode == JiraStrict {
+		return Posn{}, newError(strconv.Quote(rank) + ` has no ":"`)
+	}
+	if major == "" {
+		return Posn{}, newError(strconv.Quote(rank) + " has an empty major")
+	}
+	for _, part := range []string{major, minor} {
+		for i := 0; i < len(part); i++ {
+			if !isJiraDigit(part[i]) {
+				return Posn{}, invalidDigit(part[i], rank)
+			}
+		}
+	}
+	return Posn{Bucket: s[0] - '0', Major: major, Minor: minor}, nil
+}
+
+// A JiraRank is a rank as read from Jira, along with how it was
+// written, so that it can be written back unchanged.  Jira Cloud
+// always puts a ":" after the major, but Server and Data Center
+// sites (particularly ones whose ranks date from the migration to
+// LexoRank) have values without it, which String on a Posn would
+// otherwise add.  (Which custom field holds the rank differs between
+// sites as well, but that is for the caller to look up.)
+type JiraRank struct {
+	Rank Posn
+
+	// Bare is set for a rank written without the ":", which is only
+	// possible when it has no minor.
+	Bare bool
+}
+
+// ParseJiraRank parses a rank as ParseJiraMode does, noting whether
+// it was bare.
+func ParseJiraRank(rank string, mode JiraMode) (JiraRank, error) {
+	p, err := ParseJiraMode(rank, mode)
+	if err != nil {
+		return JiraRank{}, err
+	}
+	return JiraRank{Rank: p, Bare: !strings.Contains(rank, ":")}, nil
+}
+
+// String writes r the way it was read (after any normalization done
+// by the parsing mode).
+func (r JiraRank) String() string {
+	b, _ := r.MarshalText()
+	return string(b)
+}
+
+// MarshalText implements encoding.TextMarshaler, writing what String
+// does.
+func (r JiraRank) MarshalText() ([]byte, error) {
+	b, _ := r.Rank.AppendText(nil)
+	if r.Bare && !r.Rank.HasMinor() {
+		b = b[:len(b)-1]
+	}
+	return b, nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, parsing in the
+// default mode.
+func (r *JiraRank) UnmarshalText(b []byte) error {
+	q, err := ParseJiraRank(string(b), JiraDefault)
+	if err != nil {
+		return err
+	}
+	*r = q
+	return nil
+}
+
+// JiraMajorLen is the length of the majors Jira makes.
+const JiraMajorLen = 6
+
+// JiraToPlain turns a Jira rank into a plain rank (a string of digits,
+// as Rank makes), for consumers that order by plain ranks.  The major
+// is padded out with '0's to JiraMajorLen (Jira's own majors are
+// already that long) and the minor appended to it.  The bucket is
+// lost, so plain ranks only keep their order among ranks from the
+// same bucket (see JiraListToPlain), and so is where the major ends,
+// so converting back needs to be told (see PlainToJira).
+func JiraToPlain(p Posn) string {
+	major := p.Major
+	if len(major) < JiraMajorLen {
+		major += strings.Repeat("0", JiraMajorLen-len(major))
+	}
+	return major + p.MinorValue()
+}
+
+// JiraListToPlain is JiraToPlain for the ranks of a whole list,
+// checking that the plain ranks keep the list's order: the ranks must
+// all be in the same bucket (so a list part way through a Jira
+// rebalance can't be converted), have majors no longer than
+// JiraMajorLen, and not collide once padded.
+func JiraListToPlain(ranks []Posn) ([]string, error) {
+	out := make([]string, len(ranks))
+	for i, p := range ranks {
+		switch {
+		case p.Bucket != ranks[0].Bucket:
+			return nil, newError("ranks in buckets " + strconv.Itoa(int(ranks[0].Bucket)) + " and " +
+				strconv.Itoa(int(p.Bucket)) + " can't be ordered without their buckets")
+		case len(p.Major) > JiraMajorLen:
+			return nil, newError(p.String() + " has a major longer than " + strconv.Itoa(JiraMajorLen))
+		}
+		out[i] = JiraToPlain(p)
+	}
+	seen := make(map[string]int, len(out))
+	for i, s := range out {
+		if j, ok := seen[s]; ok {
+			return nil, newError(ranks[j].String() + " and " + ranks[i].String() + " are the same once padded")
+		}
+		seen[s] = i
+	}
+	return out, nil
+}
+
+// PlainToJira turns a plain rank into a well-formed Jira rank in the
+// given bucket: the first majorLen digits (padded with '0's if there
+// aren't that many) become the major, and the rest the minor.  Plain
+// ranks keep their order if they're all given the same bucket and
+// majorLen, except that ones differing only in trailing '0's (which
+// Rank never makes) become the same rank.  Jira's digits are 0-9 and
+// a-z, so plain ranks with other digits (such as Base62's upper case
+// letters) are rejected.
+func PlainToJira(plain string, bucket byte, majorLen int) (Posn, error) {
+	if bucket > MaxBucket {
+		return Posn{}, badBucket(bucket)
+	}
+	if majorLen <= 0 {
+		return Posn{}, newError("major length " + strconv.Itoa(majorLen) + " isn't positive")
+	}
+	if plain == "" {
+		return Posn{}, newError("empty rank")
+	}
+	for i := 0; i < len(plain); i++ {
+		if !isJiraDigit(plain[i]) {
+			return Posn{}, invalidDigit(plain[i], plain)
+		}
+	}
+	if len(plain) < majorLen {
+		return Posn{Bucket: bucket, Major: plain + strings.Repeat("0", majorLen-len(plain))}, nil
+	}
+	return Posn{Bucket: bucket, Major: plain[:majorLen], Minor: strings.TrimRight(plain[majorLen:], "0")}, nil
+}
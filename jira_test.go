@@ -0,0 +1,122 @@
+package lexorank
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseJiraMode(t *testing.T) {
+	for _, c := range []struct {
+		rank string
+		mode JiraMode
+		want Posn
+		ok   bool
+	}{
+		{"0|abc:", JiraDefault, Posn{Major: "abc"}, true},
+		{"0|abc", JiraDefault, Posn{Major: "abc"}, true},
+		{"1|abc:d", JiraStrict, Posn{Bucket: 1, Major: "abc", Minor: "d"}, true},
+		{"0|abc", JiraStrict, Posn{}, false},
+		{" 0|ABC:\n", JiraDefault, Posn{}, false},
+		{" 0|ABC:\n", JiraLenient, Posn{Major: "abc"}, true},
+		{"4|abc", JiraLenient, Posn{Bucket: 1, Major: "abc"}, true},
+		{"4|abc", JiraDefault, Posn{}, false},
+		{"0|a-c", JiraLenient, Posn{}, false},
+		{"0|:a", JiraLenient, Posn{}, false},
+		{"a|abc", JiraLenient, Posn{}, false},
+		{"", JiraLenient, Posn{}, false},
+	} {
+		got, err := ParseJiraMode(c.rank, c.mode)
+		if c.ok {
+			assert.NoError(t, err, c.rank)
+			assert.Equal(t, c.want, got, c.rank)
+		} else {
+			assert.Error(t, err, c.rank)
+		}
+	}
+}
+
+func TestParseJiraModeAgrees(t *testing.T) {
+	for _, s := range []string{"0|abc:", "0|abc", "2|a:b", "0|", "3|a", "0|a:B", "0|a:b:c"} {
+		want, ok := ParseJira(s)
+		got, err := ParseJiraMode(s, JiraDefault)
+		assert.Equal(t, ok, err == nil, s)
+		assert.Equal(t, want, got, s)
+	}
+}
+
+func TestParseJiraModeDigit(t *testing.T) {
+	_, err := ParseJiraMode("0|a_b:", JiraStrict)
+	assert.True(t, errors.Is(err, ErrInvalidDigit))
+}
+
+func TestJiraRankRoundTrip(t *testing.T) {
+	for _, s := range []string{"0|hzzzzz:", "0|hzzzzz", "1|i0000f:a", "2|0"} {
+		r, err := ParseJiraRank(s, JiraDefault)
+		assert.NoError(t, err)
+		assert.Equal(t, s, r.String())
+	}
+
+	r, err := ParseJiraRank(" 1|ABC ", JiraLenient)
+	assert.NoError(t, err)
+	assert.Equal(t, JiraRank{Rank: Posn{Bucket: 1, Major: "abc"}, Bare: true}, r)
+	assert.Equal(t, "1|abc", r.String())
+
+	// a bare rank that gains a minor needs its ":" after all
+	r.Rank.Minor = "x"
+	assert.Equal(t, "1|abc:x", r.String())
+}
+
+func TestJiraRankText(t *testing.T) {
+	var r JiraRank
+	assert.NoError(t, r.UnmarshalText([]byte("0|abc")))
+	b, err := r.MarshalText()
+	assert.NoError(t, err)
+	assert.Equal(t, "0|abc", string(b))
+	assert.Error(t, r.UnmarshalText([]byte("0|ABC")))
+}
+
+func TestJiraToPlain(t *testing.T) {
+	assert.Equal(t, "hzzzzza", JiraToPlain(Posn{Bucket: 1, Major: "hzzzzz", Minor: "a"}))
+	assert.Equal(t, "i00000", JiraToPlain(Posn{Major: "i"}))
+
+	ranks := []Posn{{Major: "hzzzzz"}, {Major: "hzzzzz", Minor: "i"}, {Major: "i"}}
+	plain, err := JiraListToPlain(ranks)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hzzzzz", "hzzzzzi", "i00000"}, plain)
+
+	_, err = JiraListToPlain([]Posn{{Major: "a"}, {Bucket: 1, Major: "b"}})
+	assert.Error(t, err)
+	_, err = JiraListToPlain([]Posn{{Major: "a0000000"}})
+	assert.Error(t, err)
+	_, err = JiraListToPlain([]Posn{{Major: "a"}, {Major: "a0"}})
+	assert.EqualError(t, err, "lexorank: 0|a: and 0|a0: are the same once padded")
+}
+
+func TestPlainToJira(t *testing.T) {
+	p, err := PlainToJira("hzzzzzi", 1, 6)
+	assert.NoError(t, err)
+	assert.Equal(t, "1|hzzzzz:i", p.String())
+	p, err = PlainToJira("i", 0, 6)
+	assert.NoError(t, err)
+	assert.Equal(t, "0|i00000:", p.String())
+
+	// there and back again
+	for _, s := range []string{"0|hzzzzz:", "2|000001:zz1", "1|i00000:"} {
+		p, ok := ParseJira(s)
+		assert.True(t, ok)
+		q, err := PlainToJira(JiraToPlain(p), p.Bucket, JiraMajorLen)
+		assert.NoError(t, err)
+		assert.Equal(t, s, q.String())
+	}
+
+	_, err = PlainToJira("aZ", 0, 6)
+	assert.Error(t, err)
+	_, err = PlainToJira("", 0, 6)
+	assert.Error(t, err)
+	_, err = PlainToJira("a", 3, 6)
+	assert.Error(t, err)
+	_, err = PlainToJira("a", 0, 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,334 @@
+package lexorank
+
+import (
+	"context"
+	"errors"
+)
+
+// A RebalanceJob rebalances a list through a Store a batch at a time,
+// for lists too big to rebalance in one go, and can pick up where it
+// left off if it's interrupted (say, by a deploy).  It works by
+// migrating the list into the next bucket, so the list stays in order
+// while the job is under way, and can be inserted into as usual.
+//
+// To make a job resumable, save its Checkpoint from OnBatch, and set
+// it before running the job again.
+type RebalanceJob struct {
+	Store     Store
+	List      string
+	Generator Generator
+
+	// BatchSize is how many items are updated at a time (1000 if
+	// zero)
+	BatchSize int
+
+	// Checkpoint is how far the job has got
+	Checkpoint Checkpoint
+
+	// OnBatch, if set, is called after each batch is written (and
+	// once more when the job finishes), for saving the checkpoint
+	// and reporting progress.  If it returns an error, the job stops
+	// with that error.
+	OnBatch func(Checkpoint) error
+
+	// Buckets, if set, has the list's active bucket, which the job
+	// moves the list out of (rather than whichever bucket it finds
+	// the list in).  When the job starts, it moves the active bucket
+	// on to the next one, so new items go straight there; if another
+	// instance has already done that, the job fails with
+	// ErrMigrating.
+	Buckets BucketStore
+}
+
+// A Checkpoint records the progress of a RebalanceJob.  It can be
+// saved as JSON.
+type Checkpoint struct {
+	// From and To are the buckets the list is being moved between
+	From, To byte
+
+	// Total is the size of the list when the job started, and Done
+	// the number of items moved so far
+	Total, Done int
+
+	// Last is the old rank of the last item moved, and Edge its new
+	// one.  (A job that is resumed works out Done and Edge afresh from
+	// the store, so they're only for reporting.)
+	Last, Edge *Posn
+
+	Started, Finished bool
+}
+
+// Percent returns how much of the job has been done, as a percentage.
+func (c Checkpoint) Percent() float64 {
+	switch {
+	case c.Finished:
+		return 100
+	case c.Total == 0:
+		return 0
+	}
+	return min(100, 100*float64(c.Done)/float64(c.Total))
+}
+
+// ErrMigrating is returned by a RebalanceJob that is started (not
+// resumed) on a list that is already part way through a migration to
+// another bucket.
+var ErrMigrating = errors.New("lexorank: list is already being migrated")
+
+func (j *RebalanceJob) batchSize() int {
+	if j.BatchSize <= 0 {
+		return 1000
+	}
+	return j.BatchSize
+}
+
+// Run runs the job until it is finished, fails or ctx is cancelled.
+func (j *RebalanceJob) Run(ctx context.Context) (err error) {
+	cp := &j.Checkpoint
+	if cp.Finished {
+		return nil
+	}
+	if !cp.Started {
+		if err := j.start(ctx); err != nil {
+			return err
+		}
+	}
+	ctx, end := j.Generator.startSpan(ctx, "RebalanceJob", cp.Total)
+	touched := 0
+	defer func() { end(touched, err) }()
+
+	// the new bucket sorts before the old one only when wrapping
+	// around (2 to 0); otherwise the list has to be moved from the
+	// end backwards to stay in order
+	forward := cp.To < cp.From
+	sp := j.Generator.rebalanceSpacing(cp.Total, cp.To)
+	if err := j.sync(ctx, forward); err != nil {
+		return err
+	}
+	for {
+		var batch []Item
+		if forward {
+			batch, err = j.Store.Items(ctx, j.List, cp.Last, j.batchSize())
+		} else {
+			batch, err = j.Store.ItemsBefore(ctx, j.List, cp.Last, j.batchSize())
+		}
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			cp.Finished = true
+			if j.OnBatch != nil {
+				return j.OnBatch(*cp)
+			}
+			return nil
+		}
+		// items inserted into the new bucket since the job started
+		// can be just past the edge; they are skipped over, and the
+		// rest is the items to move, up to anything in another bucket
+		if forward {
+			n := j.skipMoved(batch, true)
+			batch = batch[n:]
+			for i, it := range batch {
+				if it.Rank.Bucket != cp.From {
+					batch = batch[:i]
+					break
+				}
+			}
+		} else {
+			n := j.skipMoved(batch, false)
+			batch = batch[:len(batch)-n]
+			for i := len(batch) - 1; i >= 0; i-- {
+				if batch[i].Rank.Bucket != cp.From {
+					batch = batch[i+1:]
+					break
+				}
+			}
+		}
+		if len(batch) == 0 {
+			continue
+		}
+
+		ranks, err := j.ranks(sp, len(batch), forward)
+		if err != nil {
+			return err
+		}
+		updates := make([]Item, len(batch))
+		old := make([]Posn, len(batch))
+		for i, it := range batch {
+			updates[i] = Item{ID: it.ID, Rank: ranks[i]}
+			old[i] = it.Rank
+		}
+		if err := j.Store.Update(ctx, j.List, updates); err != nil {
+			return err
+		}
+		j.Generator.audit(ctx, j.List, AuditMigrate, old, updates)
+		touched += len(batch)
+		cp.Done += len(batch)
+		if forward {
+			cp.Last, cp.Edge = &batch[len(batch)-1].Rank, &ranks[len(ranks)-1]
+		} else {
+			cp.Last, cp.Edge = &batch[0].Rank, &ranks[0]
+		}
+		if j.OnBatch != nil {
+			if err := j.OnBatch(*cp); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// sync works out how far the job has got from the store, by counting
+// the items already in the new bucket, rather than trusting the
+// checkpoint, which is behind if the job stopped between writing a
+// batch and saving it.  The job carries on from the edge, which sorts
+// next to the last item moved, wherever that was.
+func (j *RebalanceJob) sync(ctx context.Context, forward bool) error {
+	cp := &j.Checkpoint
+	cp.Done, cp.Last, cp.Edge = 0, nil, nil
+	for {
+		var items []Item
+		var err error
+		if forward {
+			items, err = j.Store.Items(ctx, j.List, cp.Edge, j.batchSize())
+		} else {
+			items, err = j.Store.ItemsBefore(ctx, j.List, cp.Edge, j.batchSize())
+		}
+		if err != nil {
+			return err
+		}
+		if n := j.skipMoved(items, forward); n < len(items) || n == 0 {
+			return nil
+		}
+	}
+}
+
+// skipMoved counts the items at the front of batch (or the back, if
+// not forward) that are already in the new bucket, and moves the edge
+// (and where the job carries on from) past them
+func (j *RebalanceJob) skipMoved(batch []Item, forward bool) int {
+	cp := &j.Checkpoint
+	n := 0
+	for n < len(batch) {
+		it := &batch[n]
+		if !forward {
+			it = &batch[len(batch)-1-n]
+		}
+		if it.Rank.Bucket != cp.To {
+			break
+		}
+		cp.Last, cp.Edge = &it.Rank, &it.Rank
+		n++
+	}
+	cp.Done += n
+	return n
+}
+
+// start counts the list and works out which buckets it's moving
+// between
+func (j *RebalanceJob) start(ctx context.Context) error {
+	cp := &j.Checkpoint
+	if j.Buckets != nil {
+		b, err := j.Buckets.ActiveBucket(ctx, j.List)
+		if err != nil {
+			return err
+		}
+		if b > MaxBucket {
+			return badBucket(b)
+		}
+		cp.From = b
+	}
+	var after *Posn
+	for {
+		items, err := j.Store.Items(ctx, j.List, after, j.batchSize())
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			break
+		}
+		if cp.Total == 0 && j.Buckets == nil {
+			cp.From = items[0].Rank.Bucket
+			if cp.From > MaxBucket {
+				return badBucket(cp.From)
+			}
+		}
+		for _, it := range items {
+			if it.Rank.Bucket != cp.From {
+				return ErrMigrating
+			}
+		}
+		cp.Total += len(items)
+		after = &items[len(items)-1].Rank
+	}
+	cp.To = NextBucket(cp.From)
+	if j.Buckets != nil {
+		err := j.Buckets.SetActiveBucket(ctx, j.List, cp.From, cp.To)
+		if errors.Is(err, ErrBucketChanged) {
+			return ErrMigrating
+		}
+		if err != nil {
+			return err
+		}
+	}
+	cp.Started = true
+	return nil
+}
+
+// ranks works out the new ranks for the next n items to move, in
+// order.  The list is spread out as Rebalance would, but items added
+// since the job started don't fit into that, so they are squeezed in
+// between the last of the others and the end of the bucket.
+func (j *RebalanceJob) ranks(sp rebalanceSpacing, n int, forward bool) ([]Posn, error) {
+	cp := &j.Checkpoint
+	if forward {
+		from := min(cp.Done, cp.Total)
+		to := min(cp.Done+n, cp.Total)
+		out := sp.appendRanks(make([]Posn, 0, n), from, to)
+		// anything inserted past the edge may have taken the place of
+		// some of them
+		for len(out) > 0 && cp.Edge != nil && out[0].Compare(*cp.Edge) <= 0 {
+			out = out[1:]
+		}
+		if extra := n - len(out); extra > 0 {
+			lo := ""
+			if len(out) > 0 {
+				lo = out[len(out)-1].digits()
+			} else if cp.Edge != nil {
+				lo = cp.Edge.digits()
+			}
+			keys, err := j.Generator.spreadFresh(lo, "", extra)
+			if err != nil {
+				return nil, err
+			}
+			for _, k := range keys {
+				out = append(out, Posn{Bucket: cp.To, Major: k})
+			}
+		}
+		return out, nil
+	}
+
+	to := max(cp.Total-cp.Done, 0)
+	from := max(cp.Total-cp.Done-n, 0)
+	in := sp.appendRanks(nil, from, to)
+	for len(in) > 0 && cp.Edge != nil && in[len(in)-1].Compare(*cp.Edge) >= 0 {
+		in = in[:len(in)-1]
+	}
+	extra := n - len(in)
+	if extra == 0 {
+		return in, nil
+	}
+	hi := ""
+	if len(in) > 0 {
+		hi = in[0].digits()
+	} else if cp.Edge != nil {
+		hi = cp.Edge.digits()
+	}
+	keys, err := j.Generator.spreadFresh("", hi, extra)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Posn, 0, n)
+	for _, k := range keys {
+		out = append(out, Posn{Bucket: cp.To, Major: k})
+	}
+	return append(out, in...), nil
+}
@@ -0,0 +1,181 @@
+package lexorank
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// jobStore makes a store holding a list of n items in bucket b
+func jobStore(t *testing.T, n int, b byte) (*MemStore, []string) {
+	ranks, err := Rebalance(n, b)
+	assert.NoError(t, err)
+	m := &MemStore{}
+	var ids []string
+	for i, p := range ranks {
+		// make the ranks uneven, as a list needing rebalancing would be
+		p.Major += strconv.Itoa(i % 3)
+		id := "item" + strconv.Itoa(i)
+		ids = append(ids, id)
+		m.Put("list", Item{id, p})
+	}
+	return m, ids
+}
+
+func jobIDs(m *MemStore) []string {
+	var ids []string
+	for _, it := range m.List("list") {
+		ids = append(ids, it.ID)
+	}
+	return ids
+}
+
+func TestRebalanceJob(t *testing.T) {
+	for _, from := range []byte{0, 1, 2} {
+		m, ids := jobStore(t, 25, from)
+		var percents []float64
+		j := &RebalanceJob{Store: m, List: "list", BatchSize: 10, OnBatch: func(cp Checkpoint) error {
+			percents = append(percents, cp.Percent())
+			assert.Equal(t, ids, jobIDs(m))
+			return nil
+		}}
+		assert.NoError(t, j.Run(context.Background()))
+		assert.Equal(t, []float64{40, 80, 100, 100}, percents)
+		assert.True(t, j.Checkpoint.Finished)
+
+		want, err := Rebalance(25, NextBucket(from))
+		assert.NoError(t, err)
+		for i, it := range m.List("list") {
+			assert.Equal(t, Item{ids[i], want[i]}, it)
+		}
+		assert.NoError(t, j.Run(context.Background()))
+	}
+}
+
+func TestRebalanceJobResume(t *testing.T) {
+	m, ids := jobStore(t, 25, 0)
+	stop := errors.New("deploying")
+	var saved []byte
+	j := &RebalanceJob{Store: m, List: "list", BatchSize: 10, OnBatch: func(cp Checkpoint) error {
+		var err error
+		saved, err = json.Marshal(cp)
+		assert.NoError(t, err)
+		return stop
+	}}
+	assert.Equal(t, stop, j.Run(context.Background()))
+
+	// something is inserted into the part of the list not yet moved
+	items := m.List("list")
+	p, ok := Ranks(1, &items[3].Rank, &items[4].Rank)
+	assert.True(t, ok)
+	m.Put("list", Item{"new", p[0]})
+	ids = append(ids[:4], append([]string{"new"}, ids[4:]...)...)
+
+	j = &RebalanceJob{Store: m, List: "list", BatchSize: 10}
+	assert.NoError(t, json.Unmarshal(saved, &j.Checkpoint))
+	assert.Equal(t, 40.0, j.Checkpoint.Percent())
+	assert.NoError(t, j.Run(context.Background()))
+	assert.Equal(t, ids, jobIDs(m))
+	assert.Equal(t, 26, j.Checkpoint.Done)
+	assert.Equal(t, 100.0, j.Checkpoint.Percent())
+	for _, it := range m.List("list") {
+		assert.Equal(t, byte(1), it.Rank.Bucket)
+	}
+}
+
+func TestRebalanceJobCrash(t *testing.T) {
+	for _, from := range []byte{0, 2} {
+		// the job dies after writing its second batch, before the
+		// checkpoint for it is saved
+		m, ids := jobStore(t, 25, from)
+		stop := errors.New("crashed")
+		var saved []byte
+		j := &RebalanceJob{Store: m, List: "list", BatchSize: 10, OnBatch: func(cp Checkpoint) error {
+			if cp.Done > 10 {
+				return stop
+			}
+			var err error
+			saved, err = json.Marshal(cp)
+			assert.NoError(t, err)
+			return nil
+		}}
+		assert.Equal(t, stop, j.Run(context.Background()))
+
+		// and meanwhile something goes in just past the edge
+		items := m.List("list")
+		i := 9
+		if from == 0 {
+			i = 15
+		}
+		p, ok := Ranks(1, &items[i].Rank, &items[i+1].Rank)
+		assert.True(t, ok)
+		m.Put("list", Item{"new", p[0]})
+		ids = append(ids[:i+1], append([]string{"new"}, ids[i+1:]...)...)
+
+		j = &RebalanceJob{Store: m, List: "list", BatchSize: 10}
+		assert.NoError(t, json.Unmarshal(saved, &j.Checkpoint))
+		assert.NoError(t, j.Run(context.Background()))
+		assert.True(t, j.Checkpoint.Finished)
+		assert.Equal(t, ids, jobIDs(m))
+		seen := map[Posn]bool{}
+		for _, it := range m.List("list") {
+			assert.Equal(t, NextBucket(from), it.Rank.Bucket, it.ID)
+			assert.False(t, seen[it.Rank], "%v is repeated", it.Rank)
+			seen[it.Rank] = true
+		}
+	}
+}
+
+func TestRebalanceJobMigrating(t *testing.T) {
+	m, _ := jobStore(t, 5, 0)
+	m.Put("list", Item{"other", Posn{Bucket: 1, Major: "a"}})
+	j := &RebalanceJob{Store: m, List: "list"}
+	assert.Equal(t, ErrMigrating, j.Run(context.Background()))
+
+	j = &RebalanceJob{Store: m, List: "empty"}
+	assert.NoError(t, j.Run(context.Background()))
+	assert.True(t, j.Checkpoint.Finished)
+}
+
+func TestRebalanceJobBuckets(t *testing.T) {
+	ctx := context.Background()
+	m, ids := jobStore(t, 25, 1)
+	var bs MemBuckets
+	assert.NoError(t, bs.SetActiveBucket(ctx, "list", 0, 1))
+
+	j := &RebalanceJob{Store: m, List: "list", BatchSize: 10, Buckets: &bs}
+	assert.NoError(t, j.Run(ctx))
+	assert.Equal(t, ids, jobIDs(m))
+	for _, it := range m.List("list") {
+		assert.Equal(t, byte(2), it.Rank.Bucket)
+	}
+	b, err := bs.ActiveBucket(ctx, "list")
+	assert.NoError(t, err)
+	assert.Equal(t, byte(2), b)
+
+	// only one instance gets to start a migration: this one read the
+	// active bucket before the other started
+	other := &RebalanceJob{Store: m, List: "list", Buckets: &bs}
+	assert.NoError(t, other.start(ctx))
+	j = &RebalanceJob{Store: m, List: "list", Buckets: staleBuckets{&bs, 2}}
+	assert.Equal(t, ErrMigrating, j.Run(ctx))
+
+	// and the list has to be in its active bucket
+	assert.NoError(t, bs.SetActiveBucket(ctx, "list", 0, 1))
+	j = &RebalanceJob{Store: m, List: "list", Buckets: &bs}
+	assert.Equal(t, ErrMigrating, j.Run(ctx))
+}
+
+// staleBuckets is a BucketStore that reads an out of date bucket
+type staleBuckets struct {
+	*MemBuckets
+	stale byte
+}
+
+func (s staleBuckets) ActiveBucket(ctx context.Context, list string) (byte, error) {
+	return s.stale, nil
+}
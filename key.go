@@ -0,0 +1,120 @@
+package lexorank
+
+import (
+	"bytes"
+	"errors"
+)
+
+// ErrBadKey is returned by ParseKey for keys that AppendKey can't
+// have made.
+var ErrBadKey = errors.New("lexorank: malformed key")
+
+// AppendKey appends a single byte key for the item at p in the list
+// with the given ID to dst, such that comparing keys byte-wise orders
+// them by list and then by position, as for Compare.  That is what is
+// needed of keys in Kafka compacted topics and other ordered change
+// streams, where there is only one key to go on.
+//
+// The list ID and major are each followed by 0x00 0x01, with any 0x00
+// in them written as 0x00 0xFF, so that a shorter one sorts before a
+// longer one it is a prefix of; the bucket is a byte of its own
+// between them, and the minor comes last, as it is.
+func AppendKey(dst []byte, list string, p Posn) []byte {
+	dst = appendEscaped(dst, list)
+	dst = append(dst, p.Bucket)
+	dst = appendEscaped(dst, p.Major)
+	return append(dst, p.MinorValue()...)
+}
+
+// ParseKey is the inverse of AppendKey.  The position it returns is
+// canonical.
+func ParseKey(key []byte) (list string, p Posn, err error) {
+	list, key, ok := readEscaped(key)
+	if !ok || len(key) == 0 {
+		return "", Posn{}, ErrBadKey
+	}
+	p.Bucket = key[0]
+	if p.Major, key, ok = readEscaped(key[1:]); !ok {
+		return "", Posn{}, ErrBadKey
+	}
+	p.Minor = string(key)
+	return list, p, nil
+}
+
+func appendEscaped(dst []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		if s[i] == 0 {
+			dst = append(dst, 0, 0xff)
+		} else {
+			dst = append(dst, s[i])
+		}
+	}
+	return append(dst, 0, 1)
+}
+
+// readEscaped reads what appendEscaped wrote, returning the rest of b
+func readEscaped(b []byte) (string, []byte, bool) {
+	var s []byte
+	for {
+		i := bytes.IndexByte(b, 0)
+		if i < 0 || i+1 == len(b) {
+			return "", nil, false
+		}
+		s = append(s, b[:i]...)
+		switch b[i+1] {
+		case 1:
+			return string(s), b[i+2:], true
+		case 0xff:
+			s = append(s, 0)
+			b = b[i+2:]
+		default:
+			return "", nil, false
+		}
+	}
+}
+
+// CompareText compares ranks written as String writes them (e.g.
+// "0|hzzzzz:U"), as Compare would, for use as the comparator of an
+// embedded key-value store (Pebble's Comparer.Compare, say) that keys
+// items by their ranks as they are.  Comparing such keys byte-wise
+// gets some wrong, since the ":" before a minor sorts after the
+// digits a longer major might go on with; keys made by AppendKey
+// don't need a comparator of their own.
+//
+// Anything without a "|" is taken to be a bare major.  Keys that
+// Compare would say are equal but that are written differently (with
+// and without a trailing ":", say) are ordered byte-wise, so that
+// distinct keys never compare equal.
+func CompareText(a, b []byte) int {
+	ab, amaj, amin := splitText(a)
+	bb, bmaj, bmin := splitText(b)
+	// buckets are decimal numbers, so a longer one is bigger
+	if c := len(ab) - len(bb); c != 0 {
+		if c < 0 {
+			return -1
+		}
+		return 1
+	}
+	if c := bytes.Compare(ab, bb); c != 0 {
+		return c
+	}
+	if c := bytes.Compare(amaj, bmaj); c != 0 {
+		return c
+	}
+	if c := bytes.Compare(amin, bmin); c != 0 {
+		return c
+	}
+	return bytes.Compare(a, b)
+}
+
+// splitText splits a rank in its text form into its bucket, major and
+// minor
+func splitText(s []byte) (bucket, major, minor []byte) {
+	if i := bytes.IndexByte(s, '|'); i >= 0 {
+		bucket, s = s[:i], s[i+1:]
+	}
+	if i := bytes.IndexByte(s, ':'); i >= 0 {
+		return bucket, s[:i], s[i+1:]
+	}
+	return bucket, s, nil
+}
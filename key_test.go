@@ -0,0 +1,100 @@
+package lexorank
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyOrder(t *testing.T) {
+	type item struct {
+		list string
+		p    Posn
+	}
+	// in order
+	items := []item{
+		{"", Posn{Major: "z"}},
+		{"a", Posn{Major: "a"}},
+		{"a", Posn{Major: "a", Minor: "0"}},
+		{"a", Posn{Major: "a0"}},
+		{"a", Posn{Major: "b"}},
+		{"a", Posn{Bucket: 1, Major: "0"}},
+		{"a\x00", Posn{Major: "0"}},
+		{"a\x01", Posn{Major: "0"}},
+		{"ab", Posn{Major: "0"}},
+	}
+	for i := 1; i < len(items); i++ {
+		a := AppendKey(nil, items[i-1].list, items[i-1].p)
+		b := AppendKey(nil, items[i].list, items[i].p)
+		assert.Equal(t, -1, bytes.Compare(a, b), "%v < %v", items[i-1], items[i])
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	key := AppendKey([]byte("x"), "board\x007", Posn{Bucket: 2, Major: "a\x00b", Minor: ":U"})
+	list, p, err := ParseKey(key[1:])
+	assert.NoError(t, err)
+	assert.Equal(t, "board\x007", list)
+	assert.Equal(t, Posn{Bucket: 2, Major: "a\x00b", Minor: "U"}, p)
+
+	for _, bad := range []string{"", "a", "a\x00", "a\x00\x01", "a\x00\x01\x00a", "a\x00\x02\x00"} {
+		_, _, err := ParseKey([]byte(bad))
+		assert.True(t, errors.Is(err, ErrBadKey), "%q", bad)
+	}
+}
+
+func FuzzKey(f *testing.F) {
+	f.Add("list", byte(0), "abc", "", "list", byte(0), "abd", "U")
+	f.Add("a\x00", byte(1), "0", "z", "a", byte(2), "\x00", "")
+	f.Fuzz(func(t *testing.T, l1 string, b1 byte, maj1, min1, l2 string, b2 byte, maj2, min2 string) {
+		p1 := Posn{Bucket: b1, Major: maj1, Minor: strings.TrimPrefix(min1, ":")}
+		p2 := Posn{Bucket: b2, Major: maj2, Minor: strings.TrimPrefix(min2, ":")}
+		k1, k2 := AppendKey(nil, l1, p1), AppendKey(nil, l2, p2)
+
+		list, p, err := ParseKey(k1)
+		if err != nil || list != l1 || p != p1 {
+			t.Fatalf("%q decoded to %q %v %v", k1, list, p, err)
+		}
+
+		want := strings.Compare(l1, l2)
+		if want == 0 {
+			want = p1.Compare(p2)
+		}
+		if got := bytes.Compare(k1, k2); got != want {
+			t.Fatalf("keys compare %d, want %d", got, want)
+		}
+	})
+}
+
+func TestCompareText(t *testing.T) {
+	// in order, though not byte-wise
+	ranks := []string{"0|a:", "0|a:U", "0|a0:", "0|b", "1|0:", "10|0"}
+	for i := 1; i < len(ranks); i++ {
+		assert.Equal(t, -1, CompareText([]byte(ranks[i-1]), []byte(ranks[i])), "%s < %s", ranks[i-1], ranks[i])
+		assert.Equal(t, 1, CompareText([]byte(ranks[i]), []byte(ranks[i-1])))
+	}
+	assert.Equal(t, 1, bytes.Compare([]byte("0|a:U"), []byte("0|a0:")))
+
+	// the same rank written two ways is still two keys
+	assert.Equal(t, -1, CompareText([]byte("0|a"), []byte("0|a:")))
+	assert.Equal(t, 0, CompareText([]byte("0|a:"), []byte("0|a:")))
+	assert.Equal(t, -1, CompareText([]byte("a"), []byte("b")))
+}
+
+func FuzzCompareText(f *testing.F) {
+	f.Add(byte(0), "abc", "", byte(0), "abc0", "U")
+	f.Fuzz(func(t *testing.T, b1 byte, maj1, min1 string, b2 byte, maj2, min2 string) {
+		if strings.ContainsAny(maj1+maj2, "|:") || strings.Contains(min1+min2, "|") {
+			t.Skip()
+		}
+		p1 := Posn{Bucket: b1, Major: maj1, Minor: min1}
+		p2 := Posn{Bucket: b2, Major: maj2, Minor: min2}
+		got := CompareText([]byte(p1.String()), []byte(p2.String()))
+		if want := p1.Compare(p2); got != want {
+			t.Fatalf("%s and %s compare %d, want %d", p1, p2, got, want)
+		}
+	})
+}
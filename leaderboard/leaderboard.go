@@ -0,0 +1,177 @@
+// Package leaderboard builds keys for leaderboard-style lists: items
+// are ordered by score, highest first, with a lexorank tie-breaker
+// that lets items with the same score be reordered by hand.  Items
+// can also be pinned, which puts them above all the scored items in
+// an order of their own that score changes can't disturb.
+//
+// A key is a single string, so a plain string comparison (or an
+// index on a text column with a byte-wise collation) orders a board:
+//
+//	0<tie>             pinned
+//	1<score><tie>      scored, where <score> is 16 hex digits
+package leaderboard
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/dkolbly/lexorank"
+)
+
+const (
+	pinned = '0'
+	scored = '1'
+
+	scoreLen = 16
+)
+
+// ErrDifferentScores is returned by Reorder when asked to place an
+// item between two items with different scores, which no score can
+// do without changing the item's score.  Pin it instead.
+var ErrDifferentScores = errors.New("leaderboard: neighbours have different scores")
+
+// Key returns the key for an item with the given score and
+// tie-breaking rank (any lexorank digits; "" is fine if there are no
+// ties to break).
+func Key(score float64, tie string) (string, error) {
+	if math.IsNaN(score) {
+		return "", fmt.Errorf("leaderboard: score is NaN")
+	}
+	if err := checkTie(tie); err != nil {
+		return "", err
+	}
+	b := make([]byte, 0, 1+scoreLen+len(tie))
+	b = append(b, scored)
+	b = appendScore(b, score)
+	return string(append(b, tie...)), nil
+}
+
+// PinnedKey returns the key for a pinned item with the given rank
+// among the pinned items.
+func PinnedKey(tie string) (string, error) {
+	if tie == "" {
+		return "", fmt.Errorf("leaderboard: a pinned item needs a rank")
+	}
+	if err := checkTie(tie); err != nil {
+		return "", err
+	}
+	return string(pinned) + tie, nil
+}
+
+// Split takes a key apart.  For a pinned key, score is zero.
+func Split(key string) (score float64, tie string, isPinned bool, err error) {
+	if key == "" {
+		return 0, "", false, fmt.Errorf("leaderboard: empty key")
+	}
+	switch key[0] {
+	case pinned:
+		return 0, key[1:], true, checkTie(key[1:])
+	case scored:
+		if len(key) < 1+scoreLen {
+			return 0, "", false, fmt.Errorf("leaderboard: key %q too short", key)
+		}
+		bits, err := strconv.ParseUint(key[1:1+scoreLen], 16, 64)
+		if err != nil {
+			return 0, "", false, fmt.Errorf("leaderboard: bad score in key %q", key)
+		}
+		return decodeScore(bits), key[1+scoreLen:], false, checkTie(key[1+scoreLen:])
+	}
+	return 0, "", false, fmt.Errorf("leaderboard: key %q has unknown class", key)
+}
+
+// Compare compares two keys, which is just comparing them as strings;
+// it's here to make that explicit.
+func Compare(a, b string) int {
+	return strings.Compare(a, b)
+}
+
+// Reorder returns a new key for an item being moved by hand to
+// between the items with keys prev and next (either of which may be
+// empty, for the top or bottom of the board).  Between pinned items
+// the result is pinned; between items with the same score, it has
+// that score and a tie-breaker that puts it in between.  Otherwise,
+// the error is ErrDifferentScores.
+func Reorder(prev, next string) (string, error) {
+	var ps, ns float64
+	var pt, nt string
+	var pp, np bool
+	var err error
+	if prev != "" {
+		if ps, pt, pp, err = Split(prev); err != nil {
+			return "", err
+		}
+	}
+	if next != "" {
+		if ns, nt, np, err = Split(next); err != nil {
+			return "", err
+		}
+	}
+
+	switch {
+	case prev == "" || pp:
+		// at the top, or just after a pinned item
+		if next != "" && !np {
+			nt = ""
+		}
+		tie, ok := lexorank.Rank(pt, nt)
+		if !ok {
+			return "", fmt.Errorf("leaderboard: no room between %q and %q", prev, next)
+		}
+		return PinnedKey(tie)
+	case np:
+		return "", fmt.Errorf("leaderboard: %q is not before %q", prev, next)
+	case next == "" || ps == ns:
+		if next == "" {
+			nt = ""
+		}
+		tie, ok := lexorank.Rank(pt, nt)
+		if !ok {
+			return "", fmt.Errorf("leaderboard: no room between %q and %q", prev, next)
+		}
+		return Key(ps, tie)
+	}
+	return "", ErrDifferentScores
+}
+
+func checkTie(tie string) error {
+	for i := 0; i < len(tie); i++ {
+		if _, err := lexorank.OrderOf(tie[i]); err != nil {
+			return fmt.Errorf("leaderboard: tie-breaker %q: %w", tie, err)
+		}
+	}
+	return nil
+}
+
+// appendScore writes the score so that higher scores sort first: the
+// bits of a float are flipped around so that they sort in numeric
+// order as an unsigned integer, then complemented to reverse that
+func appendScore(b []byte, f float64) []byte {
+	if f == 0 {
+		f = 0 // no negative zero
+	}
+	bits := math.Float64bits(f)
+	if bits&(1<<63) != 0 {
+		bits = ^bits
+	} else {
+		bits |= 1 << 63
+	}
+	bits = ^bits
+	s := strconv.FormatUint(bits, 16)
+	for i := len(s); i < scoreLen; i++ {
+		b = append(b, '0')
+	}
+	return append(b, s...)
+}
+
+func decodeScore(bits uint64) float64 {
+	bits = ^bits
+	if bits&(1<<63) != 0 {
+		bits &^= 1 << 63
+	} else {
+		bits = ^bits
+	}
+	return math.Float64frombits(bits)
+}
@@ -0,0 +1,88 @@
+package leaderboard
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyOrder(t *testing.T) {
+	scores := []float64{math.Inf(1), 1e9, 100.5, 100, 1, 0, -0.5, -100, math.Inf(-1)}
+	var keys []string
+	for _, s := range scores {
+		k, err := Key(s, "U")
+		assert.NoError(t, err)
+		keys = append(keys, k)
+	}
+	assert.True(t, sort.StringsAreSorted(keys), keys)
+
+	pin, err := PinnedKey("z")
+	assert.NoError(t, err)
+	assert.True(t, pin < keys[0])
+
+	_, err = Key(math.NaN(), "")
+	assert.Error(t, err)
+	_, err = Key(1, "a-b")
+	assert.Error(t, err)
+	_, err = PinnedKey("")
+	assert.Error(t, err)
+}
+
+func TestSplit(t *testing.T) {
+	for _, s := range []float64{0, math.Copysign(0, -1), 1.5, -1.5, math.MaxFloat64, math.Inf(-1)} {
+		k, err := Key(s, "ab")
+		assert.NoError(t, err)
+		score, tie, pinned, err := Split(k)
+		assert.NoError(t, err)
+		assert.Equal(t, s, score)
+		assert.Equal(t, "ab", tie)
+		assert.Equal(t, false, pinned)
+	}
+
+	_, tie, pinned, err := Split("0xy")
+	assert.NoError(t, err)
+	assert.Equal(t, "xy", tie)
+	assert.Equal(t, true, pinned)
+
+	for _, k := range []string{"", "2abc", "1abc", "1zzzzzzzzzzzzzzzz"} {
+		_, _, _, err := Split(k)
+		assert.Error(t, err, k)
+	}
+}
+
+func TestReorder(t *testing.T) {
+	a, _ := Key(10, "a")
+	b, _ := Key(10, "b")
+	c, _ := Key(5, "")
+
+	k, err := Reorder(a, b)
+	assert.NoError(t, err)
+	assert.True(t, a < k && k < b)
+	score, _, _, _ := Split(k)
+	assert.Equal(t, 10.0, score)
+
+	k, err = Reorder(b, "")
+	assert.NoError(t, err)
+	assert.True(t, b < k)
+
+	_, err = Reorder(b, c)
+	assert.True(t, errors.Is(err, ErrDifferentScores))
+
+	// dropping at the top pins
+	k, err = Reorder("", a)
+	assert.NoError(t, err)
+	assert.True(t, k < a)
+	_, _, pinned, _ := Split(k)
+	assert.Equal(t, true, pinned)
+
+	k2, err := Reorder(k, a)
+	assert.NoError(t, err)
+	assert.True(t, k < k2 && k2 < a)
+
+	_, err = Reorder(a, k)
+	assert.Error(t, err)
+	assert.Equal(t, 0, Compare(a, a))
+}
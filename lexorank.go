@@ -5,110 +5,373 @@
 package lexorank
 
 import (
-	"fmt"
-	"regexp"
+	"encoding/json"
+	"strconv"
+	"strings"
 )
 
 const orderToByte = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
 
-func byteToOrder(b byte) byte {
-	n := _byteToOrder(b)
-	if n < 0 || n > 62 {
-		panic("bad value")
-	}
-	return n
-}
-
-func _byteToOrder(b byte) byte {
-	if b >= '0' && b <= '9' {
-		return b - '0'
-	} else if b >= 'A' && b <= 'Z' {
-		return b - 'A' + 10
-	} else if b >= 'a' && b <= 'z' {
-		return b - 'a' + 10 + 26
-	} else if b < 'A' {
-		return 0
-	} else {
-		return 10 + 26 + 26 - 1
-	}
-}
-
+// minChar and maxChar are the digits of Base62, which is what
+// fractional-indexing keys are always made of; everything else gets
+// them from its alphabet
 const (
 	minChar = byte('0')
 	maxChar = byte('z')
 )
 
-// Parses a Jira (Cloud?) lexorank field, which seems to look like:
-//    <bucket>|<base36>[:<base36>]
-
+// A Posn is a position in a list, the parts of a Jira (Cloud?)
+// lexorank field, which seems to look like:
+//
+//	<bucket>|<base36>[:<base36>]
+//
+// The major and minor are deliberately strings rather than byte
+// slices: that keeps Posn comparable (usable with == and as a map
+// key), lets ParseJira hand out substrings of its input without
+// copying, and means a Posn can be shared freely without worrying
+// about aliasing.  Code that wants to avoid conversions on output can
+// use AppendText.
 type Posn struct {
 	Bucket byte
 	Major  string
-	Minor  string // note this includes the ":" prefix
+
+	// Minor is the value of the minor part, without the ":" that
+	// separates it from the major (String puts that back).  For
+	// compatibility with code written when the separator was stored
+	// here too, a leading ":" is ignored; use MinorValue rather than
+	// reading the field directly to get the value either way.
+	Minor string
+}
+
+// MinorValue returns the value of the minor, without any separator.
+func (p Posn) MinorValue() string {
+	return strings.TrimPrefix(p.Minor, ":")
 }
 
-var jiraRank = regexp.MustCompile(`^([012])\|([0-9a-z]+)(:[0-9a-z]*)?$`)
+// HasMinor reports whether the minor part is non-empty.
+func (p Posn) HasMinor() bool {
+	return p.MinorValue() != ""
+}
+
+// PrefixedMinor returns the minor with the ":" separator in front, as
+// the Minor field used to hold it.
+//
+// Deprecated: use MinorValue; this is only here to ease the
+// transition for code that expects the old representation.
+func (p Posn) PrefixedMinor() string {
+	return ":" + p.MinorValue()
+}
 
 func (p Posn) String() string {
-	return fmt.Sprintf("%d|%s%s", p.Bucket, p.Major, p.Minor)
+	b, _ := p.AppendText(make([]byte, 0, 5+len(p.Major)+len(p.Minor)))
+	return string(b)
 }
 
-func ParseJira(rank string) (Posn, bool) {
-	m := jiraRank.FindStringSubmatch(rank)
-	if m == nil {
-		return Posn{}, false
-	}
-	return Posn{
-		Bucket: m[1][0] - '0',
-		Major:  m[2],
-		Minor:  m[3],
-	}, true
+// AppendText appends the Jira form of p (what String returns) to b,
+// which saves an allocation and a copy when writing ranks out in bulk.
+func (p Posn) AppendText(b []byte) ([]byte, error) {
+	b = strconv.AppendUint(b, uint64(p.Bucket), 10)
+	b = append(b, '|')
+	b = append(b, p.Major...)
+	b = append(b, ':')
+	return append(b, p.MinorValue()...), nil
 }
 
-const MaxMultiRank = 10 + 26 + 26 - 1
+// MarshalText implements encoding.TextMarshaler, which is what YAML
+// and TOML encoders (and encoding/json) use, so positions in config
+// and fixture files are written the way String writes them.
+func (p Posn) MarshalText() ([]byte, error) {
+	return p.AppendText(nil)
+}
 
-// Ranks arranges for there to be N ranks between `prev` and `next`
-// and returns them.  This is useful when re-ranking a group of
-// objects together at onces.
-func Ranks(n int, prev, next *Posn) ([]Posn, bool) {
-	if n > MaxMultiRank {
-		// can't accommodate that many all at once
-		return nil, false
+// UnmarshalText implements encoding.TextUnmarshaler, reading back
+// what MarshalText writes, with the same checks as Parse.
+func (p *Posn) UnmarshalText(b []byte) error {
+	q, err := Parse(string(b))
+	if err != nil {
+		return err
 	}
+	*p = q
+	return nil
+}
 
-	if prev == nil {
-		prev = &Posn{
-			Major: "000000",
-			Minor: ":",
+// MarshalJSON writes p as a JSON string, in the form MarshalText
+// gives.
+func (p Posn) MarshalJSON() ([]byte, error) {
+	b, err := p.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(b))
+}
+
+// UnmarshalJSON reads a position written by MarshalJSON, or the
+// object ({"Bucket":0,"Major":"abc","Minor":""}) that encoding/json
+// wrote before Posn was a TextMarshaler, so that JSON stored back then
+// still reads.
+func (p *Posn) UnmarshalJSON(b []byte) error {
+	switch {
+	case string(b) == "null":
+		return nil
+	case len(b) > 0 && b[0] == '{':
+		var old struct {
+			Bucket byte
+			Major  string
+			Minor  string
 		}
-		// if there *is* a next, adopt its bucket
-		if next != nil {
-			prev.Bucket = next.Bucket
+		if err := json.Unmarshal(b, &old); err != nil {
+			return err
 		}
+		*p = Posn(old)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	return p.UnmarshalText([]byte(s))
+}
+
+// Compare returns -1, 0 or +1 depending on whether p sorts before, at
+// the same place as, or after q.  Positions are ordered by bucket,
+// then major, then minor.
+func (p Posn) Compare(q Posn) int {
+	switch {
+	case p.Bucket < q.Bucket:
+		return -1
+	case p.Bucket > q.Bucket:
+		return 1
+	}
+	if c := strings.Compare(p.Major, q.Major); c != 0 {
+		return c
 	}
+	return strings.Compare(p.MinorValue(), q.MinorValue())
+}
+
+// ComparePadded is like Compare, but compares majors as if the
+// shorter one were padded out with '0's to the length of the longer,
+// the way Jira's fixed length majors are.  Comparing raw rank strings
+// (which is what Jira and most databases do) only agrees with Compare
+// when the majors are the same length; ComparePadded is the
+// comparison that raw strings agree with when a set of ranks has
+// been padded, so it's the one to use when checking ranks against
+// Jira's order.  (The padding is the smallest digit of Jira's and the
+// default alphabet; use RawOrder to check ranks in others.)
+func (p Posn) ComparePadded(q Posn) int {
+	switch {
+	case p.Bucket < q.Bucket:
+		return -1
+	case p.Bucket > q.Bucket:
+		return 1
+	}
+	// only the part of the longer major past the end of the shorter
+	// one is compared with padding; the rest is a plain comparison
+	n := min(len(p.Major), len(q.Major))
+	if c := strings.Compare(p.Major[:n], q.Major[:n]); c != 0 {
+		return c
+	}
+	if c := comparePadding(p.Major[n:]) - comparePadding(q.Major[n:]); c != 0 {
+		return c
+	}
+	return strings.Compare(p.MinorValue(), q.MinorValue())
+}
+
+// comparePadding compares s with padding of the same length
+func comparePadding(s string) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] != minChar {
+			if s[i] < minChar {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// Equal reports whether p and q are the same position, even if they
+// are written differently: a missing minor (as in "0|abc") is the
+// same as an empty one ("0|abc:"), and a minor stored the old way
+// with its ":" is the same as one without.  It agrees with Compare.
+func (p Posn) Equal(q Posn) bool {
+	return p.Compare(q) == 0
+}
+
+// Canonical returns p in canonical form, in which the Minor field
+// holds just the minor's value.  (Its String form always includes the
+// ":", even if the minor is empty, which is how Jira itself writes
+// ranks.)  Equal positions have identical canonical forms, so it
+// makes a good key for deduplication and caching.
+func (p Posn) Canonical() Posn {
+	p.Minor = p.MinorValue()
+	return p
+}
+
+// digits is the major and minor run together (without the ":"),
+// which is how a position is read when doing arithmetic on it
+func (p Posn) digits() string {
+	return p.Major + p.MinorValue()
+}
+
+// MaxBucket is the largest bucket number; Jira rotates between
+// buckets 0, 1 and 2 when rebalancing.
+const MaxBucket = 2
 
-	if next == nil {
-		next = &Posn{
-			Major: "zzzzzz",
-			Minor: ":",
+// NewPosn constructs a position, checking that it is well formed:
+// the bucket must be in range and the major (which must not be empty)
+// and minor must be made of Jira's base36 digits, so that the String
+// form is guaranteed to parse with ParseJira.  For compatibility, a
+// minor given with its leading ":" is accepted.
+func NewPosn(bucket byte, major, minor string) (Posn, error) {
+	if bucket > MaxBucket {
+		return Posn{}, badBucket(bucket)
+	}
+	if major == "" {
+		return Posn{}, newError("empty major")
+	}
+	minor = strings.TrimPrefix(minor, ":")
+	for _, part := range []string{major, minor} {
+		for i := 0; i < len(part); i++ {
+			if !isJiraDigit(part[i]) {
+				return Posn{}, invalidDigit(part[i], part)
+			}
 		}
-		// if there *is* a prev, adopt its bucket
-		if prev != nil {
-			next.Bucket = prev.Bucket
+	}
+	return Posn{Bucket: bucket, Major: major, Minor: minor}, nil
+}
+
+// ParseJira parses a rank in Jira's format.  It is hand-rolled
+// rather than using a regexp, and doesn't allocate (the major and
+// minor share storage with rank), since it gets used for validating
+// millions of rows at a time.
+func ParseJira(rank string) (Posn, bool) {
+	// <bucket>|
+	if len(rank) < 3 || rank[0] < '0' || rank[0] > '0'+MaxBucket || rank[1] != '|' {
+		return Posn{}, false
+	}
+	// <base36>
+	i := 2
+	for i < len(rank) && isJiraDigit(rank[i]) {
+		i++
+	}
+	if i == 2 {
+		return Posn{}, false
+	}
+	p := Posn{
+		Bucket: rank[0] - '0',
+		Major:  rank[2:i],
+	}
+	if i == len(rank) {
+		return p, true
+	}
+	// [:<base36>]
+	if rank[i] != ':' {
+		return Posn{}, false
+	}
+	for j := i + 1; j < len(rank); j++ {
+		if !isJiraDigit(rank[j]) {
+			return Posn{}, false
 		}
 	}
+	p.Minor = rank[i+1:]
+	return p, true
+}
 
-	if prev.Major != next.Major {
-		p, ok := majorRanks(n, *prev, *next)
-		if ok {
-			return p, true
+// Parse parses a position in the form String writes it, like
+// ParseJira, but with digits from the default alphabet rather than
+// Jira's, so that positions generated by this package (which may have
+// upper case letters in them) read back.
+func Parse(s string) (Posn, error) {
+	return Generator{}.Parse(s)
+}
+
+// Parse is like the package-level Parse, but with digits from the
+// generator's alphabet, in the form its Profile gives.
+func (g Generator) Parse(s string) (Posn, error) {
+	// the default alphabet and profile are known to go together, so
+	// only others need checking
+	if g.Alphabet.values != nil || g.Profile != (Profile{}) {
+		if err := g.Profile.Check(g.Alphabet); err != nil {
+			return Posn{}, err
 		}
 	}
-	return minorRanks(n, *prev, *next)
+	if len(s) < 3 || s[0] < '0' || s[0] > '0'+MaxBucket || s[1] != '|' {
+		return Posn{}, newError("invalid position " + strconv.Quote(s))
+	}
+	// the major's digits run up to the separator, which can't be one
+	// of them, so finding it and checking them is one pass
+	a := g.alphabet()
+	i := 2
+	for i < len(s) && a.values[s[i]] >= 0 {
+		i++
+	}
+	p := Posn{Bucket: s[0] - '0', Major: s[2:i]}
+	if i < len(s) {
+		if s[i] != g.Profile.separator() {
+			return Posn{}, newError("invalid position " + strconv.Quote(s))
+		}
+		p.Minor = s[i+1:]
+	}
+	// the minor is checked as it is, not as MinorValue reads it, so
+	// that a second separator ("0|abc::x") is an error
+	if p.Major == "" || !g.Profile.minorAlphabet(a).valid(p.Minor) {
+		return Posn{}, newError("invalid position " + strconv.Quote(s))
+	}
+	return p, nil
 }
 
-func minorRanks(n int, prev, next Posn) ([]Posn, bool) {
-	panic("TODO")
+func isJiraDigit(b byte) bool {
+	return Base36.values[b] >= 0
+}
+
+// MaxMultiRank is the most ranks that Ranks can make at once with the
+// default alphabet.  Other alphabets allow fewer (or more); see
+// Alphabet.MaxMultiRank.  (It used to be one more, but asking for
+// that many never worked.)
+const MaxMultiRank = 10 + 26 + 26 - 2
+
+// Ranks arranges for there to be N ranks between `prev` and `next`
+// and returns them.  This is useful when re-ranking a group of
+// objects together at onces.  If prev and next are in different
+// buckets (in the middle of a rebalance), the new ranks go in the
+// bucket being rebalanced into.
+func Ranks(n int, prev, next *Posn) ([]Posn, bool) {
+	return Generator{}.Ranks(n, prev, next)
+}
+
+// minorRanks is the fallback for when there's no room between the
+// majors: rather than failing, the keys grow, with the new ranks
+// sharing prev's major and being told apart by their minors.  If the
+// majors differ then any minor will do (the new ranks all come before
+// next regardless), otherwise they have to go between the minors.
+// The minors are spread through the gap as for MoveBlock, so they
+// are only as long as they need to be.
+func (g Generator) minorRanks(dst []Posn, n int, prev, next Posn) ([]Posn, bool) {
+	var hi string
+	switch c := strings.Compare(prev.Major, next.Major); {
+	case c > 0:
+		return dst, false
+	case c == 0:
+		hi = next.MinorValue()
+		if hi == "" {
+			// nothing comes before an empty minor
+			return dst, false
+		}
+	}
+	mg := g.quiet()
+	mg.Alphabet = g.Profile.minorAlphabet(g.Alphabet)
+	minors, ok := mg.spread(make([]string, 0, n), prev.MinorValue(), hi, n)
+	if !ok {
+		return dst, false
+	}
+	for _, m := range minors {
+		dst = append(dst, Posn{
+			Bucket: prev.Bucket,
+			Major:  prev.Major,
+			Minor:  m,
+		})
+	}
+	return dst, true
 }
 
 func max(a, b int) int {
@@ -119,25 +382,45 @@ func max(a, b int) int {
 	}
 }
 
-func majorRanks(n int, prev, next Posn) ([]Posn, bool) {
-	rank := ""
-	i := 0
-
+func (g Generator) majorRanks(dst []Posn, n int, prev, next Posn) ([]Posn, bool) {
+	a := g.alphabet()
 	majorLen := max(len(prev.Major), len(next.Major))
 
+	// the prefix shared by all the new ranks is built up here; once
+	// we go forward with one of the bounds at a fork in the road, the
+	// other is treated as ending there (i.e., extended with its
+	// default character), which is tracked by prevEnd and nextEnd
+	buf := g.buffer(majorLen)
+	defer g.release(buf)
+	rank := *buf
+	prevEnd, nextEnd := len(prev.Major), len(next.Major)
+	prevAt := func(i int) byte {
+		if i >= prevEnd {
+			return a.min()
+		}
+		return getChar(prev.Major, i, a.min())
+	}
+	nextAt := func(i int) byte {
+		if i >= nextEnd {
+			return a.max()
+		}
+		return getChar(next.Major, i, a.max())
+	}
+	i := 0
+
 	for {
-		prevChar := getChar(prev.Major, i, minChar)
-		nextChar := getChar(next.Major, i, maxChar)
+		prevChar := prevAt(i)
+		nextChar := nextAt(i)
 
 		if prevChar == nextChar {
 			// common prefix
-			fmt.Printf("common prefix at [%c]\n", prevChar)
-			rank += string(prevChar)
+			g.explain(Step{Kind: StepCommonPrefix, Pos: i, Prev: prevChar, Next: nextChar})
+			rank = append(rank, prevChar)
 			i++
 			continue
 		}
 
-		midChars, ok := mids(n, prevChar, nextChar)
+		midChars, ok := g.mids(n, prevChar, nextChar)
 		if !ok {
 			// we need to adjust the bounds in which we're searching for ranks
 			// at this point we have an uncommon prefix, e.g.,
@@ -148,66 +431,61 @@ func majorRanks(n int, prev, next Posn) ([]Posn, bool) {
 			// avaialble, which means going forward with
 			//   0060
 			//   006b
-			fmt.Printf("fork in the road at [%c <> %c]\n", prevChar, nextChar)
-			prevAfter := byteToOrder(getChar(prev.Major, i+1, minChar))
-			nextAfter := byteToOrder(getChar(next.Major, i+1, maxChar))
-			spaceAfterPrev := MaxMultiRank - prevAfter
+			//
+			// Going forward with next is only possible if there's
+			// something below the rest of it (or it's already
+			// behind us), since a rank that starts with the whole
+			// of next's major, followed by nothing but the smallest
+			// digit or not, comes after it.
+			prevAfter := a.order(prevAt(i + 1))
+			nextAfter := a.order(nextAt(i + 1))
+			spaceAfterPrev := a.base() - 1 - prevAfter
 			spaceBeforeNext := nextAfter
-			fmt.Printf("   after this, PREV has order %d (space %d)\n", prevAfter, spaceAfterPrev)
-			fmt.Printf("               NEXT has order %d (space %d)\n", nextAfter, spaceBeforeNext)
+			nextGoesOn := nextEnd <= i
+			for k := i + 1; k < nextEnd && !nextGoesOn; k++ {
+				nextGoesOn = a.order(next.Major[k]) > 0
+			}
 
-			if spaceAfterPrev > spaceBeforeNext {
-				next.Major = next.Major[:i] + string(prevChar)
-				fmt.Printf("  go forward with NEXT [%s]\n", next.Major)
-				rank += string(prevChar)
+			if spaceAfterPrev > spaceBeforeNext || !nextGoesOn {
+				rank = append(rank, prevChar)
+				nextEnd = i + 1
 			} else {
-				prev.Major = prev.Major[:i] + string(nextChar)
-				fmt.Printf("  go forward with PREV [%s]\n", prev.Major)
-				rank += string(nextChar)
+				rank = append(rank, nextChar)
+				prevEnd = i + 1
 			}
+			g.explain(Step{Kind: StepFork, Pos: i, Prev: prevChar, Next: nextChar, Chose: rank[i]})
 			i++
 			continue
 		}
 
 		if len(rank) == majorLen {
-			return nil, false
+			return dst, false
 		}
+		g.explain(Step{Kind: StepMidpoints, Pos: i, Prev: prevChar, Next: nextChar, Mids: midChars})
 
-		out := make([]Posn, n)
 		// arrange for the major parts to all be the same size
 		// by attaching a trailer to newly generated major ranks
-		trailer := trailer[:majorLen-1-len(rank)]
+		trailer := majorLen - 1 - len(rank)
 
-		for j, mid := range midChars {
-			out[j] = Posn{
-				Bucket: prev.Bucket,
-				Major:  rank + string(mid) + trailer,
-				Minor:  ":",
+		for _, mid := range midChars {
+			var major strings.Builder
+			major.Grow(majorLen)
+			major.Write(rank)
+			major.WriteByte(mid)
+			for k := 0; k < trailer; k++ {
+				major.WriteByte(a.mid())
 			}
+			dst = append(dst, Posn{
+				Bucket: prev.Bucket,
+				Major:  major.String(),
+			})
 		}
-		return out, true
+		return dst, true
 	}
 }
 
-const trailer = "UUUUUUUU"
-
-func mids(n int, prev, next byte) ([]byte, bool) {
-	prevo := byteToOrder(prev)
-	nexto := byteToOrder(next)
-	per := int(nexto-prevo) / (n + 1)
-	if per < 1 {
-		return nil, false
-	}
-	fmt.Printf("(%c ... %c)  is (%d ... %d)  per is %d\n",
-		prev, next,
-		prevo, nexto,
-		per)
-
-	ch := make([]byte, n)
-	for i := 0; i < n; i++ {
-		ch[i] = orderToByte[int(prevo)+per*(i+1)]
-	}
-	return ch, true
+func (g Generator) mids(n int, prev, next byte) ([]byte, bool) {
+	return g.alphabet().mids(g.Spacing, n, prev, next)
 }
 
 func getChar(s string, i int, defaultChar byte) byte {
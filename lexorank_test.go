@@ -53,3 +53,195 @@ func TestFailAdjacent(t *testing.T) {
 	assert.Equal(t, "a", rank)
 	assert.Equal(t, false, ok)
 }
+
+func TestSuccessPrefixOfNext(t *testing.T) {
+	rank, ok := Rank("a", "b5")
+	assert.Equal(t, "b", rank)
+	assert.Equal(t, true, ok)
+}
+
+func TestSuccessSkipMaxDigits(t *testing.T) {
+	rank, ok := Rank("azzx", "b")
+	assert.Equal(t, "azzy", rank)
+	assert.Equal(t, true, ok)
+}
+
+func TestFailInverted(t *testing.T) {
+	rank, ok := Rank("b", "a")
+	assert.Equal(t, "b", rank)
+	assert.Equal(t, false, ok)
+}
+
+func TestFailBadChar(t *testing.T) {
+	rank, ok := Rank("a-", "b")
+	assert.Equal(t, "a-", rank)
+	assert.Equal(t, false, ok)
+}
+
+func TestAppendRanks(t *testing.T) {
+	buf := make([]Posn, 0, 8)
+	buf, ok := Generator{}.AppendRanks(buf, 2, nil, nil)
+	assert.Equal(t, true, ok)
+	buf, ok = Generator{}.AppendRanks(buf, 1, &buf[0], &buf[1])
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 3, len(buf))
+	assert.Equal(t, 8, cap(buf))
+	assert.Equal(t, -1, buf[0].Compare(buf[2]))
+	assert.Equal(t, -1, buf[2].Compare(buf[1]))
+}
+
+func TestAppendRank(t *testing.T) {
+	g := Generator{}
+	buf := make([]byte, 0, 64)
+	buf, ok := g.AppendRank(buf, []byte("aaaa"), []byte("aaab"))
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "aaaaU", string(buf))
+
+	buf, ok = g.AppendRank(buf, nil, []byte("2"))
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "aaaaU1", string(buf))
+
+	buf, ok = g.AppendRank(buf, []byte("a"), []byte("a0"))
+	assert.Equal(t, false, ok)
+	assert.Equal(t, "aaaaU1", string(buf))
+}
+
+func TestAppendRankCanonical(t *testing.T) {
+	buf, ok := Generator{Alphabet: Crockford32}.AppendRank(nil, []byte("ab"), []byte("ad"))
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "AC", string(buf))
+}
+
+func TestAppendRankSteps(t *testing.T) {
+	// stepping at the ends of the list, as Rank does
+	g := Generator{HeadStep: 3, AppendStep: 5}
+	for _, c := range [][2]string{{"", "b1"}, {"az", ""}, {"a", "b"}, {"", ""}} {
+		want, wantOK := g.Rank(c[0], c[1])
+		got, ok := g.AppendRank([]byte("x"), []byte(c[0]), []byte(c[1]))
+		assert.Equal(t, wantOK, ok, c)
+		assert.Equal(t, "x"+want, string(got), c)
+	}
+}
+
+func TestAppendRankAllocs(t *testing.T) {
+	g := Generator{}
+	buf := make([]byte, 0, 64)
+	prev, next := []byte("hzzzzz"), []byte("i00001")
+	allocs := testing.AllocsPerRun(100, func() {
+		buf, _ = g.AppendRank(buf[:0], prev, next)
+	})
+	assert.Equal(t, 0.0, allocs)
+}
+
+func BenchmarkRanks(b *testing.B) {
+	prev := Posn{Major: "a00000"}
+	next := Posn{Major: "a0000z"}
+	buf := make([]Posn, 0, 8)
+	for i := 0; i < b.N; i++ {
+		buf, _ = Generator{}.AppendRanks(buf[:0], 4, &prev, &next)
+	}
+}
+
+func TestRanksGrow(t *testing.T) {
+	// no room left in the majors, so the minor is used
+	prev := Posn{Major: "a00000"}
+	next := Posn{Major: "a00001"}
+	ranks, ok := Ranks(1, &prev, &next)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, []Posn{{Major: "a00000", Minor: "U"}}, ranks)
+
+	// same major, so between the minors
+	prev = ranks[0]
+	next = Posn{Major: "a00000", Minor: "V"}
+	ranks, ok = Ranks(3, &prev, &next)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 3, len(ranks))
+	last := prev
+	for _, p := range ranks {
+		assert.Equal(t, "a00000", p.Major)
+		assert.Equal(t, -1, last.Compare(p))
+		last = p
+	}
+	assert.Equal(t, -1, last.Compare(next))
+
+	_, ok = Ranks(1, &next, &prev)
+	assert.Equal(t, false, ok)
+	_, ok = Ranks(1, &Posn{Major: "a"}, &Posn{Major: "a"})
+	assert.Equal(t, false, ok)
+}
+
+func BenchmarkCompare(b *testing.B) {
+	p := Posn{Major: "hzzzzz", Minor: "i"}
+	q := Posn{Major: "hzzzzz", Minor: "j"}
+	for i := 0; i < b.N; i++ {
+		p.Compare(q)
+	}
+}
+
+func BenchmarkComparePadded(b *testing.B) {
+	p := Posn{Major: "hzzzzz"}
+	q := Posn{Major: "hzzzzy"}
+	for i := 0; i < b.N; i++ {
+		p.ComparePadded(q)
+	}
+}
+
+func TestComparePadded(t *testing.T) {
+	for _, c := range []struct {
+		p, q Posn
+		want int
+	}{
+		{Posn{Major: "a"}, Posn{Major: "a0"}, 0},
+		{Posn{Major: "a"}, Posn{Major: "a00", Minor: "1"}, -1},
+		{Posn{Major: "ab"}, Posn{Major: "a0"}, 1},
+		{Posn{Major: "b"}, Posn{Major: "a000"}, 1},
+		{Posn{Bucket: 1, Major: "0"}, Posn{Major: "z"}, 1},
+		{Posn{Major: "a"}, Posn{Major: "a0-"}, 1},
+		{Posn{Major: "a0"}, Posn{Major: "a"}, 0},
+		{Posn{Major: "ab"}, Posn{Major: "ac"}, -1},
+	} {
+		assert.Equal(t, c.want, c.p.ComparePadded(c.q), "%v %v", c.p, c.q)
+		assert.Equal(t, -c.want, c.q.ComparePadded(c.p), "%v %v", c.q, c.p)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	a := Posn{Bucket: 0, Major: "hzzzzz"}
+	b := Posn{Bucket: 0, Major: "hzzzzz", Minor: "i"}
+	c := Posn{Bucket: 0, Major: "i00000"}
+	d := Posn{Bucket: 1, Major: "000000"}
+	assert.Equal(t, -1, a.Compare(b))
+	assert.Equal(t, -1, b.Compare(c))
+	assert.Equal(t, -1, c.Compare(d))
+	assert.Equal(t, 1, d.Compare(a))
+	assert.Equal(t, 0, a.Compare(Posn{Major: "hzzzzz"}))
+}
+
+func TestEqual(t *testing.T) {
+	a := Posn{Major: "abc"}
+	b := Posn{Major: "abc", Minor: ":"} // the old representation
+	assert.True(t, a.Equal(b))
+	assert.False(t, a == b)
+	assert.Equal(t, a.Canonical(), b.Canonical())
+	assert.Equal(t, "0|abc:", b.String())
+
+	assert.False(t, a.Equal(Posn{Major: "abc", Minor: "0"}))
+	assert.False(t, a.Equal(Posn{Bucket: 1, Major: "abc"}))
+	assert.Equal(t, Posn{Major: "abc", Minor: "i"}, Posn{Major: "abc", Minor: ":i"}.Canonical())
+}
+
+func TestMinorAccessors(t *testing.T) {
+	p, _ := ParseJira("0|hzzzzz:i")
+	assert.Equal(t, "i", p.Minor)
+	assert.Equal(t, "i", p.MinorValue())
+	assert.Equal(t, ":i", p.PrefixedMinor())
+	assert.True(t, p.HasMinor())
+
+	legacy := Posn{Major: "hzzzzz", Minor: ":i"}
+	assert.Equal(t, "i", legacy.MinorValue())
+	assert.Equal(t, "0|hzzzzz:i", legacy.String())
+
+	p, _ = ParseJira("0|hzzzzz")
+	assert.False(t, p.HasMinor())
+	assert.Equal(t, "0|hzzzzz:", p.String())
+}
@@ -0,0 +1,212 @@
+// Package lexorankcql helps with using ranks as clustering columns in
+// Cassandra or ScyllaDB, so that a partition's rows come back in list
+// order.  Clustering columns can't be updated: moving a row means
+// deleting it and inserting it again under its new key, so keys here
+// are kept to a fixed length (they never grow, however much a spot is
+// inserted into), and when a spot runs out of room, the way out is an
+// explicit rebalance, which moves the rows to a new bucket so that no
+// new key is ever the same as an old one.
+//
+// Keys look like "0|U0000000", a bucket digit, a bar and a major of
+// fixed length, and are compared byte-wise, as text and ascii columns
+// are.
+package lexorankcql
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dkolbly/lexorank"
+)
+
+// ErrRebalance is returned when there's no room left for a key of the
+// column's length, and the partition (or at least that part of it)
+// needs rebalancing.
+var ErrRebalance = errors.New("lexorankcql: no room, partition needs rebalancing")
+
+// Column describes a rank clustering column.
+type Column struct {
+	// Length is the number of digits in the major of every key (12
+	// if zero)
+	Length int
+
+	// Generator makes the keys
+	Generator lexorank.Generator
+}
+
+func (c Column) length() int {
+	if c.Length <= 0 {
+		return 12
+	}
+	return c.Length
+}
+
+// Key returns the key for a position, which must have a major of the
+// column's length and no minor.
+func (c Column) Key(p lexorank.Posn) (string, error) {
+	if err := c.check(p); err != nil {
+		return "", err
+	}
+	return string(rune('0'+p.Bucket)) + "|" + p.Major, nil
+}
+
+// Parse returns the position a key stands for.
+func (c Column) Parse(key string) (lexorank.Posn, error) {
+	p, err := c.Generator.Parse(key)
+	if err != nil {
+		return lexorank.Posn{}, err
+	}
+	if err := c.check(p); err != nil {
+		return lexorank.Posn{}, err
+	}
+	return p, nil
+}
+
+func (c Column) check(p lexorank.Posn) error {
+	if len(p.Major) != c.length() || p.HasMinor() || p.Bucket > lexorank.MaxBucket ||
+		!c.Generator.Alphabet.Valid(p.Major) {
+		return fmt.Errorf("lexorankcql: %v isn't a %d-digit key", p, c.length())
+	}
+	return nil
+}
+
+// Between returns a key for a row going between the rows with keys
+// prev and next, either of which may be empty, for the start or end
+// of the partition.  It fails with ErrRebalance if there's no room
+// between them.
+func (c Column) Between(prev, next string) (string, error) {
+	var lo, hi lexorank.Posn
+	var err error
+	if prev != "" {
+		if lo, err = c.Parse(prev); err != nil {
+			return "", err
+		}
+	}
+	if next != "" {
+		if hi, err = c.Parse(next); err != nil {
+			return "", err
+		}
+	}
+	bucket := lo.Bucket
+	switch {
+	case prev == "":
+		bucket = hi.Bucket
+	case next != "" && hi.Bucket != bucket:
+		return "", fmt.Errorf("lexorankcql: %s and %s are in different buckets; finish rebalancing first", prev, next)
+	}
+	major, err := c.Generator.RankFixed(lo.Major, hi.Major, c.length())
+	var ferr *lexorank.FixedLengthError
+	if errors.As(err, &ferr) {
+		return "", fmt.Errorf("%w (between %q and %q)", ErrRebalance, prev, next)
+	} else if err != nil {
+		return "", err
+	}
+	return c.Key(lexorank.Posn{Bucket: bucket, Major: major})
+}
+
+// Keys returns n keys spread evenly over the whole of a bucket, for
+// filling a new partition.
+func (c Column) Keys(n int, bucket byte) ([]string, error) {
+	if bucket > lexorank.MaxBucket {
+		return nil, fmt.Errorf("lexorankcql: bucket %d out of range", bucket)
+	}
+	majors, err := c.Generator.SpreadFixed("", "", n, c.length())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %d rows don't fit in %d digits", ErrRebalance, n, c.length())
+	}
+	keys := make([]string, n)
+	for i, m := range majors {
+		keys[i] = string(rune('0'+bucket)) + "|" + m
+	}
+	return keys, nil
+}
+
+// A Move is a row's change of key.
+type Move struct {
+	From, To string
+}
+
+// Rebalance works out how to spread out the rows of a partition, given
+// their keys in order, by moving them all into the next bucket.  The
+// moves are in the order they should be made so that the partition
+// stays in order throughout, if they're split over several batches:
+// the rows nearest the end the new bucket sorts at go first.  (A
+// batch confined to one partition is applied atomically, so a
+// partition small enough for one batch can be done in one go.)
+func (c Column) Rebalance(keys []string) ([]Move, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	from, err := c.Parse(keys[0])
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range keys[1:] {
+		p, err := c.Parse(k)
+		if err != nil {
+			return nil, err
+		}
+		if p.Bucket != from.Bucket {
+			return nil, fmt.Errorf("lexorankcql: keys in more than one bucket; finish rebalancing first")
+		}
+	}
+	to := lexorank.NextBucket(from.Bucket)
+	fresh, err := c.Keys(len(keys), to)
+	if err != nil {
+		return nil, err
+	}
+	moves := make([]Move, len(keys))
+	for i := range keys {
+		moves[i] = Move{From: keys[i], To: fresh[i]}
+	}
+	if to > from.Bucket {
+		// the new bucket sorts after the old one, so start at the
+		// end of the partition
+		for i, j := 0, len(moves)-1; i < j; i, j = i+1, j-1 {
+			moves[i], moves[j] = moves[j], moves[i]
+		}
+	}
+	return moves, nil
+}
+
+// Table describes where the rows live, for writing the statements
+// that move them.  The names are pasted into the CQL as they are, so
+// they must come from the program, not from users.
+type Table struct {
+	Name string
+
+	// Partition holds the partition key columns, Rank the rank
+	// clustering column, and Columns the rest of the columns
+	Partition []string
+	Rank      string
+	Columns   []string
+}
+
+// MoveCQL returns a batch that moves a row to a new key, by deleting
+// it and inserting it again.  Its arguments are the partition key
+// values, the old key, then the partition key values again, the new
+// key, and the values of the other columns.
+func (t Table) MoveCQL() string {
+	return "BEGIN BATCH " + t.DeleteCQL() + "; " + t.InsertCQL() + "; APPLY BATCH"
+}
+
+// DeleteCQL returns a statement that deletes a row, taking the
+// partition key values and the rank key as arguments, for building
+// batches of moves.
+func (t Table) DeleteCQL() string {
+	var where []string
+	for _, col := range append(append([]string(nil), t.Partition...), t.Rank) {
+		where = append(where, col+" = ?")
+	}
+	return "DELETE FROM " + t.Name + " WHERE " + strings.Join(where, " AND ")
+}
+
+// InsertCQL returns a statement that inserts a row, taking the
+// partition key values, the rank key and the other columns' values
+// as arguments, for building batches of moves.
+func (t Table) InsertCQL() string {
+	cols := append(append(append([]string(nil), t.Partition...), t.Rank), t.Columns...)
+	marks := strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", ")
+	return "INSERT INTO " + t.Name + " (" + strings.Join(cols, ", ") + ") VALUES (" + marks + ")"
+}
@@ -0,0 +1,72 @@
+package lexorankcql
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/dkolbly/lexorank"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBetween(t *testing.T) {
+	c := Column{Length: 4}
+	k, err := c.Between("", "")
+	assert.NoError(t, err)
+	assert.Len(t, k, 6)
+	a, err := c.Between("", k)
+	assert.NoError(t, err)
+	b, err := c.Between(k, "")
+	assert.NoError(t, err)
+	assert.True(t, slices.IsSorted([]string{a, k, b}))
+
+	p, err := c.Parse(k)
+	assert.NoError(t, err)
+	assert.Equal(t, byte(0), p.Bucket)
+
+	// keys never grow: a spot that runs out of room needs a rebalance
+	_, err = c.Between("0|0000", "0|0001")
+	assert.ErrorIs(t, err, ErrRebalance)
+
+	_, err = c.Between("0|000", "")
+	assert.Error(t, err)
+	_, err = c.Between("0|0000", "1|0001")
+	assert.Error(t, err)
+	_, err = c.Between("1|zzzz", "1|0000")
+	assert.ErrorIs(t, err, lexorank.ErrInvertedBounds)
+}
+
+func TestRebalance(t *testing.T) {
+	c := Column{Length: 4}
+	keys := []string{"0|a000", "0|a001", "0|a002"}
+	moves, err := c.Rebalance(keys)
+	assert.NoError(t, err)
+	// into bucket 1, which sorts after 0, so from the end
+	assert.Equal(t, "0|a002", moves[0].From)
+	var to []string
+	for _, m := range moves {
+		assert.Equal(t, byte('1'), m.To[0])
+		assert.NotContains(t, keys, m.To)
+		to = append(to, m.To)
+	}
+	slices.Reverse(to)
+	assert.True(t, slices.IsSorted(to))
+
+	// and from 2 to 0 from the start
+	moves, err = c.Rebalance([]string{"2|a000", "2|a001"})
+	assert.NoError(t, err)
+	assert.Equal(t, "2|a000", moves[0].From)
+	assert.True(t, moves[0].To < moves[1].To)
+
+	_, err = c.Rebalance([]string{"0|a000", "1|a001"})
+	assert.Error(t, err)
+	_, err = Column{Length: 1}.Keys(100, 0)
+	assert.ErrorIs(t, err, ErrRebalance)
+}
+
+func TestCQL(t *testing.T) {
+	tbl := Table{Name: "cards", Partition: []string{"board"}, Rank: "rank", Columns: []string{"id", "title"}}
+	assert.Equal(t, "DELETE FROM cards WHERE board = ? AND rank = ?", tbl.DeleteCQL())
+	assert.Equal(t, "INSERT INTO cards (board, rank, id, title) VALUES (?, ?, ?, ?)", tbl.InsertCQL())
+	assert.Equal(t, "BEGIN BATCH DELETE FROM cards WHERE board = ? AND rank = ?; "+
+		"INSERT INTO cards (board, rank, id, title) VALUES (?, ?, ?, ?); APPLY BATCH", tbl.MoveCQL())
+}
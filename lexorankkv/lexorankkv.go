@@ -0,0 +1,220 @@
+// Package lexorankkv helps with using ranks as the keys of an ordered
+// key-value store, such as bbolt, Badger, Pebble or LevelDB, so that
+// scanning a list is scanning a range of keys, and the value stored
+// under a rank is the item there.
+//
+// Keys are made by lexorank.AppendKey, with the list's name (and a
+// prefix for the table, if there is one) in front, so they sort
+// byte-wise, as these stores sort keys, and every list is a range of
+// its own; Bounds gives the range, for iterators that take one.
+//
+// The helpers work through Cursor and Bucket, which *bbolt.Cursor and
+// *bbolt.Bucket satisfy as they are; for Badger, a Cursor is a small
+// wrapper around a pair of iterators (one of them reversed) on the
+// same transaction.  Run each helper in a read-write transaction, so
+// that what it reads and what it writes are all or nothing.
+package lexorankkv
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/dkolbly/lexorank"
+)
+
+// A Cursor moves over the keys of a bucket in byte-wise order.  Each
+// method returns the key and value it moves to, or nil keys if there
+// are none, as bbolt's cursors do.
+type Cursor interface {
+	Seek(seek []byte) (key, value []byte)
+	Next() (key, value []byte)
+	Prev() (key, value []byte)
+	Last() (key, value []byte)
+}
+
+// A Bucket is where the keys are written.
+type Bucket interface {
+	Put(key, value []byte) error
+	Delete(key []byte) error
+}
+
+// ErrNotFound is returned when there's no item at a rank that was
+// expected to have one.
+var ErrNotFound = errors.New("lexorankkv: no item at rank")
+
+// List describes one list in a bucket.
+type List struct {
+	// Name sets the list apart from others in the same bucket; it
+	// may be empty if the bucket holds only the one list
+	Name string
+
+	// Prefix goes in front of every key, to keep the lists of one
+	// table apart from everything else, in stores (like Pebble and
+	// LevelDB) that have only the one keyspace.  No table's prefix
+	// should start with another's.
+	Prefix []byte
+
+	// Generator makes the ranks
+	Generator lexorank.Generator
+}
+
+// Key returns the key for the item at p.
+func (l List) Key(p lexorank.Posn) []byte {
+	return l.AppendKey(nil, p)
+}
+
+// AppendKey appends the key for the item at p to dst, which saves an
+// allocation when making keys in bulk.
+func (l List) AppendKey(dst []byte, p lexorank.Posn) []byte {
+	return lexorank.AppendKey(append(dst, l.Prefix...), l.Name, p)
+}
+
+// Parse returns the rank that key is for, failing with
+// lexorank.ErrBadKey if it isn't a key of the list.
+func (l List) Parse(key []byte) (lexorank.Posn, error) {
+	if !bytes.HasPrefix(key, l.Prefix) {
+		return lexorank.Posn{}, lexorank.ErrBadKey
+	}
+	name, p, err := lexorank.ParseKey(key[len(l.Prefix):])
+	if err != nil {
+		return lexorank.Posn{}, err
+	}
+	if name != l.Name {
+		return lexorank.Posn{}, lexorank.ErrBadKey
+	}
+	return p, nil
+}
+
+// rank returns the rank that key is for, if it's in the list
+func (l List) rank(key []byte) (*lexorank.Posn, bool) {
+	if key == nil {
+		return nil, false
+	}
+	p, err := l.Parse(key)
+	if err != nil {
+		return nil, false
+	}
+	return &p, true
+}
+
+// prefix returns what all the keys of the list start with
+func (l List) prefix() []byte {
+	k := l.Key(lexorank.Posn{})
+	// the bucket byte and the escaped empty major follow the name
+	return k[:len(k)-3]
+}
+
+// Bounds returns the range of keys of the items in the list from the
+// one at from up to, but not including, the one at to, as the lower
+// (inclusive) and upper (exclusive) bounds of Pebble's IterOptions
+// want them.  A nil from or to is that end of the list, so
+// Bounds(nil, nil) is the whole of it.  For an exclusive lower bound,
+// append a 0 byte to lower, which makes it the first key after.
+func (l List) Bounds(from, to *lexorank.Posn) (lower, upper []byte) {
+	if from != nil {
+		lower = l.Key(*from)
+	} else {
+		lower = l.prefix()
+	}
+	if to != nil {
+		upper = l.Key(*to)
+	} else {
+		// the prefix ends 0x00 0x01, so 0x00 0x02 is past every key
+		// that starts with it, and before any other list's
+		upper = l.prefix()
+		upper[len(upper)-1]++
+	}
+	return lower, upper
+}
+
+// Neighbours returns the ranks of the items either side of p (which
+// needn't have an item of its own), found by seeking c there.  A nil
+// rank means p is at that end of the list.
+func (l List) Neighbours(c Cursor, p lexorank.Posn) (prev, next *lexorank.Posn) {
+	key := l.Key(p)
+	k, _ := c.Seek(key)
+	if bytes.Equal(k, key) {
+		k, _ = c.Next()
+		next, _ = l.rank(k)
+		k, _ = c.Seek(key)
+		k, _ = c.Prev()
+	} else {
+		next, _ = l.rank(k)
+		if k == nil {
+			k, _ = c.Last()
+		} else {
+			k, _ = c.Prev()
+		}
+	}
+	prev, _ = l.rank(k)
+	return prev, next
+}
+
+// InsertBetween puts value in the list between the items at prev and
+// next (either of which may be nil, for the start or end of the
+// list), and returns its rank.  It fails with lexorank.ErrNoRoom if
+// there's no room there, after which the list needs Rebalance.
+func (l List) InsertBetween(b Bucket, prev, next *lexorank.Posn, value []byte) (lexorank.Posn, error) {
+	p, ok := l.Generator.Between(prev, next)
+	if !ok {
+		return lexorank.Posn{}, lexorank.ErrNoRoom
+	}
+	return p, b.Put(l.Key(p), value)
+}
+
+// InsertAfter puts value in the list straight after the item at
+// after (or at the start of the list, if after is nil), looking up
+// what follows it with c, and returns its rank.
+func (l List) InsertAfter(b Bucket, c Cursor, after *lexorank.Posn, value []byte) (lexorank.Posn, error) {
+	var next *lexorank.Posn
+	if after == nil {
+		k, _ := c.Seek(l.prefix())
+		next, _ = l.rank(k)
+	} else {
+		key := l.Key(*after)
+		if k, _ := c.Seek(key); !bytes.Equal(k, key) {
+			return lexorank.Posn{}, ErrNotFound
+		}
+		k, _ := c.Next()
+		next, _ = l.rank(k)
+	}
+	return l.InsertBetween(b, after, next, value)
+}
+
+// Rebalance gives every item in the list a fresh rank, spread out as
+// for lexorank.Rebalance, in the bucket the list is in now, by
+// reading the whole list with c, deleting the old keys and writing
+// the new ones.  It returns the number of items.
+func (l List) Rebalance(b Bucket, c Cursor) (int, error) {
+	var (
+		keys, values [][]byte
+		bucket       byte
+	)
+	for k, v := c.Seek(l.prefix()); k != nil; k, v = c.Next() {
+		p, ok := l.rank(k)
+		if !ok {
+			break
+		}
+		if len(keys) == 0 {
+			bucket = p.Bucket
+		}
+		// what bbolt returns is only good until the next write
+		keys = append(keys, bytes.Clone(k))
+		values = append(values, bytes.Clone(v))
+	}
+	ranks, err := l.Generator.Rebalance(len(keys), bucket)
+	if err != nil {
+		return 0, err
+	}
+	for _, k := range keys {
+		if err := b.Delete(k); err != nil {
+			return 0, err
+		}
+	}
+	for i, p := range ranks {
+		if err := b.Put(l.Key(p), values[i]); err != nil {
+			return 0, err
+		}
+	}
+	return len(keys), nil
+}
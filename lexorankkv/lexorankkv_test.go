@@ -0,0 +1,196 @@
+package lexorankkv
+
+import (
+	"bytes"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/dkolbly/lexorank"
+	"github.com/stretchr/testify/assert"
+)
+
+// store is an in-memory bucket, and a cursor over it, that behave as
+// bbolt's do
+type store struct {
+	keys, values [][]byte
+	at           int
+}
+
+func (s *store) get(i int) ([]byte, []byte) {
+	s.at = i
+	if i < 0 || i >= len(s.keys) {
+		return nil, nil
+	}
+	return s.keys[i], s.values[i]
+}
+
+func (s *store) Seek(seek []byte) ([]byte, []byte) {
+	i, _ := slices.BinarySearchFunc(s.keys, seek, bytes.Compare)
+	return s.get(i)
+}
+
+func (s *store) Next() ([]byte, []byte) { return s.get(s.at + 1) }
+func (s *store) Prev() ([]byte, []byte) { return s.get(s.at - 1) }
+func (s *store) Last() ([]byte, []byte) { return s.get(len(s.keys) - 1) }
+
+func (s *store) Put(key, value []byte) error {
+	i, found := slices.BinarySearchFunc(s.keys, key, bytes.Compare)
+	if found {
+		s.values[i] = value
+		return nil
+	}
+	s.keys = slices.Insert(s.keys, i, key)
+	s.values = slices.Insert(s.values, i, value)
+	return nil
+}
+
+func (s *store) Delete(key []byte) error {
+	if i, found := slices.BinarySearchFunc(s.keys, key, bytes.Compare); found {
+		s.keys = slices.Delete(s.keys, i, i+1)
+		s.values = slices.Delete(s.values, i, i+1)
+	}
+	return nil
+}
+
+func (s *store) list(l List) []string {
+	var out []string
+	for k, v := s.Seek(l.prefix()); k != nil; k, v = s.Next() {
+		if _, ok := l.rank(k); !ok {
+			break
+		}
+		out = append(out, string(v))
+	}
+	return out
+}
+
+func TestInsert(t *testing.T) {
+	s := &store{}
+	a, b := List{Name: "a"}, List{Name: "b"}
+	// another list on either side, to stay out of
+	_, err := List{Name: ""}.InsertAfter(s, s, nil, []byte("x"))
+	assert.NoError(t, err)
+	_, err = List{Name: "c"}.InsertAfter(s, s, nil, []byte("y"))
+	assert.NoError(t, err)
+
+	one, err := b.InsertAfter(s, s, nil, []byte("1"))
+	assert.NoError(t, err)
+	three, err := b.InsertAfter(s, s, &one, []byte("3"))
+	assert.NoError(t, err)
+	_, err = b.InsertAfter(s, s, &one, []byte("2"))
+	assert.NoError(t, err)
+	_, err = b.InsertAfter(s, s, nil, []byte("0"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0", "1", "2", "3"}, s.list(b))
+	assert.Empty(t, s.list(a))
+
+	prev, next := b.Neighbours(s, three)
+	assert.Equal(t, "2", string(s.valueAt(b, prev)))
+	assert.Nil(t, next)
+	prev, next = b.Neighbours(s, lexorank.Posn{Major: "0"})
+	assert.Nil(t, prev)
+	assert.Equal(t, "0", string(s.valueAt(b, next)))
+	prev, next = a.Neighbours(s, lexorank.Posn{Major: "U"})
+	assert.Nil(t, prev)
+	assert.Nil(t, next)
+
+	_, err = b.InsertAfter(s, s, &lexorank.Posn{Major: "0"}, nil)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func (s *store) valueAt(l List, p *lexorank.Posn) []byte {
+	k, v := s.Seek(l.Key(*p))
+	if !bytes.Equal(k, l.Key(*p)) {
+		return nil
+	}
+	return v
+}
+
+func TestRebalance(t *testing.T) {
+	s := &store{}
+	l := List{Name: "l", Generator: lexorank.Generator{MaxLength: 8}}
+	_, err := List{Name: "m"}.InsertAfter(s, s, nil, []byte("other"))
+	assert.NoError(t, err)
+	// always inserting after the first item uses up the room there,
+	// as far as the keys are allowed to grow
+	first, err := l.InsertAfter(s, s, nil, []byte("first"))
+	assert.NoError(t, err)
+	want := []string{"first"}
+	for i := 0; ; i++ {
+		v := strconv.Itoa(i)
+		_, err := l.InsertAfter(s, s, &first, []byte(v))
+		if err != nil {
+			assert.ErrorIs(t, err, lexorank.ErrNoRoom)
+			break
+		}
+		want = slices.Insert(want, 1, v)
+	}
+
+	n, err := l.Rebalance(s, s)
+	assert.NoError(t, err)
+	assert.Equal(t, len(want), n)
+	assert.Equal(t, want, s.list(l))
+	assert.Equal(t, []string{"other"}, s.list(List{Name: "m"}))
+
+	// the first item has moved, and there's room after it again
+	k, _ := s.Seek(l.prefix())
+	first = *must(l.rank(k))
+	_, err = l.InsertAfter(s, s, &first, []byte("again"))
+	assert.NoError(t, err)
+	assert.Equal(t, "again", s.list(l)[1])
+}
+
+func must(p *lexorank.Posn, ok bool) *lexorank.Posn {
+	if !ok {
+		panic("not in the list")
+	}
+	return p
+}
+
+func TestBounds(t *testing.T) {
+	l := List{Name: "b", Prefix: []byte("cards/")}
+	other := []List{
+		{Name: "b", Prefix: []byte("cardr/")},
+		{Name: "a", Prefix: l.Prefix},
+		{Name: "b\x00", Prefix: l.Prefix},
+		{Name: "bb", Prefix: l.Prefix},
+		{Name: "b", Prefix: []byte("cards0")},
+	}
+	ranks, err := lexorank.Rebalance(10, 0)
+	assert.NoError(t, err)
+	ranks = append(ranks, lexorank.Posn{Bucket: 1, Major: "0"}, lexorank.Posn{Bucket: 2, Major: "zz", Minor: "z"})
+
+	s := &store{}
+	for _, p := range ranks {
+		assert.NoError(t, s.Put(l.Key(p), []byte(p.String())))
+		for _, o := range other {
+			assert.NoError(t, s.Put(o.Key(p), []byte("other")))
+		}
+	}
+	scan := func(lower, upper []byte) []string {
+		var out []string
+		for k, v := s.Seek(lower); k != nil && bytes.Compare(k, upper) < 0; k, v = s.Next() {
+			out = append(out, string(v))
+		}
+		return out
+	}
+	var all []string
+	for _, p := range ranks {
+		all = append(all, p.String())
+	}
+	assert.Equal(t, all, scan(l.Bounds(nil, nil)))
+	assert.Equal(t, all[2:5], scan(l.Bounds(&ranks[2], &ranks[5])))
+	assert.Equal(t, all[:1], scan(l.Bounds(nil, &ranks[1])))
+	assert.Equal(t, all[10:], scan(l.Bounds(&ranks[10], nil)))
+
+	lower, upper := l.Bounds(&ranks[2], &ranks[5])
+	assert.Equal(t, all[3:5], scan(append(lower, 0), upper))
+
+	p, err := l.Parse(l.Key(ranks[11]))
+	assert.NoError(t, err)
+	assert.Equal(t, ranks[11], p)
+	_, err = l.Parse(other[0].Key(ranks[0]))
+	assert.ErrorIs(t, err, lexorank.ErrBadKey)
+	_, err = l.Parse(other[1].Key(ranks[0]))
+	assert.ErrorIs(t, err, lexorank.ErrBadKey)
+}
@@ -0,0 +1,67 @@
+// Package lexorankotel traces a Generator's long-running operations
+// (rebalances and the like, and the moves it makes through a Store,
+// such as RebalanceStore and InsertStore) with OpenTelemetry:
+//
+//	g := lexorank.Generator{Tracer: lexorankotel.New(tp)}
+//
+// Each operation gets a span named after it ("lexorank.Rebalance",
+// say), with the number of items it was asked for, the number it
+// touched and how long it took as attributes.
+package lexorankotel
+
+import (
+	"context"
+	"time"
+
+	"github.com/dkolbly/lexorank"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope spans are created under.
+const ScopeName = "github.com/dkolbly/lexorank"
+
+// The attributes set on spans.
+const (
+	SizeKey     = attribute.Key("lexorank.size")
+	TouchedKey  = attribute.Key("lexorank.touched")
+	DurationKey = attribute.Key("lexorank.duration_ms")
+)
+
+type tracer struct {
+	t trace.Tracer
+}
+
+// New returns a lexorank.Tracer that makes spans with tp.  If tp is
+// nil it returns nil, so that tracing stays off (and costs nothing)
+// unless a TracerProvider is supplied.
+func New(tp trace.TracerProvider) lexorank.Tracer {
+	if tp == nil {
+		return nil
+	}
+	return tracer{tp.Tracer(ScopeName)}
+}
+
+func (tr tracer) Start(ctx context.Context, op string, size int) (context.Context, lexorank.TraceSpan) {
+	ctx, span := tr.t.Start(ctx, "lexorank."+op,
+		trace.WithAttributes(SizeKey.Int(size)))
+	return ctx, &otelSpan{span, time.Now()}
+}
+
+type otelSpan struct {
+	span  trace.Span
+	start time.Time
+}
+
+func (s *otelSpan) End(touched int, err error) {
+	s.span.SetAttributes(
+		TouchedKey.Int(touched),
+		DurationKey.Float64(float64(time.Since(s.start))/float64(time.Millisecond)),
+	)
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
@@ -0,0 +1,68 @@
+package lexorankotel
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dkolbly/lexorank"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func attrs(kvs []attribute.KeyValue) map[attribute.Key]attribute.Value {
+	m := map[attribute.Key]attribute.Value{}
+	for _, kv := range kvs {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func TestSpans(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	g := lexorank.Generator{Tracer: New(tp)}
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+	err := g.RebalanceContext(ctx, 100, 1, func(int, lexorank.Posn) error { return nil })
+	assert.NoError(t, err)
+	parent.End()
+
+	spans := rec.Ended()
+	assert.Len(t, spans, 2)
+	s := spans[0]
+	assert.Equal(t, "lexorank.Rebalance", s.Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), s.Parent().SpanID())
+	a := attrs(s.Attributes())
+	assert.Equal(t, int64(100), a[SizeKey].AsInt64())
+	assert.Equal(t, int64(100), a[TouchedKey].AsInt64())
+	assert.Contains(t, a, DurationKey)
+	assert.Equal(t, codes.Unset, s.Status().Code)
+}
+
+func TestSpanError(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	g := lexorank.Generator{Tracer: New(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))}
+
+	boom := errors.New("boom")
+	err := g.RanksContext(context.Background(), 10, nil, nil, func(i int, _ lexorank.Posn) error {
+		if i == 3 {
+			return boom
+		}
+		return nil
+	})
+	assert.Equal(t, boom, err)
+
+	s := rec.Ended()[0]
+	assert.Equal(t, "lexorank.Ranks", s.Name())
+	assert.Equal(t, int64(3), attrs(s.Attributes())[TouchedKey].AsInt64())
+	assert.Equal(t, codes.Error, s.Status().Code)
+	assert.Len(t, s.Events(), 1)
+}
+
+func TestNoProvider(t *testing.T) {
+	assert.Nil(t, New(nil))
+}
@@ -0,0 +1,134 @@
+// Package lexorankpgx lets pgx (v5) bind and scan lexorank.Posn
+// values directly, in queries and in CopyFrom, without going through
+// database/sql.  Positions are stored in text (or varchar) columns in
+// the form Generator.Text writes (which, unless the generator has a
+// Profile, is what String writes), and read back with Generator.Parse.
+//
+// Register has to be called on each connection's type map, which for
+// a pool means from AfterConnect:
+//
+//	cfg.AfterConnect = func(ctx context.Context, c *pgx.Conn) error {
+//		lexorankpgx.Register(c.TypeMap(), lexorank.Generator{})
+//		return nil
+//	}
+package lexorankpgx
+
+import (
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/dkolbly/lexorank"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Register teaches m to encode and decode positions in text and
+// varchar columns, with digits from g's alphabet, and to send
+// positions as text when the column type isn't known.
+func Register(m *pgtype.Map, g lexorank.Generator) {
+	for _, name := range []string{"text", "varchar"} {
+		t, ok := m.TypeForName(name)
+		if !ok {
+			continue
+		}
+		m.RegisterType(&pgtype.Type{
+			Name:  t.Name,
+			OID:   t.OID,
+			Codec: &Codec{Next: t.Codec, Generator: g},
+		})
+	}
+	m.RegisterDefaultPgType(lexorank.Posn{}, "text")
+	m.RegisterDefaultPgType(&lexorank.Posn{}, "text")
+}
+
+// Codec handles Posn and *Posn values, and passes everything else on
+// to the codec it replaced, so that strings still work as usual.
+type Codec struct {
+	Next      pgtype.Codec
+	Generator lexorank.Generator
+}
+
+func (c *Codec) FormatSupported(format int16) bool {
+	return c.Next.FormatSupported(format)
+}
+
+func (c *Codec) PreferredFormat() int16 {
+	return c.Next.PreferredFormat()
+}
+
+// text has the same text and binary formats, so the plans below work
+// for either
+
+func (c *Codec) PlanEncode(m *pgtype.Map, oid uint32, format int16, value any) pgtype.EncodePlan {
+	switch value.(type) {
+	case lexorank.Posn, *lexorank.Posn:
+		return encodePlan{c.Generator}
+	}
+	return c.Next.PlanEncode(m, oid, format, value)
+}
+
+func (c *Codec) PlanScan(m *pgtype.Map, oid uint32, format int16, target any) pgtype.ScanPlan {
+	switch target.(type) {
+	case *lexorank.Posn, **lexorank.Posn:
+		return scanPlan{c.Generator}
+	}
+	return c.Next.PlanScan(m, oid, format, target)
+}
+
+func (c *Codec) DecodeDatabaseSQLValue(m *pgtype.Map, oid uint32, format int16, src []byte) (driver.Value, error) {
+	return c.Next.DecodeDatabaseSQLValue(m, oid, format, src)
+}
+
+func (c *Codec) DecodeValue(m *pgtype.Map, oid uint32, format int16, src []byte) (any, error) {
+	return c.Next.DecodeValue(m, oid, format, src)
+}
+
+type encodePlan struct {
+	gen lexorank.Generator
+}
+
+func (ep encodePlan) Encode(value any, buf []byte) ([]byte, error) {
+	var p lexorank.Posn
+	switch v := value.(type) {
+	case lexorank.Posn:
+		p = v
+	case *lexorank.Posn:
+		if v == nil {
+			return nil, nil
+		}
+		p = *v
+	default:
+		return nil, fmt.Errorf("lexorankpgx: cannot encode %T", value)
+	}
+	return ep.gen.AppendText(buf, p)
+}
+
+type scanPlan struct {
+	gen lexorank.Generator
+}
+
+func (sp scanPlan) Scan(src []byte, target any) error {
+	switch t := target.(type) {
+	case *lexorank.Posn:
+		if src == nil {
+			return fmt.Errorf("lexorankpgx: cannot scan NULL into %T", target)
+		}
+		p, err := sp.gen.Parse(string(src))
+		if err != nil {
+			return err
+		}
+		*t = p
+	case **lexorank.Posn:
+		if src == nil {
+			*t = nil
+			return nil
+		}
+		p, err := sp.gen.Parse(string(src))
+		if err != nil {
+			return err
+		}
+		*t = &p
+	default:
+		return fmt.Errorf("lexorankpgx: cannot scan into %T", target)
+	}
+	return nil
+}
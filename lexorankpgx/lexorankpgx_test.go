@@ -0,0 +1,79 @@
+package lexorankpgx
+
+import (
+	"testing"
+
+	"github.com/dkolbly/lexorank"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+)
+
+func newMap() *pgtype.Map {
+	m := pgtype.NewMap()
+	Register(m, lexorank.Generator{})
+	return m
+}
+
+func TestRoundTrip(t *testing.T) {
+	m := newMap()
+	p := lexorank.Posn{Bucket: 1, Major: "aZ0", Minor: "U"}
+	for _, oid := range []uint32{pgtype.TextOID, pgtype.VarcharOID} {
+		for _, format := range []int16{pgtype.TextFormatCode, pgtype.BinaryFormatCode} {
+			buf, err := m.Encode(oid, format, p, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, "1|aZ0:U", string(buf))
+
+			var got lexorank.Posn
+			assert.NoError(t, m.Scan(oid, format, buf, &got))
+			assert.Equal(t, p, got)
+		}
+	}
+}
+
+func TestNull(t *testing.T) {
+	m := newMap()
+	buf, err := m.Encode(pgtype.TextOID, pgtype.TextFormatCode, (*lexorank.Posn)(nil), nil)
+	assert.NoError(t, err)
+	assert.Nil(t, buf)
+
+	got := &lexorank.Posn{}
+	assert.NoError(t, m.Scan(pgtype.TextOID, pgtype.TextFormatCode, nil, &got))
+	assert.Nil(t, got)
+
+	var p lexorank.Posn
+	assert.Error(t, m.Scan(pgtype.TextOID, pgtype.TextFormatCode, nil, &p))
+}
+
+func TestInvalid(t *testing.T) {
+	var p lexorank.Posn
+	assert.Error(t, newMap().Scan(pgtype.TextOID, pgtype.TextFormatCode, []byte("0|a-b"), &p))
+}
+
+func TestStringsStillWork(t *testing.T) {
+	m := newMap()
+	var s string
+	assert.NoError(t, m.Scan(pgtype.TextOID, pgtype.TextFormatCode, []byte("hello"), &s))
+	assert.Equal(t, "hello", s)
+
+	buf, err := m.Encode(pgtype.TextOID, pgtype.TextFormatCode, "hello", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(buf))
+}
+
+func TestDefaultType(t *testing.T) {
+	typ, ok := newMap().TypeForValue(lexorank.Posn{})
+	assert.True(t, ok)
+	assert.Equal(t, "text", typ.Name)
+}
+
+func TestProfile(t *testing.T) {
+	m := pgtype.NewMap()
+	Register(m, lexorank.Generator{Profile: lexorank.Profile{Separator: '.'}})
+	p := lexorank.Posn{Bucket: 1, Major: "aZ0", Minor: "U"}
+	buf, err := m.Encode(pgtype.TextOID, pgtype.TextFormatCode, p, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "1|aZ0.U", string(buf))
+	var got lexorank.Posn
+	assert.NoError(t, m.Scan(pgtype.TextOID, pgtype.TextFormatCode, buf, &got))
+	assert.Equal(t, p, got)
+}
@@ -0,0 +1,113 @@
+// Package lexorankprom exports a Generator's metrics to Prometheus.
+// Wiring it up takes two lines:
+//
+//	m := lexorankprom.New("myapp")
+//	prometheus.MustRegister(m)
+//
+// and then m goes in the generator's Metrics.
+package lexorankprom
+
+import (
+	"github.com/dkolbly/lexorank"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// GapBuckets are the histogram buckets for gap sizes, as fractions of
+// the keyspace.
+var GapBuckets = prometheus.ExponentialBuckets(1e-18, 10, 18)
+
+// LengthBuckets are the histogram buckets for key lengths, in digits.
+var LengthBuckets = []float64{1, 2, 4, 6, 8, 12, 16, 24, 32, 48, 64}
+
+// Collector is a lexorank.Metrics that keeps what it's told in
+// Prometheus counters and histograms.  It's a prometheus.Collector
+// itself, so it can be registered as it is.
+type Collector struct {
+	generated  prometheus.Counter
+	growth     prometheus.Counter
+	rebalances prometheus.Counter
+	gap        prometheus.Histogram
+	length     prometheus.Histogram
+}
+
+var (
+	_ lexorank.Metrics    = (*Collector)(nil)
+	_ lexorank.KeyLengths = (*Collector)(nil)
+)
+
+// New returns a Collector whose metrics are named under namespace
+// (which may be empty), as lexorank_*.
+func New(namespace string) *Collector {
+	return NewWithLabels(namespace, nil)
+}
+
+// NewWithLabels is like New, with constant labels on every metric, for
+// telling apart several generators (one per list type, say) in the
+// same process.
+func NewWithLabels(namespace string, labels prometheus.Labels) *Collector {
+	counter := func(name, help string) prometheus.Counter {
+		return prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   "lexorank",
+			Name:        name,
+			Help:        help,
+			ConstLabels: labels,
+		})
+	}
+	histogram := func(name, help string, buckets []float64) prometheus.Histogram {
+		return prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Subsystem:   "lexorank",
+			Name:        name,
+			Help:        help,
+			ConstLabels: labels,
+			Buckets:     buckets,
+		})
+	}
+	return &Collector{
+		generated:  counter("ranks_generated_total", "Ranks handed out."),
+		growth:     counter("key_growth_digits_total", "Digits by which new ranks were longer than their neighbours."),
+		rebalances: counter("rebalances_total", "Rebalances triggered."),
+		gap: histogram("gap_fraction", "Smallest gap left next to each batch of new ranks, as a fraction of the keyspace.",
+			GapBuckets),
+		length: histogram("key_length_digits", "Length of new ranks, in digits.", LengthBuckets),
+	}
+}
+
+func (c *Collector) metrics() []prometheus.Collector {
+	return []prometheus.Collector{c.generated, c.growth, c.rebalances, c.gap, c.length}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range c.metrics() {
+		m.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, m := range c.metrics() {
+		m.Collect(ch)
+	}
+}
+
+func (c *Collector) RanksGenerated(n int) {
+	c.generated.Add(float64(n))
+}
+
+func (c *Collector) KeyGrowth(digits int) {
+	c.growth.Add(float64(digits))
+}
+
+func (c *Collector) Gap(fraction float64) {
+	c.gap.Observe(fraction)
+}
+
+func (c *Collector) RebalanceTriggered() {
+	c.rebalances.Inc()
+}
+
+func (c *Collector) KeyLength(digits int) {
+	c.length.Observe(float64(digits))
+}
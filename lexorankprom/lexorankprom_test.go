@@ -0,0 +1,66 @@
+package lexorankprom
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dkolbly/lexorank"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector(t *testing.T) {
+	m := New("test")
+	reg := prometheus.NewPedanticRegistry()
+	assert.NoError(t, reg.Register(m))
+
+	g := lexorank.Generator{Metrics: m}
+	_, ok := g.Ranks(3, nil, nil)
+	assert.True(t, ok)
+	_, ok = g.Rank("aaaa", "aaab")
+	assert.True(t, ok)
+	m.RebalanceTriggered()
+
+	assert.Equal(t, 5, testutil.CollectAndCount(m))
+	assert.Equal(t, 4.0, testutil.ToFloat64(m.generated))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.growth))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.rebalances))
+
+	problems, err := testutil.GatherAndLint(reg)
+	assert.NoError(t, err)
+	assert.Empty(t, problems)
+
+	err = testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP test_lexorank_key_length_digits Length of new ranks, in digits.
+# TYPE test_lexorank_key_length_digits histogram
+test_lexorank_key_length_digits_bucket{le="1"} 0
+test_lexorank_key_length_digits_bucket{le="2"} 0
+test_lexorank_key_length_digits_bucket{le="4"} 0
+test_lexorank_key_length_digits_bucket{le="6"} 4
+test_lexorank_key_length_digits_bucket{le="8"} 4
+test_lexorank_key_length_digits_bucket{le="12"} 4
+test_lexorank_key_length_digits_bucket{le="16"} 4
+test_lexorank_key_length_digits_bucket{le="24"} 4
+test_lexorank_key_length_digits_bucket{le="32"} 4
+test_lexorank_key_length_digits_bucket{le="48"} 4
+test_lexorank_key_length_digits_bucket{le="64"} 4
+test_lexorank_key_length_digits_bucket{le="+Inf"} 4
+test_lexorank_key_length_digits_sum 23
+test_lexorank_key_length_digits_count 4
+`), "test_lexorank_key_length_digits")
+	assert.NoError(t, err)
+}
+
+func TestLabels(t *testing.T) {
+	a := NewWithLabels("", prometheus.Labels{"list": "tasks"})
+	b := NewWithLabels("", prometheus.Labels{"list": "boards"})
+	reg := prometheus.NewRegistry()
+	assert.NoError(t, reg.Register(a))
+	assert.NoError(t, reg.Register(b))
+	a.RanksGenerated(2)
+
+	n, err := testutil.GatherAndCount(reg, "lexorank_ranks_generated_total")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+}
@@ -0,0 +1,116 @@
+package lexoranksqlx
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dkolbly/lexorank"
+	"github.com/jmoiron/sqlx"
+)
+
+// Backfill populates a rank column from an existing ordering, which
+// is the first step in moving a table off integer positions.  The
+// whole table is ranked as one list (which also orders each list
+// within it, if Table.List is set), with ranks spread evenly as for
+// lexorank.Rebalance.
+//
+// Rows are read and updated in batches, each in its own transaction,
+// using LIMIT and OFFSET over OrderBy, so rows must not be added or
+// reordered while it runs.
+type Backfill struct {
+	Table Table
+
+	// OrderBy is the existing ordering, as it would appear after
+	// ORDER BY (e.g. "position, id"); it should be total, or rows
+	// that tie may be skipped or ranked twice.
+	OrderBy string
+
+	// ColumnType, if set, is the type of a new rank column to add
+	// before backfilling (e.g. `TEXT COLLATE "C"`).
+	ColumnType string
+
+	// BatchSize is how many rows are updated per transaction (the
+	// default is 1000).
+	BatchSize int
+
+	// Progress, if set, is called after each batch with the number
+	// of rows done so far and the total.
+	Progress func(done, total int)
+}
+
+// Run does the backfill.
+func (b Backfill) Run(ctx context.Context, db *sqlx.DB) error {
+	t := b.Table
+	if b.ColumnType != "" {
+		q := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", t.Name, t.rank(), b.ColumnType)
+		if _, err := db.ExecContext(ctx, q); err != nil {
+			return err
+		}
+	}
+	var total int
+	if err := db.GetContext(ctx, &total, "SELECT COUNT(*) FROM "+t.Name); err != nil {
+		return err
+	}
+	size := b.BatchSize
+	if size <= 0 {
+		size = 1000
+	}
+
+	batch := make([]string, 0, size)
+	done := 0
+	flush := func() error {
+		if err := b.batch(ctx, db, done, batch); err != nil {
+			return err
+		}
+		done += len(batch)
+		batch = batch[:0]
+		if b.Progress != nil {
+			b.Progress(done, total)
+		}
+		return nil
+	}
+	err := t.Generator.RebalanceContext(ctx, total, 0, func(_ int, p lexorank.Posn) error {
+		batch = append(batch, p.Major+p.MinorValue())
+		if len(batch) < size {
+			return nil
+		}
+		return flush()
+	})
+	if err != nil || len(batch) == 0 {
+		return err
+	}
+	return flush()
+}
+
+// batch gives the rows starting at offset the given ranks
+func (b Backfill) batch(ctx context.Context, db *sqlx.DB, offset int, ranks []string) error {
+	t := b.Table
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var ids []any
+	q := fmt.Sprintf("SELECT %s FROM %s ORDER BY %s LIMIT %d OFFSET %d",
+		t.id(), t.Name, b.OrderBy, len(ranks), offset)
+	if err := tx.SelectContext(ctx, &ids, q); err != nil {
+		return err
+	}
+	if len(ids) != len(ranks) {
+		return fmt.Errorf("lexoranksqlx: expected %d rows at offset %d, found %d (was the table changed?)",
+			len(ranks), offset, len(ids))
+	}
+	q = fmt.Sprintf("UPDATE %s SET %s = :rank WHERE %s = :id", t.Name, t.rank(), t.id())
+	stmt, err := tx.PrepareNamedContext(ctx, q)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for i, id := range ids {
+		if _, err := stmt.ExecContext(ctx, Row{ID: id, Rank: ranks[i]}); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,41 @@
+package lexoranksqlx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackfill(t *testing.T) {
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+	db.MustExec(`CREATE TABLE tasks (id INTEGER PRIMARY KEY, position INTEGER)`)
+	// positions with ties, broken by id
+	for i := 0; i < 25; i++ {
+		db.MustExec(`INSERT INTO tasks (id, position) VALUES (?, ?)`, 100-i, i/2)
+	}
+
+	var progress [][2]int
+	err = Backfill{
+		Table:      Table{Name: "tasks"},
+		OrderBy:    "position, id",
+		ColumnType: "TEXT COLLATE BINARY",
+		BatchSize:  10,
+		Progress:   func(done, total int) { progress = append(progress, [2]int{done, total}) },
+	}.Run(context.Background(), db)
+	assert.NoError(t, err)
+	assert.Equal(t, [][2]int{{10, 25}, {20, 25}, {25, 25}}, progress)
+
+	var byPosition, byRank []int
+	assert.NoError(t, db.Select(&byPosition, `SELECT id FROM tasks ORDER BY position, id`))
+	assert.NoError(t, db.Select(&byRank, `SELECT id FROM tasks ORDER BY rank`))
+	assert.Equal(t, byPosition, byRank)
+
+	var missing int
+	assert.NoError(t, db.Get(&missing, `SELECT COUNT(*) FROM tasks WHERE rank IS NULL`))
+	assert.Equal(t, 0, missing)
+}
@@ -0,0 +1,99 @@
+package lexoranksqlx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/dkolbly/lexorank"
+	"github.com/jmoiron/sqlx"
+)
+
+// BucketTable is a lexorank.BucketStore that keeps each list's active
+// bucket in a table of its own, with a row per list (a list without
+// one is in bucket 0), like
+//
+//	CREATE TABLE list_buckets (list TEXT PRIMARY KEY, bucket INTEGER NOT NULL)
+//
+// The list column must be unique, which is what makes setting the
+// bucket of a list that hasn't got a row yet safe.  As for Table, the
+// names are pasted into the SQL as they are.
+type BucketTable struct {
+	DB   *sqlx.DB
+	Name string
+
+	// List and Bucket are the names of the columns, which default to
+	// "list" and "bucket".
+	List, Bucket string
+}
+
+var _ lexorank.BucketStore = BucketTable{}
+
+func (t BucketTable) list() string {
+	if t.List == "" {
+		return "list"
+	}
+	return t.List
+}
+
+func (t BucketTable) bucket() string {
+	if t.Bucket == "" {
+		return "bucket"
+	}
+	return t.Bucket
+}
+
+// ActiveBucket implements lexorank.BucketStore.
+func (t BucketTable) ActiveBucket(ctx context.Context, list string) (byte, error) {
+	var b int
+	q := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ?", t.bucket(), t.Name, t.list())
+	err := t.DB.GetContext(ctx, &b, t.DB.Rebind(q), list)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return 0, nil
+	case err != nil:
+		return 0, err
+	case b < 0 || b > lexorank.MaxBucket:
+		return 0, fmt.Errorf("lexoranksqlx: list %q has bucket %d", list, b)
+	}
+	return byte(b), nil
+}
+
+// SetActiveBucket implements lexorank.BucketStore, with an UPDATE that
+// only matches the row if it still has bucket from (or, for a list
+// without a row, an INSERT that fails if another got there first).
+func (t BucketTable) SetActiveBucket(ctx context.Context, list string, from, to byte) error {
+	if to > lexorank.MaxBucket {
+		return fmt.Errorf("lexoranksqlx: bucket %d out of range", to)
+	}
+	q := fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ? AND %s = ?", t.Name, t.bucket(), t.list(), t.bucket())
+	res, err := t.DB.ExecContext(ctx, t.DB.Rebind(q), to, list, from)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+	if from != 0 {
+		return lexorank.ErrBucketChanged
+	}
+	q = fmt.Sprintf("INSERT INTO %s (%s, %s) VALUES (?, ?)", t.Name, t.list(), t.bucket())
+	if _, err := t.DB.ExecContext(ctx, t.DB.Rebind(q), list, to); err != nil {
+		// most likely the row is there after all, so check, rather
+		// than trying to tell a unique violation from other errors
+		// in every database's own way
+		if t.exists(ctx, list) {
+			return fmt.Errorf("%w: %v", lexorank.ErrBucketChanged, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// exists reports whether the list has a row
+func (t BucketTable) exists(ctx context.Context, list string) bool {
+	var n int
+	q := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s = ?", t.Name, t.list())
+	return t.DB.GetContext(ctx, &n, t.DB.Rebind(q), list) == nil && n > 0
+}
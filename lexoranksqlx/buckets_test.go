@@ -0,0 +1,46 @@
+package lexoranksqlx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dkolbly/lexorank"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBucketTable(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+	db.MustExec(`CREATE TABLE list_buckets (board TEXT PRIMARY KEY, bucket INTEGER NOT NULL)`)
+	bt := BucketTable{DB: db, Name: "list_buckets", List: "board"}
+
+	b, err := bt.ActiveBucket(ctx, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, byte(0), b)
+
+	// the first change adds the row, and later ones update it
+	assert.NoError(t, bt.SetActiveBucket(ctx, "a", 0, 1))
+	assert.ErrorIs(t, bt.SetActiveBucket(ctx, "a", 0, 1), lexorank.ErrBucketChanged)
+	assert.NoError(t, bt.SetActiveBucket(ctx, "a", 1, 2))
+	assert.ErrorIs(t, bt.SetActiveBucket(ctx, "a", 1, 2), lexorank.ErrBucketChanged)
+	b, err = bt.ActiveBucket(ctx, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, byte(2), b)
+	assert.Error(t, bt.SetActiveBucket(ctx, "a", 2, 3))
+
+	assert.ErrorIs(t, bt.SetActiveBucket(ctx, "b", 1, 2), lexorank.ErrBucketChanged)
+
+	// and it drives a rebalance job
+	m := &lexorank.MemStore{}
+	m.Put("b", lexorank.Item{ID: "x", Rank: lexorank.Posn{Major: "a"}})
+	j := &lexorank.RebalanceJob{Store: m, List: "b", Buckets: bt}
+	assert.NoError(t, j.Run(ctx))
+	assert.Equal(t, byte(1), m.List("b")[0].Rank.Bucket)
+	b, err = bt.ActiveBucket(ctx, "b")
+	assert.NoError(t, err)
+	assert.Equal(t, byte(1), b)
+}
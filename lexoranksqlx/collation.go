@@ -0,0 +1,116 @@
+package lexoranksqlx
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// probes are ranks chosen so that the usual wrong collations put them
+// in the wrong order: case-insensitive ones (which put "a" before
+// "B"), ones that skip punctuation (which read "a:0" as "a0"), and
+// ones that compare runs of digits as numbers (which put "a9" before
+// "a10")
+var probes = []string{
+	"0", "9", "A", "B", "Z", "a", "b", "z",
+	"a0", "a1", "a:0", "a10", "a9", "aZ", "aa",
+}
+
+// A CollationError says how a database orders ranks differently from
+// the library.  Want is the probe ranks in the order they should
+// come back, and Got the order they did.
+type CollationError struct {
+	Want, Got []string
+	Diagnosis string
+}
+
+func (e *CollationError) Error() string {
+	return "lexoranksqlx: rank column doesn't sort byte-wise: " + e.Diagnosis
+}
+
+// VerifyCollation checks that the database sorts the rank column the
+// way the ranks themselves do, which it only does with a byte-wise
+// collation; anything else sorts most ranks correctly and quietly
+// gets the rest wrong.  It inserts some probe ranks (setting no other
+// column, so the others need defaults) in a transaction, reads them
+// back with ORDER BY, and rolls the transaction back.  If the order
+// is wrong, the error is a *CollationError saying what the collation
+// looks like it's doing.
+func (t Table) VerifyCollation(ctx context.Context, db *sql.DB) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// the probes are all plain digits and letters, so they're safe
+	// to paste in, which saves worrying about placeholder styles
+	values := make([]string, len(probes))
+	for i, p := range probes {
+		values[i] = "('" + p + "')"
+	}
+	q := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", t.Name, t.rank(), strings.Join(values, ", "))
+	if _, err := tx.ExecContext(ctx, q); err != nil {
+		return fmt.Errorf("inserting probe ranks: %w", err)
+	}
+
+	in := make([]string, len(probes))
+	for i, p := range probes {
+		in[i] = "'" + p + "'"
+	}
+	q = fmt.Sprintf("SELECT %[1]s FROM %[2]s WHERE %[1]s IN (%[3]s) ORDER BY %[1]s",
+		t.rank(), t.Name, strings.Join(in, ", "))
+	rows, err := tx.QueryContext(ctx, q)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var r string
+		if err := rows.Scan(&r); err != nil {
+			return err
+		}
+		got = append(got, r)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	// rows that were already there with the same ranks as the probes
+	// come back next to them, if the collation is right
+	got = slices.Compact(got)
+
+	want := slices.Clone(probes)
+	slices.Sort(want)
+	if slices.Equal(got, want) {
+		return nil
+	}
+	return &CollationError{Want: want, Got: got, Diagnosis: diagnose(got)}
+}
+
+// diagnose guesses what's wrong with a collation from the order it put
+// the probes in
+func diagnose(got []string) string {
+	before := func(a, b string) bool {
+		i, j := slices.Index(got, a), slices.Index(got, b)
+		return i >= 0 && j >= 0 && i < j
+	}
+	switch {
+	case before("a", "B") || before("b", "Z"):
+		return `it is case-insensitive (it sorts "a" before "B")`
+	case before("a:0", "a1"):
+		return `it ignores punctuation (it sorts "a:0" before "a1")`
+	case before("a9", "a10"):
+		return `it compares numbers by value (it sorts "a9" before "a10")`
+	case len(got) != len(probes):
+		return fmt.Sprintf("%d of %d probe ranks came back", len(got), len(probes))
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i-1] >= got[i] {
+			return fmt.Sprintf("it sorts %q before %q", got[i-1], got[i])
+		}
+	}
+	return "it sorts them in an unknown order"
+}
@@ -0,0 +1,32 @@
+package lexoranksqlx
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyCollation(t *testing.T) {
+	db := open(t)
+	ctx := context.Background()
+	assert.NoError(t, cards.VerifyCollation(ctx, db.DB))
+	// the probes are rolled back
+	assert.Equal(t, []int{1, 2, 3}, order(t, db, "a"))
+	var n int
+	assert.NoError(t, db.Get(&n, `SELECT COUNT(*) FROM cards`))
+	assert.Equal(t, 4, n)
+
+	db.MustExec(`CREATE TABLE nocase (id INTEGER PRIMARY KEY, rank TEXT COLLATE NOCASE)`)
+	err := Table{Name: "nocase"}.VerifyCollation(ctx, db.DB)
+	var ce *CollationError
+	assert.True(t, errors.As(err, &ce))
+	assert.Contains(t, ce.Diagnosis, "case-insensitive")
+	assert.NotEqual(t, ce.Want, ce.Got)
+}
+
+func TestDiagnose(t *testing.T) {
+	assert.Equal(t, `it ignores punctuation (it sorts "a:0" before "a1")`, diagnose([]string{"a:0", "a1"}))
+	assert.Equal(t, `it compares numbers by value (it sorts "a9" before "a10")`, diagnose([]string{"a9", "a10"}))
+}
@@ -0,0 +1,194 @@
+// Package lexoranksqlx is a thin layer over sqlx for keeping a rank
+// column in a SQL table.  Ranks are stored as the plain strings that
+// lexorank.Rank generates, so the column must use a byte-wise
+// collation (BINARY in SQLite, "C" in PostgreSQL, a _bin collation in
+// MySQL) for ORDER BY to agree with the ranks; Table.VerifyCollation
+// checks that it does.
+package lexoranksqlx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dkolbly/lexorank"
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrNoRoom is returned by Move when there is no rank between the
+// neighbours at the target index; the list needs rebalancing.
+var ErrNoRoom = errors.New("lexoranksqlx: no room between neighbours")
+
+// ErrIndex is returned when an index is past the end of the list.
+var ErrIndex = errors.New("lexoranksqlx: index out of range")
+
+// Row is a rank change, in a form that can be passed straight to
+// NamedExec along with the statement from UpdateSQL.
+type Row struct {
+	ID   any    `db:"id"`
+	List any    `db:"list"`
+	Rank string `db:"rank"`
+}
+
+// Table describes where the ranks live.  The names are pasted into
+// the SQL as they are, so they must come from the program, not from
+// users.
+type Table struct {
+	Name string
+
+	// ID and Rank are the names of the key and rank columns,
+	// which default to "id" and "rank".
+	ID, Rank string
+
+	// List, if set, is the column that splits the table into
+	// independently ranked lists; otherwise the whole table is one
+	// list.
+	List string
+
+	// Generator makes the new ranks.
+	Generator lexorank.Generator
+
+	// Attempts is how many times Move tries the transaction before
+	// giving up (the default is 3).  Retryable decides which errors
+	// are worth another attempt; if it is nil, any error other than
+	// ErrNoRoom, ErrIndex, sql.ErrNoRows (there's no such row) or the
+	// context's is.
+	Attempts  int
+	Retryable func(error) bool
+}
+
+func (t Table) id() string {
+	if t.ID == "" {
+		return "id"
+	}
+	return t.ID
+}
+
+func (t Table) rank() string {
+	if t.Rank == "" {
+		return "rank"
+	}
+	return t.Rank
+}
+
+// UpdateSQL returns a statement for NamedExec that sets the rank of
+// the Row with the given ID (and List, if the table has one).
+func (t Table) UpdateSQL() string {
+	q := fmt.Sprintf("UPDATE %s SET %s = :rank WHERE %s = :id", t.Name, t.rank(), t.id())
+	if t.List != "" {
+		q += fmt.Sprintf(" AND %s = :list", t.List)
+	}
+	return q
+}
+
+// Neighbors fetches, in one query, the ranks either side of position
+// index in the list (counting from 0, in rank order), which are
+// where a row has to go to end up at that index.  The row exclude
+// (if not nil) is left out of the count, so that a row being moved
+// doesn't get in its own way.  A nil prev or next means the index is
+// at that end of the list.
+func (t Table) Neighbors(ctx context.Context, q sqlx.ExtContext, list any, index int, exclude any) (prev, next *string, err error) {
+	if index < 0 {
+		return nil, nil, ErrIndex
+	}
+	var where []string
+	var args []any
+	if t.List != "" {
+		where = append(where, t.List+" = ?")
+		args = append(args, list)
+	}
+	if exclude != nil {
+		where = append(where, t.id()+" <> ?")
+		args = append(args, exclude)
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s", t.rank(), t.Name)
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	// starting one before the index picks up both neighbours at once
+	limit, offset := 2, index-1
+	if index == 0 {
+		limit, offset = 1, 0
+	}
+	query += fmt.Sprintf(" ORDER BY %s LIMIT %d OFFSET %d", t.rank(), limit, offset)
+
+	var ranks []string
+	if err := sqlx.SelectContext(ctx, q, &ranks, q.Rebind(query), args...); err != nil {
+		return nil, nil, err
+	}
+	if index > 0 {
+		if len(ranks) == 0 {
+			return nil, nil, ErrIndex
+		}
+		prev, ranks = &ranks[0], ranks[1:]
+	}
+	if len(ranks) > 0 {
+		next = &ranks[0]
+	}
+	return prev, next, nil
+}
+
+// Move moves the row id to position index in the list, in a
+// transaction that is retried as described for Attempts, and returns
+// the row's new rank.
+func (t Table) Move(ctx context.Context, db *sqlx.DB, list, id any, index int) (string, error) {
+	attempts := t.Attempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+	var err error
+	for i := 0; i < attempts; i++ {
+		var rank string
+		if rank, err = t.move(ctx, db, list, id, index); err == nil {
+			return rank, nil
+		}
+		if !t.retryable(ctx, err) {
+			break
+		}
+	}
+	return "", err
+}
+
+func (t Table) retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, ErrNoRoom) || errors.Is(err, ErrIndex) || errors.Is(err, sql.ErrNoRows) {
+		return false
+	}
+	if t.Retryable != nil {
+		return t.Retryable(err)
+	}
+	return true
+}
+
+func (t Table) move(ctx context.Context, db *sqlx.DB, list, id any, index int) (string, error) {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	prev, next, err := t.Neighbors(ctx, tx, list, index, id)
+	if err != nil {
+		return "", err
+	}
+	var lo, hi string
+	if prev != nil {
+		lo = *prev
+	}
+	if next != nil {
+		hi = *next
+	}
+	rank, ok := t.Generator.Rank(lo, hi)
+	if !ok {
+		return "", ErrNoRoom
+	}
+	res, err := tx.NamedExecContext(ctx, t.UpdateSQL(), Row{ID: id, List: list, Rank: rank})
+	if err != nil {
+		return "", err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return "", sql.ErrNoRows
+	}
+	return rank, tx.Commit()
+}
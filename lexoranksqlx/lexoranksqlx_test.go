@@ -0,0 +1,149 @@
+package lexoranksqlx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/dkolbly/lexorank"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func open(t *testing.T) *sqlx.DB {
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	db.MustExec(`CREATE TABLE cards (id INTEGER PRIMARY KEY, board TEXT, rank TEXT COLLATE BINARY)`)
+	for _, r := range []Row{
+		{ID: 1, List: "a", Rank: "a"},
+		{ID: 2, List: "a", Rank: "b"},
+		{ID: 3, List: "a", Rank: "c"},
+		{ID: 4, List: "b", Rank: "U"},
+	} {
+		_, err := db.NamedExec(`INSERT INTO cards (id, board, rank) VALUES (:id, :list, :rank)`, r)
+		assert.NoError(t, err)
+	}
+	return db
+}
+
+func order(t *testing.T, db *sqlx.DB, board string) []int {
+	var ids []int
+	assert.NoError(t, db.Select(&ids, `SELECT id FROM cards WHERE board = ? ORDER BY rank`, board))
+	return ids
+}
+
+var cards = Table{Name: "cards", List: "board"}
+
+func TestNeighbors(t *testing.T) {
+	db := open(t)
+	ctx := context.Background()
+
+	prev, next, err := cards.Neighbors(ctx, db, "a", 0, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, prev)
+	assert.Equal(t, "a", *next)
+
+	prev, next, err = cards.Neighbors(ctx, db, "a", 1, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", *prev)
+	assert.Equal(t, "b", *next)
+
+	prev, next, err = cards.Neighbors(ctx, db, "a", 3, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "c", *prev)
+	assert.Nil(t, next)
+
+	// leaving out card 2 closes its gap
+	prev, next, err = cards.Neighbors(ctx, db, "a", 1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", *prev)
+	assert.Equal(t, "c", *next)
+
+	_, _, err = cards.Neighbors(ctx, db, "a", 4, nil)
+	assert.True(t, errors.Is(err, ErrIndex))
+}
+
+func TestMove(t *testing.T) {
+	db := open(t)
+	ctx := context.Background()
+
+	rank, err := cards.Move(ctx, db, "a", 3, 0)
+	assert.NoError(t, err)
+	assert.True(t, rank < "a")
+	assert.Equal(t, []int{3, 1, 2}, order(t, db, "a"))
+
+	_, err = cards.Move(ctx, db, "a", 3, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, order(t, db, "a"))
+
+	_, err = cards.Move(ctx, db, "a", 1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2, 1, 3}, order(t, db, "a"))
+	assert.Equal(t, []int{4}, order(t, db, "b"))
+
+	_, err = cards.Move(ctx, db, "a", 9, 0)
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+}
+
+func TestMoveRetry(t *testing.T) {
+	db := open(t)
+	tries := 0
+	tbl := Table{Name: "missing", Attempts: 2, Retryable: func(error) bool {
+		tries++
+		return true
+	}}
+	_, err := tbl.Move(context.Background(), db, nil, 1, 0)
+	assert.Error(t, err)
+	assert.Equal(t, 2, tries)
+}
+
+func TestMoveNoRoom(t *testing.T) {
+	db := open(t)
+	db.MustExec(`UPDATE cards SET rank = 'a0' WHERE id = 2`)
+	_, err := cards.Move(context.Background(), db, "a", 3, 1)
+	assert.True(t, errors.Is(err, ErrNoRoom))
+}
+
+func TestUpdateSQL(t *testing.T) {
+	assert.Equal(t, "UPDATE t SET pos = :rank WHERE key = :id",
+		Table{Name: "t", ID: "key", Rank: "pos"}.UpdateSQL())
+}
+
+func TestKeysetPaging(t *testing.T) {
+	db := open(t)
+	// a tie, for the IDs to break
+	db.MustExec(`INSERT INTO cards (id, board, rank) VALUES (5, 'a', 'b')`)
+
+	var got []int
+	cursor := ""
+	for {
+		q := `SELECT id, rank FROM cards WHERE board = ?`
+		args := []any{"a"}
+		if cursor != "" {
+			rank, id, err := lexorank.DecodeCursor(cursor)
+			assert.NoError(t, err)
+			where, more := lexorank.KeysetWhere("rank", "id", rank.Major, id, false)
+			q += " AND " + where
+			args = append(args, more...)
+		}
+		var page []struct {
+			ID   int
+			Rank string
+		}
+		assert.NoError(t, db.Select(&page, q+` ORDER BY rank, id LIMIT 2`, args...))
+		if len(page) == 0 {
+			break
+		}
+		for _, r := range page {
+			got = append(got, r.ID)
+		}
+		last := page[len(page)-1]
+		cursor = lexorank.EncodeCursor(lexorank.Posn{Major: last.Rank}, strconv.Itoa(last.ID))
+	}
+	assert.Equal(t, []int{1, 2, 5, 3}, got)
+}
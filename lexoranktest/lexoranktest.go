@@ -0,0 +1,58 @@
+// Package lexoranktest provides assertions for testing code that
+// hands out ranks, checking the same invariants the lexorank package
+// itself relies on.  Each helper stops the test (with t.Fatalf) if
+// the invariant doesn't hold.
+package lexoranktest
+
+import (
+	"testing"
+
+	"github.com/dkolbly/lexorank"
+)
+
+// RequireBetween checks that got sorts strictly between prev and
+// next.  A nil prev or next is open ended, as for lexorank.Ranks.
+func RequireBetween(t testing.TB, prev *lexorank.Posn, got lexorank.Posn, next *lexorank.Posn) {
+	t.Helper()
+	require(t, lexorank.StrictlyBetween(prev, got, next))
+}
+
+// RequireSorted checks that ranks are in strictly increasing order,
+// i.e., sorted with no duplicates.
+func RequireSorted(t testing.TB, ranks []lexorank.Posn) {
+	t.Helper()
+	for i := 1; i < len(ranks); i++ {
+		if ranks[i-1].Compare(ranks[i]) >= 0 {
+			t.Fatalf("ranks out of order at %d: %s then %s", i, ranks[i-1], ranks[i])
+		}
+	}
+}
+
+// RequireValid checks that rank is well formed for the default
+// (Base62) alphabet: its bucket is in range, its major isn't empty
+// and it has no stray characters.
+func RequireValid(t testing.TB, rank lexorank.Posn) {
+	t.Helper()
+	RequireValidIn(t, lexorank.Base62, rank)
+}
+
+// RequireValidIn is like RequireValid, but for ranks written in the
+// given alphabet.
+func RequireValidIn(t testing.TB, a lexorank.Alphabet, rank lexorank.Posn) {
+	t.Helper()
+	require(t, lexorank.ValidCharset(a, rank))
+}
+
+// RequireNoTrailingMin checks that rank doesn't end in the smallest
+// digit of the alphabet, which would leave no room just before it.
+func RequireNoTrailingMin(t testing.TB, a lexorank.Alphabet, rank lexorank.Posn) {
+	t.Helper()
+	require(t, lexorank.NoTrailingMin(a, rank))
+}
+
+func require(t testing.TB, v []lexorank.Violation) {
+	t.Helper()
+	if len(v) > 0 {
+		t.Fatalf("%s", v[0])
+	}
+}
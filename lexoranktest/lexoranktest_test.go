@@ -0,0 +1,74 @@
+package lexoranktest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dkolbly/lexorank"
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder stands in for a *testing.T, noting whether the test was
+// failed rather than stopping it
+type recorder struct {
+	testing.TB
+	failed string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.failed = fmt.Sprintf(format, args...)
+}
+
+func fails(f func(t testing.TB)) bool {
+	r := &recorder{}
+	f(r)
+	return r.failed != ""
+}
+
+func TestRequireBetween(t *testing.T) {
+	prev := lexorank.Posn{Major: "a"}
+	next := lexorank.Posn{Major: "c"}
+	got := lexorank.Posn{Major: "b"}
+	RequireBetween(t, &prev, got, &next)
+	RequireBetween(t, nil, got, nil)
+
+	assert.True(t, fails(func(t testing.TB) { RequireBetween(t, &prev, prev, &next) }))
+	assert.True(t, fails(func(t testing.TB) { RequireBetween(t, &prev, next, &next) }))
+	assert.True(t, fails(func(t testing.TB) { RequireBetween(t, nil, got, &prev) }))
+}
+
+func TestRequireSorted(t *testing.T) {
+	ranks, ok := lexorank.Ranks(10, nil, nil)
+	assert.True(t, ok)
+	RequireSorted(t, ranks)
+
+	ranks[3], ranks[4] = ranks[4], ranks[3]
+	assert.True(t, fails(func(t testing.TB) { RequireSorted(t, ranks) }))
+	dup := []lexorank.Posn{{Major: "a"}, {Major: "a", Minor: ":"}}
+	assert.True(t, fails(func(t testing.TB) { RequireSorted(t, dup) }))
+}
+
+func TestRequireValid(t *testing.T) {
+	RequireValid(t, lexorank.Posn{Bucket: 2, Major: "aZ", Minor: "0"})
+
+	for _, p := range []lexorank.Posn{
+		{Bucket: 3, Major: "a"},
+		{},
+		{Major: "a-b"},
+		{Major: "a", Minor: "_"},
+	} {
+		assert.True(t, fails(func(t testing.TB) { RequireValid(t, p) }), p.String())
+	}
+	assert.True(t, fails(func(t testing.TB) {
+		RequireValidIn(t, lexorank.Base36, lexorank.Posn{Major: "aZ"})
+	}))
+}
+
+func TestRequireNoTrailingMin(t *testing.T) {
+	RequireNoTrailingMin(t, lexorank.Base62, lexorank.Posn{Major: "a0", Minor: "1"})
+	assert.True(t, fails(func(t testing.TB) {
+		RequireNoTrailingMin(t, lexorank.Base62, lexorank.Posn{Major: "a", Minor: "0"})
+	}))
+}
@@ -0,0 +1,146 @@
+// Package lexorankv2 is a preview of the API proposed for v2 of
+// lexorank, built on the current package so it can be tried out (and
+// argued about) before anything is broken.
+//
+// It is centred on Rank, an immutable value backed by the rank's text,
+// which can only be made by parsing or generating, so a Rank is always
+// valid: there are no fields to set to something that isn't a rank,
+// and no need to check ranks that are passed around.  lexorank.Posn
+// stays, as the form to take ranks apart in (and the one that Jira's
+// ranks parse into), and converting between the two is cheap.
+//
+// Ranks are made with the default generator; a v2 Generator would
+// hand out Ranks in the same way.
+package lexorankv2
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/dkolbly/lexorank"
+)
+
+// A Rank is a position in a list, as text like "0|hzzzzz:i".  The
+// zero Rank isn't a position, but stands for an open end of the list
+// where one is wanted, as for Between.
+type Rank struct {
+	// s is always what lexorank.Posn.String gives for a valid
+	// position, or empty
+	s string
+}
+
+// ErrZero is returned when a Rank is needed but the zero Rank is
+// given.
+var ErrZero = errors.New("lexorankv2: zero rank")
+
+// Parse reads a rank in the form String writes it.
+func Parse(s string) (Rank, error) {
+	p, err := lexorank.Parse(s)
+	if err != nil {
+		return Rank{}, err
+	}
+	return Rank{p.String()}, nil
+}
+
+// FromPosn returns p as a Rank, if it's valid.
+func FromPosn(p lexorank.Posn) (Rank, error) {
+	return Parse(p.String())
+}
+
+// Between returns a rank strictly between prev and next, either of
+// which may be zero for an open end of the list; with both zero, it
+// returns a rank to start a list with.  It fails if prev doesn't come
+// before next, or there's no room between them.
+func Between(prev, next Rank) (Rank, error) {
+	var bounds [2]*lexorank.Posn
+	for i, r := range []Rank{prev, next} {
+		if !r.IsZero() {
+			p := r.Posn()
+			bounds[i] = &p
+		}
+	}
+	if bounds[0] != nil && bounds[1] != nil {
+		if err := lexorank.CheckBounds(*bounds[0], *bounds[1]); err != nil {
+			return Rank{}, err
+		}
+	}
+	ranks, ok := lexorank.Ranks(1, bounds[0], bounds[1])
+	if !ok {
+		return Rank{}, lexorank.ErrNoRoom
+	}
+	return Rank{ranks[0].String()}, nil
+}
+
+// Between returns a rank strictly between r and next, as the
+// package-level Between does.
+func (r Rank) Between(next Rank) (Rank, error) {
+	return Between(r, next)
+}
+
+// Next returns a rank after r, with nothing in between them, for
+// adding to the end of a list.  (Unlike lexorank.Posn.Next, it leaves
+// room after r, rather than being the very next rank.)
+func (r Rank) Next() (Rank, error) {
+	if r.IsZero() {
+		return Rank{}, ErrZero
+	}
+	return Between(r, Rank{})
+}
+
+// Prev returns a rank before r, for adding to the start of a list.
+func (r Rank) Prev() (Rank, error) {
+	if r.IsZero() {
+		return Rank{}, ErrZero
+	}
+	return Between(Rank{}, r)
+}
+
+// Compare returns -1, 0 or +1 depending on whether r sorts before, at
+// the same place as, or after s, as lexorank.Posn.Compare does.  The
+// zero Rank sorts before everything else.
+func (r Rank) Compare(s Rank) int {
+	if r.IsZero() || s.IsZero() {
+		return strings.Compare(r.s, s.s)
+	}
+	return r.Posn().Compare(s.Posn())
+}
+
+// IsZero reports whether r is the zero Rank.
+func (r Rank) IsZero() bool {
+	return r.s == ""
+}
+
+// String returns the rank's text, or "" for the zero Rank.
+func (r Rank) String() string {
+	return r.s
+}
+
+// Posn returns the rank taken apart, or the zero Posn for the zero
+// Rank.
+func (r Rank) Posn() lexorank.Posn {
+	if r.IsZero() {
+		return lexorank.Posn{}
+	}
+	i := strings.IndexByte(r.s, ':')
+	return lexorank.Posn{Bucket: r.s[0] - '0', Major: r.s[2:i], Minor: r.s[i+1:]}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (r Rank) MarshalText() ([]byte, error) {
+	return []byte(r.s), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, with the same
+// checks as Parse; empty text is the zero Rank.
+func (r *Rank) UnmarshalText(b []byte) error {
+	if len(b) == 0 {
+		*r = Rank{}
+		return nil
+	}
+	q, err := Parse(string(b))
+	if err != nil {
+		return err
+	}
+	*r = q
+	return nil
+}
@@ -0,0 +1,71 @@
+package lexorankv2
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/dkolbly/lexorank"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	r, err := Parse("1|hzzzzz:i")
+	assert.NoError(t, err)
+	assert.Equal(t, "1|hzzzzz:i", r.String())
+	assert.Equal(t, lexorank.Posn{Bucket: 1, Major: "hzzzzz", Minor: "i"}, r.Posn())
+
+	// written the one way, however it was given
+	r, err = Parse("0|abc")
+	assert.NoError(t, err)
+	assert.Equal(t, "0|abc:", r.String())
+
+	for _, s := range []string{"", "nope", "3|a:", "0|a-b:"} {
+		_, err = Parse(s)
+		assert.Error(t, err, s)
+	}
+
+	r, err = FromPosn(lexorank.Posn{Major: "a", Minor: ":b"})
+	assert.NoError(t, err)
+	assert.Equal(t, "0|a:b", r.String())
+	_, err = FromPosn(lexorank.Posn{Major: "a b"})
+	assert.Error(t, err)
+}
+
+func TestBetween(t *testing.T) {
+	first, err := Between(Rank{}, Rank{})
+	assert.NoError(t, err)
+	next, err := first.Next()
+	assert.NoError(t, err)
+	prev, err := first.Prev()
+	assert.NoError(t, err)
+	mid, err := first.Between(next)
+	assert.NoError(t, err)
+	for _, pair := range [][2]Rank{{prev, first}, {first, mid}, {mid, next}} {
+		assert.Equal(t, -1, pair[0].Compare(pair[1]))
+		assert.Equal(t, 1, pair[1].Compare(pair[0]))
+	}
+	assert.Equal(t, 0, mid.Compare(mid))
+
+	_, err = next.Between(first)
+	assert.ErrorIs(t, err, lexorank.ErrInvertedBounds)
+	_, err = Rank{}.Next()
+	assert.ErrorIs(t, err, ErrZero)
+	_, err = Rank{}.Prev()
+	assert.ErrorIs(t, err, ErrZero)
+	assert.Equal(t, -1, Rank{}.Compare(first))
+	assert.Equal(t, lexorank.Posn{}, Rank{}.Posn())
+}
+
+func TestJSON(t *testing.T) {
+	var v struct {
+		A, B Rank
+	}
+	assert.NoError(t, json.Unmarshal([]byte(`{"A": "0|a:", "B": ""}`), &v))
+	assert.Equal(t, "0|a:", v.A.String())
+	assert.True(t, v.B.IsZero())
+	b, err := json.Marshal(v)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"A": "0|a:", "B": ""}`, string(b))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"A": "nope"}`), &v))
+}
@@ -0,0 +1,112 @@
+package lexorank
+
+import (
+	"context"
+	"errors"
+	"strconv"
+)
+
+// RebalanceIfNeeded looks over a list in s, asks pol what to do about
+// it, and does the cheapest thing that will do: nothing, re-spreading
+// a window of the list, rebalancing the whole list, or moving it to
+// the next bucket (or finishing a move that was interrupted).  It
+// returns the decision it acted on.  It's meant to be run from a
+// cron job, or as a Scheduler's Rebalance.
+func RebalanceIfNeeded(ctx context.Context, s Store, list string, pol Policy) (Decision, error) {
+	return Generator{}.RebalanceIfNeeded(ctx, s, list, pol)
+}
+
+// RebalanceIfNeeded is like the package-level RebalanceIfNeeded, but
+// uses the generator's configuration.
+func (g Generator) RebalanceIfNeeded(ctx context.Context, s Store, list string, pol Policy) (d Decision, err error) {
+	items, err := s.Items(ctx, list, nil, 0)
+	if err != nil {
+		return Decision{}, err
+	}
+	ranks := make([]Posn, len(items))
+	for i, it := range items {
+		ranks[i] = it.Rank
+	}
+	d = pol.DecideWith(g, ranks)
+
+	switch d.Action {
+	case NoAction:
+		return d, nil
+	case RebalanceLocal:
+		ups, ok := g.RebalanceWindow(ranks, d.Center, d.Radius)
+		if !ok {
+			// no room in the window, so there's nothing for it but
+			// to do the lot
+			d.Action = RebalanceFull
+			break
+		}
+		var (
+			changed []Item
+			old     []Posn
+		)
+		for _, u := range ups {
+			if !items[u.Index].Rank.Equal(u.Rank) {
+				changed = append(changed, Item{ID: items[u.Index].ID, Rank: u.Rank})
+				old = append(old, items[u.Index].Rank)
+			}
+		}
+		if len(changed) == 0 {
+			return d, nil
+		}
+		if g.Metrics != nil {
+			g.Metrics.RebalanceTriggered()
+		}
+		if err := s.Update(ctx, list, changed); err != nil {
+			return d, err
+		}
+		g.audit(ctx, list, AuditRebalance, old, changed)
+		return d, nil
+	case MigrateBucket:
+		return d, g.migrateStore(ctx, s, list, items, ranks)
+	}
+	return d, g.RebalanceStore(ctx, s, list)
+}
+
+// migrateStore moves a list to the next bucket, a batch at a time
+func (g Generator) migrateStore(ctx context.Context, s Store, list string, items []Item, ranks []Posn) error {
+	if !g.BucketUsage(ranks).Migrating {
+		job := RebalanceJob{Store: s, List: list, Generator: g, Buckets: g.Buckets}
+		return job.Run(ctx)
+	}
+
+	// a migration was started but not finished, and there's no
+	// checkpoint to resume it from, so plan the rest from scratch
+	plan, ok := g.PlanMigration(ranks)
+	if !ok {
+		return newError("list " + strconv.Quote(list) + " can't be migrated")
+	}
+	if g.Buckets != nil {
+		// whatever started it should have moved the active bucket on
+		// already, but make sure
+		err := g.Buckets.SetActiveBucket(ctx, list, plan.From, plan.To)
+		if errors.Is(err, ErrBucketChanged) {
+			var b byte
+			if b, err = g.Buckets.ActiveBucket(ctx, list); err == nil && b != plan.To {
+				err = ErrMigrating
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for len(plan.Updates) > 0 {
+		n := min(len(plan.Updates), 1000)
+		batch := make([]Item, n)
+		old := make([]Posn, n)
+		for k, u := range plan.Updates[:n] {
+			batch[k] = Item{ID: items[u.Index].ID, Rank: u.Rank}
+			old[k] = items[u.Index].Rank
+		}
+		if err := s.Update(ctx, list, batch); err != nil {
+			return err
+		}
+		g.audit(ctx, list, AuditMigrate, old, batch)
+		plan.Updates = plan.Updates[n:]
+	}
+	return nil
+}
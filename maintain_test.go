@@ -0,0 +1,141 @@
+package lexorank
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRebalanceIfNeeded(t *testing.T) {
+	ctx := context.Background()
+	m := &MemStore{}
+	ranks, err := Rebalance(200, 0)
+	assert.NoError(t, err)
+	for i, p := range ranks {
+		m.Put("a", Item{strconv.Itoa(i), p})
+	}
+
+	d, err := RebalanceIfNeeded(ctx, m, "a", DefaultPolicy)
+	assert.NoError(t, err)
+	assert.Equal(t, NoAction, d.Action)
+
+	// one long key only needs a window re-spread
+	long := ranks[100]
+	long.Major = ranks[99].Major + strings.Repeat("z", 40)
+	m.Put("a", Item{"100", long})
+	d, err = RebalanceIfNeeded(ctx, m, "a", DefaultPolicy)
+	assert.NoError(t, err)
+	assert.Equal(t, RebalanceLocal, d.Action)
+	assertStoreOrder(t, m.List("a"), 200, 0)
+	d, err = RebalanceIfNeeded(ctx, m, "a", DefaultPolicy)
+	assert.NoError(t, err)
+	assert.Equal(t, NoAction, d.Action)
+
+	// trouble at both ends means a full rebalance, or a migration for
+	// a big list
+	items := m.List("a")
+	for _, i := range []int{10, 190} {
+		items[i].Rank.Major += strings.Repeat("z", 40)
+		m.Put("a", items[i])
+	}
+	counts := &MetricsCounters{}
+	d, err = Generator{Metrics: counts}.RebalanceIfNeeded(ctx, m, "a", DefaultPolicy)
+	assert.NoError(t, err)
+	assert.Equal(t, RebalanceFull, d.Action)
+	assert.Equal(t, int64(1), counts.Rebalances)
+	assertStoreOrder(t, m.List("a"), 200, 0)
+
+	items = m.List("a")
+	for _, i := range []int{10, 190} {
+		items[i].Rank.Major += strings.Repeat("z", 40)
+		m.Put("a", items[i])
+	}
+	pol := DefaultPolicy
+	pol.MaxPerBucket = 100
+	d, err = RebalanceIfNeeded(ctx, m, "a", pol)
+	assert.NoError(t, err)
+	assert.Equal(t, MigrateBucket, d.Action)
+	assertStoreOrder(t, m.List("a"), 200, 1)
+}
+
+func TestRebalanceIfNeededResume(t *testing.T) {
+	ctx := context.Background()
+	m := &MemStore{}
+	ranks, err := Rebalance(50, 0)
+	assert.NoError(t, err)
+	plan, ok := PlanMigration(ranks)
+	assert.True(t, ok)
+	// half the list got moved before the migration was interrupted
+	for _, u := range plan.Updates[:25] {
+		ranks[u.Index] = u.Rank
+	}
+	for i, p := range ranks {
+		m.Put("a", Item{strconv.Itoa(i), p})
+	}
+
+	d, err := RebalanceIfNeeded(ctx, m, "a", DefaultPolicy)
+	assert.NoError(t, err)
+	assert.Equal(t, MigrateBucket, d.Action)
+	assertStoreOrder(t, m.List("a"), 50, 1)
+}
+
+func TestRebalanceIfNeededBuckets(t *testing.T) {
+	ctx := context.Background()
+	m := &MemStore{}
+	ranks, err := Rebalance(50, 0)
+	assert.NoError(t, err)
+	for i, p := range ranks {
+		if i == 10 || i == 40 {
+			p.Major += strings.Repeat("z", 40)
+		}
+		m.Put("a", Item{strconv.Itoa(i), p})
+	}
+	var bs MemBuckets
+	pol := DefaultPolicy
+	pol.MaxPerBucket = 10
+	pol.MaxWindow = 4
+
+	// a migration moves the active bucket on
+	d, err := Generator{Buckets: &bs}.RebalanceIfNeeded(ctx, m, "a", pol)
+	assert.NoError(t, err)
+	assert.Equal(t, MigrateBucket, d.Action)
+	assertStoreOrder(t, m.List("a"), 50, 1)
+	b, err := bs.ActiveBucket(ctx, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, byte(1), b)
+
+	// as does finishing one that was interrupted
+	ranks, err = Rebalance(50, 1)
+	assert.NoError(t, err)
+	plan, ok := PlanMigration(ranks)
+	assert.True(t, ok)
+	for _, u := range plan.Updates[:25] {
+		ranks[u.Index] = u.Rank
+	}
+	for i, p := range ranks {
+		m.Put("b", Item{strconv.Itoa(i), p})
+	}
+	assert.NoError(t, bs.SetActiveBucket(ctx, "b", 0, 1))
+	d, err = Generator{Buckets: &bs}.RebalanceIfNeeded(ctx, m, "b", DefaultPolicy)
+	assert.NoError(t, err)
+	assert.Equal(t, MigrateBucket, d.Action)
+	assertStoreOrder(t, m.List("b"), 50, 2)
+	b, err = bs.ActiveBucket(ctx, "b")
+	assert.NoError(t, err)
+	assert.Equal(t, byte(2), b)
+}
+
+// assertStoreOrder checks a list's items are still in their original
+// order (by ID), all in the given bucket
+func assertStoreOrder(t *testing.T, items []Item, n int, bucket byte) {
+	t.Helper()
+	if assert.Len(t, items, n) {
+		for i, it := range items {
+			assert.Equal(t, strconv.Itoa(i), it.ID)
+			assert.Equal(t, bucket, it.Rank.Bucket)
+		}
+	}
+}
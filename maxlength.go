@@ -0,0 +1,99 @@
+package lexorank
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+)
+
+// ErrMaxLength is returned (wrapped in a *MaxLengthError) when a new
+// rank would need more digits than the generator's MaxLength allows.
+var ErrMaxLength = errors.New("lexorank: rank would exceed the maximum length")
+
+// A MaxLengthError reports that there was no room for a rank within
+// the maximum length, along with a suggested window of the list to
+// rebalance to make room: the items list[Lo:Hi] (which may be empty)
+// together with the new one, re-spread between list[Lo-1] and
+// list[Hi], will fit comfortably.  If even the whole list won't fit,
+// the window covers all of it.
+type MaxLengthError struct {
+	Length int
+	Lo, Hi int
+}
+
+func (e *MaxLengthError) Error() string {
+	return ErrMaxLength.Error() + " of " + strconv.Itoa(e.Length) + "; rebalance items " +
+		strconv.Itoa(e.Lo) + " to " + strconv.Itoa(e.Hi)
+}
+
+func (e *MaxLengthError) Unwrap() error {
+	return ErrMaxLength
+}
+
+// RankAt returns a rank for a new item inserted at index i of list,
+// which holds the ranks of a list in order (i.e., between list[i-1]
+// and list[i], with the ends being open).  If the generator has a
+// MaxLength and the rank would be longer, the error is a
+// *MaxLengthError (which matches ErrMaxLength with errors.Is).
+func (g Generator) RankAt(list []string, i int) (string, error) {
+	if i < 0 || i > len(list) {
+		return "", newError("index " + strconv.Itoa(i) + " out of range")
+	}
+	prev, next := "", ""
+	if i > 0 {
+		prev = list[i-1]
+	}
+	if i < len(list) {
+		next = list[i]
+	}
+	if a := g.alphabet(); prev != "" && next != "" && a.valid(prev) && a.valid(next) &&
+		a.canonical(prev) >= a.canonical(next) {
+		return "", &BoundsError{Prev: prev, Next: next}
+	}
+	if r, ok := g.Rank(prev, next); ok {
+		return r, nil
+	}
+
+	// find out whether it failed for lack of length, rather than
+	// something being wrong with the bounds
+	unlimited := g.quiet()
+	unlimited.MaxLength = 0
+	r, ok := unlimited.Rank(prev, next)
+	if !ok || !g.tooLong(len(r)) {
+		return "", newError("no room between " + strconv.Quote(prev) + " and " + strconv.Quote(next))
+	}
+	lo, hi := g.rebalanceWindow(list, i)
+	return "", &MaxLengthError{Length: g.MaxLength, Lo: lo, Hi: hi}
+}
+
+// rebalanceWindow widens the window list[lo:hi] around an insertion
+// at i until there is room within g.MaxLength for its items plus the
+// new one at least twice over
+func (g Generator) rebalanceWindow(list []string, i int) (lo, hi int) {
+	a := g.alphabet()
+	lo, hi = i, i
+	for {
+		prev, next := string(a.min()), string(a.max())
+		if lo > 0 {
+			prev = list[lo-1]
+		}
+		if hi < len(list) {
+			next = list[hi]
+		}
+		if a.valid(prev) && a.valid(next) {
+			room := capacity(a, a.canonical(prev), a.canonical(next), g.MaxLength)
+			if room.Cmp(big.NewInt(int64(2*(hi-lo+1)))) >= 0 {
+				return lo, hi
+			}
+		}
+		if lo == 0 && hi == len(list) {
+			return lo, hi
+		}
+		if lo > 0 {
+			lo--
+		}
+		if hi < len(list) {
+			hi++
+		}
+	}
+}
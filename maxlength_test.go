@@ -0,0 +1,62 @@
+package lexorank
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxLength(t *testing.T) {
+	g := Generator{MaxLength: 2}
+	rank, ok := g.Rank("a", "b")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "aU", rank)
+
+	_, ok = g.Rank("a0", "a1")
+	assert.Equal(t, false, ok)
+
+	_, ok = g.AppendRank(nil, []byte("a0"), []byte("a1"))
+	assert.Equal(t, false, ok)
+}
+
+func TestMaxLengthReserve(t *testing.T) {
+	g := Generator{MaxLength: 2, Reserve: 100}
+	rank, ok := g.Rank("a", "b")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "aU", rank)
+}
+
+func TestRankAt(t *testing.T) {
+	g := Generator{MaxLength: 2}
+	list := []string{"a", "a0", "a1", "a2", "b", "c"}
+	rank, err := g.RankAt(list, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, "bU", rank)
+
+	_, err = g.RankAt(list, 2)
+	assert.True(t, errors.Is(err, ErrMaxLength))
+	var lerr *MaxLengthError
+	assert.True(t, errors.As(err, &lerr))
+	assert.Equal(t, 2, lerr.Length)
+	assert.True(t, lerr.Lo < 2 && lerr.Hi > 2, lerr)
+
+	// re-spreading the window makes room
+	n := lerr.Hi - lerr.Lo + 1
+	prev, next := "", ""
+	if lerr.Lo > 0 {
+		prev = list[lerr.Lo-1]
+	}
+	if lerr.Hi < len(list) {
+		next = list[lerr.Hi]
+	}
+	spread, ok := g.spread(nil, prev, next, n)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, n, len(spread))
+
+	_, err = g.RankAt(list, 7)
+	assert.Error(t, err)
+	_, err = g.RankAt([]string{"b", "a"}, 1)
+	assert.True(t, errors.Is(err, ErrInvertedBounds))
+	assert.False(t, errors.Is(err, ErrMaxLength))
+}
@@ -0,0 +1,57 @@
+package lexorank
+
+import "sort"
+
+// ResolveConflicts sorts out a list in which concurrent writers have
+// given several items the same rank, as happens when replicas that
+// were edited offline are merged.  ranks holds the ranks of the
+// list's items (in any order), and tiebreak orders items with equal
+// ranks (by their IDs, say): it reports whether item i should come
+// before item j.  In each group of equal ranks, the item that comes
+// first keeps its rank and the rest get new ones between it and the
+// next rank up, in tiebreak order.  The result depends only on the
+// ranks and tiebreak, not on the order of ranks, so every replica
+// that resolves the same merged list makes the same updates.
+//
+// A group that there's no room to spread out is left as it is.
+func ResolveConflicts(ranks []Posn, tiebreak func(i, j int) bool) []Update {
+	return Generator{}.ResolveConflicts(ranks, tiebreak)
+}
+
+// ResolveConflicts is like the package-level ResolveConflicts, but
+// uses the generator's configuration.
+func (g Generator) ResolveConflicts(ranks []Posn, tiebreak func(i, j int) bool) []Update {
+	order := make([]int, len(ranks))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(x, y int) bool {
+		i, j := order[x], order[y]
+		if c := ranks[i].Compare(ranks[j]); c != 0 {
+			return c < 0
+		}
+		return tiebreak(i, j)
+	})
+
+	var out []Update
+	for start := 0; start < len(order); {
+		end := start + 1
+		for end < len(order) && ranks[order[end]].Equal(ranks[order[start]]) {
+			end++
+		}
+		if end-start > 1 {
+			var next *Posn
+			if end < len(order) {
+				next = &ranks[order[end]]
+			}
+			fresh, ok := g.quiet().AllocateBlock(&ranks[order[start]], next, end-start-1)
+			if ok {
+				for k, p := range fresh {
+					out = append(out, Update{Index: order[start+1+k], Rank: p})
+				}
+			}
+		}
+		start = end
+	}
+	return out
+}
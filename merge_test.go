@@ -0,0 +1,45 @@
+package lexorank
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveConflicts(t *testing.T) {
+	type item struct {
+		id   string
+		rank Posn
+	}
+	a, b, c := Posn{Major: "a"}, Posn{Major: "b"}, Posn{Major: "c"}
+	items := []item{{"x", b}, {"p", a}, {"z", b}, {"y", b}, {"q", c}, {"r", c}}
+
+	resolve := func(items []item) map[string]Posn {
+		ranks := make([]Posn, len(items))
+		for i, it := range items {
+			ranks[i] = it.rank
+		}
+		out := map[string]Posn{}
+		for _, u := range ResolveConflicts(ranks, func(i, j int) bool {
+			return items[i].id < items[j].id
+		}) {
+			out[items[u.Index].id] = u.Rank
+		}
+		return out
+	}
+
+	got := resolve(items)
+	assert.Len(t, got, 3)
+	assert.True(t, b.Compare(got["y"]) < 0)
+	assert.True(t, got["y"].Compare(got["z"]) < 0)
+	assert.True(t, got["z"].Compare(c) < 0)
+	assert.True(t, c.Compare(got["r"]) < 0)
+
+	// another replica with the items in a different order comes to
+	// the same conclusion
+	slices.Reverse(items)
+	assert.Equal(t, got, resolve(items))
+
+	assert.Empty(t, ResolveConflicts([]Posn{a, b, c}, func(i, j int) bool { return i < j }))
+}
@@ -0,0 +1,108 @@
+package lexorank
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+// Metrics receives statistics about key-space health from a
+// Generator, for wiring into whatever telemetry system is at hand.
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	// RanksGenerated counts ranks handed out.
+	RanksGenerated(n int)
+
+	// KeyGrowth is called when a new rank is longer than both of the
+	// ranks it was put between, with how many digits longer it is.
+	KeyGrowth(digits int)
+
+	// Gap reports the smallest gap, as a fraction of the keyspace,
+	// left next to a batch of newly generated ranks.
+	Gap(fraction float64)
+
+	// RebalanceTriggered counts rebalances: each call of Rebalance or
+	// RebalanceParallel (in any of their forms, including the ones
+	// under RebalanceStore), and each local rebalance done by
+	// RebalanceIfNeeded.
+	RebalanceTriggered()
+}
+
+// KeyLengths can be implemented by Metrics that also want the length
+// (in digits) of each rank generated, to see how long keys are getting
+// rather than just how fast they grow.
+type KeyLengths interface {
+	KeyLength(digits int)
+}
+
+// observe reports a batch of generated ranks (as digit strings) to
+// the generator's metrics
+func (g Generator) observe(prev, next string, out []string) {
+	m := g.Metrics
+	a := g.alphabet()
+	m.RanksGenerated(len(out))
+
+	bound := max(len(prev), len(next))
+	lengths, _ := m.(KeyLengths)
+	longest := 0
+	for _, s := range out {
+		longest = max(longest, len(s))
+		if lengths != nil {
+			lengths.KeyLength(len(s))
+		}
+	}
+	if longest > bound {
+		m.KeyGrowth(longest - bound)
+	}
+
+	gap := math.Inf(1)
+	last := a.fraction(prev)
+	for _, s := range append(out, next) {
+		f := a.fraction(s)
+		gap = math.Min(gap, f-last)
+		last = f
+	}
+	m.Gap(gap)
+}
+
+// MetricsCounters is a simple Metrics implementation that just keeps
+// totals, for tests or for exporting by hand.  The zero value is
+// ready to use.
+type MetricsCounters struct {
+	Generated  int64 // ranks generated
+	Grown      int64 // digits of key-length growth
+	Rebalances int64 // rebalances triggered
+
+	mu       sync.Mutex
+	smallest float64
+	observed bool
+}
+
+func (c *MetricsCounters) RanksGenerated(n int) {
+	atomic.AddInt64(&c.Generated, int64(n))
+}
+
+func (c *MetricsCounters) KeyGrowth(digits int) {
+	atomic.AddInt64(&c.Grown, int64(digits))
+}
+
+func (c *MetricsCounters) RebalanceTriggered() {
+	atomic.AddInt64(&c.Rebalances, 1)
+}
+
+func (c *MetricsCounters) Gap(fraction float64) {
+	c.mu.Lock()
+	if !c.observed || fraction < c.smallest {
+		c.smallest = fraction
+		c.observed = true
+	}
+	c.mu.Unlock()
+}
+
+// SmallestGap returns the smallest gap observed so far, and false if
+// nothing has been observed yet.
+func (c *MetricsCounters) SmallestGap() (float64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.smallest, c.observed
+}
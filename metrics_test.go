@@ -0,0 +1,67 @@
+package lexorank
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsRanks(t *testing.T) {
+	m := &MetricsCounters{}
+	g := Generator{Metrics: m}
+	_, ok := g.Ranks(3, nil, nil)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, int64(3), atomic.LoadInt64(&m.Generated))
+	assert.Equal(t, int64(0), atomic.LoadInt64(&m.Grown))
+
+	gap, ok := m.SmallestGap()
+	assert.Equal(t, true, ok)
+	assert.InDelta(t, 0.24, gap, 0.02)
+}
+
+func TestMetricsRank(t *testing.T) {
+	m := &MetricsCounters{}
+	g := Generator{Metrics: m}
+	_, ok := g.Rank("aaaa", "aaab")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, int64(1), atomic.LoadInt64(&m.Generated))
+	assert.Equal(t, int64(1), atomic.LoadInt64(&m.Grown))
+
+	_, ok = g.Rank("a", "a0")
+	assert.Equal(t, false, ok)
+	assert.Equal(t, int64(1), atomic.LoadInt64(&m.Generated))
+}
+
+func TestMetricsRebalance(t *testing.T) {
+	m := &MetricsCounters{}
+	g := Generator{Metrics: m}
+	_, err := g.Rebalance(10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), atomic.LoadInt64(&m.Rebalances))
+	assert.NoError(t, g.RebalanceParallel(10, 0, 2, func(int, Posn) error { return nil }))
+	assert.Equal(t, int64(2), atomic.LoadInt64(&m.Rebalances))
+}
+
+func TestMetricsNone(t *testing.T) {
+	m := &MetricsCounters{}
+	_, ok := m.SmallestGap()
+	assert.Equal(t, false, ok)
+}
+
+type lengthMetrics struct {
+	MetricsCounters
+	lengths []int
+}
+
+func (m *lengthMetrics) KeyLength(digits int) {
+	m.lengths = append(m.lengths, digits)
+}
+
+func TestMetricsKeyLength(t *testing.T) {
+	m := &lengthMetrics{}
+	g := Generator{Metrics: m}
+	_, ok := g.Rank("aaaa", "aaab")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, []int{5}, m.lengths)
+}
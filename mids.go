@@ -0,0 +1,65 @@
+package lexorank
+
+import "strconv"
+
+// Mids returns k digits, in order, evenly spaced strictly between the
+// digits lo and hi.  It's the step at the heart of generating ranks,
+// for building keys of your own.  It fails with ErrNoRoom if there
+// aren't k digits between lo and hi.
+func (a Alphabet) Mids(k int, lo, hi byte) ([]byte, error) {
+	a = a.orDefault()
+	for _, b := range []byte{lo, hi} {
+		if a.values[b] < 0 {
+			return nil, invalidDigit(b, "")
+		}
+	}
+	if a.order(lo) >= a.order(hi) {
+		return nil, &BoundsError{Prev: string(lo), Next: string(hi)}
+	}
+	out, ok := a.mids(Uniform, k, lo, hi)
+	if !ok {
+		return nil, &wrapError{ErrNoRoom.Error() + " for " + strconv.Itoa(k) + " digits between " +
+			quoteByte(lo) + " and " + quoteByte(hi), ErrNoRoom}
+	}
+	return out, nil
+}
+
+// MidStrings is like Mids, but for strings of digits: it returns k
+// strings, in order, evenly spread between lo and hi, each as short as
+// it can be.  Either bound may be empty, for the start or end of the
+// keyspace.
+func (a Alphabet) MidStrings(k int, lo, hi string) ([]string, error) {
+	a = a.orDefault()
+	for _, s := range []string{lo, hi} {
+		for i := 0; i < len(s); i++ {
+			if a.values[s[i]] < 0 {
+				return nil, invalidDigit(s[i], s)
+			}
+		}
+	}
+	if lo != "" && hi != "" && a.canonical(lo) >= a.canonical(hi) {
+		return nil, &BoundsError{Prev: lo, Next: hi}
+	}
+	out, ok := Generator{Alphabet: a}.spread(make([]string, 0, k), lo, hi, k)
+	if !ok {
+		return nil, &wrapError{ErrNoRoom.Error() + " for " + strconv.Itoa(k) + " strings between " +
+			strconv.Quote(lo) + " and " + strconv.Quote(hi), ErrNoRoom}
+	}
+	return out, nil
+}
+
+// mids returns n digits between prev and next, which must be valid,
+// spaced as s says
+func (a Alphabet) mids(s Spacing, n int, prev, next byte) ([]byte, bool) {
+	prevo := a.order(prev)
+	nexto := a.order(next)
+	offsets, ok := s.offsets(n, nexto-prevo)
+	if !ok {
+		return nil, false
+	}
+	ch := make([]byte, n)
+	for i, off := range offsets {
+		ch[i] = a.digit(prevo + off)
+	}
+	return ch, true
+}
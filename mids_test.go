@@ -0,0 +1,53 @@
+package lexorank
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMids(t *testing.T) {
+	out, err := Base62.Mids(3, '0', '8')
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("246"), out)
+
+	out, err = Base36.Mids(1, 'a', 'c')
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("b"), out)
+
+	out, err = Alphabet{}.Mids(0, '0', '1')
+	assert.NoError(t, err)
+	assert.Empty(t, out)
+
+	_, err = Base62.Mids(1, '0', '1')
+	assert.ErrorIs(t, err, ErrNoRoom)
+	_, err = Base62.Mids(1, '5', '1')
+	assert.ErrorIs(t, err, ErrInvertedBounds)
+	_, err = Base36.Mids(1, '0', 'Z')
+	assert.ErrorIs(t, err, ErrInvalidDigit)
+}
+
+func TestMidStrings(t *testing.T) {
+	out, err := Base62.MidStrings(5, "a", "b")
+	assert.NoError(t, err)
+	assert.Len(t, out, 5)
+	assert.True(t, slices.IsSorted(out))
+	assert.Less(t, "a", out[0])
+	assert.Less(t, out[4], "b")
+	for _, s := range out {
+		assert.Len(t, s, 2)
+	}
+
+	out, err = Base36.MidStrings(2, "", "")
+	assert.NoError(t, err)
+	assert.True(t, slices.IsSorted(out))
+	assert.True(t, Base36.Valid(out[0]+out[1]))
+
+	_, err = Base62.MidStrings(1, "a", "a0")
+	assert.ErrorIs(t, err, ErrNoRoom)
+	_, err = Base62.MidStrings(1, "b", "a")
+	assert.ErrorIs(t, err, ErrInvertedBounds)
+	_, err = Base62.MidStrings(1, "a!", "")
+	assert.ErrorIs(t, err, ErrInvalidDigit)
+}
@@ -0,0 +1,292 @@
+package lexorank
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+)
+
+// MoveBlock gives new ranks to a block of items that are being moved
+// together to between prev and next, as when dragging a multi-card
+// selection on a board.  The result holds the new rank for each item
+// of block, in the same order, so that the items keep their order
+// relative to each other.  The new ranks are spread through the gap
+// by repeatedly splitting it, which keeps them about as short as
+// they can be.  A nil prev or next means the start or end of the
+// list.
+func MoveBlock(block []Posn, prev, next *Posn) ([]Posn, bool) {
+	return Generator{}.MoveBlock(block, prev, next)
+}
+
+// MoveBlock is like the package-level MoveBlock, but uses the
+// generator's configuration.
+func (g Generator) MoveBlock(block []Posn, prev, next *Posn) ([]Posn, bool) {
+	shape := Posn{Major: "000000"}
+	if len(block) > 0 {
+		shape = block[0]
+	}
+	ranks, ok := g.block(prev, next, len(block), shape)
+	if !ok {
+		return nil, false
+	}
+
+	// hand out the new ranks according to where each item was in the
+	// block, in case it wasn't given in order
+	order := make([]int, len(block))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return block[order[i]].Compare(block[order[j]]) < 0
+	})
+	out := make([]Posn, len(block))
+	for i, j := range order {
+		out[j] = ranks[i]
+	}
+	return out, true
+}
+
+// AllocateBlock returns k new ranks, in order, between prev and next
+// (either of which may be nil, for the start or end of the list), all
+// worked out at once.  This lets an importer stamp a batch of rows
+// without asking for ranks one at a time.  Unlike Ranks, there is no
+// limit on k; the ranks are spread through the gap as for MoveBlock.
+func AllocateBlock(prev, next *Posn, k int) ([]Posn, bool) {
+	return Generator{}.AllocateBlock(prev, next, k)
+}
+
+// AllocateBlock is like the package-level AllocateBlock, but uses the
+// generator's configuration.
+func (g Generator) AllocateBlock(prev, next *Posn, k int) ([]Posn, bool) {
+	return g.block(prev, next, k, Posn{Major: "000000"})
+}
+
+// block spreads k ranks between prev and next, shaped like the bounds
+// (or like shape, if there aren't any)
+func (g Generator) block(prev, next *Posn, k int, shape Posn) ([]Posn, bool) {
+	out := make([]Posn, 0, k)
+	err := g.ranksFunc(k, prev, next, shape, func(i int, p Posn) error {
+		out = append(out, p)
+		return nil
+	})
+	return out, err == nil
+}
+
+// spread appends n ranks between lo and hi (which may be empty, as
+// for Rank) to dst in increasing order, placing each one in the
+// middle of what's left so that none of them grows longer than it has
+// to
+func (g Generator) spread(dst []string, lo, hi string, n int) ([]string, bool) {
+	err := g.spreadFunc(lo, hi, n, func(r string) error {
+		dst = append(dst, r)
+		return nil
+	})
+	return dst, err == nil
+}
+
+// noRoom reports whether there's nothing at all between x and y,
+// because y is x followed by nothing but the smallest digit
+func noRoom(a Alphabet, x, y string) bool {
+	if len(y) <= len(x) || y[:len(x)] != x {
+		return false
+	}
+	for i := len(x); i < len(y); i++ {
+		if y[i] != a.min() {
+			return false
+		}
+	}
+	return true
+}
+
+// ErrNoRoom is returned when there isn't room between two ranks for
+// the ranks wanted, short of rebalancing.
+var ErrNoRoom = errors.New("lexorank: no room")
+
+// spreadFunc is like spread, but passes the ranks to emit one at a
+// time instead of collecting them, stopping if emit returns an error
+func (g Generator) spreadFunc(lo, hi string, n int, emit func(string) error) error {
+	if n == 0 {
+		return nil
+	}
+	mid, ok := g.Rank(lo, hi)
+	if !ok {
+		return ErrNoRoom
+	}
+	left := (n - 1) / 2
+
+	// make sure there's room on both sides of mid for the ranks that
+	// will go there, which there isn't if it's only the smallest
+	// digit that tells them apart
+	a := g.alphabet()
+	for tries := 0; tries < 8; tries++ {
+		var alt string
+		switch {
+		case n-1-left > 0 && noRoom(a, mid, hi):
+			alt, ok = g.Rank(lo, mid)
+		case left > 0 && noRoom(a, lo, mid):
+			alt, ok = g.Rank(mid, hi)
+		default:
+			ok = false
+		}
+		if !ok {
+			break
+		}
+		mid = alt
+	}
+	if err := g.spreadFunc(lo, mid, left, emit); err != nil {
+		return err
+	}
+	if err := emit(mid); err != nil {
+		return err
+	}
+	return g.spreadFunc(mid, hi, n-1-left, emit)
+}
+
+// An Update is a new rank for the item at Index in a list.
+type Update struct {
+	Index int
+	Rank  Posn
+}
+
+// MoveSelection moves an arbitrary selection of items, not
+// necessarily next to each other, so that they end up together at a
+// drop position, in the same order relative to each other as before.
+// list holds the ranks of the whole list in order, selected holds the
+// indexes into it of the items being moved, and to is the index in
+// list of the item they are being dropped in front of (len(list)
+// meaning the end).  Items that aren't selected keep their ranks; the
+// result has an update for each selected item, in list order.
+func MoveSelection(list []Posn, selected []int, to int) ([]Update, bool) {
+	return Generator{}.MoveSelection(list, selected, to)
+}
+
+// MoveSelection is like the package-level MoveSelection, but uses the
+// generator's configuration.
+func (g Generator) MoveSelection(list []Posn, selected []int, to int) ([]Update, bool) {
+	if to < 0 || to > len(list) {
+		return nil, false
+	}
+	moving := make([]bool, len(list))
+	for _, i := range selected {
+		if i < 0 || i >= len(list) {
+			return nil, false
+		}
+		moving[i] = true
+	}
+
+	// the new neighbours are the closest items on either side of the
+	// drop position that are staying put
+	var prev, next *Posn
+	for i := to - 1; i >= 0; i-- {
+		if !moving[i] {
+			prev = &list[i]
+			break
+		}
+	}
+	for i := to; i < len(list); i++ {
+		if !moving[i] {
+			next = &list[i]
+			break
+		}
+	}
+
+	var indexes []int
+	var block []Posn
+	for i, m := range moving {
+		if m {
+			indexes = append(indexes, i)
+			block = append(block, list[i])
+		}
+	}
+	ranks, ok := g.MoveBlock(block, prev, next)
+	if !ok {
+		return nil, false
+	}
+	out := make([]Update, len(ranks))
+	for k, r := range ranks {
+		out[k] = Update{Index: indexes[k], Rank: r}
+	}
+	return out, true
+}
+
+// MoveToIndex works out the new rank for a drag and drop: the item at
+// from in a list is dropped so that it ends up at index to, counting
+// as if it had already been taken out of the list (which is how most
+// drag and drop libraries report the drop).  ranks holds the ranks of
+// the whole list in order.  If there's no room between the item's new
+// neighbours, some of the items around them are re-spread to make
+// room, and their new ranks come back as updates (with indexes into
+// ranks); otherwise only the moved item changes.  Moving an item to
+// where it already is changes nothing.  It fails with ErrNoRoom if
+// even re-spreading the whole list wouldn't make room.
+func MoveToIndex(ranks []Posn, from, to int) (Posn, []Update, error) {
+	return Generator{}.MoveToIndex(ranks, from, to)
+}
+
+// MoveToIndex is like the package-level MoveToIndex, but uses the
+// generator's configuration.  With a MaxLength, a gap that only has
+// room for longer keys counts as having none.
+func (g Generator) MoveToIndex(ranks []Posn, from, to int) (Posn, []Update, error) {
+	for _, i := range []int{from, to} {
+		if i < 0 || i >= len(ranks) {
+			return Posn{}, nil, newError("index " + strconv.Itoa(i) + " out of range")
+		}
+	}
+	if from == to {
+		return ranks[from], nil, nil
+	}
+
+	// the rest of the list, which the item goes into at to
+	rest := make([]Posn, 0, len(ranks)-1)
+	rest = append(append(rest, ranks[:from]...), ranks[from+1:]...)
+	index := func(k int) int {
+		if k >= from {
+			return k + 1
+		}
+		return k
+	}
+
+	var prev, next *Posn
+	if to > 0 {
+		prev = &rest[to-1]
+	}
+	if to < len(rest) {
+		next = &rest[to]
+	}
+	if r, ok := g.Ranks(1, prev, next); ok && !g.tooLong(len(r[0].digits())) {
+		return r[0], nil, nil
+	}
+
+	// re-spread ever wider windows of the rest of the list around the
+	// drop, with a place for the item in them
+	for radius := 4; ; radius *= 2 {
+		lo, hi := max(to-radius, 0), min(to+radius, len(rest))
+		prev, next = nil, nil
+		if lo > 0 {
+			prev = &rest[lo-1]
+		}
+		if hi < len(rest) {
+			next = &rest[hi]
+		}
+		fresh, ok := g.AllocateBlock(prev, next, hi-lo+1)
+		for _, r := range fresh {
+			ok = ok && !g.tooLong(len(r.digits()))
+		}
+		if ok {
+			var ups []Update
+			for k := lo; k < hi; k++ {
+				r := fresh[k-lo]
+				if k >= to {
+					r = fresh[k-lo+1]
+				}
+				if !rest[k].Equal(r) {
+					ups = append(ups, Update{Index: index(k), Rank: r})
+				}
+			}
+			return fresh[to-lo], ups, nil
+		}
+		if lo == 0 && hi == len(rest) {
+			return Posn{}, nil, ErrNoRoom
+		}
+	}
+}
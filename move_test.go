@@ -0,0 +1,206 @@
+package lexorank
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoveBlock(t *testing.T) {
+	block := []Posn{
+		{Major: "a00000"},
+		{Major: "a00001"},
+		{Major: "a00002"},
+	}
+	prev := Posn{Major: "m00000"}
+	next := Posn{Major: "m00001"}
+	out, ok := MoveBlock(block, &prev, &next)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 3, len(out))
+	last := prev
+	for _, p := range out {
+		assert.Equal(t, 1, p.Compare(last))
+		assert.Equal(t, "m00000", p.Major)
+		assert.Equal(t, 1, len(p.Minor))
+		last = p
+	}
+	assert.Equal(t, -1, last.Compare(next))
+}
+
+func TestMoveBlockUnordered(t *testing.T) {
+	block := []Posn{
+		{Major: "c"},
+		{Major: "a"},
+		{Major: "b"},
+	}
+	out, ok := MoveBlock(block, nil, nil)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 1, out[0].Compare(out[2]))
+	assert.Equal(t, 1, out[2].Compare(out[1]))
+}
+
+func TestMoveBlockMajors(t *testing.T) {
+	// majors of different lengths, whose digits run together sort
+	// the other way round
+	cases := [][2]Posn{
+		{{Major: "a", Minor: "y"}, {Major: "a0"}},
+		{{Major: "a"}, {Major: "a0"}},
+		{{Major: "ab"}, {Major: "b"}},
+		{{Major: "a0"}, {Major: "b", Minor: "0"}},
+	}
+	block := []Posn{{Major: "x"}, {Major: "y"}, {Major: "z"}, {Major: "zz"}}
+	for _, c := range cases {
+		out, ok := MoveBlock(block, &c[0], &c[1])
+		assert.Equal(t, true, ok, c)
+		last := c[0]
+		for _, p := range out {
+			assert.Equal(t, 1, p.Compare(last), p)
+			last = p
+		}
+		assert.Equal(t, -1, last.Compare(c[1]), c)
+	}
+}
+
+func TestMoveBlockNoRoom(t *testing.T) {
+	prev := Posn{Major: "b"}
+	next := Posn{Major: "a"}
+	_, ok := MoveBlock([]Posn{{Major: "c"}}, &prev, &next)
+	assert.Equal(t, false, ok)
+}
+
+func TestMoveSelection(t *testing.T) {
+	list := []Posn{
+		{Major: "a"},
+		{Major: "c"},
+		{Major: "e"},
+		{Major: "g"},
+		{Major: "i"},
+	}
+	// move the first and fourth items to between "e" and "i"
+	up, ok := MoveSelection(list, []int{3, 0}, 4)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 2, len(up))
+	assert.Equal(t, 0, up[0].Index)
+	assert.Equal(t, 3, up[1].Index)
+
+	for _, u := range up {
+		list[u.Index] = u.Rank
+	}
+	assert.Equal(t, 1, list[0].Compare(list[2]))
+	assert.Equal(t, 1, list[3].Compare(list[0]))
+	assert.Equal(t, -1, list[3].Compare(list[4]))
+}
+
+func TestMoveSelectionToEnds(t *testing.T) {
+	list := []Posn{{Major: "b"}, {Major: "d"}, {Major: "f"}}
+	up, ok := MoveSelection(list, []int{2}, 0)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, -1, up[0].Rank.Compare(list[0]))
+
+	up, ok = MoveSelection(list, []int{0}, 3)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 1, up[0].Rank.Compare(list[2]))
+
+	_, ok = MoveSelection(list, []int{5}, 0)
+	assert.Equal(t, false, ok)
+	_, ok = MoveSelection(list, []int{0}, 4)
+	assert.Equal(t, false, ok)
+}
+
+func TestAllocateBlock(t *testing.T) {
+	prev := Posn{Major: "a00000"}
+	next := Posn{Major: "a00001"}
+	out, ok := AllocateBlock(&prev, &next, 1000)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 1000, len(out))
+	last := prev
+	for _, p := range out {
+		assert.Equal(t, -1, last.Compare(p))
+		assert.True(t, len(p.Minor) <= 2, p.String())
+		last = p
+	}
+	assert.Equal(t, -1, last.Compare(next))
+
+	out, ok = AllocateBlock(nil, nil, 3)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 3, len(out))
+
+	_, ok = AllocateBlock(&next, &prev, 1)
+	assert.Equal(t, false, ok)
+}
+
+// applyMove returns the list after MoveToIndex's answer is applied,
+// in rank order, as the indexes the items had
+func applyMove(t *testing.T, ranks []Posn, from int, p Posn, ups []Update) []int {
+	t.Helper()
+	moved := slices.Clone(ranks)
+	moved[from] = p
+	for _, u := range ups {
+		assert.NotEqual(t, from, u.Index)
+		moved[u.Index] = u.Rank
+	}
+	order := make([]int, len(ranks))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(i, j int) int { return moved[i].Compare(moved[j]) })
+	for k := 1; k < len(order); k++ {
+		assert.Equal(t, -1, moved[order[k-1]].Compare(moved[order[k]]))
+	}
+	return order
+}
+
+func TestMoveToIndex(t *testing.T) {
+	ranks, err := Rebalance(5, 0)
+	assert.NoError(t, err)
+
+	p, ups, err := MoveToIndex(ranks, 1, 3)
+	assert.NoError(t, err)
+	assert.Empty(t, ups)
+	assert.Equal(t, []int{0, 2, 3, 1, 4}, applyMove(t, ranks, 1, p, ups))
+
+	p, ups, err = MoveToIndex(ranks, 3, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{3, 0, 1, 2, 4}, applyMove(t, ranks, 3, p, ups))
+
+	p, _, err = MoveToIndex(ranks, 0, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3, 4, 0}, applyMove(t, ranks, 0, p, nil))
+
+	p, ups, err = MoveToIndex(ranks, 2, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, ranks[2], p)
+	assert.Empty(t, ups)
+
+	_, _, err = MoveToIndex(ranks, 0, 5)
+	assert.Error(t, err)
+	_, _, err = MoveToIndex(ranks, -1, 0)
+	assert.Error(t, err)
+}
+
+func TestMoveToIndexNoRoom(t *testing.T) {
+	// ranks packed as tight as they go at three digits
+	g := Generator{MaxLength: 3}
+	var ranks []Posn
+	for i := 0; i < 20; i++ {
+		ranks = append(ranks, Posn{Major: "U" + Base62.Encode(uint64(i), 2)})
+	}
+	p, ups, err := g.MoveToIndex(ranks, 0, 10)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ups)
+	want := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 0, 11, 12, 13, 14, 15, 16, 17, 18, 19}
+	assert.Equal(t, want, applyMove(t, ranks, 0, p, ups))
+	assert.LessOrEqual(t, len(p.digits()), 3)
+	for _, u := range ups {
+		assert.LessOrEqual(t, len(u.Rank.digits()), 3)
+	}
+
+	// the whole list is packed solid
+	ranks = ranks[:0]
+	for i := 1; i < 62; i++ {
+		ranks = append(ranks, Posn{Major: Base62.Encode(uint64(i), 1)})
+	}
+	_, _, err = Generator{MaxLength: 1}.MoveToIndex(ranks, 0, 30)
+	assert.ErrorIs(t, err, ErrNoRoom)
+}
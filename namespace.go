@@ -0,0 +1,130 @@
+package lexorank
+
+import (
+	"strconv"
+	"strings"
+)
+
+// NamespaceSep separates a namespace from the rank in a namespaced
+// key.  Namespace names can't contain it.
+const NamespaceSep = '~'
+
+// A Namespace puts the ranks of a list under a prefix, as in
+// "proj42~0|hzzzzz:", for when many lists share one sorted index
+// (such as a single key-value store or a text column with one index
+// over all of it).  Since names can't contain the separator, no
+// namespace's prefix is a prefix of another's, so the keys of
+// different namespaces never interleave: each one has a contiguous
+// run of the index, from Bounds.
+//
+// Within a namespace, keys are compared by rank (so by Compare, not
+// byte-wise, which orders majors of different lengths wrongly).
+type Namespace struct {
+	Name string
+
+	// Generator makes the ranks
+	Generator Generator
+}
+
+// check returns why the namespace's name is no good, if it isn't
+func (ns Namespace) check() error {
+	if ns.Name == "" {
+		return newError("empty namespace")
+	}
+	if strings.IndexByte(ns.Name, NamespaceSep) >= 0 {
+		return newError("namespace " + strconv.Quote(ns.Name) + " contains " + quoteByte(NamespaceSep))
+	}
+	return nil
+}
+
+// prefix returns the name and separator that start the namespace's
+// keys
+func (ns Namespace) prefix() string {
+	return ns.Name + string(NamespaceSep)
+}
+
+// Key returns the key for rank p in the namespace.
+func (ns Namespace) Key(p Posn) (string, error) {
+	if err := ns.check(); err != nil {
+		return "", err
+	}
+	s, err := ns.Generator.Text(p)
+	if err != nil {
+		return "", err
+	}
+	return ns.prefix() + s, nil
+}
+
+// Contains reports whether key is in the namespace.
+func (ns Namespace) Contains(key string) bool {
+	return ns.check() == nil && strings.HasPrefix(key, ns.prefix())
+}
+
+// Parse returns the rank in a key of the namespace.  It fails if the
+// key is in another namespace (or none).
+func (ns Namespace) Parse(key string) (Posn, error) {
+	if err := ns.check(); err != nil {
+		return Posn{}, err
+	}
+	if !strings.HasPrefix(key, ns.prefix()) {
+		return Posn{}, newError("key " + strconv.Quote(key) + " isn't in namespace " + strconv.Quote(ns.Name))
+	}
+	return ns.Generator.Parse(key[len(ns.Name)+1:])
+}
+
+// Compare compares two keys of the namespace by their ranks, as
+// Posn.Compare does.  It fails if either isn't in the namespace, since
+// keys from different namespaces have no order that means anything.
+func (ns Namespace) Compare(a, b string) (int, error) {
+	p, err := ns.Parse(a)
+	if err != nil {
+		return 0, err
+	}
+	q, err := ns.Parse(b)
+	if err != nil {
+		return 0, err
+	}
+	return p.Compare(q), nil
+}
+
+// Between returns a key of the namespace between the keys prev and
+// next, which must both be in it; an empty key is open ended.  It
+// fails with ErrNoRoom if there's no room between them.
+func (ns Namespace) Between(prev, next string) (string, error) {
+	var bounds [2]*Posn
+	for i, k := range []string{prev, next} {
+		if k == "" {
+			continue
+		}
+		p, err := ns.Parse(k)
+		if err != nil {
+			return "", err
+		}
+		bounds[i] = &p
+	}
+	if err := ns.check(); err != nil {
+		return "", err
+	}
+	r, ok := ns.Generator.Ranks(1, bounds[0], bounds[1])
+	if !ok {
+		return "", ErrNoRoom
+	}
+	return ns.Key(r[0])
+}
+
+// Bounds returns the range of the sorted index that the namespace's
+// keys are all in, lower inclusive and upper exclusive, for range
+// scans.
+func (ns Namespace) Bounds() (lower, upper string) {
+	return ns.prefix(), ns.Name + string(NamespaceSep+1)
+}
+
+// SplitNamespace splits a namespaced key into the name of its
+// namespace and the rank, without parsing the rank.
+func SplitNamespace(key string) (name, rank string, err error) {
+	i := strings.IndexByte(key, NamespaceSep)
+	if i <= 0 {
+		return "", "", newError("key " + strconv.Quote(key) + " has no namespace")
+	}
+	return key[:i], key[i+1:], nil
+}
@@ -0,0 +1,89 @@
+package lexorank
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespace(t *testing.T) {
+	ns := Namespace{Name: "proj42"}
+	k, err := ns.Key(Posn{Major: "hzzzzz"})
+	assert.NoError(t, err)
+	assert.Equal(t, "proj42~0|hzzzzz:", k)
+	assert.True(t, ns.Contains(k))
+	p, err := ns.Parse(k)
+	assert.NoError(t, err)
+	assert.Equal(t, Posn{Major: "hzzzzz"}, p)
+
+	a, err := ns.Between("", k)
+	assert.NoError(t, err)
+	b, err := ns.Between(a, k)
+	assert.NoError(t, err)
+	c, err := ns.Between(k, "")
+	assert.NoError(t, err)
+	for _, pair := range [][2]string{{a, b}, {b, k}, {k, c}} {
+		cmp, err := ns.Compare(pair[0], pair[1])
+		assert.NoError(t, err)
+		assert.Equal(t, -1, cmp)
+	}
+
+	name, rank, err := SplitNamespace(k)
+	assert.NoError(t, err)
+	assert.Equal(t, "proj42", name)
+	assert.Equal(t, "0|hzzzzz:", rank)
+	_, _, err = SplitNamespace("0|hzzzzz:")
+	assert.Error(t, err)
+
+	// keys from other namespaces don't mix
+	other := Namespace{Name: "proj4"}
+	ok, err := other.Key(Posn{Major: "a"})
+	assert.NoError(t, err)
+	assert.False(t, ns.Contains(ok))
+	_, err = ns.Parse(ok)
+	assert.Error(t, err)
+	_, err = ns.Compare(k, ok)
+	assert.Error(t, err)
+	_, err = ns.Between(ok, "")
+	assert.Error(t, err)
+
+	for _, bad := range []string{"", "a~b"} {
+		_, err = Namespace{Name: bad}.Key(p)
+		assert.Error(t, err)
+		assert.False(t, Namespace{Name: bad}.Contains(bad+"~0|a:"))
+	}
+}
+
+func TestNamespacesDontInterleave(t *testing.T) {
+	// names that are prefixes of each other, and ranks of different
+	// lengths, which are the likeliest to mix
+	names := []string{"p", "p4", "p42", "q"}
+	ranks := []Posn{{Major: "0"}, {Major: "zzzzzz", Minor: "zz"}, {Major: "a"}, {Bucket: 2, Major: "a"}}
+	var keys []string
+	for _, n := range names {
+		for _, p := range ranks {
+			k, err := Namespace{Name: n}.Key(p)
+			assert.NoError(t, err)
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	for i, n := range names {
+		ns := Namespace{Name: n}
+		lo, hi := ns.Bounds()
+		var in []string
+		for _, k := range keys {
+			if ns.Contains(k) {
+				in = append(in, k)
+				assert.True(t, lo <= k && k < hi, k)
+			} else {
+				assert.False(t, lo <= k && k < hi, k)
+			}
+		}
+		assert.Len(t, in, len(ranks), names[i])
+		// the namespace's keys are a contiguous run
+		first := slices.Index(keys, in[0])
+		assert.Equal(t, in, keys[first:first+len(in)])
+	}
+}
@@ -0,0 +1,26 @@
+package lexorank
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Normalize maps the acceptable variants of a Jira rank to its
+// canonical form, so that ranks ingested from different upstream
+// systems can be compared as plain strings:
+//
+//   - Jira ranks are base36, so upper case letters are lowered
+//   - trailing zeros in the minor are trimmed; they are padding that
+//     some systems add, which would otherwise make the same position
+//     look different
+//   - a missing minor is written as an empty one
+//
+// Anything that still doesn't parse is an error.
+func Normalize(s string) (string, error) {
+	p, ok := ParseJira(strings.ToLower(s))
+	if !ok {
+		return "", newError("invalid rank " + strconv.Quote(s))
+	}
+	p.Minor = strings.TrimRight(p.MinorValue(), "0")
+	return p.String(), nil
+}
@@ -0,0 +1,30 @@
+package lexorank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"0|hzzzzz:":    "0|hzzzzz:",
+		"0|hzzzzz":     "0|hzzzzz:",
+		"0|HZZZZZ:I":   "0|hzzzzz:i",
+		"1|hzzzzz:i00": "1|hzzzzz:i",
+		"1|hzzzzz:000": "1|hzzzzz:",
+		"2|a0000:":     "2|a0000:",
+	}
+	for in, want := range cases {
+		got, err := Normalize(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+}
+
+func TestNormalizeErrors(t *testing.T) {
+	for _, in := range []string{"", "3|abc:", "0|ab-c", "0|abc:de:f"} {
+		_, err := Normalize(in)
+		assert.Error(t, err, in)
+	}
+}
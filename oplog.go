@@ -0,0 +1,192 @@
+package lexorank
+
+import (
+	"encoding/binary"
+	"errors"
+	"strconv"
+)
+
+// An OpKind is the kind of a rank operation.
+type OpKind byte
+
+const (
+	OpInsert OpKind = iota + 1
+	OpMove
+	OpRebalance
+	OpDelete
+)
+
+func (k OpKind) String() string {
+	switch k {
+	case OpInsert:
+		return "insert"
+	case OpMove:
+		return "move"
+	case OpRebalance:
+		return "rebalance"
+	case OpDelete:
+		return "delete"
+	default:
+		return "unknown"
+	}
+}
+
+// An Entry gives the rank of the item with the given ID.
+type Entry struct {
+	ID   string
+	Rank Posn
+}
+
+// An Op is a change to the order of a list, for event-sourced systems
+// that persist ordering changes and replay them later.  It records
+// the ranks that resulted rather than how they were worked out, so
+// that replaying is deterministic even if the way ranks are generated
+// changes in the meantime.  An insert, move or delete has one entry
+// (for a delete, the rank the item had); a rebalance has one for each
+// item it changed.
+type Op struct {
+	Kind    OpKind
+	Entries []Entry
+}
+
+// InsertOp records inserting a new item with the given rank.
+func InsertOp(id string, rank Posn) Op {
+	return Op{Kind: OpInsert, Entries: []Entry{{id, rank}}}
+}
+
+// MoveOp records an existing item moving to the given rank.
+func MoveOp(id string, rank Posn) Op {
+	return Op{Kind: OpMove, Entries: []Entry{{id, rank}}}
+}
+
+// DeleteOp records removing the item, which had the given rank.
+func DeleteOp(id string, rank Posn) Op {
+	return Op{Kind: OpDelete, Entries: []Entry{{id, rank}}}
+}
+
+// RebalanceOp records a batch of existing items getting new ranks.
+func RebalanceOp(entries []Entry) Op {
+	return Op{Kind: OpRebalance, Entries: entries}
+}
+
+// Apply applies the operation to a list, given as a map from item ID
+// to rank.  It fails, leaving the list alone, if the operation
+// doesn't make sense for it: inserting an item that is already there,
+// or moving (or deleting) one that isn't.
+func (op Op) Apply(list map[string]Posn) error {
+	if err := op.check(list); err != nil {
+		return err
+	}
+	for _, e := range op.Entries {
+		if op.Kind == OpDelete {
+			delete(list, e.ID)
+		} else {
+			list[e.ID] = e.Rank
+		}
+	}
+	return nil
+}
+
+// check returns why op can't be applied to list, if it can't
+func (op Op) check(list map[string]Posn) error {
+	switch op.Kind {
+	case OpInsert:
+		if len(op.Entries) != 1 {
+			return newError("insert op with " + strconv.Itoa(len(op.Entries)) + " entries")
+		}
+		if _, ok := list[op.Entries[0].ID]; ok {
+			return newError("insert of " + strconv.Quote(op.Entries[0].ID) + ", which is already there")
+		}
+	case OpMove, OpRebalance, OpDelete:
+		if op.Kind != OpRebalance && len(op.Entries) != 1 {
+			return newError(op.Kind.String() + " op with " + strconv.Itoa(len(op.Entries)) + " entries")
+		}
+		for _, e := range op.Entries {
+			if _, ok := list[e.ID]; !ok {
+				return newError(op.Kind.String() + " of " + strconv.Quote(e.ID) + ", which isn't there")
+			}
+		}
+	default:
+		return newError("unknown op kind " + strconv.Itoa(int(op.Kind)))
+	}
+	return nil
+}
+
+// opVersion is the first byte of an encoded op, so that the encoding
+// can change later
+const opVersion = 1
+
+// MarshalBinary encodes the operation compactly.
+func (op Op) MarshalBinary() ([]byte, error) {
+	return op.AppendBinary(nil)
+}
+
+// AppendBinary appends the encoded operation to b.
+func (op Op) AppendBinary(b []byte) ([]byte, error) {
+	b = append(b, opVersion, byte(op.Kind))
+	b = binary.AppendUvarint(b, uint64(len(op.Entries)))
+	for _, e := range op.Entries {
+		b = appendString(b, e.ID)
+		b = append(b, e.Rank.Bucket)
+		b = appendString(b, e.Rank.Major)
+		b = appendString(b, e.Rank.MinorValue())
+	}
+	return b, nil
+}
+
+var errShortOp = errors.New("lexorank: truncated op")
+
+// UnmarshalBinary decodes an operation encoded by MarshalBinary.
+func (op *Op) UnmarshalBinary(b []byte) error {
+	if len(b) < 2 {
+		return errShortOp
+	}
+	if b[0] != opVersion {
+		return newError("unknown op encoding version " + strconv.Itoa(int(b[0])))
+	}
+	kind := OpKind(b[1])
+	b = b[2:]
+	n, k := binary.Uvarint(b)
+	if k <= 0 || n > uint64(len(b)) {
+		return errShortOp
+	}
+	b = b[k:]
+	entries := make([]Entry, n)
+	for i := range entries {
+		var e Entry
+		var err error
+		if e.ID, b, err = readString(b); err != nil {
+			return err
+		}
+		if len(b) == 0 {
+			return errShortOp
+		}
+		e.Rank.Bucket, b = b[0], b[1:]
+		if e.Rank.Major, b, err = readString(b); err != nil {
+			return err
+		}
+		if e.Rank.Minor, b, err = readString(b); err != nil {
+			return err
+		}
+		entries[i] = e
+	}
+	if len(b) != 0 {
+		return newError(strconv.Itoa(len(b)) + " bytes of junk after op")
+	}
+	*op = Op{Kind: kind, Entries: entries}
+	return nil
+}
+
+func appendString(b []byte, s string) []byte {
+	b = binary.AppendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+func readString(b []byte) (string, []byte, error) {
+	n, k := binary.Uvarint(b)
+	if k <= 0 || n > uint64(len(b)-k) {
+		return "", nil, errShortOp
+	}
+	b = b[k:]
+	return string(b[:n]), b[n:], nil
+}
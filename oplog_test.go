@@ -0,0 +1,82 @@
+package lexorank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpApply(t *testing.T) {
+	list := map[string]Posn{}
+	assert.NoError(t, InsertOp("a", Posn{Major: "a00000"}).Apply(list))
+	assert.NoError(t, InsertOp("b", Posn{Major: "b00000"}).Apply(list))
+	assert.Error(t, InsertOp("a", Posn{Major: "c00000"}).Apply(list))
+
+	assert.NoError(t, MoveOp("a", Posn{Major: "c00000"}).Apply(list))
+	assert.Error(t, MoveOp("x", Posn{Major: "c00000"}).Apply(list))
+	assert.Equal(t, Posn{Major: "c00000"}, list["a"])
+
+	rb := RebalanceOp([]Entry{
+		{"b", Posn{Bucket: 1, Major: "U00000"}},
+		{"a", Posn{Bucket: 1, Major: "k00000"}},
+	})
+	assert.NoError(t, rb.Apply(list))
+	assert.Equal(t, byte(1), list["a"].Bucket)
+
+	// all or nothing
+	rb = RebalanceOp([]Entry{
+		{"b", Posn{Major: "000000"}},
+		{"x", Posn{Major: "100000"}},
+	})
+	assert.Error(t, rb.Apply(list))
+	assert.Equal(t, Posn{Bucket: 1, Major: "U00000"}, list["b"])
+
+	assert.Error(t, Op{Kind: 9}.Apply(list))
+	assert.Equal(t, "rebalance", OpRebalance.String())
+}
+
+func TestOpEncoding(t *testing.T) {
+	ops := []Op{
+		InsertOp("item-1", Posn{Major: "a00000"}),
+		MoveOp("item-1", Posn{Bucket: 2, Major: "a00000", Minor: "x"}),
+		RebalanceOp([]Entry{
+			{"p", Posn{Major: "1"}},
+			{"q", Posn{Major: "2", Minor: ":3"}},
+		}),
+		RebalanceOp([]Entry{}),
+	}
+	for _, op := range ops {
+		b, err := op.MarshalBinary()
+		assert.NoError(t, err)
+		var got Op
+		assert.NoError(t, got.UnmarshalBinary(b))
+		assert.Equal(t, op.Kind, got.Kind)
+		assert.Equal(t, len(op.Entries), len(got.Entries))
+		for i, e := range op.Entries {
+			assert.Equal(t, e.ID, got.Entries[i].ID)
+			assert.True(t, e.Rank.Equal(got.Entries[i].Rank))
+		}
+
+		// every truncation is caught
+		for i := 0; i < len(b); i++ {
+			assert.Error(t, new(Op).UnmarshalBinary(b[:i]), "%x", b[:i])
+		}
+		assert.Error(t, new(Op).UnmarshalBinary(append(b, 0)))
+	}
+
+	assert.Error(t, new(Op).UnmarshalBinary([]byte{2, 1, 0}))
+}
+
+func TestDeleteOp(t *testing.T) {
+	list := map[string]Posn{"x": {Major: "a"}}
+	assert.Error(t, DeleteOp("y", Posn{}).Apply(list))
+	assert.NoError(t, DeleteOp("x", Posn{Major: "a"}).Apply(list))
+	assert.Empty(t, list)
+	assert.Equal(t, "delete", OpDelete.String())
+
+	b, err := DeleteOp("x", Posn{Major: "a"}).MarshalBinary()
+	assert.NoError(t, err)
+	var op Op
+	assert.NoError(t, op.UnmarshalBinary(b))
+	assert.Equal(t, DeleteOp("x", Posn{Major: "a"}), op)
+}
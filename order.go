@@ -0,0 +1,81 @@
+package lexorank
+
+// An Order is the direction a list is shown in, for code that doesn't
+// want to care whether it is dealing with an ordinary list or one
+// that is shown newest-first (see Reversed).  Before, After and
+// Between are in terms of how the list is shown.
+type Order interface {
+	// Before returns a rank for an item shown just before p
+	Before(p Posn) (Posn, bool)
+	// After returns a rank for an item shown just after p
+	After(p Posn) (Posn, bool)
+	// Between returns a rank for an item shown between prev and
+	// next, either of which may be nil for the start or end
+	Between(prev, next *Posn) (Posn, bool)
+	// Compare compares two ranks in the order they are shown
+	Compare(p, q Posn) int
+}
+
+var (
+	_ Order = Generator{}
+	_ Order = Reversed{}
+)
+
+// Before returns a rank that sorts before p, with nothing in between
+// but the start of the list.  (To put an item between p and the item
+// before it, use Between.)
+func (g Generator) Before(p Posn) (Posn, bool) {
+	return g.Between(nil, &p)
+}
+
+// After returns a rank that sorts after p, with nothing in between
+// but the end of the list.
+func (g Generator) After(p Posn) (Posn, bool) {
+	return g.Between(&p, nil)
+}
+
+// Between returns a single rank between prev and next, as Ranks does.
+func (g Generator) Between(prev, next *Posn) (Posn, bool) {
+	out, ok := g.Ranks(1, prev, next)
+	if !ok {
+		return Posn{}, false
+	}
+	return out[0], true
+}
+
+// Compare compares p and q, as p.Compare(q).
+func (g Generator) Compare(p, q Posn) int {
+	return p.Compare(q)
+}
+
+// Reversed is for lists that are shown in descending order of rank,
+// such as newest-first feeds, so that "after" means a smaller rank.
+// It does the inverting, so that call sites can talk about the list
+// the way it is shown.
+type Reversed struct {
+	Generator Generator
+}
+
+// Before returns a rank for an item shown before (that is, sorting
+// after) p.
+func (r Reversed) Before(p Posn) (Posn, bool) {
+	return r.Generator.After(p)
+}
+
+// After returns a rank for an item shown after (that is, sorting
+// before) p.
+func (r Reversed) After(p Posn) (Posn, bool) {
+	return r.Generator.Before(p)
+}
+
+// Between returns a rank for an item shown between prev and next, so
+// that it sorts between next and prev.
+func (r Reversed) Between(prev, next *Posn) (Posn, bool) {
+	return r.Generator.Between(next, prev)
+}
+
+// Compare compares p and q in the order they are shown, i.e. it is
+// q.Compare(p).
+func (r Reversed) Compare(p, q Posn) int {
+	return q.Compare(p)
+}
@@ -0,0 +1,36 @@
+package lexorank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrders(t *testing.T) {
+	for _, o := range []Order{Generator{}, Reversed{}} {
+		p := Posn{Major: "U00000"}
+		before, ok := o.Before(p)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, -1, o.Compare(before, p))
+
+		after, ok := o.After(p)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, 1, o.Compare(after, p))
+
+		mid, ok := o.Between(&before, &p)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, 1, o.Compare(mid, before))
+		assert.Equal(t, -1, o.Compare(mid, p))
+
+		_, ok = o.Between(&p, &before)
+		assert.Equal(t, false, ok)
+	}
+}
+
+func TestReversed(t *testing.T) {
+	p := Posn{Major: "U00000"}
+	after, ok := Reversed{}.After(p)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, -1, after.Compare(p))
+	assert.Equal(t, 1, Reversed{}.Compare(after, p))
+}
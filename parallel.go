@@ -0,0 +1,113 @@
+package lexorank
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// RebalanceParallel is like RebalanceFunc, but for lists big enough
+// that working out the ranks on one core takes too long: the list is
+// cut into chunks whose ranks are computed concurrently by the given
+// number of goroutines (GOMAXPROCS if workers isn't positive).  fn is
+// still called from a single goroutine, in order, and only a few
+// chunks per worker are held in memory at once.
+func RebalanceParallel(n int, bucket byte, workers int, fn func(i int, p Posn) error) error {
+	return Generator{}.RebalanceParallel(n, bucket, workers, fn)
+}
+
+// RebalanceParallelContext is like RebalanceParallel, but gives up
+// with the context's error if it is cancelled (which is checked
+// between chunks).
+func RebalanceParallelContext(ctx context.Context, n int, bucket byte, workers int, fn func(i int, p Posn) error) error {
+	return Generator{}.RebalanceParallelContext(ctx, n, bucket, workers, fn)
+}
+
+// RebalanceParallel is like the package-level RebalanceParallel, but
+// uses the generator's configuration.
+func (g Generator) RebalanceParallel(n int, bucket byte, workers int, fn func(i int, p Posn) error) error {
+	return g.RebalanceParallelContext(context.Background(), n, bucket, workers, fn)
+}
+
+// RebalanceParallelContext is like the package-level
+// RebalanceParallelContext, but uses the generator's configuration.
+func (g Generator) RebalanceParallelContext(ctx context.Context, n int, bucket byte, workers int, fn func(i int, p Posn) error) error {
+	if bucket > MaxBucket {
+		return badBucket(bucket)
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if g.Metrics != nil {
+		g.Metrics.RebalanceTriggered()
+	}
+	ctx, end := g.startSpan(ctx, "RebalanceParallel", n)
+	touched := 0
+	sp := g.rebalanceSpacing(n, bucket)
+	chunks := (n + rebalanceChunk - 1) / rebalanceChunk
+
+	// each chunk's ranks arrive on its own channel, so that they can
+	// be passed on in order however the work gets done; the slots
+	// limit how far ahead of fn the workers get
+	results := make([]chan []Posn, chunks)
+	for c := range results {
+		results[c] = make(chan []Posn, 1)
+	}
+	slots := make(chan struct{}, 2*workers)
+	jobs := make(chan int)
+	done := make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(jobs)
+		for c := 0; c < chunks; c++ {
+			select {
+			case slots <- struct{}{}:
+			case <-done:
+				return
+			}
+			select {
+			case jobs <- c:
+			case <-done:
+				return
+			}
+		}
+	}()
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range jobs {
+				from := c * rebalanceChunk
+				results[c] <- sp.appendRanks(make([]Posn, 0, rebalanceChunk), from, min(from+rebalanceChunk, n))
+			}
+		}()
+	}
+
+	var err error
+	for c := 0; c < chunks && err == nil; c++ {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		var out []Posn
+		select {
+		case out = <-results[c]:
+		case <-ctx.Done():
+			err = ctx.Err()
+			continue
+		}
+		for k, p := range out {
+			if err = fn(c*rebalanceChunk+k, p); err != nil {
+				break
+			}
+			touched++
+		}
+		<-slots
+	}
+	close(done)
+	wg.Wait()
+	end(touched, err)
+	return err
+}
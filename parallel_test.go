@@ -0,0 +1,55 @@
+package lexorank
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRebalanceParallel(t *testing.T) {
+	const n = 50000
+	want, err := Rebalance(n, 1)
+	assert.NoError(t, err)
+
+	got := make([]Posn, 0, n)
+	err = RebalanceParallel(n, 1, 4, func(i int, p Posn) error {
+		assert.Equal(t, len(got), i)
+		got = append(got, p)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestRebalanceParallelStop(t *testing.T) {
+	stop := errors.New("stop")
+	n := 0
+	err := RebalanceParallel(100000, 0, 0, func(i int, p Posn) error {
+		n++
+		if i == 5000 {
+			return stop
+		}
+		return nil
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 5001, n)
+
+	assert.Error(t, RebalanceParallel(1, 3, 1, nil))
+	assert.NoError(t, RebalanceParallel(0, 0, 2, nil))
+}
+
+func TestRebalanceParallelContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	n := 0
+	err := RebalanceParallelContext(ctx, 100000, 0, 2, func(i int, p Posn) error {
+		n++
+		if i == 10 {
+			cancel()
+		}
+		return nil
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, rebalanceChunk, n)
+}
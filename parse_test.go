@@ -0,0 +1,233 @@
+package lexorank
+
+import (
+	"encoding"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+// jiraRank is the grammar ParseJira implements by hand; it is kept
+// here to cross-check the parser
+var jiraRank = regexp.MustCompile(`^([012])\|([0-9a-z]+)(:[0-9a-z]*)?$`)
+
+func parseJiraRegexp(rank string) (Posn, bool) {
+	m := jiraRank.FindStringSubmatch(rank)
+	if m == nil {
+		return Posn{}, false
+	}
+	return Posn{
+		Bucket: m[1][0] - '0',
+		Major:  m[2],
+		Minor:  strings.TrimPrefix(m[3], ":"),
+	}, true
+}
+
+var parseCases = []string{
+	"0|hzzzzz:",
+	"1|hzzzzz:",
+	"2|i00000:",
+	"0|hzzzzz:i",
+	"0|hzzzzz:i0a",
+	"0|hzzzzz",
+	"0|a",
+	"0|a:",
+	"3|hzzzzz:",
+	"0|",
+	"0|:",
+	"0|:a",
+	"0|hzzzzz::",
+	"0|hzzzzz:A",
+	"0|HZZZZZ:",
+	"0hzzzzz:",
+	"0|hzzzzz: ",
+	" 0|hzzzzz:",
+	"|hzzzzz:",
+	"",
+	"0",
+	"0|-",
+	"00|a",
+}
+
+func TestParseJiraMatchesRegexp(t *testing.T) {
+	for _, s := range parseCases {
+		want, wantOK := parseJiraRegexp(s)
+		got, ok := ParseJira(s)
+		assert.Equal(t, wantOK, ok, s)
+		assert.Equal(t, want, got, s)
+	}
+}
+
+func TestParseJiraRoundTrip(t *testing.T) {
+	p, ok := ParseJira("1|hzzzzz:i")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, Posn{Bucket: 1, Major: "hzzzzz", Minor: "i"}, p)
+	assert.Equal(t, "1|hzzzzz:i", p.String())
+}
+
+func TestParseJiraAllocs(t *testing.T) {
+	allocs := testing.AllocsPerRun(100, func() {
+		ParseJira("0|hzzzzz:i")
+	})
+	assert.Equal(t, 0.0, allocs)
+}
+
+func BenchmarkParseJira(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		ParseJira("0|hzzzzz:i")
+	}
+}
+
+func BenchmarkParseJiraRegexp(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		parseJiraRegexp("0|hzzzzz:i")
+	}
+}
+
+func TestAppendText(t *testing.T) {
+	buf := []byte("rank=")
+	buf, err := Posn{Bucket: 2, Major: "hzzzzz", Minor: "i"}.AppendText(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "rank=2|hzzzzz:i", string(buf))
+
+	p := Posn{Bucket: 1, Major: "a"}
+	allocs := testing.AllocsPerRun(100, func() {
+		buf, _ = p.AppendText(buf[:0])
+	})
+	assert.Equal(t, 0.0, allocs)
+}
+
+func TestNewPosn(t *testing.T) {
+	p, err := NewPosn(1, "hzzzzz", "i")
+	assert.NoError(t, err)
+	assert.Equal(t, Posn{Bucket: 1, Major: "hzzzzz", Minor: "i"}, p)
+
+	p, err = NewPosn(0, "hzzzzz", ":i")
+	assert.NoError(t, err)
+	assert.Equal(t, "i", p.Minor)
+
+	for _, c := range []struct {
+		bucket       byte
+		major, minor string
+	}{
+		{3, "hzzzzz", ""},
+		{0, "", ""},
+		{0, "HZZZZZ", ""},
+		{0, "hzz-zz", ""},
+		{0, "hzzzzz", "i:j"},
+		{0, "hzzzzz", "::i"},
+	} {
+		_, err := NewPosn(c.bucket, c.major, c.minor)
+		assert.Error(t, err, "%v", c)
+	}
+}
+
+func TestNewPosnParses(t *testing.T) {
+	p, err := NewPosn(2, "a0", "")
+	assert.NoError(t, err)
+	q, ok := ParseJira(p.String())
+	assert.Equal(t, true, ok)
+	assert.Equal(t, p, q)
+}
+
+func TestParse(t *testing.T) {
+	for _, p := range []Posn{
+		{Major: "aZ09"},
+		{Bucket: 2, Major: "U", Minor: "zz"},
+	} {
+		got, err := Parse(p.String())
+		assert.NoError(t, err)
+		assert.Equal(t, p, got)
+	}
+	got, err := Parse("1|abc")
+	assert.NoError(t, err)
+	assert.Equal(t, Posn{Bucket: 1, Major: "abc"}, got)
+
+	for _, s := range []string{"", "0|", "0|:a", "3|abc:", "0abc", "0|a-b:", "0|ab:c:d", "0|abc::x", "0|abc::"} {
+		_, err := Parse(s)
+		assert.Error(t, err, s)
+	}
+	_, err = Generator{Alphabet: Base36}.Parse("0|aZ:")
+	assert.Error(t, err)
+
+	// the separator is checked against the alphabet
+	colon, err := NewAlphabet("0123456789:")
+	assert.NoError(t, err)
+	_, err = Generator{Alphabet: colon}.Parse("0|12:")
+	assert.Error(t, err)
+
+	// and so is a second one, whatever it is
+	_, err = Generator{Profile: Profile{Separator: '~'}}.Parse("0|abc~~x")
+	assert.Error(t, err)
+}
+
+func TestParseAllocs(t *testing.T) {
+	allocs := testing.AllocsPerRun(100, func() {
+		Parse("0|hzzzzz:i")
+	})
+	assert.Equal(t, 0.0, allocs)
+}
+
+func BenchmarkParse(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Parse("0|hzzzzz:i")
+	}
+}
+
+func TestTextMarshaling(t *testing.T) {
+	var _ encoding.TextMarshaler = Posn{}
+	var _ encoding.TextUnmarshaler = &Posn{}
+
+	p := Posn{Bucket: 1, Major: "aZ", Minor: ":U"}
+	b, err := p.MarshalText()
+	assert.NoError(t, err)
+	assert.Equal(t, "1|aZ:U", string(b))
+
+	var q Posn
+	assert.NoError(t, q.UnmarshalText(b))
+	assert.Equal(t, p.Canonical(), q)
+
+	assert.Error(t, q.UnmarshalText([]byte("1|a-z")))
+	assert.Equal(t, p.Canonical(), q)
+}
+
+func TestJSON(t *testing.T) {
+	type row struct {
+		Rank Posn
+	}
+	b, err := json.Marshal(row{Posn{Bucket: 1, Major: "aZ", Minor: "U"}})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"Rank":"1|aZ:U"}`, string(b))
+
+	var r row
+	assert.NoError(t, json.Unmarshal(b, &r))
+	assert.Equal(t, Posn{Bucket: 1, Major: "aZ", Minor: "U"}, r.Rank)
+
+	// JSON written before Posn was a TextMarshaler still reads
+	r = row{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"Rank":{"Bucket":2,"Major":"abc","Minor":":x"}}`), &r))
+	assert.Equal(t, Posn{Bucket: 2, Major: "abc", Minor: ":x"}, r.Rank)
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"Rank":null}`), &r))
+	assert.Equal(t, Posn{Bucket: 2, Major: "abc", Minor: ":x"}, r.Rank)
+	assert.Error(t, json.Unmarshal([]byte(`{"Rank":"1|a-z"}`), &r))
+	assert.Error(t, json.Unmarshal([]byte(`{"Rank":{"Bucket":"x"}}`), &r))
+}
+
+func TestYAML(t *testing.T) {
+	type fixture struct {
+		Rank Posn `yaml:"rank"`
+	}
+	b, err := yaml.Marshal(fixture{Posn{Major: "aZ"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "rank: '0|aZ:'\n", string(b))
+
+	var f fixture
+	assert.NoError(t, yaml.Unmarshal(b, &f))
+	assert.Equal(t, Posn{Major: "aZ"}, f.Rank)
+	assert.Error(t, yaml.Unmarshal([]byte("rank: 0|a-Z\n"), &f))
+}
@@ -0,0 +1,242 @@
+package lexorank
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+)
+
+// ErrOutsidePartition is returned when ranks are asked for in a
+// partition between neighbours that are both on one side of it.
+var ErrOutsidePartition = errors.New("lexorank: bounds outside partition")
+
+// Partitions carve the keyspace of a list into named, disjoint parts,
+// in order, such as "imported", "user" and "system", so that ranks
+// handed out in one part always sort before those in the next,
+// however the list is edited, without checking anything item by item.
+// Within each bucket, a part is the ranks whose majors lie between
+// its boundaries, and ranks for a part are only ever made there.
+//
+// That only holds while ranks come from the Partitions: Rebalance,
+// RebalanceStore and RebalanceJob spread a list over the whole
+// keyspace, with no idea of its parts, so a partitioned list has to
+// be rebalanced with Partitions.Rebalance or RebalanceStore instead.
+type Partitions struct {
+	// Bucket is the bucket ranks go in when the list is empty (a
+	// rank's neighbours decide otherwise)
+	Bucket byte
+
+	// Generator makes the ranks; it has to use the alphabet of the
+	// one the partitions were made with
+	Generator Generator
+
+	parts []Partition
+}
+
+// A Partition is one part of a list's keyspace: the ranks whose
+// majors are at least Lo and less than Hi ("" for the start or end of
+// the keyspace).
+type Partition struct {
+	Name   string
+	Lo, Hi string
+}
+
+// NewPartitions carves the keyspace into parts with the given names,
+// in order, each an equal share of it.  The names must be unique.
+func NewPartitions(names ...string) (Partitions, error) {
+	return Generator{}.NewPartitions(names...)
+}
+
+// NewPartitions is like the package-level NewPartitions, but carves
+// up the generator's alphabet, and the partitions make their ranks
+// with the generator.
+func (g Generator) NewPartitions(names ...string) (Partitions, error) {
+	if len(names) == 0 {
+		return Partitions{}, newError("no partitions")
+	}
+	for i, name := range names {
+		if slices.Index(names[:i], name) >= 0 {
+			return Partitions{}, newError("partition " + strconv.Quote(name) + " is repeated")
+		}
+	}
+	a := g.alphabet()
+	cuts, err := g.quiet().EvenSplit(Posn{Major: string(a.min())}, Posn{Major: string(a.max())}, len(names)-1)
+	if err != nil {
+		return Partitions{}, err
+	}
+	ps := Partitions{Generator: g, parts: make([]Partition, len(names))}
+	for i, name := range names {
+		ps.parts[i].Name = name
+		if i > 0 {
+			ps.parts[i].Lo = cuts[i-1].Major
+		}
+		if i < len(cuts) {
+			ps.parts[i].Hi = cuts[i].Major
+		}
+	}
+	return ps, nil
+}
+
+// All returns the partitions, in order.
+func (ps Partitions) All() []Partition {
+	return slices.Clone(ps.parts)
+}
+
+// Of returns the name of the partition p is in.
+func (ps Partitions) Of(p Posn) (string, bool) {
+	for _, pt := range ps.parts {
+		if pt.Contains(p) {
+			return pt.Name, true
+		}
+	}
+	return "", false
+}
+
+// Contains reports whether p is in the partition.
+func (pt Partition) Contains(p Posn) bool {
+	return (pt.Lo == "" || p.Compare(Posn{Bucket: p.Bucket, Major: pt.Lo}) >= 0) &&
+		(pt.Hi == "" || p.Compare(Posn{Bucket: p.Bucket, Major: pt.Hi}) < 0)
+}
+
+// Ranks returns n ranks in the named partition between prev and next,
+// either of which may be nil, as for Ranks.  Neighbours in earlier or
+// later partitions are treated as the edges of this one, so the first
+// item of a partition can go after the last of the one before it.  It
+// fails with ErrOutsidePartition if prev is after the partition or
+// next before it, or ErrNoRoom if there's no room.
+func (ps Partitions) Ranks(name string, n int, prev, next *Posn) ([]Posn, error) {
+	i := slices.IndexFunc(ps.parts, func(pt Partition) bool { return pt.Name == name })
+	if i < 0 {
+		return nil, newError("no partition " + strconv.Quote(name))
+	}
+	pt := ps.parts[i]
+	if prev != nil && next != nil && prev.Bucket != next.Bucket {
+		// in the middle of a migration, the ranks go in the bucket
+		// being moved into, so the partition is carved out of that
+		var ok bool
+		if prev, next, ok = ps.Generator.crossBucket(*prev, *next); !ok {
+			return nil, ErrNoRoom
+		}
+	}
+	bucket := ps.Bucket
+	switch {
+	case prev != nil:
+		bucket = prev.Bucket
+	case next != nil:
+		bucket = next.Bucket
+	}
+
+	lo, hi := prev, next
+	if pt.Lo != "" {
+		edge := Posn{Bucket: bucket, Major: pt.Lo}
+		if next != nil && next.Compare(edge) <= 0 {
+			return nil, ErrOutsidePartition
+		}
+		if lo == nil || lo.Compare(edge) < 0 {
+			lo = &edge
+		}
+	}
+	if pt.Hi != "" {
+		edge := Posn{Bucket: bucket, Major: pt.Hi}
+		if prev != nil && prev.Compare(edge) >= 0 {
+			return nil, ErrOutsidePartition
+		}
+		if hi == nil || hi.Compare(edge) > 0 {
+			hi = &edge
+		}
+	}
+	if lo != nil && hi != nil {
+		if err := CheckBounds(*lo, *hi); err != nil {
+			return nil, err
+		}
+	}
+	ranks, ok := ps.Generator.Ranks(n, lo, hi)
+	if !ok {
+		return nil, ErrNoRoom
+	}
+	if lo == nil && hi == nil {
+		for k := range ranks {
+			ranks[k].Bucket = bucket
+		}
+	}
+	return ranks, nil
+}
+
+// Rebalance returns fresh ranks for a list with the given ranks, in
+// order, as the package-level Rebalance does, but with each item kept
+// in its partition: the items of each part are spread evenly over
+// that part alone.  The new ranks are in the bucket of the first.
+func (ps Partitions) Rebalance(ranks []Posn) ([]Posn, error) {
+	if len(ranks) == 0 {
+		return nil, nil
+	}
+	a := ps.Generator.alphabet()
+	bucket := ranks[0].Bucket
+	out := make([]Posn, 0, len(ranks))
+	for _, pt := range ps.parts {
+		k := 0
+		for _, p := range ranks[len(out):] {
+			if !pt.Contains(p) {
+				break
+			}
+			k++
+		}
+		if k == 0 {
+			continue
+		}
+		lo, hi := Posn{Bucket: bucket, Major: pt.Lo}, Posn{Bucket: bucket, Major: pt.Hi}
+		if lo.Major == "" {
+			lo.Major = string(a.min())
+		}
+		if hi.Major == "" {
+			hi.Major = string(a.max())
+		}
+		spread, err := ps.Generator.quiet().EvenSplit(lo, hi, k)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, spread...)
+	}
+	if len(out) < len(ranks) {
+		// what's left is out of order, or in another bucket
+		return nil, newError("rank " + ranks[len(out)].String() + " is out of order with its partitions")
+	}
+	return out, nil
+}
+
+// RebalanceStore is like the package-level RebalanceStore, but
+// rebalances each partition of the list within its own part of the
+// keyspace, as Rebalance does.
+func (ps Partitions) RebalanceStore(ctx context.Context, s Store, list string) error {
+	items, err := s.Items(ctx, list, nil, 0)
+	if err != nil || len(items) == 0 {
+		return err
+	}
+	ranks := make([]Posn, len(items))
+	for i, it := range items {
+		ranks[i] = it.Rank
+	}
+	fresh, err := ps.Rebalance(ranks)
+	if err != nil {
+		return err
+	}
+	var (
+		updates []Item
+		old     []Posn
+	)
+	for i, p := range fresh {
+		if !items[i].Rank.Equal(p) {
+			updates = append(updates, Item{ID: items[i].ID, Rank: p})
+			old = append(old, items[i].Rank)
+		}
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	if err := s.Update(ctx, list, updates); err != nil {
+		return err
+	}
+	ps.Generator.audit(ctx, list, AuditRebalance, old, updates)
+	return nil
+}
@@ -0,0 +1,146 @@
+package lexorank
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartitions(t *testing.T) {
+	ps, err := NewPartitions("imported", "user", "system")
+	assert.NoError(t, err)
+	all := ps.All()
+	assert.Len(t, all, 3)
+	assert.Equal(t, "", all[0].Lo)
+	assert.Equal(t, all[0].Hi, all[1].Lo)
+	assert.Equal(t, all[1].Hi, all[2].Lo)
+	assert.Equal(t, "", all[2].Hi)
+
+	// build a list by inserting into the partitions in a jumble, each
+	// at the end of its part
+	var list []Posn
+	last := map[string]*Posn{}
+	for i := 0; i < 60; i++ {
+		name := []string{"user", "imported", "system", "user"}[i%4]
+		var next *Posn
+		if prev := last[name]; prev != nil {
+			if k := slices.IndexFunc(list, func(p Posn) bool { return p.Compare(*prev) > 0 }); k >= 0 {
+				next = &list[k]
+			}
+		}
+		r, err := ps.Ranks(name, 1, last[name], next)
+		assert.NoError(t, err)
+		got, ok := ps.Of(r[0])
+		assert.True(t, ok)
+		assert.Equal(t, name, got)
+		list = append(list, r[0])
+		slices.SortFunc(list, Posn.Compare)
+		last[name] = &r[0]
+	}
+
+	// each part is a run of the list, in order
+	var parts []string
+	for _, p := range list {
+		name, _ := ps.Of(p)
+		if len(parts) == 0 || parts[len(parts)-1] != name {
+			parts = append(parts, name)
+		}
+	}
+	assert.Equal(t, []string{"imported", "user", "system"}, parts)
+}
+
+func TestPartitionsNeighbours(t *testing.T) {
+	ps, err := NewPartitions("imported", "user")
+	assert.NoError(t, err)
+	imported, err := ps.Ranks("imported", 3, nil, nil)
+	assert.NoError(t, err)
+
+	// the first user item goes after the last imported one, but in
+	// the user part, even when placed right after it
+	r, err := ps.Ranks("user", 1, &imported[2], nil)
+	assert.NoError(t, err)
+	name, _ := ps.Of(r[0])
+	assert.Equal(t, "user", name)
+
+	// and an imported item placed before the first user item stays
+	// in its own part
+	r2, err := ps.Ranks("imported", 1, &imported[2], &r[0])
+	assert.NoError(t, err)
+	name, _ = ps.Of(r2[0])
+	assert.Equal(t, "imported", name)
+
+	_, err = ps.Ranks("imported", 1, &r[0], nil)
+	assert.ErrorIs(t, err, ErrOutsidePartition)
+	_, err = ps.Ranks("user", 1, nil, &imported[0])
+	assert.ErrorIs(t, err, ErrOutsidePartition)
+	_, err = ps.Ranks("nope", 1, nil, nil)
+	assert.Error(t, err)
+
+	// an empty list starts in the given bucket; after that the
+	// neighbours decide, even across a migration
+	ps.Bucket = 2
+	r, err = ps.Ranks("user", 1, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, byte(2), r[0].Bucket)
+	next := Posn{Bucket: 1, Major: "zz"}
+	r, err = ps.Ranks("imported", 1, &imported[0], &next)
+	assert.NoError(t, err)
+	assert.Equal(t, byte(1), r[0].Bucket)
+	name, _ = ps.Of(r[0])
+	assert.Equal(t, "imported", name)
+
+	_, err = NewPartitions()
+	assert.Error(t, err)
+	_, err = NewPartitions("a", "b", "a")
+	assert.Error(t, err)
+}
+
+func TestPartitionsAlphabet(t *testing.T) {
+	g := Generator{Alphabet: Base36}
+	ps, err := g.NewPartitions("a", "b")
+	assert.NoError(t, err)
+	assert.True(t, Base36.Valid(ps.All()[0].Hi))
+	r, err := ps.Ranks("b", 2, nil, nil)
+	assert.NoError(t, err)
+	for _, p := range r {
+		assert.True(t, Base36.Valid(p.Major), p.String())
+	}
+}
+
+func TestPartitionsRebalance(t *testing.T) {
+	ctx := context.Background()
+	ps, err := NewPartitions("imported", "user")
+	assert.NoError(t, err)
+	user, err := ps.Ranks("user", 10, nil, nil)
+	assert.NoError(t, err)
+	imported, err := ps.Ranks("imported", 2, nil, &user[0])
+	assert.NoError(t, err)
+	m := &MemStore{}
+	for i, p := range append(imported, user...) {
+		m.Put("list", Item{strconv.Itoa(i), p})
+	}
+
+	assert.NoError(t, ps.RebalanceStore(ctx, m, "list"))
+	items := m.List("list")
+	for i, it := range items {
+		assert.Equal(t, strconv.Itoa(i), it.ID)
+		name, _ := ps.Of(it.Rank)
+		assert.Equal(t, map[bool]string{true: "imported", false: "user"}[i < 2], name, it.Rank.String())
+	}
+
+	// so there's still room after the last imported item
+	r, err := ps.Ranks("imported", 1, &items[1].Rank, &items[2].Rank)
+	assert.NoError(t, err)
+	name, _ := ps.Of(r[0])
+	assert.Equal(t, "imported", name)
+
+	// a list that isn't in order can't be rebalanced
+	_, err = ps.Rebalance([]Posn{user[0], imported[0]})
+	assert.Error(t, err)
+	fresh, err := ps.Rebalance(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, fresh)
+}
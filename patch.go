@@ -0,0 +1,220 @@
+package lexorank
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+)
+
+// A Patch is a list of reorder intents, in a small JSON format for
+// clients and servers to exchange: the client says where items should
+// go relative to each other, and the server works out the ranks, with
+// ApplyPatch.  A patch encodes as a JSON array of PatchOps, like
+//
+//	[{"op": "insert-after", "id": "x", "after": "y"},
+//	 {"op": "move-before", "id": "y", "before": "z"},
+//	 {"op": "rebalance-batch", "ids": ["a", "b", "c"]}]
+type Patch []PatchOp
+
+// A PatchOp is one reorder intent.
+type PatchOp struct {
+	Op string `json:"op"`
+
+	// ID is the item inserted or moved
+	ID string `json:"id,omitempty"`
+
+	// After is the item an insert-after goes after (the start of the
+	// list, if empty), and Before the item a move-before goes before
+	// (the end of the list, if empty)
+	After  string `json:"after,omitempty"`
+	Before string `json:"before,omitempty"`
+
+	// IDs are the items a rebalance-batch spreads out, which must be
+	// next to each other in the list, in any order
+	IDs []string `json:"ids,omitempty"`
+}
+
+// The kinds of PatchOp
+const (
+	PatchInsertAfter    = "insert-after"
+	PatchMoveBefore     = "move-before"
+	PatchRebalanceBatch = "rebalance-batch"
+)
+
+// ApplyPatch resolves a patch into rank writes, which it applies to a
+// list (given as a map from item ID to rank, as for Op.Apply), and
+// returns, an Op for each PatchOp, for persisting.  It fails, leaving
+// the list alone, if any of the patch doesn't make sense for the list,
+// or there's no room for a rank it needs.
+func ApplyPatch(list map[string]Posn, p Patch) ([]Op, error) {
+	return Generator{}.ApplyPatch(list, p)
+}
+
+// ApplyPatch is like the package-level ApplyPatch, but uses the
+// generator's configuration.
+func (g Generator) ApplyPatch(list map[string]Posn, p Patch) ([]Op, error) {
+	work := maps.Clone(list)
+	ops := make([]Op, 0, len(p))
+	for i, po := range p {
+		op, err := g.resolve(work, po)
+		if err == nil {
+			err = op.Apply(work)
+		}
+		if err != nil {
+			return nil, &wrapError{err.Error() + " (patch op " + strconv.Itoa(i) + ")", err}
+		}
+		ops = append(ops, op)
+	}
+	clear(list)
+	maps.Copy(list, work)
+	return ops, nil
+}
+
+// resolve works out the ranks for one PatchOp
+func (g Generator) resolve(list map[string]Posn, po PatchOp) (Op, error) {
+	switch po.Op {
+	case PatchInsertAfter:
+		if _, ok := list[po.ID]; ok {
+			return Op{}, newError("insert of " + strconv.Quote(po.ID) + ", which is already there")
+		}
+		var prev *Posn
+		if po.After != "" {
+			p, err := lookup(list, po.After)
+			if err != nil {
+				return Op{}, err
+			}
+			prev = &p
+		}
+		_, next := nearest(list, prev, 1, "")
+		r, err := g.one(prev, next)
+		return InsertOp(po.ID, r), err
+
+	case PatchMoveBefore:
+		if _, err := lookup(list, po.ID); err != nil {
+			return Op{}, err
+		}
+		if po.Before == po.ID {
+			return Op{}, newError("move of " + strconv.Quote(po.ID) + " before itself")
+		}
+		var next *Posn
+		if po.Before != "" {
+			p, err := lookup(list, po.Before)
+			if err != nil {
+				return Op{}, err
+			}
+			next = &p
+		}
+		_, prev := nearest(list, next, -1, po.ID)
+		r, err := g.one(prev, next)
+		return MoveOp(po.ID, r), err
+
+	case PatchRebalanceBatch:
+		if len(po.IDs) == 0 {
+			return RebalanceOp(nil), nil
+		}
+		entries := make([]Entry, len(po.IDs))
+		in := make(map[string]bool, len(po.IDs))
+		for i, id := range po.IDs {
+			p, err := lookup(list, id)
+			if err != nil {
+				return Op{}, err
+			}
+			if in[id] {
+				return Op{}, newError("rebalance of " + strconv.Quote(id) + " twice")
+			}
+			in[id] = true
+			entries[i] = Entry{id, p}
+		}
+		slices.SortFunc(entries, func(a, b Entry) int { return a.Rank.Compare(b.Rank) })
+		first, last := entries[0].Rank, entries[len(entries)-1].Rank
+		for id, p := range list {
+			if !in[id] && p.Compare(first) > 0 && p.Compare(last) < 0 {
+				return Op{}, newError("rebalance of items that aren't next to each other: " + strconv.Quote(id) + " is among them")
+			}
+		}
+		_, prev := nearest(list, &first, -1, "")
+		_, next := nearest(list, &last, 1, "")
+		ranks, ok := g.Ranks(len(entries), prev, next)
+		if !ok {
+			return Op{}, ErrNoRoom
+		}
+		for i := range entries {
+			entries[i].Rank = ranks[i]
+		}
+		return RebalanceOp(entries), nil
+	}
+	return Op{}, newError("unknown patch op " + strconv.Quote(po.Op))
+}
+
+// one returns a rank between prev and next
+func (g Generator) one(prev, next *Posn) (Posn, error) {
+	r, ok := g.Ranks(1, prev, next)
+	if !ok {
+		return Posn{}, ErrNoRoom
+	}
+	return r[0], nil
+}
+
+func lookup(list map[string]Posn, id string) (Posn, error) {
+	p, ok := list[id]
+	if !ok {
+		return Posn{}, newError(strconv.Quote(id) + " isn't there")
+	}
+	return p, nil
+}
+
+// nearest returns the item of list, other than skip, whose rank
+// comes nearest before p (for dir < 0) or after p (for dir > 0), or
+// nearest the end or start of the list if p is nil; it returns "" and
+// nil if there isn't one
+func nearest(list map[string]Posn, p *Posn, dir int, skip string) (string, *Posn) {
+	var best string
+	var bp *Posn
+	for id, q := range list {
+		if id == skip || p != nil && q.Compare(*p)*dir <= 0 {
+			continue
+		}
+		if bp == nil || q.Compare(*bp)*dir < 0 {
+			best, bp = id, &q
+		}
+	}
+	return best, bp
+}
+
+// EmitPatch expresses ops, which are to be applied to list in turn, as
+// a patch, for clients to send intents rather than ranks: an insert
+// becomes an insert-after, a move a move-before, and a rebalance a
+// rebalance-batch.  The list is left alone.  It fails if an op can't
+// be applied, or is a delete, which patches have no way of saying.
+func EmitPatch(list map[string]Posn, ops []Op) (Patch, error) {
+	work := maps.Clone(list)
+	p := make(Patch, 0, len(ops))
+	for i, op := range ops {
+		if err := op.check(work); err != nil {
+			return nil, &wrapError{err.Error() + " (op " + strconv.Itoa(i) + ")", err}
+		}
+		var po PatchOp
+		switch op.Kind {
+		case OpInsert:
+			e := op.Entries[0]
+			po = PatchOp{Op: PatchInsertAfter, ID: e.ID}
+			po.After, _ = nearest(work, &e.Rank, -1, e.ID)
+		case OpMove:
+			e := op.Entries[0]
+			po = PatchOp{Op: PatchMoveBefore, ID: e.ID}
+			po.Before, _ = nearest(work, &e.Rank, 1, e.ID)
+		case OpRebalance:
+			entries := slices.Clone(op.Entries)
+			slices.SortFunc(entries, func(a, b Entry) int { return a.Rank.Compare(b.Rank) })
+			po = PatchOp{Op: PatchRebalanceBatch, IDs: make([]string, len(entries))}
+			for j, e := range entries {
+				po.IDs[j] = e.ID
+			}
+		default:
+			return nil, newError("op " + strconv.Itoa(i) + ": a " + op.Kind.String() + " op can't go in a patch")
+		}
+		op.Apply(work)
+		p = append(p, po)
+	}
+	return p, nil
+}
@@ -0,0 +1,96 @@
+package lexorank
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// order returns the IDs of list in rank order
+func order(list map[string]Posn) []string {
+	var out []string
+	for id := range list {
+		out = append(out, id)
+	}
+	slices.SortFunc(out, func(a, b string) int { return list[a].Compare(list[b]) })
+	return out
+}
+
+func TestApplyPatch(t *testing.T) {
+	const doc = `[
+		{"op": "insert-after", "id": "a"},
+		{"op": "insert-after", "id": "c", "after": "a"},
+		{"op": "insert-after", "id": "b", "after": "a"},
+		{"op": "insert-after", "id": "z"},
+		{"op": "move-before", "id": "z"},
+		{"op": "move-before", "id": "c", "before": "a"},
+		{"op": "rebalance-batch", "ids": ["a", "c", "b"]}
+	]`
+	var p Patch
+	assert.NoError(t, json.Unmarshal([]byte(doc), &p))
+
+	list := map[string]Posn{}
+	ops, err := ApplyPatch(list, p)
+	assert.NoError(t, err)
+	assert.Len(t, ops, len(p))
+	assert.Equal(t, []string{"c", "a", "b", "z"}, order(list))
+	assert.Equal(t, OpRebalance, ops[6].Kind)
+	assert.Len(t, ops[6].Entries, 3)
+
+	// the ops replay to the same list
+	replay := map[string]Posn{}
+	for _, op := range ops {
+		assert.NoError(t, op.Apply(replay))
+	}
+	assert.Equal(t, list, replay)
+
+	// and emit back to a patch with the same intents
+	back, err := EmitPatch(map[string]Posn{}, ops)
+	assert.NoError(t, err)
+	assert.Equal(t, Patch{
+		{Op: PatchInsertAfter, ID: "a"},
+		{Op: PatchInsertAfter, ID: "c", After: "a"},
+		{Op: PatchInsertAfter, ID: "b", After: "a"},
+		{Op: PatchInsertAfter, ID: "z"},
+		{Op: PatchMoveBefore, ID: "z"},
+		{Op: PatchMoveBefore, ID: "c", Before: "a"},
+		{Op: PatchRebalanceBatch, IDs: []string{"c", "a", "b"}},
+	}, back)
+	b, err := json.Marshal(back[1])
+	assert.NoError(t, err)
+	assert.Equal(t, `{"op":"insert-after","id":"c","after":"a"}`, string(b))
+}
+
+func TestApplyPatchErrors(t *testing.T) {
+	list := map[string]Posn{}
+	_, err := ApplyPatch(list, Patch{{Op: PatchInsertAfter, ID: "a"}, {Op: PatchInsertAfter, ID: "b", After: "a"}})
+	assert.NoError(t, err)
+	before := order(list)
+
+	for _, p := range []Patch{
+		{{Op: PatchInsertAfter, ID: "a"}},
+		{{Op: PatchInsertAfter, ID: "x", After: "nope"}},
+		{{Op: PatchMoveBefore, ID: "nope"}},
+		{{Op: PatchMoveBefore, ID: "a", Before: "a"}},
+		{{Op: PatchRebalanceBatch, IDs: []string{"a", "a"}}},
+		{{Op: "shuffle"}},
+		// the first op would work, but the list is left alone
+		{{Op: PatchInsertAfter, ID: "c"}, {Op: PatchInsertAfter, ID: "c"}},
+	} {
+		_, err := ApplyPatch(list, p)
+		assert.Error(t, err)
+		assert.Equal(t, before, order(list))
+	}
+
+	_, err = ApplyPatch(list, Patch{{Op: PatchInsertAfter, ID: "m", After: "a"}})
+	assert.NoError(t, err)
+	_, err = ApplyPatch(list, Patch{{Op: PatchRebalanceBatch, IDs: []string{"a", "b"}}})
+	assert.ErrorContains(t, err, `"m" is among them`)
+	assert.True(t, strings.HasSuffix(err.Error(), "(patch op 0)"))
+
+	_, err = EmitPatch(list, []Op{DeleteOp("a", list["a"])})
+	assert.Error(t, err)
+}
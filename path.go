@@ -0,0 +1,114 @@
+package lexorank
+
+import "strings"
+
+// A Path orders a node in a tree: it holds one rank per level, from
+// the root down, so that a node's path is its parent's path plus its
+// own rank among its siblings.  Ordering paths with Compare gives a
+// depth-first (outline) order, with parents before their children.
+type Path []string
+
+// ParsePath is the inverse of Path.String.
+func ParsePath(s string) Path {
+	if s == "" {
+		return nil
+	}
+	return Path(strings.Split(s, "."))
+}
+
+// String joins the segments with ".".  Since "." sorts before every
+// digit of the built-in alphabets except Base64URL's "-", the result
+// sorts as a plain string the same way Compare orders paths.
+func (p Path) String() string {
+	return strings.Join(p, ".")
+}
+
+// Parent returns the path of p's parent, which is nil for a root.
+func (p Path) Parent() Path {
+	if len(p) == 0 {
+		return nil
+	}
+	return p[:len(p)-1:len(p)-1]
+}
+
+// Compare orders paths segment by segment, a parent coming before
+// all its descendants.
+func (p Path) Compare(q Path) int {
+	for i := 0; i < len(p) && i < len(q); i++ {
+		if c := strings.Compare(p[i], q[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(p) < len(q):
+		return -1
+	case len(p) > len(q):
+		return 1
+	}
+	return 0
+}
+
+// IsDescendantOf reports whether p is inside the subtree rooted at q
+// (including q itself).
+func (p Path) IsDescendantOf(q Path) bool {
+	if len(p) < len(q) {
+		return false
+	}
+	for i := range q {
+		if p[i] != q[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ChildBetween returns the path for a new child of parent which goes
+// between the sibling ranks prev and next (either of which may be
+// empty to mean the first or last child).
+func ChildBetween(parent Path, prev, next string) (Path, bool) {
+	return Generator{}.ChildBetween(parent, prev, next)
+}
+
+// ChildBetween is like the package-level ChildBetween, but uses the
+// generator's alphabet.
+func (g Generator) ChildBetween(parent Path, prev, next string) (Path, bool) {
+	r, ok := g.Rank(prev, next)
+	if !ok {
+		return nil, false
+	}
+	child := make(Path, len(parent), len(parent)+1)
+	copy(child, parent)
+	return append(child, r), true
+}
+
+// Reparent moves the subtree rooted at root so that it becomes a
+// child of newParent, between the siblings prev and next.  It returns
+// the new path of every node in nodes; nodes outside the subtree are
+// returned unchanged.
+func Reparent(nodes []Path, root, newParent Path, prev, next string) ([]Path, bool) {
+	return Generator{}.Reparent(nodes, root, newParent, prev, next)
+}
+
+// Reparent is like the package-level Reparent, but uses the
+// generator's alphabet.
+func (g Generator) Reparent(nodes []Path, root, newParent Path, prev, next string) ([]Path, bool) {
+	if newParent.IsDescendantOf(root) {
+		// can't move a subtree inside itself
+		return nil, false
+	}
+	newRoot, ok := g.ChildBetween(newParent, prev, next)
+	if !ok {
+		return nil, false
+	}
+	out := make([]Path, len(nodes))
+	for i, n := range nodes {
+		if !n.IsDescendantOf(root) {
+			out[i] = n
+			continue
+		}
+		moved := make(Path, 0, len(newRoot)+len(n)-len(root))
+		moved = append(moved, newRoot...)
+		out[i] = append(moved, n[len(root):]...)
+	}
+	return out, true
+}
@@ -0,0 +1,64 @@
+package lexorank
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPathCompare(t *testing.T) {
+	paths := []Path{
+		ParsePath("b"),
+		ParsePath("a.c"),
+		ParsePath("a"),
+		ParsePath("a.b.z"),
+		ParsePath("a.b"),
+	}
+	sort.Slice(paths, func(i, j int) bool { return paths[i].Compare(paths[j]) < 0 })
+	var got []string
+	for _, p := range paths {
+		got = append(got, p.String())
+	}
+	assert.Equal(t, []string{"a", "a.b", "a.b.z", "a.c", "b"}, got)
+	assert.True(t, sort.StringsAreSorted(got))
+}
+
+func TestPathParent(t *testing.T) {
+	assert.Equal(t, Path{"a", "b"}, ParsePath("a.b.c").Parent())
+	assert.Equal(t, Path(nil), Path(nil).Parent())
+	assert.True(t, ParsePath("a.b.c").IsDescendantOf(ParsePath("a.b")))
+	assert.False(t, ParsePath("a.c").IsDescendantOf(ParsePath("a.b")))
+}
+
+func TestChildBetween(t *testing.T) {
+	parent := ParsePath("a")
+	p, ok := ChildBetween(parent, "", "")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "a.U", p.String())
+
+	p, ok = ChildBetween(parent, "U", "")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "a.j", p.String())
+	assert.Equal(t, Path{"a"}, parent)
+}
+
+func TestReparent(t *testing.T) {
+	nodes := []Path{
+		ParsePath("a"),
+		ParsePath("a.U"),
+		ParsePath("a.U.U"),
+		ParsePath("b"),
+	}
+	out, ok := Reparent(nodes, ParsePath("a.U"), ParsePath("b"), "", "")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, []Path{
+		ParsePath("a"),
+		ParsePath("b.U"),
+		ParsePath("b.U.U"),
+		ParsePath("b"),
+	}, out)
+
+	_, ok = Reparent(nodes, ParsePath("a"), ParsePath("a.U"), "", "")
+	assert.Equal(t, false, ok)
+}
@@ -0,0 +1,116 @@
+package lexorank
+
+// A CostModel prices the writes needed to insert an item, for
+// PlanInsert.  In the zero CostModel every row written costs 1 and
+// key length is free.
+type CostModel struct {
+	// PerWrite is the cost of writing a row (the new item's
+	// included) and PerDigit the cost of each digit of the keys
+	// written, which stands for index size
+	PerWrite, PerDigit float64
+
+	// MaxLen, if positive, rules out plans with longer keys
+	MaxLen int
+
+	// MaxNudge is the most neighbours a plan may move (2 if zero)
+	MaxNudge int
+}
+
+func (c CostModel) cost(ranks []Posn) float64 {
+	perWrite := c.PerWrite
+	if perWrite == 0 && c.PerDigit == 0 {
+		perWrite = 1
+	}
+	total := 0.0
+	for _, p := range ranks {
+		total += perWrite + c.PerDigit*float64(len(p.digits()))
+	}
+	return total
+}
+
+// An InsertPlan is the cheapest way PlanInsert found to insert an
+// item: it goes at Rank, and the neighbours in Updates (if any) move
+// out of its way.
+type InsertPlan struct {
+	Rank    Posn
+	Updates []Update
+	Cost    float64
+}
+
+// PlanInsert works out how to insert an item at index i of list
+// (which holds the ranks of a list in order), when the gap there is
+// tight: the new key can be allowed to grow, or neighbours can be
+// nudged along to make room so that the keys stay short.  Each way
+// is priced with cost, and the cheapest (with ties going to the one
+// with fewest writes) is returned.  It fails if there's no way within
+// cost's limits.
+func PlanInsert(list []Posn, i int, cost CostModel) (InsertPlan, bool) {
+	return Generator{}.PlanInsert(list, i, cost)
+}
+
+// PlanInsert is like the package-level PlanInsert, but uses the
+// generator's configuration.
+func (g Generator) PlanInsert(list []Posn, i int, cost CostModel) (InsertPlan, bool) {
+	if i < 0 || i > len(list) {
+		return InsertPlan{}, false
+	}
+	nudge := cost.MaxNudge
+	if nudge <= 0 {
+		nudge = 2
+	}
+	var best InsertPlan
+	found := false
+	// try moving l neighbours before the new item and r after it,
+	// re-spreading them along with it
+	for l := 0; l <= nudge && l <= i; l++ {
+		for r := 0; l+r <= nudge && i+r <= len(list); r++ {
+			var prev, next *Posn
+			if i-l > 0 {
+				prev = &list[i-l-1]
+			}
+			if i+r < len(list) {
+				next = &list[i+r]
+			}
+			ranks, ok := g.quiet().AllocateBlock(prev, next, l+r+1)
+			if !ok {
+				continue
+			}
+			plan := InsertPlan{Rank: ranks[l]}
+			written := []Posn{ranks[l]}
+			for k, p := range ranks {
+				j := i - l + k // index in list of the neighbour
+				if k > l {
+					j--
+				}
+				if k == l || list[j].Equal(p) {
+					continue
+				}
+				plan.Updates = append(plan.Updates, Update{Index: j, Rank: p})
+				written = append(written, p)
+			}
+			if !cost.fits(written) {
+				continue
+			}
+			plan.Cost = cost.cost(written)
+			if found && (plan.Cost > best.Cost ||
+				plan.Cost == best.Cost && len(plan.Updates) >= len(best.Updates)) {
+				continue
+			}
+			best = plan
+			found = true
+		}
+	}
+	return best, found
+}
+
+func (c CostModel) fits(ranks []Posn) bool {
+	if c.MaxLen <= 0 {
+		return true
+	}
+	for _, p := range ranks {
+		if len(p.digits()) > c.MaxLen {
+			return false
+		}
+	}
+	return true
+}
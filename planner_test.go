@@ -0,0 +1,52 @@
+package lexorank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanInsertRoomy(t *testing.T) {
+	list := []Posn{{Major: "a"}, {Major: "c"}}
+	plan, ok := PlanInsert(list, 1, CostModel{})
+	assert.Equal(t, true, ok)
+	assert.Equal(t, Posn{Major: "b"}, plan.Rank)
+	assert.Empty(t, plan.Updates)
+	assert.Equal(t, 1.0, plan.Cost)
+}
+
+func TestPlanInsertNudge(t *testing.T) {
+	// "a0" to "a01" leaves only long keys, but "a0" can move down
+	list := []Posn{{Major: "U"}, {Major: "a0"}, {Major: "a01"}}
+
+	// when only writes count, growing the key is cheapest
+	plan, ok := PlanInsert(list, 2, CostModel{})
+	assert.Equal(t, true, ok)
+	assert.Empty(t, plan.Updates)
+
+	// when key length counts for a lot, a neighbour moves
+	plan, ok = PlanInsert(list, 2, CostModel{PerWrite: 1, PerDigit: 10})
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 1, len(plan.Updates))
+	assert.Equal(t, 1, len(plan.Rank.Major))
+
+	// and the result is in order
+	list[plan.Updates[0].Index] = plan.Updates[0].Rank
+	list = append(list[:2], append([]Posn{plan.Rank}, list[2:]...)...)
+	for i := 1; i < len(list); i++ {
+		assert.True(t, list[i-1].Compare(list[i]) < 0, "%v", list)
+	}
+}
+
+func TestPlanInsertMaxLen(t *testing.T) {
+	list := []Posn{{Major: "a"}, {Major: "a01"}}
+	_, ok := PlanInsert(list, 1, CostModel{MaxLen: 2, MaxNudge: 1})
+	assert.Equal(t, true, ok)
+
+	list = []Posn{{Major: "a0"}, {Major: "a01"}}
+	_, ok = PlanInsert(list, 1, CostModel{MaxLen: 2})
+	assert.Equal(t, true, ok)
+
+	_, ok = PlanInsert(list, 3, CostModel{})
+	assert.Equal(t, false, ok)
+}
@@ -0,0 +1,132 @@
+package lexorank
+
+import "strconv"
+
+// A Policy decides when and how a list should be rebalanced, so that
+// services sharing a list (or an operations team) can share the same
+// thresholds.  Zero thresholds aren't checked.
+type Policy struct {
+	// MaxLen is the longest a key (major and minor together) may get
+	// before the list needs attention
+	MaxLen int
+
+	// MinGap is the smallest gap between neighbours, as a fraction of
+	// the keyspace, that doesn't need attention
+	MinGap float64
+
+	// MaxPerBucket is the biggest list that is rebalanced in place;
+	// bigger ones are migrated to the next bucket instead, which can
+	// be done a batch at a time while the list stays in use
+	MaxPerBucket int
+
+	// MaxWindow is the most items a local rebalance may touch; if the
+	// trouble is more widespread than that, the whole list is
+	// rebalanced
+	MaxWindow int
+}
+
+// DefaultPolicy is a reasonable starting point.
+var DefaultPolicy = Policy{
+	MaxLen:       32,
+	MinGap:       1e-12,
+	MaxPerBucket: 100000,
+	MaxWindow:    64,
+}
+
+// An Action is what a Policy recommends doing to a list.
+type Action int
+
+const (
+	// NoAction means the list is fine as it is.
+	NoAction Action = iota
+
+	// RebalanceLocal means re-spreading just the items within Radius
+	// of Center, as RebalanceWindow does.
+	RebalanceLocal
+
+	// RebalanceFull means giving the whole list fresh ranks in its
+	// current bucket, as Rebalance does.
+	RebalanceFull
+
+	// MigrateBucket means moving the list to the next bucket (see
+	// PlanMigration), or finishing a migration already under way.
+	MigrateBucket
+)
+
+func (a Action) String() string {
+	switch a {
+	case NoAction:
+		return "none"
+	case RebalanceLocal:
+		return "local"
+	case RebalanceFull:
+		return "full"
+	case MigrateBucket:
+		return "migrate"
+	default:
+		return "unknown"
+	}
+}
+
+// A Decision is a Policy's verdict on a list.  For RebalanceLocal,
+// Center and Radius give the window to re-spread.
+type Decision struct {
+	Action         Action
+	Center, Radius int
+	Reason         string
+}
+
+// Decide looks at the ranks of a list, in list order, and decides
+// what (if anything) to do about them.
+func (pol Policy) Decide(ranks []Posn) Decision {
+	return pol.DecideWith(Generator{}, ranks)
+}
+
+// DecideWith is like Decide, for ranks made by g.
+func (pol Policy) DecideWith(g Generator, ranks []Posn) Decision {
+	b := g.BucketUsage(ranks)
+	if b.Migrating {
+		return Decision{Action: MigrateBucket, Reason: "a bucket migration is under way"}
+	}
+
+	// find the span of the list that's in trouble
+	a := g.alphabet()
+	lo, hi := len(ranks), -1
+	trouble := func(i int) {
+		lo, hi = min(lo, i), max(hi, i)
+	}
+	var reason string
+	for i, p := range ranks {
+		if pol.MaxLen > 0 && len(p.digits()) > pol.MaxLen {
+			trouble(i)
+			reason = "keys longer than " + strconv.Itoa(pol.MaxLen) + " digits"
+		}
+	}
+	if pol.MinGap > 0 {
+		for i := 1; i < len(ranks); i++ {
+			if a.fraction(ranks[i].digits())-a.fraction(ranks[i-1].digits()) < pol.MinGap {
+				trouble(i - 1)
+				trouble(i)
+				if reason == "" {
+					reason = "gaps smaller than " + strconv.FormatFloat(pol.MinGap, 'g', -1, 64)
+				}
+			}
+		}
+	}
+	switch {
+	case hi < 0:
+		return Decision{Action: NoAction}
+	case pol.MaxWindow <= 0 || hi-lo+1 <= pol.MaxWindow:
+		// leave a little room either side, for the items in the
+		// window to spread out into
+		radius := (hi-lo)/2 + 1
+		if pol.MaxWindow > 0 {
+			radius = max(radius, min(pol.MaxWindow/2, radius*2))
+		}
+		return Decision{Action: RebalanceLocal, Center: (lo + hi + 1) / 2, Radius: radius, Reason: reason}
+	case pol.MaxPerBucket > 0 && len(ranks) > pol.MaxPerBucket:
+		return Decision{Action: MigrateBucket, Reason: reason + " over a large list"}
+	default:
+		return Decision{Action: RebalanceFull, Reason: reason}
+	}
+}
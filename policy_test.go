@@ -0,0 +1,53 @@
+package lexorank
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyFine(t *testing.T) {
+	ranks, err := Rebalance(100, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, Decision{Action: NoAction}, DefaultPolicy.Decide(ranks))
+}
+
+func TestPolicyLocal(t *testing.T) {
+	ranks, err := Rebalance(100, 0)
+	assert.NoError(t, err)
+	ranks[50].Major = ranks[49].Major + strings.Repeat("z", 40)
+	d := DefaultPolicy.Decide(ranks)
+	assert.Equal(t, RebalanceLocal, d.Action)
+	assert.Equal(t, 50, d.Center)
+	assert.True(t, d.Radius >= 1)
+	assert.True(t, d.Radius <= DefaultPolicy.MaxWindow/2)
+	assert.Contains(t, d.Reason, "longer")
+}
+
+func TestPolicyFull(t *testing.T) {
+	ranks, err := Rebalance(200, 0)
+	assert.NoError(t, err)
+	ranks[10].Major += strings.Repeat("z", 40)
+	ranks[190].Major += strings.Repeat("z", 40)
+	d := DefaultPolicy.Decide(ranks)
+	assert.Equal(t, RebalanceFull, d.Action)
+
+	pol := DefaultPolicy
+	pol.MaxPerBucket = 100
+	assert.Equal(t, MigrateBucket, pol.Decide(ranks).Action)
+}
+
+func TestPolicyGap(t *testing.T) {
+	ranks := []Posn{{Major: "a00000"}, {Major: "a00001"}, {Major: "b00000"}}
+	d := Policy{MinGap: 1e-9}.Decide(ranks)
+	assert.Equal(t, RebalanceLocal, d.Action)
+	assert.Equal(t, 1, d.Center)
+	assert.Contains(t, d.Reason, "gaps")
+}
+
+func TestPolicyMigrating(t *testing.T) {
+	ranks := []Posn{{Major: "a"}, {Bucket: 1, Major: "b"}}
+	assert.Equal(t, MigrateBucket, DefaultPolicy.Decide(ranks).Action)
+	assert.Equal(t, "migrate", MigrateBucket.String())
+}
@@ -0,0 +1,37 @@
+package lexorank
+
+import "sync"
+
+// bufPool holds scratch buffers for building ranks, to take the
+// pressure off the garbage collector when ranks are being generated
+// in bulk.  (Callers who want to manage memory themselves can use the
+// Append variants, such as AppendRanks, with buffers of their own.)
+var bufPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 64)
+		return &b
+	},
+}
+
+// buffer returns an empty scratch buffer with room for at least n
+// bytes, which should be handed back with release when done with
+func (g Generator) buffer(n int) *[]byte {
+	if g.NoPool {
+		b := make([]byte, 0, n)
+		return &b
+	}
+	b := bufPool.Get().(*[]byte)
+	if cap(*b) < n {
+		*b = make([]byte, 0, n)
+	}
+	*b = (*b)[:0]
+	return b
+}
+
+func (g Generator) release(b *[]byte) {
+	if g.NoPool || cap(*b) > 1024 {
+		// don't hang on to outsized buffers
+		return
+	}
+	bufPool.Put(b)
+}
@@ -0,0 +1,37 @@
+package lexorank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNoPool(t *testing.T) {
+	prev := Posn{Major: "a5z000"}
+	next := Posn{Major: "a6b000"}
+	pooled, ok := Generator{}.Ranks(5, &prev, &next)
+	assert.Equal(t, true, ok)
+	unpooled, ok := Generator{NoPool: true}.Ranks(5, &prev, &next)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, pooled, unpooled)
+
+	a, err := Generator{}.Rebalance(10, 0)
+	assert.NoError(t, err)
+	b, err := Generator{NoPool: true}.Rebalance(10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, a, b)
+}
+
+func TestBuffer(t *testing.T) {
+	g := Generator{}
+	b := g.buffer(10)
+	assert.Equal(t, 0, len(*b))
+	assert.True(t, cap(*b) >= 10)
+	*b = append(*b, "junk"...)
+	g.release(b)
+
+	b = g.buffer(2000)
+	assert.Equal(t, 0, len(*b))
+	assert.True(t, cap(*b) >= 2000)
+	g.release(b)
+}
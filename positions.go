@@ -0,0 +1,75 @@
+package lexorank
+
+import (
+	"math/big"
+	"sort"
+)
+
+// PositionOptions configures FromPositions.
+type PositionOptions struct {
+	// Headroom is the least number of unused ranks of the same length
+	// to leave in each gap, before the first item, between each pair
+	// and after the last (1000 if zero).  The more there are, the
+	// longer a list can take inserts at the same spot before its keys
+	// start to grow, at the cost of longer keys to begin with.
+	Headroom int
+
+	// Bucket is the bucket to put the ranks in
+	Bucket byte
+}
+
+// FromPositions gives ranks to items ordered by an integer column,
+// which is the usual first step in moving a list off integer
+// positions.  positions holds each item's position (in any order,
+// and ties are fine); the result holds each item's rank, so that the
+// ranks sort the items by position, with ties kept in the order they
+// are given.  Unlike Rebalance, which keeps keys as short as it can,
+// the keys are made long enough to leave the given headroom around
+// every item, so that a list can be inserted into heavily as soon as
+// it has been migrated.
+func FromPositions(positions []int, opts PositionOptions) ([]Posn, error) {
+	return Generator{}.FromPositions(positions, opts)
+}
+
+// FromPositions is like the package-level FromPositions, but uses the
+// generator's configuration.
+func (g Generator) FromPositions(positions []int, opts PositionOptions) ([]Posn, error) {
+	if opts.Bucket > MaxBucket {
+		return nil, badBucket(opts.Bucket)
+	}
+	headroom := opts.Headroom
+	if headroom <= 0 {
+		headroom = 1000
+	}
+	n := len(positions)
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return positions[order[i]] < positions[order[j]]
+	})
+
+	// each of the n+1 gaps needs room for its headroom and the item
+	// that ends it
+	need := new(big.Int).Mul(big.NewInt(int64(n+1)), big.NewInt(int64(headroom+1)))
+	base := big.NewInt(int64(g.alphabet().base()))
+	length := 1
+	for room := new(big.Int).Set(base); room.Cmp(need) < 0; room.Mul(room, base) {
+		length++
+	}
+	length = max(length, g.freshLen(n))
+	if g.tooLong(length) {
+		return nil, ErrMaxLength
+	}
+
+	keys, err := g.SpreadFixed("", "", n, length)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Posn, n)
+	for k, i := range order {
+		out[i] = Posn{Bucket: opts.Bucket, Major: keys[k]}
+	}
+	return out, nil
+}
@@ -0,0 +1,40 @@
+package lexorank
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromPositions(t *testing.T) {
+	ranks, err := FromPositions([]int{30, 10, 20, 10}, PositionOptions{Bucket: 1})
+	assert.NoError(t, err)
+	assert.Len(t, ranks, 4)
+	for _, i := range [][2]int{{1, 3}, {3, 2}, {2, 0}} {
+		assert.True(t, ranks[i[0]].Compare(ranks[i[1]]) < 0)
+	}
+	for _, p := range ranks {
+		assert.Equal(t, byte(1), p.Bucket)
+		assert.Len(t, p.Major, 6)
+	}
+
+	// lots of headroom makes for longer keys, with that much room
+	// between them
+	const headroom = 1e12
+	ranks, err = FromPositions([]int{1, 2, 3, 4}, PositionOptions{Headroom: headroom})
+	assert.NoError(t, err)
+	assert.Len(t, ranks[0].Major, 8)
+	for i := 1; i < len(ranks); i++ {
+		gap := new(big.Int).Sub(digitsValue(Base62, ranks[i].Major, 8), digitsValue(Base62, ranks[i-1].Major, 8))
+		assert.True(t, gap.Cmp(big.NewInt(headroom)) > 0)
+	}
+
+	_, err = Generator{MaxLength: 6}.FromPositions([]int{1, 2}, PositionOptions{Headroom: headroom})
+	assert.Equal(t, ErrMaxLength, err)
+	_, err = FromPositions(nil, PositionOptions{Bucket: 3})
+	assert.Error(t, err)
+	ranks, err = FromPositions(nil, PositionOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, ranks)
+}
@@ -0,0 +1,78 @@
+package lexorank
+
+import "strconv"
+
+// A Profile describes how positions are written as text (by
+// Generator.Text, and read back by Generator.Parse), for fitting in
+// with rank columns that weren't written by this package.  The zero
+// value is the usual Jira form.
+type Profile struct {
+	// Separator goes between the major and the minor (':' if zero).
+	// It mustn't be a digit of the alphabet, or '|'.
+	Separator byte
+
+	// MinorAlphabet, if set, is the numeral system minors are
+	// written in, when it isn't the same as the majors'.  Minors are
+	// compared byte-wise like majors, so its digits must be in
+	// ascending byte order too (as NewAlphabet insists).  Things
+	// that read a major and minor as one run of digits, such as
+	// ToBigInt and ApproxFraction, don't know about it.
+	MinorAlphabet Alphabet
+}
+
+// minorAlphabet returns the alphabet of minors, given the majors'
+func (pr Profile) minorAlphabet(a Alphabet) Alphabet {
+	if pr.MinorAlphabet.digits == "" {
+		return a.orDefault()
+	}
+	return pr.MinorAlphabet
+}
+
+func (pr Profile) separator() byte {
+	if pr.Separator == 0 {
+		return ':'
+	}
+	return pr.Separator
+}
+
+// Check reports whether the profile can be used with the alphabet:
+// if the separator could be mistaken for a digit (of the major or the
+// minor), or the bucket's bar, positions wouldn't read back as they
+// were written.
+func (pr Profile) Check(a Alphabet) error {
+	sep := pr.separator()
+	if sep == '|' || a.orDefault().values[sep] >= 0 || pr.minorAlphabet(a).values[sep] >= 0 {
+		return newError("separator " + quoteByte(sep) + " clashes with the alphabet")
+	}
+	return nil
+}
+
+// validPosn reports whether p's major and minor are made of the right
+// digits.  The minor may have a ":" in front, the way the Minor field
+// used to hold it, but only the one.
+func (g Generator) validPosn(p Posn) bool {
+	minor := p.Minor
+	if len(minor) > 0 && minor[0] == ':' {
+		minor = minor[1:]
+	}
+	return g.alphabet().valid(p.Major) && g.Profile.minorAlphabet(g.Alphabet).valid(minor)
+}
+
+// Text returns p as text, as String does, but in the form the
+// generator's Profile gives.
+func (g Generator) Text(p Posn) (string, error) {
+	b, err := g.AppendText(make([]byte, 0, 5+len(p.Major)+len(p.Minor)), p)
+	return string(b), err
+}
+
+// AppendText is like Text, but appends the text to b.
+func (g Generator) AppendText(b []byte, p Posn) ([]byte, error) {
+	if err := g.Profile.Check(g.Alphabet); err != nil {
+		return b, err
+	}
+	b = strconv.AppendUint(b, uint64(p.Bucket), 10)
+	b = append(b, '|')
+	b = append(b, p.Major...)
+	b = append(b, g.Profile.separator())
+	return append(b, p.MinorValue()...), nil
+}
@@ -0,0 +1,75 @@
+package lexorank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfile(t *testing.T) {
+	for _, sep := range []byte{'.', '~'} {
+		g := Generator{Profile: Profile{Separator: sep}}
+		p := Posn{Bucket: 1, Major: "hzzzzz", Minor: "i"}
+		s, err := g.Text(p)
+		assert.NoError(t, err)
+		assert.Equal(t, "1|hzzzzz"+string(sep)+"i", s)
+		back, err := g.Parse(s)
+		assert.NoError(t, err)
+		assert.Equal(t, p, back)
+
+		// the usual separator isn't one any more
+		_, err = g.Parse("1|hzzzzz:i")
+		assert.Error(t, err)
+	}
+
+	s, err := Generator{}.Text(Posn{Major: "a", Minor: ":1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "0|a:1", s)
+
+	// separators that could be digits (or a bar) are refused
+	tilde, err := NewAlphabet("0123456789~")
+	assert.NoError(t, err)
+	for _, g := range []Generator{
+		{Profile: Profile{Separator: 'a'}},
+		{Profile: Profile{Separator: '|'}},
+		{Profile: Profile{Separator: '~'}, Alphabet: tilde},
+	} {
+		assert.Error(t, g.Profile.Check(g.Alphabet))
+		_, err := g.Text(Posn{Major: "1"})
+		assert.Error(t, err)
+		_, err = g.Parse("0|1")
+		assert.Error(t, err)
+	}
+	assert.NoError(t, Profile{Separator: 'A'}.Check(Base36))
+}
+
+func TestMinorAlphabet(t *testing.T) {
+	// Jira's digits for the major, but base62 for the minor
+	g := Generator{Alphabet: Base36, Profile: Profile{MinorAlphabet: Base62}}
+	p, err := g.Parse("0|hzzzzz:Az")
+	assert.NoError(t, err)
+	assert.Equal(t, Posn{Major: "hzzzzz", Minor: "Az"}, p)
+	_, err = g.Parse("0|hzzZzz:A")
+	assert.Error(t, err)
+	_, err = Generator{Alphabet: Base36}.Parse("0|hzzzzz:Az")
+	assert.Error(t, err)
+
+	// minors are generated in their own alphabet
+	prev, next := Posn{Major: "hzzzzz", Minor: "1"}, Posn{Major: "hzzzzz", Minor: "2"}
+	out, ok := g.Ranks(3, &prev, &next)
+	assert.True(t, ok)
+	for _, q := range out {
+		assert.Equal(t, "hzzzzz", q.Major)
+		assert.True(t, Base62.Valid(q.Minor))
+		assert.Equal(t, -1, prev.Compare(q))
+		assert.Equal(t, -1, q.Compare(next))
+		s, err := g.Text(q)
+		assert.NoError(t, err)
+		back, err := g.Parse(s)
+		assert.NoError(t, err)
+		assert.Equal(t, q, back)
+	}
+	assert.Equal(t, "1U", out[1].Minor)
+
+	assert.Error(t, Profile{Separator: 'Z', MinorAlphabet: Base62}.Check(Base36))
+}
@@ -0,0 +1,82 @@
+package lexorank
+
+// A Promotion is what RanksAt proposes when there's no room between
+// the minors of two items with the same major: the whole run of items
+// sharing that major is given new ranks with majors of their own (a
+// small, local rebalance), making room for the new ones.  The caller
+// has to write the updates along with the new items.
+type Promotion struct {
+	// Updates has new ranks for the items of the run, in list order
+	Updates []Update
+}
+
+// RanksAt returns n ranks for new items inserted at index i of list,
+// which holds the ranks of a list in order, so that they go between
+// list[i-1] and list[i] (with the ends being open).  Generally it's
+// the same as Ranks, but if there's no room and the generator has
+// PromoteMinors set, then rather than failing it returns the ranks
+// that go with a Promotion; a nil Promotion means there's nothing
+// else to change.
+func (g Generator) RanksAt(list []Posn, i, n int) ([]Posn, *Promotion, bool) {
+	if i < 0 || i > len(list) {
+		return nil, nil, false
+	}
+	var prev, next *Posn
+	if i > 0 {
+		prev = &list[i-1]
+	}
+	if i < len(list) {
+		next = &list[i]
+	}
+	if ranks, ok := g.Ranks(n, prev, next); ok {
+		return ranks, nil, true
+	}
+	if !g.PromoteMinors || prev == nil || next == nil ||
+		prev.Bucket != next.Bucket || prev.Major != next.Major {
+		return nil, nil, false
+	}
+	return g.promote(list, i, n)
+}
+
+// promote respreads the run of items around i whose major is the same
+// as list[i]'s, together with n new ones, between the items either
+// side of the run, giving each a major of its own
+func (g Generator) promote(list []Posn, i, n int) ([]Posn, *Promotion, bool) {
+	run := list[i]
+	lo, hi := i, i
+	for lo > 0 && list[lo-1].Bucket == run.Bucket && list[lo-1].Major == run.Major {
+		lo--
+	}
+	for hi < len(list) && list[hi].Bucket == run.Bucket && list[hi].Major == run.Major {
+		hi++
+	}
+	// only the majors of the items either side matter, since any
+	// major strictly between them sorts between the items whatever
+	// their minors
+	var loMajor, hiMajor string
+	if lo > 0 && list[lo-1].Bucket == run.Bucket {
+		loMajor = list[lo-1].Major
+	}
+	if hi < len(list) && list[hi].Bucket == run.Bucket {
+		hiMajor = list[hi].Major
+	}
+	majors, ok := g.quiet().spread(nil, loMajor, hiMajor, hi-lo+n)
+	if !ok {
+		return nil, nil, false
+	}
+
+	promo := &Promotion{Updates: make([]Update, 0, hi-lo)}
+	ranks := make([]Posn, 0, n)
+	for j, m := range majors {
+		p := Posn{Bucket: run.Bucket, Major: m}
+		switch k := lo + j; {
+		case k < i:
+			promo.Updates = append(promo.Updates, Update{Index: k, Rank: p})
+		case k < i+n:
+			ranks = append(ranks, p)
+		default:
+			promo.Updates = append(promo.Updates, Update{Index: k - n, Rank: p})
+		}
+	}
+	return ranks, promo, true
+}
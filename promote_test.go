@@ -0,0 +1,62 @@
+package lexorank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRanksAt(t *testing.T) {
+	list := []Posn{{Major: "a"}, {Major: "c"}}
+	ranks, promo, ok := Generator{}.RanksAt(list, 1, 2)
+	assert.Equal(t, true, ok)
+	assert.Nil(t, promo)
+	assert.Equal(t, 2, len(ranks))
+
+	_, _, ok = Generator{}.RanksAt(list, 3, 1)
+	assert.Equal(t, false, ok)
+}
+
+func TestRanksAtPromote(t *testing.T) {
+	// no room between minors "1" and "10"
+	list := []Posn{
+		{Major: "a"},
+		{Major: "b", Minor: "0"},
+		{Major: "b", Minor: "1"},
+		{Major: "b", Minor: "10"},
+		{Major: "c"},
+	}
+	_, _, ok := Generator{}.RanksAt(list, 3, 1)
+	assert.Equal(t, false, ok)
+
+	g := Generator{PromoteMinors: true}
+	ranks, promo, ok := g.RanksAt(list, 3, 2)
+	assert.Equal(t, true, ok)
+	assert.NotNil(t, promo)
+	assert.Equal(t, 2, len(ranks))
+
+	var idx []int
+	for _, u := range promo.Updates {
+		idx = append(idx, u.Index)
+		list[u.Index] = u.Rank
+	}
+	assert.Equal(t, []int{1, 2, 3}, idx)
+
+	// the new ranks go in where they were asked for, and everything
+	// is in order, with a major of its own
+	list = append(list[:3], append(ranks, list[3:]...)...)
+	for j := 1; j < len(list); j++ {
+		assert.True(t, list[j-1].Major < list[j].Major, "%v %v", list[j-1], list[j])
+		assert.False(t, list[j].HasMinor())
+	}
+	assert.Equal(t, ranks[0], list[3])
+}
+
+func TestRanksAtPromoteOpenEnds(t *testing.T) {
+	list := []Posn{{Major: "b", Minor: "1"}, {Major: "b", Minor: "10"}}
+	ranks, promo, ok := Generator{PromoteMinors: true}.RanksAt(list, 1, 1)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 2, len(promo.Updates))
+	assert.True(t, promo.Updates[0].Rank.Compare(ranks[0]) < 0)
+	assert.True(t, ranks[0].Compare(promo.Updates[1].Rank) < 0)
+}
@@ -0,0 +1,377 @@
+package lexorank
+
+import (
+	"math/big"
+	"strings"
+	"sync"
+)
+
+// A RadixIndex holds a set of ranks in a trie of their digits, for
+// finding unused ranks near a given one: when many writers are
+// inserting around the same spot, each can Claim a rank of its own
+// without the list as a whole being locked.  Buckets are ignored, so
+// the ranks should all be in the same one.  The zero value is an
+// empty index for ranks in the default alphabet; a RadixIndex is safe
+// for concurrent use.
+type RadixIndex struct {
+	// Alphabet is the numeral system the ranks are written in
+	Alphabet Alphabet
+
+	mu   sync.Mutex
+	root radixNode
+	n    int
+}
+
+type radixNode struct {
+	children map[int]*radixNode // by digit value
+	used     bool
+
+	// below counts the ranks in this subtree by how many digits
+	// longer than this node they are, for telling when all the ranks
+	// up to some length are taken
+	below []int
+}
+
+// Len returns the number of ranks in the index.
+func (x *RadixIndex) Len() int {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.n
+}
+
+// values returns the digit values of p's digits, or false if it isn't
+// valid
+func (x *RadixIndex) values(p Posn) ([]int, bool) {
+	a := x.Alphabet.orDefault()
+	s := p.digits()
+	if !a.valid(s) {
+		return nil, false
+	}
+	v := make([]int, len(s))
+	for i := range v {
+		v[i] = a.order(s[i])
+	}
+	// trailing zeros make no difference to where a rank sorts
+	return trimZeros(v), true
+}
+
+// Add adds p to the index, returning false if it was already there
+// (or isn't valid).
+func (x *RadixIndex) Add(p Posn) bool {
+	v, ok := x.values(p)
+	if !ok {
+		return false
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.add(v)
+}
+
+func (x *RadixIndex) add(v []int) bool {
+	if x.contains(v) {
+		return false
+	}
+	n := &x.root
+	for d := 0; ; d++ {
+		for len(n.below) <= len(v)-d {
+			n.below = append(n.below, 0)
+		}
+		n.below[len(v)-d]++
+		if d == len(v) {
+			break
+		}
+		if n.children == nil {
+			n.children = make(map[int]*radixNode)
+		}
+		ch := n.children[v[d]]
+		if ch == nil {
+			ch = &radixNode{}
+			n.children[v[d]] = ch
+		}
+		n = ch
+	}
+	n.used = true
+	x.n++
+	return true
+}
+
+// Remove takes p out of the index, returning false if it wasn't
+// there.
+func (x *RadixIndex) Remove(p Posn) bool {
+	v, ok := x.values(p)
+	if !ok {
+		return false
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if !x.contains(v) {
+		return false
+	}
+	n := &x.root
+	for d := 0; ; d++ {
+		n.below[len(v)-d]--
+		if d == len(v) {
+			break
+		}
+		ch := n.children[v[d]]
+		if ch.below[len(v)-d-1] == 1 && sum(ch.below) == 1 {
+			// nothing else is under here
+			delete(n.children, v[d])
+			x.n--
+			return true
+		}
+		n = ch
+	}
+	n.used = false
+	x.n--
+	return true
+}
+
+func sum(counts []int) int {
+	total := 0
+	for _, c := range counts {
+		total += c
+	}
+	return total
+}
+
+// Contains reports whether p is in the index.
+func (x *RadixIndex) Contains(p Posn) bool {
+	v, ok := x.values(p)
+	if !ok {
+		return false
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.contains(v)
+}
+
+func (x *RadixIndex) contains(v []int) bool {
+	n := &x.root
+	for _, c := range v {
+		if n = n.children[c]; n == nil {
+			return false
+		}
+	}
+	return n.used
+}
+
+// Nearest returns the unused rank at most maxLen digits long that is
+// closest to p (ties going to the lower one; p itself is returned if
+// it is short enough and unused), in p's bucket.  It returns false if
+// every rank up to maxLen digits is taken.
+func (x *RadixIndex) Nearest(p Posn, maxLen int) (Posn, bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	v, ok := x.nearest(p, maxLen)
+	if !ok {
+		return Posn{}, false
+	}
+	return x.posn(p.Bucket, v), true
+}
+
+// Claim is like Nearest, but adds the rank it finds to the index, so
+// that no one else can claim it.
+func (x *RadixIndex) Claim(p Posn, maxLen int) (Posn, bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	v, ok := x.nearest(p, maxLen)
+	if !ok {
+		return Posn{}, false
+	}
+	x.add(v)
+	return x.posn(p.Bucket, v), true
+}
+
+func (x *RadixIndex) posn(bucket byte, v []int) Posn {
+	a := x.Alphabet.orDefault()
+	b := make([]byte, len(v))
+	for i, c := range v {
+		b[i] = a.digit(c)
+	}
+	return Posn{Bucket: bucket, Major: string(b)}
+}
+
+// nearest finds the closest free rank to p, as canonical digit values.
+// Ranks up to maxLen digits long are thought of as maxLen-digit
+// numbers (padding them with zeros), and the search is for the free
+// ones either side of p in that grid.
+func (x *RadixIndex) nearest(p Posn, maxLen int) ([]int, bool) {
+	a := x.Alphabet.orDefault()
+	s := p.digits()
+	if maxLen <= 0 || !a.valid(s) {
+		return nil, false
+	}
+	target := make([]int, maxLen)
+	for i := 0; i < maxLen && i < len(s); i++ {
+		target[i] = a.order(s[i])
+	}
+	// the all-zero rank (the empty string) is never free
+	lo, loOK := x.lastFree(&x.root, target, true, true)
+	var hi []int
+	hiOK := false
+	if len(s) <= maxLen || strings.Trim(s[maxLen:], string(a.min())) == "" {
+		hi, hiOK = x.firstFree(&x.root, target, true, true)
+	} else if increment(target, a.base()) {
+		// p was cut short, so the points above it start one up
+		hi, hiOK = x.firstFree(&x.root, target, true, true)
+	}
+	switch {
+	case !loOK && !hiOK:
+		return nil, false
+	case !loOK:
+		return trimZeros(hi), true
+	case !hiOK:
+		return trimZeros(lo), true
+	}
+	width := max(maxLen, len(s))
+	pv := digitsValue(a, s, width)
+	dlo := new(big.Int).Sub(pv, x.value(lo, width))
+	dhi := new(big.Int).Sub(x.value(hi, width), pv)
+	if dhi.Cmp(dlo) < 0 {
+		return trimZeros(hi), true
+	}
+	return trimZeros(lo), true
+}
+
+func (x *RadixIndex) value(v []int, width int) *big.Int {
+	a := x.Alphabet.orDefault()
+	base := big.NewInt(int64(a.base()))
+	out := new(big.Int)
+	for i := 0; i < width; i++ {
+		out.Mul(out, base)
+		if i < len(v) {
+			out.Add(out, big.NewInt(int64(v[i])))
+		}
+	}
+	return out
+}
+
+// increment adds one to v, returning false if it overflows
+func increment(v []int, base int) bool {
+	for i := len(v) - 1; i >= 0; i-- {
+		if v[i]++; v[i] < base {
+			return true
+		}
+		v[i] = 0
+	}
+	return false
+}
+
+func trimZeros(v []int) []int {
+	for len(v) > 0 && v[len(v)-1] == 0 {
+		v = v[:len(v)-1]
+	}
+	return v
+}
+
+// full reports whether every point under n, which has rel digits
+// below it, is taken; zeroUsed says whether the point of all zeros
+// is, which (since it's a shorter rank) is marked on an ancestor
+func (x *RadixIndex) full(n *radixNode, rel int, zeroUsed bool) bool {
+	used := 0
+	if zeroUsed && !n.used {
+		used++
+	}
+	for k := 0; k <= rel && k < len(n.below); k++ {
+		used += n.below[k]
+	}
+	room, base := 1, x.Alphabet.orDefault().base()
+	for range rel {
+		if room *= base; room > used {
+			return false
+		}
+	}
+	return room <= used
+}
+
+// firstFree finds the first free point at or after target (or after
+// all zeros, if not tight) under n
+func (x *RadixIndex) firstFree(n *radixNode, target []int, tight, zeroUsed bool) ([]int, bool) {
+	zeroUsed = zeroUsed || n.used
+	if len(target) == 0 {
+		return nil, !zeroUsed
+	}
+	start := 0
+	if tight {
+		start = target[0]
+	}
+	for c := start; c < x.Alphabet.orDefault().base(); c++ {
+		t := tight && c == target[0]
+		chZero := c == 0 && zeroUsed
+		ch := n.children[c]
+		if ch == nil {
+			// nothing under here is taken, except perhaps the point
+			// of all zeros
+			rest := make([]int, len(target)-1)
+			if t {
+				copy(rest, target[1:])
+			}
+			if chZero && allZero(rest) {
+				if len(rest) == 0 {
+					continue
+				}
+				rest[len(rest)-1] = 1
+			}
+			return append([]int{c}, rest...), true
+		}
+		if x.full(ch, len(target)-1, chZero) {
+			continue
+		}
+		if rest, ok := x.firstFree(ch, target[1:], t, chZero); ok {
+			return append([]int{c}, rest...), true
+		}
+	}
+	return nil, false
+}
+
+// lastFree is like firstFree, but finds the last free point at or
+// before target (or before all maxes, if not tight)
+func (x *RadixIndex) lastFree(n *radixNode, target []int, tight, zeroUsed bool) ([]int, bool) {
+	zeroUsed = zeroUsed || n.used
+	if len(target) == 0 {
+		return nil, !zeroUsed
+	}
+	base := x.Alphabet.orDefault().base()
+	start := base - 1
+	if tight {
+		start = target[0]
+	}
+	for c := start; c >= 0; c-- {
+		t := tight && c == target[0]
+		chZero := c == 0 && zeroUsed
+		ch := n.children[c]
+		if ch == nil {
+			rest := make([]int, len(target)-1)
+			if t {
+				copy(rest, target[1:])
+			} else {
+				for i := range rest {
+					rest[i] = base - 1
+				}
+			}
+			if chZero && allZero(rest) {
+				// the all-zero point is the lowest, so there's
+				// nothing free at or below it here
+				continue
+			}
+			return append([]int{c}, rest...), true
+		}
+		if x.full(ch, len(target)-1, chZero) {
+			continue
+		}
+		if rest, ok := x.lastFree(ch, target[1:], t, chZero); ok {
+			return append([]int{c}, rest...), true
+		}
+	}
+	return nil, false
+}
+
+func allZero(v []int) bool {
+	for _, c := range v {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,125 @@
+package lexorank
+
+import (
+	"math/big"
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRadixIndex(t *testing.T) {
+	var x RadixIndex
+	for _, s := range []string{"U", "V", "W", "Uz", "T"} {
+		assert.True(t, x.Add(Posn{Major: s}))
+	}
+	assert.False(t, x.Add(Posn{Major: "U0"}))
+	assert.False(t, x.Add(Posn{Major: "U-"}))
+	assert.Equal(t, 5, x.Len())
+	assert.True(t, x.Contains(Posn{Major: "U00"}))
+
+	p, ok := x.Nearest(Posn{Bucket: 1, Major: "U"}, 1)
+	assert.True(t, ok)
+	assert.Equal(t, Posn{Bucket: 1, Major: "S"}, p)
+	// ties go to the lower rank
+	p, ok = x.Nearest(Posn{Major: "U"}, 2)
+	assert.True(t, ok)
+	assert.Equal(t, "Tz", p.Major)
+	p, ok = x.Nearest(Posn{Major: "U1"}, 2)
+	assert.True(t, ok)
+	assert.Equal(t, "U1", p.Major)
+	p, ok = x.Nearest(Posn{Major: "Uzzz"}, 2)
+	assert.True(t, ok)
+	assert.Equal(t, "V1", p.Major)
+
+	assert.True(t, x.Remove(Posn{Major: "U"}))
+	assert.False(t, x.Remove(Posn{Major: "U"}))
+	assert.True(t, x.Contains(Posn{Major: "Uz"}))
+	p, ok = x.Nearest(Posn{Major: "U"}, 2)
+	assert.True(t, ok)
+	assert.Equal(t, "U", p.Major)
+}
+
+func TestRadixIndexBruteForce(t *testing.T) {
+	a, err := NewAlphabet("0123")
+	assert.NoError(t, err)
+	rng := rand.New(rand.NewSource(1))
+	const maxLen = 3
+
+	// every rank of up to three digits, as three-digit numbers
+	var all []string
+	for v := 1; v < 64; v++ {
+		s := []byte{a.digit(v / 16), a.digit(v / 4 % 4), a.digit(v % 4)}
+		all = append(all, strings.TrimRight(string(s), "0"))
+	}
+	value := func(s string, width int) *big.Int {
+		return digitsValue(a, s, width)
+	}
+
+	for round := 0; round < 200; round++ {
+		x := RadixIndex{Alphabet: a}
+		used := map[string]bool{}
+		for _, s := range all {
+			if rng.Intn(10) < 8 {
+				x.Add(Posn{Major: s})
+				used[s] = true
+			}
+		}
+		for k := 0; k < 20; k++ {
+			b := make([]byte, 1+rng.Intn(5))
+			for i := range b {
+				b[i] = a.digit(rng.Intn(4))
+			}
+			p := Posn{Major: string(b)}
+			width := max(maxLen, len(b))
+			pv := value(p.Major, width)
+
+			var best string
+			var bestD *big.Int
+			for _, s := range all {
+				if used[s] {
+					continue
+				}
+				d := new(big.Int).Sub(value(s, width), pv)
+				d.Abs(d)
+				if bestD == nil || d.Cmp(bestD) < 0 {
+					best, bestD = s, d
+				}
+			}
+			got, ok := x.Nearest(p, maxLen)
+			assert.Equal(t, bestD != nil, ok)
+			if ok {
+				d := new(big.Int).Sub(value(got.Major, width), pv)
+				assert.Equal(t, 0, d.Abs(d).Cmp(bestD), "%s: got %s, want %s", p.Major, got.Major, best)
+				assert.False(t, used[got.Major])
+			}
+		}
+	}
+}
+
+func TestRadixIndexClaim(t *testing.T) {
+	var x RadixIndex
+	x.Add(Posn{Major: "U"})
+	var mu sync.Mutex
+	claimed := map[string]bool{}
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				p, ok := x.Claim(Posn{Major: "U"}, 3)
+				assert.True(t, ok)
+				mu.Lock()
+				assert.False(t, claimed[p.Major])
+				claimed[p.Major] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, claimed, 400)
+	assert.Equal(t, 401, x.Len())
+}
@@ -0,0 +1,226 @@
+package lexorank
+
+// Rank returns the shortest string that sorts strictly between prev
+// and next and doesn't end in the smallest digit (see NoTrailingMin).
+// An empty prev or next stands for the lowest ("0") or highest ("z")
+// rank respectively.  Unlike Ranks, no trailer is
+// attached, so the result is only as long as it needs to be, which
+// keeps keys (and the indexes built on them) small over many
+// inserts.
+//
+// If there is no room between prev and next (or they are out of
+// order or contain characters outside the alphabet), prev is returned
+// along with false.
+func Rank(prev, next string) (string, bool) {
+	return Generator{}.Rank(prev, next)
+}
+
+// Rank is like the package-level Rank, but works in the generator's
+// alphabet, where the lowest and highest ranks are its smallest and
+// largest digits.
+func (g Generator) Rank(prev, next string) (string, bool) {
+	a := g.alphabet()
+	lo, hi := prev, next
+	if lo == "" {
+		lo = string(a.min())
+	}
+	if hi == "" {
+		hi = string(a.max())
+	}
+	if !a.valid(lo) || !a.valid(hi) {
+		return prev, false
+	}
+	lo, hi = a.canonical(lo), a.canonical(hi)
+	if lo >= hi {
+		return prev, false
+	}
+	if g.Writers > 1 {
+		var ok bool
+		if lo, hi, ok = g.writerRange(a, lo, hi); !ok {
+			return prev, false
+		}
+	}
+	var step int
+	switch {
+	case prev == "" && next != "":
+		step = g.HeadStep
+	case next == "" && prev != "":
+		step = g.AppendStep
+	}
+	if step > 0 {
+		if ranks, ok := g.stepRanks(a, lo, hi, 1, step, prev == ""); ok && between(a, []byte(lo), []byte(ranks[0]), []byte(hi)) {
+			if g.Metrics != nil {
+				g.observe(lo, hi, ranks)
+			}
+			g.checkLowGap(lo, hi, ranks)
+			return ranks[0], true
+		}
+	}
+	rank, ok := shortestBetween(a, lo, hi)
+	if ok && g.Reserve > 0 {
+		rank = g.reserve(a, lo, hi, rank)
+	}
+	if !ok || g.tooLong(len(rank)) || !between(a, []byte(lo), []byte(rank), []byte(hi)) {
+		g.fire(g.OnExhaustion, lo, hi, 1)
+		return prev, false
+	}
+	if g.Metrics != nil {
+		g.observe(lo, hi, []string{rank})
+	}
+	g.checkLowGap(lo, hi, []string{rank})
+	return rank, true
+}
+
+// AppendRank is like Rank, but works on byte slices and appends the
+// new rank to dst, returning the extended slice.  Nil or empty bounds
+// are open ended, and HeadStep and AppendStep apply when only one of
+// them is.  Unless the generator has Metrics, callbacks or steps
+// configured, it doesn't allocate (beyond growing dst), which matters
+// in bulk import paths.  On failure, dst is returned unchanged.
+func (g Generator) AppendRank(dst, prev, next []byte) ([]byte, bool) {
+	a := g.alphabet()
+	var step int
+	switch {
+	case len(prev) == 0 && len(next) != 0:
+		step = g.HeadStep
+	case len(next) == 0 && len(prev) != 0:
+		step = g.AppendStep
+	}
+	head := len(prev) == 0
+	var lo, hi [1]byte
+	if len(prev) == 0 {
+		lo[0] = a.min()
+		prev = lo[:]
+	}
+	if len(next) == 0 {
+		hi[0] = a.max()
+		next = hi[:]
+	}
+	if !a.validBytes(prev) || !a.validBytes(next) || a.compare(prev, next) >= 0 {
+		return dst, false
+	}
+	if g.Writers > 1 {
+		lo, hi, ok := g.writerRange(a, a.canonical(string(prev)), a.canonical(string(next)))
+		if !ok {
+			return dst, false
+		}
+		prev, next = []byte(lo), []byte(hi)
+	}
+	if step > 0 {
+		lo, hi := a.canonical(string(prev)), a.canonical(string(next))
+		if ranks, ok := g.stepRanks(a, lo, hi, 1, step, head); ok && between(a, prev, []byte(ranks[0]), next) {
+			if g.Metrics != nil {
+				g.observe(lo, hi, ranks)
+			}
+			g.checkLowGap(lo, hi, ranks)
+			return append(dst, ranks[0]...), true
+		}
+	}
+	start := len(dst)
+	out, ok := appendBetween(dst, a, prev, next)
+	if !ok || g.tooLong(len(out)-start) || !between(a, prev, out[start:], next) {
+		if g.OnExhaustion != nil {
+			g.fire(g.OnExhaustion, string(prev), string(next), 1)
+		}
+		return dst, false
+	}
+	if g.Reserve > 0 {
+		lo, hi := a.canonical(string(prev)), a.canonical(string(next))
+		rank := g.reserve(a, lo, hi, string(out[start:]))
+		out = append(out[:start], rank...)
+	}
+	if g.Metrics != nil || g.LowGap > 0 {
+		rank := string(out[start:])
+		lo, hi := a.canonical(string(prev)), a.canonical(string(next))
+		if g.Metrics != nil {
+			g.observe(lo, hi, []string{rank})
+		}
+		g.checkLowGap(lo, hi, []string{rank})
+	}
+	return out, true
+}
+
+// between reports whether the digits r sort strictly between lo and
+// hi, as a last check before a rank is handed out
+func between(a Alphabet, lo, r, hi []byte) bool {
+	return a.compare(lo, r) < 0 && a.compare(r, hi) < 0
+}
+
+// shortestBetween finds the shortest string strictly between lo and
+// hi, which must satisfy lo < hi, that doesn't end in the smallest
+// digit.
+func shortestBetween(a Alphabet, lo, hi string) (string, bool) {
+	b, ok := appendBetween(nil, a, []byte(lo), []byte(hi))
+	return string(b), ok
+}
+
+// appendBetween appends the shortest string strictly between lo and
+// hi (which must be valid and satisfy lo < hi) that doesn't end in
+// the smallest digit to dst.  Digits are compared by value and
+// written out canonically, so the bounds may use aliases.  It fails
+// only if hi is lo followed by nothing but smallest digits.
+func appendBetween(dst []byte, a Alphabet, lo, hi []byte) ([]byte, bool) {
+	// skip the common prefix; since lo < hi, hi can't run out first
+	i := 0
+	for i < len(lo) && a.values[lo[i]] == a.values[hi[i]] {
+		i++
+	}
+
+	l := -1 // lo has run out, so any digit at all puts us above it
+	if i < len(lo) {
+		l = a.order(lo[i])
+	}
+	h := a.order(hi[i])
+
+	if h-l > 1 {
+		// there is room for a digit strictly in between
+		dst = a.appendCanonical(dst, hi[:i])
+		return appendMid(dst, a, l, h), true
+	}
+
+	// No room at this position, so the answer must share a prefix
+	// with one of the bounds.  Sharing hi's prefix is always shorter,
+	// but it's only possible when hi continues past this position
+	// (because hi[:i+1] is then a proper prefix of hi).  If that
+	// prefix ends in the smallest digit, it's lo with nothing to spare,
+	// so go on to the next digit of hi.
+	if i+1 < len(hi) {
+		if h == 0 {
+			return appendBetween(dst, a, hi[:i+1], hi)
+		}
+		return a.appendCanonical(dst, hi[:i+1]), true
+	}
+	if l < 0 {
+		return dst, false
+	}
+
+	// Otherwise follow lo, skipping over maximal digits (which have
+	// nothing above them) until there is room.
+	j := i + 1
+	for j < len(lo) && a.order(lo[j]) == a.base()-1 {
+		j++
+	}
+	l = -1
+	if j < len(lo) {
+		l = a.order(lo[j])
+	}
+	dst = a.appendCanonical(dst, lo[:j])
+	return appendMid(dst, a, l, a.base()), true
+}
+
+// appendMid appends a digit halfway between the digit values l and h
+// (where h-l > 1, and -1 and the base stand for either end) to dst.
+// If that's the smallest digit, which would leave no room just before
+// the rank, it takes the next one up instead, or if there isn't one
+// between l and h, appends the smallest digit followed by the middle
+// of the next position.
+func appendMid(dst []byte, a Alphabet, l, h int) []byte {
+	d := (l + h) / 2
+	if d == 0 {
+		if h <= 1 {
+			return appendMid(append(dst, a.digit(0)), a, -1, a.base())
+		}
+		d = 1
+	}
+	return append(dst, a.digit(d))
+}
@@ -0,0 +1,215 @@
+package lexorank
+
+import (
+	"context"
+	"iter"
+)
+
+// RankAll gives ranks to a list of items that is already in the order
+// it should be, such as one just reconciled with an external system,
+// touching as few of them as it can.  Items with valid ranks that are
+// in order among themselves keep them (the most of them that can),
+// and the rest (new items, with no rank, and those that are out of
+// place) get new ranks, spread between the ranks kept either side.
+// It returns the items to update, with their new ranks, in list
+// order.
+func RankAll(items []Item) ([]Item, error) {
+	return Generator{}.RankAllContext(context.Background(), items)
+}
+
+// RankAllContext is like RankAll, but gives up with the context's
+// error if it is cancelled.
+func RankAllContext(ctx context.Context, items []Item) ([]Item, error) {
+	return Generator{}.RankAllContext(ctx, items)
+}
+
+// RankAll is like the package-level RankAll, but uses the generator's
+// configuration.
+func (g Generator) RankAll(items []Item) ([]Item, error) {
+	return g.RankAllContext(context.Background(), items)
+}
+
+// RankAllContext is like the package-level RankAllContext, but uses
+// the generator's configuration.
+func (g Generator) RankAllContext(ctx context.Context, items []Item) (updates []Item, err error) {
+	ctx, end := g.startSpan(ctx, "RankAll", len(items))
+	defer func() { end(len(updates), err) }()
+
+	err = g.rankRuns(ctx, items, g.keepers(items, nil), nil, len(items), func(it Item) error {
+		updates = append(updates, it)
+		return nil
+	})
+	return updates, err
+}
+
+// rankRuns gives new ranks to the first n items that aren't marked to
+// be kept, each run of them going between the kept items either side
+// (or prev, before the first), passing them to emit
+func (g Generator) rankRuns(ctx context.Context, items []Item, keep []bool, prev *Posn, n int, emit func(Item) error) error {
+	for i := 0; i < n; {
+		if keep[i] {
+			prev = &items[i].Rank
+			i++
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// the run of items needing ranks, and the kept rank after it
+		j := i
+		for j < len(items) && !keep[j] {
+			j++
+		}
+		var next *Posn
+		if j < len(items) {
+			next = &items[j].Rank
+		}
+		ranks, ok := g.AllocateBlock(prev, next, j-i)
+		if !ok {
+			return ErrNoRoom
+		}
+		for k, p := range ranks {
+			if err := emit(Item{ID: items[i+k].ID, Rank: p}); err != nil {
+				return err
+			}
+		}
+		i = j
+	}
+	return nil
+}
+
+// keepers marks the items whose ranks can stay: the longest run of
+// valid ranks after after (if it isn't nil), not necessarily next to
+// each other, that are strictly increasing
+func (g Generator) keepers(items []Item, after *Posn) []bool {
+	a := g.alphabet()
+	// tails[l] is the index of the item that ends the best increasing
+	// run of length l+1 found so far, and back links each item to the
+	// one before it in its run
+	var tails []int
+	back := make([]int, len(items))
+	for i, it := range items {
+		p := it.Rank
+		if p.Major == "" || p.Bucket > MaxBucket || !a.valid(p.digits()) ||
+			after != nil && p.Compare(*after) <= 0 {
+			continue
+		}
+		lo, hi := 0, len(tails)
+		for lo < hi {
+			m := (lo + hi) / 2
+			if items[tails[m]].Rank.Compare(p) < 0 {
+				lo = m + 1
+			} else {
+				hi = m
+			}
+		}
+		if lo < len(tails) && items[tails[lo]].Rank.Equal(p) {
+			// a duplicate, and the first of them is as good
+			continue
+		}
+		back[i] = -1
+		if lo > 0 {
+			back[i] = tails[lo-1]
+		}
+		if lo == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[lo] = i
+		}
+	}
+	keep := make([]bool, len(items))
+	if len(tails) > 0 {
+		for i := tails[len(tails)-1]; i >= 0; i = back[i] {
+			keep[i] = true
+		}
+	}
+	return keep
+}
+
+// rankAllWindow is how many items RankAllSeq looks at a time
+const rankAllWindow = 4096
+
+// RankAllSeq is like RankAll, but for lists too big to hold in memory:
+// it reads the items, in order, from items (which might be reading
+// them from a database cursor), and passes the updates to emit as it
+// goes, holding only a few thousand items at a time.  As it can't see
+// the whole list at once, it may touch more items than RankAll would:
+// the ranks it keeps are picked from a window of items at a time, and
+// if there's nothing worth keeping for a whole window (a long run of
+// new items), they're squeezed in just after the last rank kept, to
+// leave the rest of the keyspace for the items still to come.
+func RankAllSeq(ctx context.Context, items iter.Seq2[Item, error], emit func(Item) error) error {
+	return Generator{}.RankAllSeq(ctx, items, emit)
+}
+
+// RankAllSeq is like the package-level RankAllSeq, but uses the
+// generator's configuration.
+func (g Generator) RankAllSeq(ctx context.Context, items iter.Seq2[Item, error], emit func(Item) error) (err error) {
+	ctx, end := g.startSpan(ctx, "RankAllSeq", 0)
+	touched := 0
+	defer func() { end(touched, err) }()
+	count := func(it Item) error {
+		touched++
+		return emit(it)
+	}
+
+	var buf []Item
+	var prev *Posn
+	flush := func(final bool) error {
+		keep := g.keepers(buf, prev)
+		if final {
+			return g.rankRuns(ctx, buf, keep, prev, len(buf), count)
+		}
+		last := -1
+		for i := len(buf) - 1; i >= 0 && last < 0; i-- {
+			if keep[i] {
+				last = i
+			}
+		}
+		if last >= 0 {
+			if err := g.rankRuns(ctx, buf, keep, prev, last+1, count); err != nil {
+				return err
+			}
+			p := buf[last].Rank
+			prev = &p
+			buf = append(buf[:0], buf[last+1:]...)
+		}
+		if len(buf) < rankAllWindow/2 {
+			return nil
+		}
+		// nothing left is worth keeping, and there are a lot of
+		// them, so squeeze them in as close after prev as they'll go
+		next := Posn{Major: string(g.alphabet().digit(1))}
+		if prev != nil {
+			var ok bool
+			if next, ok = g.Next(*prev); !ok {
+				return ErrNoRoom
+			}
+		}
+		ranks, ok := g.AllocateBlock(prev, &next, len(buf))
+		if !ok {
+			return ErrNoRoom
+		}
+		for k, p := range ranks {
+			if err := count(Item{ID: buf[k].ID, Rank: p}); err != nil {
+				return err
+			}
+		}
+		prev = &ranks[len(ranks)-1]
+		buf = buf[:0]
+		return nil
+	}
+
+	for it, err := range items {
+		if err != nil {
+			return err
+		}
+		buf = append(buf, it)
+		if len(buf) >= rankAllWindow {
+			if err := flush(false); err != nil {
+				return err
+			}
+		}
+	}
+	return flush(true)
+}
@@ -0,0 +1,118 @@
+package lexorank
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRankAll(t *testing.T) {
+	ranks, err := Rebalance(6, 0)
+	assert.NoError(t, err)
+	items := []Item{
+		{"new1", Posn{}},
+		{"a", ranks[0]},
+		{"d", ranks[3]}, // moved up from further down
+		{"b", ranks[1]},
+		{"c", ranks[2]},
+		{"new2", Posn{}},
+		{"bad", Posn{Major: "a!"}},
+		{"e", ranks[4]},
+		{"dup", ranks[4]},
+		{"f", ranks[5]},
+	}
+	updates, err := RankAll(items)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"new1", "d", "new2", "bad", "dup"}, ids(updates))
+
+	// with the updates applied, the items are in order
+	byID := map[string]Posn{}
+	for _, u := range updates {
+		byID[u.ID] = u.Rank
+	}
+	for i := range items {
+		if p, ok := byID[items[i].ID]; ok {
+			items[i].Rank = p
+		}
+		if i > 0 {
+			assert.Equal(t, -1, items[i-1].Rank.Compare(items[i].Rank), items[i].ID)
+		}
+	}
+
+	// and then there's nothing more to do
+	updates, err = RankAll(items)
+	assert.NoError(t, err)
+	assert.Empty(t, updates)
+
+	// a list of nothing but new items is spread out from scratch
+	updates, err = RankAll([]Item{{ID: "x"}, {ID: "y"}})
+	assert.NoError(t, err)
+	assert.Len(t, updates, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = RankAllContext(ctx, []Item{{ID: "x"}})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = Generator{MaxLength: 6}.RankAll([]Item{{"a", Posn{Major: "a00000"}}, {ID: "x"}, {"b", Posn{Major: "a00001"}}})
+	assert.ErrorIs(t, err, ErrNoRoom)
+}
+
+func TestRankAllSeq(t *testing.T) {
+	ranks, err := Rebalance(3*rankAllWindow, 1)
+	assert.NoError(t, err)
+	var items []Item
+	for i, p := range ranks {
+		items = append(items, Item{ID: "old" + strconv.Itoa(i), Rank: p})
+		switch {
+		case i == 10:
+			// a long run of new items, longer than the window
+			for k := 0; k < rankAllWindow+10; k++ {
+				items = append(items, Item{ID: "new" + strconv.Itoa(k)})
+			}
+		case i%100 == 50:
+			items = append(items, Item{ID: "one" + strconv.Itoa(i)})
+		}
+	}
+	// and one moved a long way
+	items[5].Rank = ranks[len(ranks)-5]
+
+	seq := func(yield func(Item, error) bool) {
+		for _, it := range items {
+			if !yield(it, nil) {
+				return
+			}
+		}
+	}
+	byID := map[string]Posn{}
+	assert.NoError(t, RankAllSeq(context.Background(), seq, func(it Item) error {
+		_, dup := byID[it.ID]
+		assert.False(t, dup)
+		byID[it.ID] = it.Rank
+		return nil
+	}))
+	// all the new items and the moved one, and no more than a few
+	// others, get new ranks
+	want, err := RankAll(items)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, len(byID), len(want))
+	assert.Less(t, len(byID), len(want)+10)
+	for i := range items {
+		if p, ok := byID[items[i].ID]; ok {
+			items[i].Rank = p
+		}
+		if i > 0 {
+			assert.Equal(t, -1, items[i-1].Rank.Compare(items[i].Rank), items[i].ID)
+		}
+	}
+
+	// errors from the source are passed on
+	boom := errors.New("boom")
+	err = RankAllSeq(context.Background(), func(yield func(Item, error) bool) {
+		yield(Item{}, boom)
+	}, func(Item) error { return nil })
+	assert.ErrorIs(t, err, boom)
+}
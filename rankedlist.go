@@ -0,0 +1,279 @@
+package lexorank
+
+import (
+	"iter"
+	"slices"
+)
+
+// A RankedList is an ordered in-memory index from ranks to values,
+// for servers that keep lists in memory and want to insert into them
+// by rank directly.  The zero value is an empty list using the
+// default Generator.  A RankedList isn't safe for concurrent use,
+// though its snapshots are (see Snapshot).
+type RankedList[V any] struct {
+	// Generator makes the ranks for InsertBetween
+	Generator Generator
+
+	// the entries are kept in order in chunks of at most listChunk,
+	// which makes inserting and deleting cheap without the fuss of a
+	// balanced tree
+	chunks [][]ListEntry[V]
+	n      int
+
+	// shared is set when a snapshot shares chunks, and from then on
+	// owned says which chunks have been copied since, and so can be
+	// changed in place (if it's nil, they all can)
+	shared bool
+	owned  []bool
+}
+
+// A ListEntry is an item in a RankedList.
+type ListEntry[V any] struct {
+	Rank  Posn
+	Value V
+}
+
+const listChunk = 128
+
+// Len returns the number of entries.
+func (l *RankedList[V]) Len() int {
+	return l.n
+}
+
+// find returns the chunk p is in (or would go in) and where in it
+func (l *RankedList[V]) find(p Posn) (c, i int, found bool) {
+	c, _ = slices.BinarySearchFunc(l.chunks, p, func(chunk []ListEntry[V], p Posn) int {
+		return chunk[len(chunk)-1].Rank.Compare(p)
+	})
+	if c == len(l.chunks) {
+		if c == 0 {
+			return 0, 0, false
+		}
+		// after the end goes at the end of the last chunk
+		return c - 1, len(l.chunks[c-1]), false
+	}
+	i, found = slices.BinarySearchFunc(l.chunks[c], p, func(e ListEntry[V], p Posn) int {
+		return e.Rank.Compare(p)
+	})
+	return c, i, found
+}
+
+// Get returns the value with rank p.
+func (l *RankedList[V]) Get(p Posn) (V, bool) {
+	c, i, found := l.find(p)
+	if !found {
+		var zero V
+		return zero, false
+	}
+	return l.chunks[c][i].Value, true
+}
+
+// Set gives the value with rank p, replacing any there already.
+func (l *RankedList[V]) Set(p Posn, v V) {
+	c, i, found := l.find(p)
+	if found {
+		l.own(c)
+		l.chunks[c][i].Value = v
+		return
+	}
+	l.n++
+	if len(l.chunks) == 0 {
+		l.chunks = [][]ListEntry[V]{{{p, v}}}
+		l.shared, l.owned = false, nil
+		return
+	}
+	l.own(c)
+	chunk := slices.Insert(l.chunks[c], i, ListEntry[V]{p, v})
+	if len(chunk) <= listChunk {
+		l.chunks[c] = chunk
+		return
+	}
+	// split full chunks in two, copying the second half so that the
+	// halves don't share storage
+	half := len(chunk) / 2
+	l.chunks[c] = chunk[:half:half]
+	l.chunks = slices.Insert(l.chunks, c+1, slices.Clone(chunk[half:]))
+	if l.owned != nil {
+		l.owned = slices.Insert(l.owned, c+1, true)
+	}
+}
+
+// Delete removes the entry with rank p, and reports whether there was
+// one.
+func (l *RankedList[V]) Delete(p Posn) bool {
+	c, i, found := l.find(p)
+	if !found {
+		return false
+	}
+	l.n--
+	l.own(c)
+	chunk := slices.Delete(l.chunks[c], i, i+1)
+	if len(chunk) == 0 {
+		l.deleteChunk(c)
+		return true
+	}
+	l.chunks[c] = chunk
+	// merge small chunks with the next, so that a list that shrinks
+	// doesn't end up as lots of tiny chunks
+	if c+1 < len(l.chunks) && len(chunk)+len(l.chunks[c+1]) <= listChunk/2 {
+		l.chunks[c] = append(chunk, l.chunks[c+1]...)
+		l.deleteChunk(c + 1)
+	}
+	return true
+}
+
+func (l *RankedList[V]) deleteChunk(c int) {
+	l.chunks = slices.Delete(l.chunks, c, c+1)
+	if l.owned != nil {
+		l.owned = slices.Delete(l.owned, c, c+1)
+	}
+}
+
+// own makes chunk c (and the list of chunks) safe to change in place,
+// copying whatever a snapshot might still be looking at
+func (l *RankedList[V]) own(c int) {
+	if l.shared {
+		l.chunks = slices.Clone(l.chunks)
+		l.owned = make([]bool, len(l.chunks))
+		l.shared = false
+	}
+	if l.owned != nil && !l.owned[c] {
+		// with room to grow, since it's about to
+		l.chunks[c] = append(make([]ListEntry[V], 0, len(l.chunks[c])+1), l.chunks[c]...)
+		l.owned[c] = true
+	}
+}
+
+// Snapshot returns a view of the list as it is now, which doesn't
+// change when the list does.  It's cheap to take, since the list is
+// only copied a chunk at a time as it changes after, and once taken
+// it can be read from any number of goroutines without a lock while
+// the list is being changed, so a server can render a consistent view
+// of a list while writers carry on.  (Taking it is a change to the
+// list, as far as locking goes.)
+func (l *RankedList[V]) Snapshot() ListSnapshot[V] {
+	l.shared = true
+	return ListSnapshot[V]{list: RankedList[V]{Generator: l.Generator, chunks: l.chunks, n: l.n}}
+}
+
+// A ListSnapshot is a view of a RankedList taken by Snapshot.  It is
+// safe for concurrent use.
+type ListSnapshot[V any] struct {
+	list RankedList[V]
+}
+
+// Len returns the number of entries.
+func (s ListSnapshot[V]) Len() int {
+	return s.list.Len()
+}
+
+// Get returns the value with rank p.
+func (s ListSnapshot[V]) Get(p Posn) (V, bool) {
+	return s.list.Get(p)
+}
+
+// Neighbors returns the entries either side of rank p, as for
+// RankedList.Neighbors.
+func (s ListSnapshot[V]) Neighbors(p Posn) (prev, next *ListEntry[V]) {
+	return s.list.Neighbors(p)
+}
+
+// Range returns the entries with ranks from lo up to (but not
+// including) hi, in order; a nil lo or hi is open ended.
+func (s ListSnapshot[V]) Range(lo, hi *Posn) iter.Seq2[Posn, V] {
+	return s.list.Range(lo, hi)
+}
+
+// All returns all the entries, in order.
+func (s ListSnapshot[V]) All() iter.Seq2[Posn, V] {
+	return s.list.All()
+}
+
+// before returns the entry before position i of chunk c, if any
+func (l *RankedList[V]) before(c, i int) *ListEntry[V] {
+	if i > 0 {
+		e := l.chunks[c][i-1]
+		return &e
+	}
+	if c > 0 {
+		chunk := l.chunks[c-1]
+		e := chunk[len(chunk)-1]
+		return &e
+	}
+	return nil
+}
+
+// Neighbors returns the entries either side of rank p (which needn't
+// be in the list), or nil at the ends of the list.
+func (l *RankedList[V]) Neighbors(p Posn) (prev, next *ListEntry[V]) {
+	if l.n == 0 {
+		return nil, nil
+	}
+	c, i, found := l.find(p)
+	prev = l.before(c, i)
+	if found {
+		i++
+	}
+	if i == len(l.chunks[c]) {
+		c, i = c+1, 0
+	}
+	if c < len(l.chunks) {
+		e := l.chunks[c][i]
+		next = &e
+	}
+	return prev, next
+}
+
+// InsertBetween adds v to the list with a rank between prev and next,
+// which must be neighbours in the list (either may be nil, for the
+// start or end of the list), and returns its rank.
+func (l *RankedList[V]) InsertBetween(prev, next *Posn, v V) (Posn, bool) {
+	// check the bounds really are neighbours, so the new rank can't
+	// collide with anything
+	var after *ListEntry[V]
+	if prev != nil {
+		if _, ok := l.Get(*prev); !ok {
+			return Posn{}, false
+		}
+		_, after = l.Neighbors(*prev)
+	} else if l.n > 0 {
+		e := l.chunks[0][0]
+		after = &e
+	}
+	if (next == nil) != (after == nil) || next != nil && !after.Rank.Equal(*next) {
+		return Posn{}, false
+	}
+
+	ranks, ok := l.Generator.Ranks(1, prev, next)
+	if !ok {
+		return Posn{}, false
+	}
+	l.Set(ranks[0], v)
+	return ranks[0], true
+}
+
+// Range returns the entries with ranks from lo up to (but not
+// including) hi, in order; a nil lo or hi is open ended.
+func (l *RankedList[V]) Range(lo, hi *Posn) iter.Seq2[Posn, V] {
+	return func(yield func(Posn, V) bool) {
+		c, i := 0, 0
+		if lo != nil {
+			c, i, _ = l.find(*lo)
+		}
+		for ; c < len(l.chunks); c, i = c+1, 0 {
+			for _, e := range l.chunks[c][i:] {
+				if hi != nil && e.Rank.Compare(*hi) >= 0 {
+					return
+				}
+				if !yield(e.Rank, e.Value) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// All returns all the entries, in order.
+func (l *RankedList[V]) All() iter.Seq2[Posn, V] {
+	return l.Range(nil, nil)
+}
@@ -0,0 +1,202 @@
+package lexorank
+
+import (
+	"iter"
+	"math/rand"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRankedList(t *testing.T) {
+	var l RankedList[string]
+	a, ok := l.InsertBetween(nil, nil, "a")
+	assert.True(t, ok)
+	c, ok := l.InsertBetween(&a, nil, "c")
+	assert.True(t, ok)
+	b, ok := l.InsertBetween(&a, &c, "b")
+	assert.True(t, ok)
+	assert.Equal(t, 3, l.Len())
+
+	// the bounds have to be neighbours in the list
+	_, ok = l.InsertBetween(&a, &c, "x")
+	assert.False(t, ok)
+	_, ok = l.InsertBetween(nil, nil, "x")
+	assert.False(t, ok)
+	_, ok = l.InsertBetween(nil, &b, "x")
+	assert.False(t, ok)
+	_, ok = l.InsertBetween(&Posn{Major: "0"}, &a, "x")
+	assert.False(t, ok)
+
+	v, ok := l.Get(b)
+	assert.True(t, ok)
+	assert.Equal(t, "b", v)
+
+	prev, next := l.Neighbors(b)
+	assert.Equal(t, &ListEntry[string]{a, "a"}, prev)
+	assert.Equal(t, &ListEntry[string]{c, "c"}, next)
+	prev, next = l.Neighbors(Posn{Major: "0"})
+	assert.Nil(t, prev)
+	assert.Equal(t, "a", next.Value)
+
+	var got []string
+	for _, v := range l.Range(&b, nil) {
+		got = append(got, v)
+	}
+	assert.Equal(t, []string{"b", "c"}, got)
+
+	assert.True(t, l.Delete(b))
+	assert.False(t, l.Delete(b))
+	assert.Equal(t, 2, l.Len())
+}
+
+func TestRankedListRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var l RankedList[int]
+	var ref []Posn
+	for k := 0; k < 5000; k++ {
+		if len(ref) > 0 && rng.Intn(3) == 0 {
+			i := rng.Intn(len(ref))
+			assert.True(t, l.Delete(ref[i]))
+			ref = slices.Delete(ref, i, i+1)
+			continue
+		}
+		i := rng.Intn(len(ref) + 1)
+		var prev, next *Posn
+		if i > 0 {
+			prev = &ref[i-1]
+		}
+		if i < len(ref) {
+			next = &ref[i]
+		}
+		p, ok := l.InsertBetween(prev, next, k)
+		assert.True(t, ok)
+		ref = slices.Insert(ref, i, p)
+	}
+	assert.Equal(t, len(ref), l.Len())
+
+	var got []Posn
+	for p := range l.All() {
+		got = append(got, p)
+	}
+	assert.Equal(t, ref, got)
+
+	for i := 1; i+1 < len(ref); i += 97 {
+		prev, next := l.Neighbors(ref[i])
+		assert.Equal(t, ref[i-1], prev.Rank)
+		assert.Equal(t, ref[i+1], next.Rank)
+	}
+
+	got = got[:0]
+	for p := range l.Range(&ref[10], &ref[20]) {
+		got = append(got, p)
+	}
+	assert.Equal(t, ref[10:20], got)
+}
+
+func TestRankedListSnapshot(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var l RankedList[int]
+	var ref []Posn
+	entries := func(all iter.Seq2[Posn, int]) []ListEntry[int] {
+		var out []ListEntry[int]
+		for p, v := range all {
+			out = append(out, ListEntry[int]{p, v})
+		}
+		return out
+	}
+	type snap struct {
+		s    ListSnapshot[int]
+		want []ListEntry[int]
+	}
+	var snaps []snap
+	for k := 0; k < 3000; k++ {
+		if k%100 == 0 {
+			snaps = append(snaps, snap{l.Snapshot(), entries(l.All())})
+		}
+		if len(ref) > 0 && rng.Intn(3) == 0 {
+			i := rng.Intn(len(ref))
+			assert.True(t, l.Delete(ref[i]))
+			ref = slices.Delete(ref, i, i+1)
+			continue
+		}
+		i := rng.Intn(len(ref) + 1)
+		var prev, next *Posn
+		if i > 0 {
+			prev = &ref[i-1]
+		}
+		if i < len(ref) {
+			next = &ref[i]
+			if rng.Intn(4) == 0 {
+				// overwrite a value in place
+				l.Set(ref[i], -1)
+			}
+		}
+		p, ok := l.InsertBetween(prev, next, k)
+		assert.True(t, ok)
+		ref = slices.Insert(ref, i, p)
+	}
+
+	// every snapshot is still what the list was when it was taken
+	for _, s := range snaps {
+		assert.Equal(t, len(s.want), s.s.Len())
+		assert.Equal(t, s.want, entries(s.s.All()))
+	}
+	var got []Posn
+	for p := range l.All() {
+		got = append(got, p)
+	}
+	assert.Equal(t, ref, got)
+}
+
+func TestRankedListSnapshotConcurrent(t *testing.T) {
+	var (
+		mu sync.Mutex
+		l  RankedList[int]
+		wg sync.WaitGroup
+	)
+	// the writer keeps moving entries about
+	ref, err := Rebalance(300, 0)
+	assert.NoError(t, err)
+	for k, p := range ref {
+		l.Set(p, k)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		rng := rand.New(rand.NewSource(1))
+		for k := 0; k < 5000; k++ {
+			mu.Lock()
+			i := rng.Intn(len(ref))
+			l.Delete(ref[i])
+			ref = slices.Delete(ref, i, i+1)
+			j := rng.Intn(len(ref) + 1)
+			var prev, next *Posn
+			if j > 0 {
+				prev = &ref[j-1]
+			}
+			if j < len(ref) {
+				next = &ref[j]
+			}
+			p, ok := l.InsertBetween(prev, next, k)
+			assert.True(t, ok)
+			ref = slices.Insert(ref, j, p)
+			mu.Unlock()
+		}
+	}()
+	// while readers render snapshots without the lock
+	for k := 0; k < 200; k++ {
+		mu.Lock()
+		s := l.Snapshot()
+		mu.Unlock()
+		n, last := 0, Posn{}
+		for p := range s.All() {
+			assert.Equal(t, 1, p.Compare(last))
+			n, last = n+1, p
+		}
+		assert.Equal(t, s.Len(), n)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,269 @@
+package lexorank
+
+import (
+	"context"
+	"math/big"
+	"strings"
+)
+
+// RanksFunc is for generating more ranks than it's sensible to hold
+// in memory at once, as when renumbering a list of millions of rows.
+// It generates n ranks between prev and next (either of which may be
+// nil, for the start or end of the list), spread as for
+// AllocateBlock, passing each to fn, in order, as soon as it is
+// worked out.  If fn returns an error, generation stops and RanksFunc
+// returns it.
+func RanksFunc(n int, prev, next *Posn, fn func(i int, p Posn) error) error {
+	return Generator{}.RanksFunc(n, prev, next, fn)
+}
+
+// RanksContext is like RanksFunc, but gives up with the context's
+// error if it is cancelled (which is checked every few thousand
+// ranks).
+func RanksContext(ctx context.Context, n int, prev, next *Posn, fn func(i int, p Posn) error) error {
+	return Generator{}.RanksContext(ctx, n, prev, next, fn)
+}
+
+// RanksFunc is like the package-level RanksFunc, but uses the
+// generator's configuration.
+func (g Generator) RanksFunc(n int, prev, next *Posn, fn func(i int, p Posn) error) error {
+	return g.RanksContext(context.Background(), n, prev, next, fn)
+}
+
+// RanksContext is like the package-level RanksContext, but uses the
+// generator's configuration.
+func (g Generator) RanksContext(ctx context.Context, n int, prev, next *Posn, fn func(i int, p Posn) error) (err error) {
+	ctx, end := g.startSpan(ctx, "Ranks", n)
+	touched := 0
+	defer func() { end(touched, err) }()
+	return g.ranksFunc(n, prev, next, Posn{Major: "000000"}, func(i int, p Posn) error {
+		if i%rebalanceChunk == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		if err := fn(i, p); err != nil {
+			return err
+		}
+		touched++
+		return nil
+	})
+}
+
+// ranksFunc does the work of RanksFunc, shaping the ranks like the
+// bounds (or like shape, if there aren't any)
+func (g Generator) ranksFunc(n int, prev, next *Posn, shape Posn, fn func(i int, p Posn) error) error {
+	var lo, hi string
+	if next != nil {
+		hi = next.digits()
+		shape = *next
+	}
+	if prev != nil {
+		lo = prev.digits()
+		shape = *prev
+	}
+	// the ranks are worked out as strings of digits, and split into
+	// major and minor like shape, which orders them the same way as
+	// long as the bounds do too
+	split := shape.fromDigits
+	if prev != nil && next != nil {
+		if err := CheckBounds(*prev, *next); err != nil {
+			return err
+		}
+		switch {
+		case prev.Bucket != next.Bucket:
+			// everything after prev in its bucket comes before next
+			hi = ""
+		case strings.HasPrefix(next.Major, prev.Major) && prev.Major != next.Major:
+			// any rank with a longer major than prev's would come
+			// after next, so they all go in prev's minor
+			lo, hi = prev.MinorValue(), ""
+			split = func(r string) Posn {
+				return Posn{Bucket: prev.Bucket, Major: prev.Major, Minor: r}
+			}
+		case prev.Major != next.Major:
+			// and a rank split like prev with next's major and a
+			// lower minor may have a major that's too long
+			hi = next.Major
+		}
+	}
+
+	// check each rank before it goes out, so that a slip can't put
+	// ranks out of order
+	i, last := 0, prev
+	return g.spreadFunc(lo, hi, n, func(r string) error {
+		p := split(r)
+		if last != nil && last.Compare(p) >= 0 || next != nil && p.Compare(*next) >= 0 {
+			return &wrapError{ErrNotBetween.Error() + ": " + p.String(), ErrNotBetween}
+		}
+		last = &p
+		err := fn(i, p)
+		i++
+		return err
+	})
+}
+
+// Rebalance returns fresh ranks for a whole list of n items, in the
+// given bucket: majors of a fixed length (at least six digits, as
+// Jira uses, and more if the list is big enough to need it) spread
+// evenly through the keyspace, leaving as much room as possible
+// between each item.  Rebalancing into the next bucket (see
+// NextBucket and PlanMigration) lets the new ranks be written while
+// the old ones are still in use.
+func Rebalance(n int, bucket byte) ([]Posn, error) {
+	return Generator{}.Rebalance(n, bucket)
+}
+
+// RebalanceFunc is like Rebalance, but passes each rank to fn as soon
+// as it is worked out, rather than collecting them, so that memory
+// use stays flat however big the list.  If fn returns an error,
+// RebalanceFunc stops and returns it.
+func RebalanceFunc(n int, bucket byte, fn func(i int, p Posn) error) error {
+	return Generator{}.RebalanceFunc(n, bucket, fn)
+}
+
+// RebalanceContext is like RebalanceFunc, but gives up with the
+// context's error if it is cancelled (which is checked every few
+// thousand ranks), so that a long-running rebalance can be aborted
+// cleanly.
+func RebalanceContext(ctx context.Context, n int, bucket byte, fn func(i int, p Posn) error) error {
+	return Generator{}.RebalanceContext(ctx, n, bucket, fn)
+}
+
+// Rebalance is like the package-level Rebalance, but uses the
+// generator's configuration.
+func (g Generator) Rebalance(n int, bucket byte) ([]Posn, error) {
+	out := make([]Posn, 0, n)
+	err := g.RebalanceFunc(n, bucket, func(i int, p Posn) error {
+		out = append(out, p)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RebalanceFunc is like the package-level RebalanceFunc, but uses the
+// generator's configuration.
+func (g Generator) RebalanceFunc(n int, bucket byte, fn func(i int, p Posn) error) error {
+	return g.RebalanceContext(context.Background(), n, bucket, fn)
+}
+
+// RebalanceContext is like the package-level RebalanceContext, but
+// uses the generator's configuration.
+func (g Generator) RebalanceContext(ctx context.Context, n int, bucket byte, fn func(i int, p Posn) error) (err error) {
+	if bucket > MaxBucket {
+		return badBucket(bucket)
+	}
+	if g.Metrics != nil {
+		g.Metrics.RebalanceTriggered()
+	}
+	ctx, end := g.startSpan(ctx, "Rebalance", n)
+	touched := 0
+	defer func() { end(touched, err) }()
+	sp := g.rebalanceSpacing(n, bucket)
+	var buf []Posn
+	for from := 0; from < n; from += rebalanceChunk {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		buf = sp.appendRanks(buf[:0], from, min(from+rebalanceChunk, n))
+		for k, p := range buf {
+			if err := fn(from+k, p); err != nil {
+				return err
+			}
+			touched++
+		}
+	}
+	return nil
+}
+
+// RebalanceWindow re-spreads just the items around a congested spot
+// in a list: those within radius of center (ranks[center-radius] up
+// to but not including ranks[center+radius], cut short at the ends of
+// the list), evenly between the items either side, which stay put.
+// ranks holds the ranks of the whole list, in order.  The result has
+// an update for each item in the window, in list order, so a hotspot
+// can be fixed with a handful of writes rather than a full rebalance.
+func RebalanceWindow(ranks []Posn, center, radius int) ([]Update, bool) {
+	return Generator{}.RebalanceWindow(ranks, center, radius)
+}
+
+// RebalanceWindow is like the package-level RebalanceWindow, but uses
+// the generator's configuration.
+func (g Generator) RebalanceWindow(ranks []Posn, center, radius int) ([]Update, bool) {
+	if center < 0 || center > len(ranks) || radius < 0 {
+		return nil, false
+	}
+	lo, hi := max(center-radius, 0), min(center+radius, len(ranks))
+	var prev, next *Posn
+	if lo > 0 {
+		prev = &ranks[lo-1]
+	}
+	if hi < len(ranks) {
+		next = &ranks[hi]
+	}
+	fresh, ok := g.AllocateBlock(prev, next, hi-lo)
+	if !ok {
+		return nil, false
+	}
+	out := make([]Update, len(fresh))
+	for k, p := range fresh {
+		out[k] = Update{Index: lo + k, Rank: p}
+	}
+	return out, true
+}
+
+// rebalanceChunk is how many ranks are worked out at a time
+const rebalanceChunk = 1 << 12
+
+// rebalanceSpacing describes the even spacing of a rebalanced list
+type rebalanceSpacing struct {
+	a      Alphabet
+	bucket byte
+	width  int
+	step   *big.Int
+	gen    Generator
+}
+
+func (g Generator) rebalanceSpacing(n int, bucket byte) rebalanceSpacing {
+	a := g.alphabet()
+	width := g.freshLen(n)
+	base := big.NewInt(int64(a.base()))
+	span := new(big.Int).Exp(base, big.NewInt(int64(width)), nil)
+	return rebalanceSpacing{
+		a:      a,
+		bucket: bucket,
+		width:  width,
+		step:   span.Quo(span, big.NewInt(int64(n+1))),
+		gen:    g,
+	}
+}
+
+// appendRanks appends the ranks of items from up to (but not
+// including) to to dst.  It only reads sp, so it is safe to call
+// concurrently.
+func (sp rebalanceSpacing) appendRanks(dst []Posn, from, to int) []Posn {
+	base := big.NewInt(int64(sp.a.base()))
+	v := new(big.Int).Mul(sp.step, big.NewInt(int64(from)))
+	buf := sp.gen.buffer(sp.width)
+	defer sp.gen.release(buf)
+	major := (*buf)[:sp.width]
+	q, r := new(big.Int), new(big.Int)
+	for i := from; i < to; i++ {
+		v.Add(v, sp.step)
+		q.Set(v)
+		for k := sp.width - 1; k >= 0; k-- {
+			q.QuoRem(q, base, r)
+			major[k] = sp.a.digit(int(r.Int64()))
+		}
+		// ranks don't end in the smallest digit; the step is at
+		// least 2, so the next one up is still short of the next rank
+		if major[sp.width-1] == sp.a.min() {
+			major[sp.width-1] = sp.a.digit(1)
+		}
+		dst = append(dst, Posn{Bucket: sp.bucket, Major: string(major)})
+	}
+	return dst
+}
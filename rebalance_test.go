@@ -0,0 +1,117 @@
+package lexorank
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRanksFunc(t *testing.T) {
+	prev := Posn{Major: "a00000"}
+	next := Posn{Major: "b00000"}
+	var got []Posn
+	err := RanksFunc(500, &prev, &next, func(i int, p Posn) error {
+		assert.Equal(t, len(got), i)
+		got = append(got, p)
+		return nil
+	})
+	assert.NoError(t, err)
+	want, ok := AllocateBlock(&prev, &next, 500)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, want, got)
+
+	stop := errors.New("stop")
+	n := 0
+	err = RanksFunc(500, nil, nil, func(i int, p Posn) error {
+		n++
+		if i == 9 {
+			return stop
+		}
+		return nil
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 10, n)
+
+	err = RanksFunc(1, &next, &prev, func(int, Posn) error { return nil })
+	assert.True(t, errors.Is(err, ErrInvertedBounds))
+}
+
+func TestRebalance(t *testing.T) {
+	out, err := Rebalance(3, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []Posn{
+		{Bucket: 1, Major: "FV0001"},
+		{Bucket: 1, Major: "V00001"},
+		{Bucket: 1, Major: "kV0001"},
+	}, out)
+
+	out, err = Rebalance(100000, 0)
+	assert.NoError(t, err)
+	for i := 1; i < len(out); i++ {
+		assert.Equal(t, -1, out[i-1].Compare(out[i]))
+	}
+
+	_, err = Rebalance(1, 3)
+	assert.Error(t, err)
+}
+
+func TestRebalanceContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	n := 0
+	err := RebalanceContext(ctx, 100000, 0, func(i int, p Posn) error {
+		n++
+		if i == 10 {
+			cancel()
+		}
+		return nil
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, rebalanceChunk, n)
+
+	n = 0
+	err = RanksContext(ctx, 10, nil, nil, func(int, Posn) error {
+		n++
+		return nil
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestRebalanceWindow(t *testing.T) {
+	// a hotspot of long keys in the middle of a list
+	ranks := []Posn{
+		{Major: "a00000"},
+		{Major: "b00000"},
+		{Major: "b000000001"},
+		{Major: "b000000002"},
+		{Major: "b000000003"},
+		{Major: "c00000"},
+		{Major: "d00000"},
+	}
+	updates, ok := RebalanceWindow(ranks, 3, 2)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 4, len(updates))
+	for k, u := range updates {
+		assert.Equal(t, 1+k, u.Index)
+		assert.True(t, len(u.Rank.Major) <= 6)
+		ranks[u.Index] = u.Rank
+	}
+	assert.Equal(t, Posn{Major: "a00000"}, ranks[0])
+	assert.Equal(t, Posn{Major: "d00000"}, ranks[6])
+	for i := 1; i < len(ranks); i++ {
+		assert.True(t, ranks[i-1].Compare(ranks[i]) < 0)
+	}
+}
+
+func TestRebalanceWindowEnds(t *testing.T) {
+	ranks := []Posn{{Major: "a0000001"}, {Major: "a0000002"}, {Major: "c00000"}}
+	updates, ok := RebalanceWindow(ranks, 0, 2)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 2, len(updates))
+	assert.True(t, updates[1].Rank.Compare(ranks[2]) < 0)
+
+	_, ok = RebalanceWindow(ranks, 4, 1)
+	assert.Equal(t, false, ok)
+}
@@ -0,0 +1,40 @@
+package lexorank
+
+import (
+	"math/big"
+	"strings"
+)
+
+// maxReserveDigits caps how much longer reserve will make a rank, so
+// that an absurd reservation can't produce an absurd key
+const maxReserveDigits = 16
+
+// reserve lengthens r, which lies strictly between the canonical
+// bounds lo and hi, until there's room for g.Reserve more ranks of the
+// same length on either side of it (or it reaches g.MaxLength)
+func (g Generator) reserve(a Alphabet, lo, hi, r string) string {
+	want := big.NewInt(int64(g.Reserve))
+	for extra := 0; extra <= maxReserveDigits; extra++ {
+		n := len(r)
+		if capacity(a, lo, r, n).Cmp(want) >= 0 && capacity(a, r, hi, n).Cmp(want) >= 0 {
+			break
+		}
+		if extra == maxReserveDigits || g.tooLong(n+1) {
+			break
+		}
+		// add a digit halfway through what's available, which is
+		// everything unless r is a prefix of hi, in which case it
+		// has to stay below hi's next digit
+		top := a.base()
+		if strings.HasPrefix(hi, r) {
+			top = a.order(hi[n])
+		}
+		if top/2 == 0 {
+			// that would be the smallest digit, which ranks
+			// don't end in
+			break
+		}
+		r += string(a.digit(top / 2))
+	}
+	return r
+}
@@ -0,0 +1,45 @@
+package lexorank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReserve(t *testing.T) {
+	g := Generator{Reserve: 40}
+	rank, ok := g.Rank("a", "b")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "aUV", rank)
+
+	// without a reservation, the shortest rank is used
+	rank, ok = Generator{}.Rank("a", "b")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "aU", rank)
+}
+
+func TestReserveRoom(t *testing.T) {
+	g := Generator{Reserve: 100}
+	rank, ok := g.Rank("a", "b")
+	assert.Equal(t, true, ok)
+
+	// there is room for 100 ranks no longer than it on either side
+	for _, gap := range [][2]string{{"a", rank}, {rank, "b"}} {
+		c := capacity(Base62, gap[0], gap[1], len(rank))
+		assert.True(t, c.Int64() >= 100, c)
+	}
+}
+
+func TestReservePrefixOfNext(t *testing.T) {
+	g := Generator{Reserve: 10}
+	rank, ok := g.Rank("a", "b1")
+	assert.Equal(t, true, ok)
+	assert.True(t, "a" < rank && rank < "b1", rank)
+}
+
+func TestReserveAppendRank(t *testing.T) {
+	g := Generator{Reserve: 40}
+	rank, ok := g.AppendRank([]byte("x"), []byte("a"), []byte("b"))
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "xaUV", string(rank))
+}
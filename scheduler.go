@@ -0,0 +1,157 @@
+package lexorank
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// A Scheduler rebalances lists in the background, as they are found
+// to need it, at a limited rate, so that maintenance trickles along
+// rather than a burst of exhausted lists all being rebalanced at
+// once.  Hook it up to a generator's callbacks with Event:
+//
+//	s := &lexorank.Scheduler{Store: store, Every: time.Second}
+//	g.OnExhaustion = s.Event
+//	g.OnLowGap = s.Event
+//	go s.Run(ctx)
+//
+// A list asked for again while it's waiting, or being rebalanced, is
+// only rebalanced once.
+type Scheduler struct {
+	// Store holds the lists
+	Store Store
+
+	// Generator makes the new ranks
+	Generator Generator
+
+	// Every is the least time between starting one rebalance and the
+	// next (zero for no limit)
+	Every time.Duration
+
+	// Workers is the most rebalances run at once (1 if zero)
+	Workers int
+
+	// Rebalance, if set, does the maintenance of a list instead of
+	// Generator.RebalanceStore
+	Rebalance func(ctx context.Context, list string) error
+
+	// OnError, if set, is told about rebalances that fail
+	OnError func(list string, err error)
+
+	mu      sync.Mutex
+	queue   []string
+	waiting map[string]bool // queued or running
+	wake    chan struct{}
+}
+
+func (s *Scheduler) init() {
+	if s.waiting == nil {
+		s.waiting = make(map[string]bool)
+		s.wake = make(chan struct{}, 1)
+	}
+}
+
+// Schedule asks for a list to be rebalanced.
+func (s *Scheduler) Schedule(list string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.init()
+	if s.waiting[list] {
+		return
+	}
+	s.waiting[list] = true
+	s.queue = append(s.queue, list)
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Event schedules the list an event is about, if it's known.  It has
+// the right signature for a Generator's OnExhaustion, OnRebalance and
+// OnLowGap.
+func (s *Scheduler) Event(e Event) {
+	if e.List != "" {
+		s.Schedule(e.List)
+	}
+}
+
+// Pending returns how many lists are waiting to be (or being)
+// rebalanced.
+func (s *Scheduler) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.waiting)
+}
+
+// Run works through the lists as they are scheduled, until ctx is
+// cancelled, then waits for any rebalances under way to stop and
+// returns the context's error.
+func (s *Scheduler) Run(ctx context.Context) error {
+	s.mu.Lock()
+	s.init()
+	wake := s.wake
+	s.mu.Unlock()
+
+	slots := make(chan struct{}, max(s.Workers, 1))
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	var last time.Time
+	for {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if s.Every > 0 && !last.IsZero() {
+			t := time.NewTimer(time.Until(last.Add(s.Every)))
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				t.Stop()
+				return ctx.Err()
+			}
+		}
+		list, ok := s.next()
+		for !ok {
+			select {
+			case <-wake:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			list, ok = s.next()
+		}
+		last = time.Now()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.rebalance(ctx, list); err != nil && s.OnError != nil {
+				s.OnError(list, err)
+			}
+			s.mu.Lock()
+			delete(s.waiting, list)
+			s.mu.Unlock()
+			<-slots
+		}()
+	}
+}
+
+// next takes the next list off the queue
+func (s *Scheduler) next() (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.queue) == 0 {
+		return "", false
+	}
+	list := s.queue[0]
+	s.queue = s.queue[1:]
+	return list, true
+}
+
+func (s *Scheduler) rebalance(ctx context.Context, list string) error {
+	if s.Rebalance != nil {
+		return s.Rebalance(ctx, list)
+	}
+	return s.Generator.RebalanceStore(ctx, s.Store, list)
+}
@@ -0,0 +1,102 @@
+package lexorank
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduler(t *testing.T) {
+	var mu sync.Mutex
+	var done []string
+	running, most := 0, 0
+	s := &Scheduler{
+		Workers: 2,
+		Rebalance: func(ctx context.Context, list string) error {
+			mu.Lock()
+			running++
+			most = max(most, running)
+			mu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			mu.Lock()
+			running--
+			done = append(done, list)
+			mu.Unlock()
+			return nil
+		},
+	}
+	for _, list := range []string{"a", "b", "a", "c", "d", "b"} {
+		s.Event(Event{List: list})
+	}
+	s.Event(Event{})
+	assert.Equal(t, 4, s.Pending())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error)
+	go func() { stopped <- s.Run(ctx) }()
+	assert.Eventually(t, func() bool { return s.Pending() == 0 }, time.Second, time.Millisecond)
+	cancel()
+	assert.Equal(t, context.Canceled, <-stopped)
+
+	assert.ElementsMatch(t, []string{"a", "b", "c", "d"}, done)
+	assert.Equal(t, 2, most)
+}
+
+func TestSchedulerRate(t *testing.T) {
+	var mu sync.Mutex
+	var starts []time.Time
+	s := &Scheduler{
+		Workers: 4,
+		Every:   20 * time.Millisecond,
+		Rebalance: func(ctx context.Context, list string) error {
+			mu.Lock()
+			starts = append(starts, time.Now())
+			mu.Unlock()
+			return nil
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+	for _, list := range []string{"a", "b", "c"} {
+		s.Schedule(list)
+	}
+	assert.Eventually(t, func() bool { return s.Pending() == 0 }, time.Second, time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, starts, 3)
+	for i := 1; i < len(starts); i++ {
+		assert.GreaterOrEqual(t, starts[i].Sub(starts[i-1]), 20*time.Millisecond)
+	}
+}
+
+func TestSchedulerStore(t *testing.T) {
+	m := &MemStore{}
+	m.Put("a", Item{"x", Posn{Major: "a"}}, Item{"y", Posn{Major: "a0"}})
+	m.Put("bad", Item{"x", Posn{Bucket: 9, Major: "a"}})
+
+	var failed []string
+	s := &Scheduler{Store: m, OnError: func(list string, err error) {
+		failed = append(failed, list)
+	}}
+	g := Generator{OnExhaustion: s.Event, list: "a"}
+	_, ok := g.Rank("a", "a0")
+	assert.False(t, ok)
+	s.Schedule("bad")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error)
+	go func() { stopped <- s.Run(ctx) }()
+	assert.Eventually(t, func() bool { return s.Pending() == 0 }, time.Second, time.Millisecond)
+	cancel()
+	assert.True(t, errors.Is(<-stopped, context.Canceled))
+
+	want, _ := Rebalance(2, 0)
+	assert.Equal(t, []Item{{"x", want[0]}, {"y", want[1]}}, m.List("a"))
+	assert.Equal(t, []string{"bad"}, failed)
+}
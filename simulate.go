@@ -0,0 +1,155 @@
+package lexorank
+
+import "math/rand"
+
+// A Pattern is a shape of insert workload for a Simulation.
+type Pattern int
+
+const (
+	// AlwaysTop inserts every item at the start of the list
+	AlwaysTop Pattern = iota
+	// AlwaysBottom appends every item to the end of the list
+	AlwaysBottom
+	// RandomInserts inserts at uniformly random positions
+	RandomInserts
+	// Hotspot inserts at random within a small neighbourhood in the
+	// middle of the list
+	Hotspot
+)
+
+func (p Pattern) String() string {
+	switch p {
+	case AlwaysTop:
+		return "always-top"
+	case AlwaysBottom:
+		return "always-bottom"
+	case RandomInserts:
+		return "random"
+	case Hotspot:
+		return "hotspot"
+	default:
+		return "unknown"
+	}
+}
+
+// A Simulation replays an insert pattern against a list ranked by a
+// generator, to see how a configuration holds up before trusting it
+// with real data.  Whenever there is no room for an insert, the whole
+// list is rebalanced (re-spread evenly) and the insert retried.
+type Simulation struct {
+	Pattern   Pattern
+	Ops       int
+	Generator Generator
+
+	// Seed seeds the random choices of the RandomInserts and
+	// Hotspot patterns, so that runs are repeatable
+	Seed int64
+
+	// HotspotWidth is how many items either side of the middle of
+	// the list Hotspot inserts land within (default 4)
+	HotspotWidth int
+}
+
+// A SimulationReport is the outcome of running a Simulation.
+type SimulationReport struct {
+	// Ops is the number of inserts done, which is less than asked
+	// for if even a rebalance couldn't make room
+	Ops int
+
+	// MaxLen and MeanLen describe the key lengths at the end
+	MaxLen  int
+	MeanLen float64
+
+	// Growth is the mean key length after each tenth of the run
+	Growth []float64
+
+	// Rebalances counts how often the list had to be rebalanced
+	Rebalances int
+
+	// Collisions counts new ranks that didn't sort strictly between
+	// their neighbours, which should never happen
+	Collisions int
+}
+
+// Run runs the simulation.
+func (s Simulation) Run() SimulationReport {
+	g := s.Generator
+	rnd := rand.New(rand.NewSource(s.Seed))
+	width := s.HotspotWidth
+	if width <= 0 {
+		width = 4
+	}
+
+	var rep SimulationReport
+	var list []string
+	total := 0
+	for op := 0; op < s.Ops; op++ {
+		i := s.Pattern.index(rnd, len(list), width)
+		r, ok := g.rankInto(list, i)
+		if !ok {
+			rep.Rebalances++
+			respread, ok := g.spread(make([]string, 0, len(list)), "", "", len(list))
+			if !ok {
+				break
+			}
+			total = 0
+			for _, key := range respread {
+				total += len(key)
+			}
+			list = respread
+			if r, ok = g.rankInto(list, i); !ok {
+				break
+			}
+		}
+		if (i > 0 && list[i-1] >= r) || (i < len(list) && r >= list[i]) {
+			rep.Collisions++
+		}
+		list = append(list, "")
+		copy(list[i+1:], list[i:])
+		list[i] = r
+		total += len(r)
+		rep.Ops++
+
+		if tenth := s.Ops / 10; tenth > 0 && rep.Ops%tenth == 0 {
+			rep.Growth = append(rep.Growth, float64(total)/float64(len(list)))
+		}
+	}
+
+	for _, key := range list {
+		if len(key) > rep.MaxLen {
+			rep.MaxLen = len(key)
+		}
+	}
+	if len(list) > 0 {
+		rep.MeanLen = float64(total) / float64(len(list))
+	}
+	return rep
+}
+
+// index picks where in a list of n items the pattern inserts next
+func (p Pattern) index(rnd *rand.Rand, n, width int) int {
+	switch p {
+	case AlwaysBottom:
+		return n
+	case RandomInserts:
+		return rnd.Intn(n + 1)
+	case Hotspot:
+		lo := max(n/2-width, 0)
+		hi := min(n/2+width, n)
+		return lo + rnd.Intn(hi-lo+1)
+	}
+	return 0
+}
+
+// rankInto generates a rank for an insert at index i of the sorted
+// list
+func (g Generator) rankInto(list []string, i int) (string, bool) {
+	prev, next := "", ""
+	if i > 0 {
+		prev = list[i-1]
+	}
+	if i < len(list) {
+		next = list[i]
+	}
+	return g.Rank(prev, next)
+}
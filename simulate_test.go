@@ -0,0 +1,38 @@
+package lexorank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimulate(t *testing.T) {
+	for _, p := range []Pattern{AlwaysTop, AlwaysBottom, RandomInserts, Hotspot} {
+		rep := Simulation{Pattern: p, Ops: 500, Seed: 1}.Run()
+		assert.Equal(t, 500, rep.Ops, p.String())
+		assert.Equal(t, 0, rep.Collisions, p.String())
+		assert.Equal(t, 10, len(rep.Growth), p.String())
+		assert.True(t, rep.MeanLen > 0 && rep.MeanLen <= float64(rep.MaxLen), p.String())
+	}
+}
+
+func TestSimulateRebalances(t *testing.T) {
+	// appending to the end only grows the keys slowly, but with a
+	// tight length budget they run out and need rebalancing
+	rep := Simulation{
+		Pattern:   AlwaysBottom,
+		Ops:       200,
+		Generator: Generator{MaxLength: 3},
+	}.Run()
+	assert.Equal(t, 200, rep.Ops)
+	assert.True(t, rep.Rebalances > 0)
+	assert.True(t, rep.MaxLen <= 3)
+
+	rep = Simulation{Pattern: AlwaysBottom, Ops: 200}.Run()
+	assert.Equal(t, 0, rep.Rebalances)
+}
+
+func TestSimulateRepeatable(t *testing.T) {
+	s := Simulation{Pattern: RandomInserts, Ops: 300, Seed: 42}
+	assert.Equal(t, s.Run(), s.Run())
+}
@@ -0,0 +1,289 @@
+package lexorank
+
+import (
+	"hash/maphash"
+	"iter"
+	"math/rand"
+	"sync"
+)
+
+// A SkipIndex is an in-memory index of many lists (the boards of a
+// real-time collaboration server, say), each a skiplist from ranks to
+// values, so that inserting, moving, deleting and finding neighbours
+// all take O(log n).  Unlike a RankedList it is safe for concurrent
+// use: the lists are spread over a fixed number of locks, so writers
+// to different lists seldom wait for each other, while each list is
+// only ever changed by one writer at a time.  The zero value is an
+// empty index using the default Generator.
+type SkipIndex[V any] struct {
+	// Generator makes the ranks for InsertBetween and Move
+	Generator Generator
+
+	stripes [skipStripes]skipStripe[V]
+}
+
+const (
+	skipStripes  = 64
+	skipMaxLevel = 24
+)
+
+var skipSeed = maphash.MakeSeed()
+
+type skipStripe[V any] struct {
+	mu    sync.RWMutex
+	lists map[string]*skipList[V]
+}
+
+type skipList[V any] struct {
+	head  skipNode[V]
+	level int
+	n     int
+}
+
+type skipNode[V any] struct {
+	entry ListEntry[V]
+	next  []*skipNode[V]
+}
+
+func (x *SkipIndex[V]) stripe(list string) *skipStripe[V] {
+	return &x.stripes[maphash.String(skipSeed, list)%skipStripes]
+}
+
+// read calls f with the list, which is nil if it's empty, holding the
+// list's lock for reading
+func (x *SkipIndex[V]) read(list string, f func(s *skipList[V])) {
+	st := x.stripe(list)
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	f(st.lists[list])
+}
+
+// write calls f with the list, holding the list's lock, and drops the
+// list afterwards if f left it empty
+func (x *SkipIndex[V]) write(list string, f func(s *skipList[V])) {
+	st := x.stripe(list)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	s := st.lists[list]
+	if s == nil {
+		s = &skipList[V]{head: skipNode[V]{next: make([]*skipNode[V], skipMaxLevel)}}
+		if st.lists == nil {
+			st.lists = make(map[string]*skipList[V])
+		}
+		st.lists[list] = s
+	}
+	f(s)
+	if s.n == 0 {
+		delete(st.lists, list)
+	}
+}
+
+// Len returns the number of entries in the list.
+func (x *SkipIndex[V]) Len(list string) (n int) {
+	x.read(list, func(s *skipList[V]) {
+		if s != nil {
+			n = s.n
+		}
+	})
+	return n
+}
+
+// Get returns the value with rank p in the list.
+func (x *SkipIndex[V]) Get(list string, p Posn) (v V, ok bool) {
+	x.read(list, func(s *skipList[V]) {
+		if at := s.find(p); at != nil {
+			v, ok = at.entry.Value, true
+		}
+	})
+	return v, ok
+}
+
+// Set gives the value with rank p in the list, replacing any there
+// already.
+func (x *SkipIndex[V]) Set(list string, p Posn, v V) {
+	x.write(list, func(s *skipList[V]) {
+		s.set(p, v)
+	})
+}
+
+// Delete removes the entry with rank p from the list, and reports
+// whether there was one.
+func (x *SkipIndex[V]) Delete(list string, p Posn) (ok bool) {
+	x.write(list, func(s *skipList[V]) {
+		_, ok = s.delete(p)
+	})
+	return ok
+}
+
+// Neighbors returns the entries either side of rank p (which needn't
+// be in the list), or nil at the ends of the list.
+func (x *SkipIndex[V]) Neighbors(list string, p Posn) (prev, next *ListEntry[V]) {
+	x.read(list, func(s *skipList[V]) {
+		if s == nil {
+			return
+		}
+		before, at := s.path(p, nil)
+		if before != &s.head {
+			e := before.entry
+			prev = &e
+		}
+		if at != nil && at.entry.Rank.Compare(p) == 0 {
+			at = at.next[0]
+		}
+		if at != nil {
+			e := at.entry
+			next = &e
+		}
+	})
+	return prev, next
+}
+
+// InsertBetween adds v to the list with a rank between prev and next,
+// which must be neighbours in the list (either may be nil, for the
+// start or end of the list), and returns its rank.  It fails if they
+// aren't neighbours, as happens when another writer got in between
+// first, or if there's no room between them.
+func (x *SkipIndex[V]) InsertBetween(list string, prev, next *Posn, v V) (r Posn, ok bool) {
+	x.write(list, func(s *skipList[V]) {
+		if !s.neighbours(prev, next) {
+			return
+		}
+		var ranks []Posn
+		if ranks, ok = x.Generator.Ranks(1, prev, next); ok {
+			r = ranks[0]
+			s.set(r, v)
+		}
+	})
+	return r, ok
+}
+
+// Move moves the entry with rank from to between prev and next, which
+// must be neighbours in the list once it's gone, all in one go, and
+// returns its new rank.  If it fails, the list is left as it was.
+func (x *SkipIndex[V]) Move(list string, from Posn, prev, next *Posn) (r Posn, ok bool) {
+	x.write(list, func(s *skipList[V]) {
+		v, found := s.delete(from)
+		if !found {
+			return
+		}
+		var ranks []Posn
+		if s.neighbours(prev, next) {
+			ranks, ok = x.Generator.Ranks(1, prev, next)
+		}
+		if !ok {
+			s.set(from, v)
+			return
+		}
+		r = ranks[0]
+		s.set(r, v)
+	})
+	return r, ok
+}
+
+// Range returns the entries of the list with ranks from lo up to (but
+// not including) hi, in order; a nil lo or hi is open ended.  The list
+// is locked for reading while the loop runs, so the loop mustn't
+// change it.
+func (x *SkipIndex[V]) Range(list string, lo, hi *Posn) iter.Seq2[Posn, V] {
+	return func(yield func(Posn, V) bool) {
+		x.read(list, func(s *skipList[V]) {
+			if s == nil {
+				return
+			}
+			at := s.head.next[0]
+			if lo != nil {
+				_, at = s.path(*lo, nil)
+			}
+			for ; at != nil; at = at.next[0] {
+				if hi != nil && at.entry.Rank.Compare(*hi) >= 0 {
+					return
+				}
+				if !yield(at.entry.Rank, at.entry.Value) {
+					return
+				}
+			}
+		})
+	}
+}
+
+// path returns the last node before p (the head, if there's none) and
+// the first at or after it, filling in update with the last node
+// before p at each level, if it's not nil
+func (s *skipList[V]) path(p Posn, update *[skipMaxLevel]*skipNode[V]) (before, at *skipNode[V]) {
+	x := &s.head
+	for lv := s.level - 1; lv >= 0; lv-- {
+		for x.next[lv] != nil && x.next[lv].entry.Rank.Compare(p) < 0 {
+			x = x.next[lv]
+		}
+		if update != nil {
+			update[lv] = x
+		}
+	}
+	return x, x.next[0]
+}
+
+// find returns the node with rank p, if there is one
+func (s *skipList[V]) find(p Posn) *skipNode[V] {
+	if s == nil {
+		return nil
+	}
+	if _, at := s.path(p, nil); at != nil && at.entry.Rank.Compare(p) == 0 {
+		return at
+	}
+	return nil
+}
+
+// neighbours reports whether prev and next are next to each other in
+// the list
+func (s *skipList[V]) neighbours(prev, next *Posn) bool {
+	after := s.head.next[0]
+	if prev != nil {
+		at := s.find(*prev)
+		if at == nil {
+			return false
+		}
+		after = at.next[0]
+	}
+	if after == nil || next == nil {
+		return after == nil && next == nil
+	}
+	return after.entry.Rank.Compare(*next) == 0
+}
+
+func (s *skipList[V]) set(p Posn, v V) {
+	var update [skipMaxLevel]*skipNode[V]
+	_, at := s.path(p, &update)
+	if at != nil && at.entry.Rank.Compare(p) == 0 {
+		at.entry.Value = v
+		return
+	}
+	level := 1
+	for level < skipMaxLevel && rand.Intn(4) == 0 {
+		level++
+	}
+	for ; s.level < level; s.level++ {
+		update[s.level] = &s.head
+	}
+	node := &skipNode[V]{entry: ListEntry[V]{p, v}, next: make([]*skipNode[V], level)}
+	for lv := range node.next {
+		node.next[lv] = update[lv].next[lv]
+		update[lv].next[lv] = node
+	}
+	s.n++
+}
+
+func (s *skipList[V]) delete(p Posn) (v V, ok bool) {
+	var update [skipMaxLevel]*skipNode[V]
+	_, at := s.path(p, &update)
+	if at == nil || at.entry.Rank.Compare(p) != 0 {
+		return v, false
+	}
+	for lv, next := range at.next {
+		update[lv].next[lv] = next
+	}
+	for s.level > 0 && s.head.next[s.level-1] == nil {
+		s.level--
+	}
+	s.n--
+	return at.entry.Value, true
+}
@@ -0,0 +1,149 @@
+package lexorank
+
+import (
+	"math/rand"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSkipIndex(t *testing.T) {
+	var x SkipIndex[string]
+	a, ok := x.InsertBetween("l", nil, nil, "a")
+	assert.True(t, ok)
+	c, ok := x.InsertBetween("l", &a, nil, "c")
+	assert.True(t, ok)
+	b, ok := x.InsertBetween("l", &a, &c, "b")
+	assert.True(t, ok)
+	assert.Equal(t, 3, x.Len("l"))
+	assert.Equal(t, 0, x.Len("m"))
+
+	// the bounds have to be neighbours in the list
+	_, ok = x.InsertBetween("l", &a, &c, "x")
+	assert.False(t, ok)
+	_, ok = x.InsertBetween("l", nil, nil, "x")
+	assert.False(t, ok)
+	_, ok = x.InsertBetween("l", nil, &b, "x")
+	assert.False(t, ok)
+	_, ok = x.InsertBetween("l", &Posn{Major: "0"}, &a, "x")
+	assert.False(t, ok)
+	assert.Equal(t, 3, x.Len("l"))
+
+	v, ok := x.Get("l", b)
+	assert.True(t, ok)
+	assert.Equal(t, "b", v)
+	_, ok = x.Get("m", b)
+	assert.False(t, ok)
+
+	prev, next := x.Neighbors("l", b)
+	assert.Equal(t, &ListEntry[string]{a, "a"}, prev)
+	assert.Equal(t, &ListEntry[string]{c, "c"}, next)
+	prev, next = x.Neighbors("l", Posn{Major: "0"})
+	assert.Nil(t, prev)
+	assert.Equal(t, "a", next.Value)
+	prev, next = x.Neighbors("m", b)
+	assert.Nil(t, prev)
+	assert.Nil(t, next)
+
+	// move a to the end
+	a2, ok := x.Move("l", a, &c, nil)
+	assert.True(t, ok)
+	_, ok = x.Move("l", a, &c, nil)
+	assert.False(t, ok)
+	// without c, the start of the list and a2 aren't neighbours, so
+	// the move fails and c stays put
+	_, ok = x.Move("l", c, nil, &a2)
+	assert.False(t, ok)
+	var got []string
+	for _, v := range x.Range("l", nil, nil) {
+		got = append(got, v)
+	}
+	assert.Equal(t, []string{"b", "c", "a"}, got)
+
+	got = got[:0]
+	for _, v := range x.Range("l", &c, nil) {
+		got = append(got, v)
+	}
+	assert.Equal(t, []string{"c", "a"}, got)
+
+	assert.True(t, x.Delete("l", b))
+	assert.False(t, x.Delete("l", b))
+	assert.Equal(t, 2, x.Len("l"))
+	assert.True(t, x.Delete("l", c))
+	assert.True(t, x.Delete("l", a2))
+	assert.Equal(t, 0, x.Len("l"))
+}
+
+func TestSkipIndexRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var x SkipIndex[int]
+	var ref []Posn
+	for k := 0; k < 5000; k++ {
+		if len(ref) > 0 && rng.Intn(3) == 0 {
+			i := rng.Intn(len(ref))
+			assert.True(t, x.Delete("l", ref[i]))
+			ref = slices.Delete(ref, i, i+1)
+			continue
+		}
+		i := rng.Intn(len(ref) + 1)
+		var prev, next *Posn
+		if i > 0 {
+			prev = &ref[i-1]
+		}
+		if i < len(ref) {
+			next = &ref[i]
+		}
+		p, ok := x.InsertBetween("l", prev, next, k)
+		assert.True(t, ok)
+		ref = slices.Insert(ref, i, p)
+	}
+	assert.Equal(t, len(ref), x.Len("l"))
+
+	var got []Posn
+	for p := range x.Range("l", nil, nil) {
+		got = append(got, p)
+	}
+	assert.Equal(t, ref, got)
+
+	for i := 1; i+1 < len(ref); i += 97 {
+		prev, next := x.Neighbors("l", ref[i])
+		assert.Equal(t, ref[i-1], prev.Rank)
+		assert.Equal(t, ref[i+1], next.Rank)
+	}
+
+	got = got[:0]
+	for p := range x.Range("l", &ref[10], &ref[20]) {
+		got = append(got, p)
+	}
+	assert.Equal(t, ref[10:20], got)
+}
+
+func TestSkipIndexConcurrent(t *testing.T) {
+	var x SkipIndex[int]
+	var wg sync.WaitGroup
+	// writers on the same lists collide, and have to go again
+	for w := 0; w < 16; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			list := strconv.Itoa(w % 4)
+			for k := 0; k < 200; {
+				prev, _ := x.Neighbors(list, Posn{Bucket: MaxBucket, Major: "zzzzzzzz"})
+				var after *Posn
+				if prev != nil {
+					after = &prev.Rank
+				}
+				if _, ok := x.InsertBetween(list, after, nil, k); ok {
+					k++
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	for l := 0; l < 4; l++ {
+		assert.Equal(t, 800, x.Len(strconv.Itoa(l)))
+	}
+}
@@ -0,0 +1,41 @@
+package lexorank
+
+import "sort"
+
+// ComparePosn compares two positions, for use with slices.SortFunc
+// and friends.  It is the same as a.Compare(b), and in particular
+// treats a missing minor the same as an empty one.
+func ComparePosn(a, b Posn) int {
+	return a.Compare(b)
+}
+
+// RankSorter adapts a slice of ranks to sort.Interface, optionally
+// keeping one or more parallel slices of items in step with it.
+type RankSorter struct {
+	Ranks []Posn
+
+	// SwapItems, if set, is called whenever two ranks are swapped, so
+	// that it can swap the corresponding items
+	SwapItems func(i, j int)
+}
+
+func (s RankSorter) Len() int {
+	return len(s.Ranks)
+}
+
+func (s RankSorter) Less(i, j int) bool {
+	return s.Ranks[i].Compare(s.Ranks[j]) < 0
+}
+
+func (s RankSorter) Swap(i, j int) {
+	s.Ranks[i], s.Ranks[j] = s.Ranks[j], s.Ranks[i]
+	if s.SwapItems != nil {
+		s.SwapItems(i, j)
+	}
+}
+
+// SortByRank sorts ranks (stably), calling swap to keep any parallel
+// slices of items in step.
+func SortByRank(ranks []Posn, swap func(i, j int)) {
+	sort.Stable(RankSorter{Ranks: ranks, SwapItems: swap})
+}
@@ -0,0 +1,42 @@
+package lexorank
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComparePosn(t *testing.T) {
+	ranks := []Posn{
+		{Bucket: 1, Major: "a"},
+		{Major: "b", Minor: "1"},
+		{Major: "b"},
+		{Major: "a"},
+	}
+	sort.Slice(ranks, func(i, j int) bool {
+		return ComparePosn(ranks[i], ranks[j]) < 0
+	})
+	assert.Equal(t, []Posn{
+		{Major: "a"},
+		{Major: "b"},
+		{Major: "b", Minor: "1"},
+		{Bucket: 1, Major: "a"},
+	}, ranks)
+}
+
+func TestSortByRank(t *testing.T) {
+	ranks := []Posn{{Major: "c"}, {Major: "a"}, {Major: "b"}}
+	names := []string{"carol", "alice", "bob"}
+	SortByRank(ranks, func(i, j int) {
+		names[i], names[j] = names[j], names[i]
+	})
+	assert.Equal(t, []string{"alice", "bob", "carol"}, names)
+	assert.Equal(t, []Posn{{Major: "a"}, {Major: "b"}, {Major: "c"}}, ranks)
+}
+
+func TestRankSorterWithoutItems(t *testing.T) {
+	ranks := []Posn{{Major: "b"}, {Major: "a", Minor: ":"}}
+	SortByRank(ranks, nil)
+	assert.Equal(t, "a", ranks[0].Major)
+}
@@ -0,0 +1,85 @@
+package lexorank
+
+// Spacing selects where within a gap newly generated ranks land.
+//
+// Uniform spreads them evenly, which is the right thing when nothing
+// is known about future inserts.  Append-heavy workloads (which keep
+// inserting right after the last rank handed out) do better with
+// FrontLoaded, which packs the new ranks up against prev and leaves
+// the room after them.  Prepend-heavy workloads want the opposite,
+// BackLoaded.  Geometric places each successive rank halfway between
+// the previous one and next, so the gaps shrink toward next.
+type Spacing int
+
+const (
+	Uniform Spacing = iota
+	FrontLoaded
+	BackLoaded
+	Geometric
+)
+
+func (s Spacing) String() string {
+	switch s {
+	case Uniform:
+		return "uniform"
+	case FrontLoaded:
+		return "front-loaded"
+	case BackLoaded:
+		return "back-loaded"
+	case Geometric:
+		return "geometric"
+	default:
+		return "unknown"
+	}
+}
+
+// offsets returns n strictly increasing offsets in the open interval
+// (0, span) arranged according to the spacing strategy, or false if
+// there isn't room for that many.
+func (s Spacing) offsets(n, span int) ([]int, bool) {
+	if n < 1 {
+		return nil, n == 0
+	}
+	out := make([]int, n)
+	switch s {
+	case FrontLoaded, BackLoaded:
+		// use only the half of the gap next to the favored bound
+		per := span / (2 * (n + 1))
+		if per < 1 {
+			// not enough room to leave any slack; fall back to
+			// packing them as tightly as possible
+			if n >= span {
+				return nil, false
+			}
+			per = 1
+		}
+		for i := range out {
+			if s == FrontLoaded {
+				out[i] = per * (i + 1)
+			} else {
+				out[i] = span - per*(n-i)
+			}
+		}
+	case Geometric:
+		remain := span
+		at := 0
+		for i := range out {
+			step := remain / 2
+			if step < 1 {
+				return nil, false
+			}
+			at += step
+			remain -= step
+			out[i] = at
+		}
+	default:
+		per := span / (n + 1)
+		if per < 1 {
+			return nil, false
+		}
+		for i := range out {
+			out[i] = per * (i + 1)
+		}
+	}
+	return out, true
+}
@@ -0,0 +1,51 @@
+package lexorank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpacingUniform(t *testing.T) {
+	off, ok := Uniform.offsets(3, 40)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, []int{10, 20, 30}, off)
+}
+
+func TestSpacingFrontLoaded(t *testing.T) {
+	off, ok := FrontLoaded.offsets(3, 40)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, []int{5, 10, 15}, off)
+}
+
+func TestSpacingBackLoaded(t *testing.T) {
+	off, ok := BackLoaded.offsets(3, 40)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, []int{25, 30, 35}, off)
+}
+
+func TestSpacingGeometric(t *testing.T) {
+	off, ok := Geometric.offsets(3, 40)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, []int{20, 30, 35}, off)
+}
+
+func TestSpacingNoRoom(t *testing.T) {
+	_, ok := Uniform.offsets(3, 3)
+	assert.Equal(t, false, ok)
+	_, ok = FrontLoaded.offsets(3, 3)
+	assert.Equal(t, false, ok)
+	_, ok = Geometric.offsets(2, 2)
+	assert.Equal(t, false, ok)
+}
+
+func TestGeneratorSpacing(t *testing.T) {
+	uniform, ok := Generator{}.Ranks(1, nil, nil)
+	assert.Equal(t, true, ok)
+	front, ok := Generator{Spacing: FrontLoaded}.Ranks(1, nil, nil)
+	assert.Equal(t, true, ok)
+	back, ok := Generator{Spacing: BackLoaded}.Ranks(1, nil, nil)
+	assert.Equal(t, true, ok)
+	assert.True(t, front[0].Major < uniform[0].Major)
+	assert.True(t, uniform[0].Major < back[0].Major)
+}
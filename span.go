@@ -0,0 +1,40 @@
+package lexorank
+
+// A Span is the open interval of the rank space between two
+// positions, i.e., the places an item could be put between Lo and Hi.
+type Span struct {
+	Lo, Hi Posn
+}
+
+// Contains reports whether p lies strictly inside the span.
+func (s Span) Contains(p Posn) bool {
+	return s.Lo.Compare(p) < 0 && p.Compare(s.Hi) < 0
+}
+
+// Mid returns a position in the middle of the span.
+func (s Span) Mid() (Posn, bool) {
+	p, ok := Ranks(1, &s.Lo, &s.Hi)
+	if !ok {
+		return Posn{}, false
+	}
+	return p[0], true
+}
+
+// Split divides the span at n new positions, returning the n+1
+// sub-spans in order.  The sub-spans share their boundaries, so
+// together they contain everything the span does except the new
+// positions themselves.
+func (s Span) Split(n int) ([]Span, bool) {
+	p, ok := Ranks(n, &s.Lo, &s.Hi)
+	if !ok {
+		return nil, false
+	}
+	out := make([]Span, n+1)
+	lo := s.Lo
+	for i, hi := range p {
+		out[i] = Span{Lo: lo, Hi: hi}
+		lo = hi
+	}
+	out[n] = Span{Lo: lo, Hi: s.Hi}
+	return out, true
+}
@@ -0,0 +1,37 @@
+package lexorank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpanContains(t *testing.T) {
+	s := Span{Lo: Posn{Major: "a00000"}, Hi: Posn{Major: "b00000"}}
+	assert.True(t, s.Contains(Posn{Major: "aU0000"}))
+	assert.True(t, s.Contains(Posn{Major: "a00000", Minor: "1"}))
+	assert.False(t, s.Contains(s.Lo))
+	assert.False(t, s.Contains(s.Hi))
+	assert.False(t, s.Contains(Posn{Bucket: 1, Major: "aU0000"}))
+}
+
+func TestSpanMid(t *testing.T) {
+	s := Span{Lo: Posn{Major: "000000"}, Hi: Posn{Major: "zzzzzz"}}
+	m, ok := s.Mid()
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "UUUUUU", m.Major)
+	assert.True(t, s.Contains(m))
+}
+
+func TestSpanSplit(t *testing.T) {
+	s := Span{Lo: Posn{Major: "000000"}, Hi: Posn{Major: "zzzzzz"}}
+	parts, ok := s.Split(3)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 4, len(parts))
+	assert.Equal(t, s.Lo, parts[0].Lo)
+	assert.Equal(t, s.Hi, parts[3].Hi)
+	for i := 1; i < len(parts); i++ {
+		assert.Equal(t, parts[i-1].Hi, parts[i].Lo)
+		assert.True(t, s.Contains(parts[i].Lo))
+	}
+}
@@ -0,0 +1,85 @@
+package lexorank
+
+import (
+	"math/big"
+	"strconv"
+)
+
+// EvenSplit returns n ranks strictly between prev and next, which
+// must be in the same bucket, spread so that the smallest of the gaps
+// between them (and between them and the bounds) is as big as it can
+// be: the gaps differ by at most one step in the last digit, rather
+// than the remainder all ending up in the last gap as it does when
+// digits are divided up one at a time.  That leaves the most room for
+// whatever is inserted among them later.  The ranks are all majors,
+// long enough to leave plenty of room (less any smallest digits they
+// would end in; see NoTrailingMin), so they are spread through the
+// gap between the majors of prev and next; if there is none (the
+// majors are the same, or only differ by trailing smallest digits),
+// the error matches ErrNoRoom.
+func EvenSplit(prev, next Posn, n int) ([]Posn, error) {
+	return Generator{}.EvenSplit(prev, next, n)
+}
+
+// EvenSplit is like the package-level EvenSplit, but uses the
+// generator's configuration.
+func (g Generator) EvenSplit(prev, next Posn, n int) ([]Posn, error) {
+	flo, fhi, width, err := g.majorGap(prev, next, "split")
+	if err != nil {
+		return nil, err
+	}
+	if n <= 0 {
+		return nil, nil
+	}
+	a := g.alphabet()
+	parts := big.NewInt(int64(n) + 1)
+	// the gap isn't empty, so there is room for n at some width
+	for width = max(g.freshLen(n), width); ; width++ {
+		if g.tooLong(width) {
+			return nil, ErrMaxLength
+		}
+		if fracUnits(a, flo, fhi, width).Cmp(parts) < 0 {
+			continue
+		}
+		// the kth rank is exactly k/(n+1) of the way along, rounded
+		// down, which spreads the remainder one unit per gap
+		out := make([]Posn, n)
+		for k := range out {
+			s, _ := fracRank(a, flo, fhi, fracAt(flo, fhi, big.NewRat(int64(k)+1, int64(n)+1)), width)
+			out[k] = Posn{Bucket: prev.Bucket, Major: s}
+		}
+		if err := checkBetween(prev, out, next); err != nil {
+			return nil, err
+		}
+		return out, nil
+	}
+}
+
+// majorGap checks that prev and next are bounds ranks can be placed
+// between, and returns the fractions of the keyspace (see
+// ExactFraction) their majors stand for, and the length of the longer
+// major.  A major that lies strictly between those fractions sorts
+// strictly between prev and next, whatever their minors; working on
+// the majors and minors run together instead doesn't, when the majors
+// are different lengths.  If there is no room between the majors, the
+// error matches ErrNoRoom.
+func (g Generator) majorGap(prev, next Posn, what string) (flo, fhi *big.Rat, width int, err error) {
+	a := g.alphabet()
+	for _, p := range []Posn{prev, next} {
+		if !a.valid(p.digits()) {
+			return nil, nil, 0, newError("invalid position " + strconv.Quote(p.String()))
+		}
+	}
+	if prev.Bucket != next.Bucket {
+		return nil, nil, 0, newError("can't " + what + " between buckets " + strconv.Itoa(int(prev.Bucket)) +
+			" and " + strconv.Itoa(int(next.Bucket)))
+	}
+	if err := CheckBounds(prev, next); err != nil {
+		return nil, nil, 0, err
+	}
+	flo, fhi = fracOf(a, a.canonical(prev.Major)), fracOf(a, a.canonical(next.Major))
+	if flo.Cmp(fhi) >= 0 {
+		return nil, nil, 0, &wrapError{ErrNoRoom.Error() + ": no major between " + prev.String() + " and " + next.String(), ErrNoRoom}
+	}
+	return flo, fhi, max(len(prev.Major), len(next.Major)), nil
+}
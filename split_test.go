@@ -0,0 +1,97 @@
+package lexorank
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvenSplit(t *testing.T) {
+	prev, next := Posn{Major: "a"}, Posn{Major: "a00009"}
+	out, err := EvenSplit(prev, next, 3)
+	assert.NoError(t, err)
+
+	// 9 steps into 4 gaps is three of 2 and one of 3
+	gaps := splitGaps(t, prev, out, next)
+	assert.Len(t, gaps, 4)
+	for _, gap := range gaps {
+		assert.Contains(t, []int64{2, 3}, gap)
+	}
+
+	// 61 steps into 11 gaps is 5 or 6 each, where leaving the
+	// remainder to the last gap would make it 11
+	prev, next = Posn{Bucket: 2, Major: "000000"}, Posn{Bucket: 2, Major: "00000z"}
+	out, err = EvenSplit(prev, next, 10)
+	assert.NoError(t, err)
+	gaps = splitGaps(t, prev, out, next)
+	lo, hi := gaps[0], gaps[0]
+	for _, gap := range gaps {
+		lo = min(lo, gap)
+		if gap > hi {
+			hi = gap
+		}
+	}
+	assert.LessOrEqual(t, hi-lo, int64(1))
+	assert.Equal(t, int64(5), lo)
+	for _, p := range out {
+		assert.Equal(t, byte(2), p.Bucket)
+	}
+
+	// no room at six digits means a seventh
+	out, err = EvenSplit(Posn{Major: "a00000"}, Posn{Major: "a00001"}, 2)
+	assert.NoError(t, err)
+	assert.Len(t, out[0].Major, 7)
+
+	_, err = Generator{MaxLength: 6}.EvenSplit(Posn{Major: "a00000"}, Posn{Major: "a00001"}, 2)
+	assert.ErrorIs(t, err, ErrMaxLength)
+	_, err = EvenSplit(next, prev, 1)
+	assert.ErrorIs(t, err, ErrInvertedBounds)
+	_, err = EvenSplit(Posn{Major: "a"}, Posn{Bucket: 1, Major: "a"}, 1)
+	assert.Error(t, err)
+	out, err = EvenSplit(prev, next, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, out)
+}
+
+func TestEvenSplitMajors(t *testing.T) {
+	// the ranks go between the majors, whatever the minors, and
+	// sort between the bounds even when the majors are different
+	// lengths
+	for _, c := range [][2]Posn{
+		{{Major: "a", Minor: "5"}, {Major: "a1"}},
+		{{Major: "a", Minor: "zz"}, {Major: "b0"}},
+		{{Major: "ab", Minor: "1"}, {Major: "b", Minor: "1"}},
+	} {
+		out, err := EvenSplit(c[0], c[1], 3)
+		assert.NoError(t, err, c)
+		requireBetween(t, c[0], out, c[1])
+	}
+
+	// with nothing between the majors, there's no room
+	_, err := EvenSplit(Posn{Major: "a"}, Posn{Major: "a", Minor: "5"}, 1)
+	assert.ErrorIs(t, err, ErrNoRoom)
+	_, err = EvenSplit(Posn{Major: "a", Minor: "1"}, Posn{Major: "a00"}, 1)
+	assert.ErrorIs(t, err, ErrNoRoom)
+}
+
+// splitGaps returns the gaps between the ranks, in units of the last
+// digit of the longest
+func splitGaps(t *testing.T, prev Posn, ranks []Posn, next Posn) []int64 {
+	width := 0
+	for _, p := range ranks {
+		width = max(width, len(p.Major))
+	}
+	all := append(append([]Posn{prev}, ranks...), next)
+	var gaps []int64
+	var last *big.Int
+	for _, p := range all {
+		v := digitsValue(Base62, p.digits(), width)
+		if last != nil {
+			gaps = append(gaps, new(big.Int).Sub(v, last).Int64())
+			assert.Positive(t, gaps[len(gaps)-1])
+		}
+		last = v
+	}
+	return gaps
+}
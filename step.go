@@ -0,0 +1,132 @@
+package lexorank
+
+import "math/big"
+
+// Next returns the rank immediately after p with the same number of
+// digits, or, if p is already the largest rank of its length, the
+// first rank one digit longer (which extends the minor).  This is the
+// smallest step that can be taken, which is what exclusive range
+// queries over rank-ordered keys need.  It returns false if p isn't
+// valid.
+func (p Posn) Next() (Posn, bool) {
+	return Generator{}.Next(p)
+}
+
+// Prev returns the rank immediately before p with the same number of
+// digits.  It returns false if p isn't valid or there is no such
+// rank, because p is made up entirely of zeros.
+func (p Posn) Prev() (Posn, bool) {
+	return Generator{}.Prev(p)
+}
+
+// Next is like Posn.Next, but in the generator's alphabet.
+func (g Generator) Next(p Posn) (Posn, bool) {
+	a := g.alphabet()
+	s := p.digits()
+	if !a.valid(s) {
+		return Posn{}, false
+	}
+	buf := []byte(a.canonical(s))
+	i := len(buf) - 1
+	for ; i >= 0; i-- {
+		if buf[i] != a.max() {
+			buf[i] = a.digit(a.order(buf[i]) + 1)
+			break
+		}
+		buf[i] = a.min()
+	}
+	if i < 0 {
+		// everything carried; instead of wrapping around, grow by
+		// a digit
+		buf = append([]byte(a.canonical(s)), a.min())
+	}
+	return p.withDigits(string(buf)), true
+}
+
+// Prev is like Posn.Prev, but in the generator's alphabet.
+func (g Generator) Prev(p Posn) (Posn, bool) {
+	a := g.alphabet()
+	s := p.digits()
+	if !a.valid(s) {
+		return Posn{}, false
+	}
+	buf := []byte(a.canonical(s))
+	i := len(buf) - 1
+	for ; i >= 0; i-- {
+		if buf[i] != a.min() {
+			buf[i] = a.digit(a.order(buf[i]) - 1)
+			break
+		}
+		buf[i] = a.max()
+	}
+	if i < 0 {
+		return Posn{}, false
+	}
+	return p.withDigits(string(buf)), true
+}
+
+// withDigits is the inverse of digits: it splits s back into a major
+// of the same length as p's and a minor holding the rest
+func (p Posn) withDigits(s string) Posn {
+	return Posn{
+		Bucket: p.Bucket,
+		Major:  s[:len(p.Major)],
+		Minor:  s[len(p.Major):],
+	}
+}
+
+// NthAfter returns the rank n slots after p, where a slot is one step
+// in the last digit of p's major, so that the result is a major as
+// long as p's, with room for items between it and p.  This is for
+// laying out landing spots ahead of time (placeholders for the pages
+// of a list, say) without knowing what comes after them.  It returns
+// false if p isn't valid, or the keyspace runs out first.
+func (p Posn) NthAfter(n int) (Posn, bool) {
+	return Generator{}.NthAfter(p, n, 0)
+}
+
+// NthBefore is like NthAfter, but counts back from p.  The rank made
+// of nothing but zeros, which nothing can be placed before, is never
+// returned.
+func (p Posn) NthBefore(n int) (Posn, bool) {
+	return Generator{}.NthBefore(p, n, 0)
+}
+
+// NthAfter is like Posn.NthAfter, but in the generator's alphabet and
+// with slots of the given width, in digits: the result is a major of
+// that many digits.  A width of zero means p's major's.  Widths
+// shorter than p make for coarser slots (and so a rank approximately
+// n slots away, as p doesn't fall on a slot boundary).
+func (g Generator) NthAfter(p Posn, n, width int) (Posn, bool) {
+	return g.nth(p, n, width)
+}
+
+// NthBefore is like NthAfter, but counts back from p.
+func (g Generator) NthBefore(p Posn, n, width int) (Posn, bool) {
+	return g.nth(p, -n, width)
+}
+
+// nth does the work of NthAfter and NthBefore, counting back if n is
+// negative
+func (g Generator) nth(p Posn, n, width int) (Posn, bool) {
+	a := g.alphabet()
+	if !a.valid(p.digits()) || p.Major == "" {
+		return Posn{}, false
+	}
+	if width <= 0 {
+		width = len(p.Major)
+	}
+	// p cut down (or padded out) to the width, which is at or below
+	// p, so going up a slot always gets past it
+	v := digitsValue(a, a.canonical(p.Major), width)
+	v.Add(v, big.NewInt(int64(n)))
+	limit := new(big.Int).Exp(big.NewInt(int64(a.base())), big.NewInt(int64(width)), nil)
+	if v.Sign() <= 0 || v.Cmp(limit) >= 0 {
+		return Posn{}, false
+	}
+	q := Posn{Bucket: p.Bucket, Major: digitsString(a, v, width)}
+	if n < 0 && q.Compare(p) >= 0 || n > 0 && q.Compare(p) <= 0 {
+		return Posn{}, false
+	}
+	return q, true
+}
@@ -0,0 +1,95 @@
+package lexorank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNext(t *testing.T) {
+	cases := []struct {
+		in, want Posn
+	}{
+		{Posn{Major: "aaa"}, Posn{Major: "aab"}},
+		{Posn{Major: "aaz"}, Posn{Major: "ab0"}},
+		{Posn{Bucket: 1, Major: "aaa", Minor: "zz"}, Posn{Bucket: 1, Major: "aab", Minor: "00"}},
+		{Posn{Major: "zzz"}, Posn{Major: "zzz", Minor: "0"}},
+	}
+	for _, c := range cases {
+		got, ok := c.in.Next()
+		assert.Equal(t, true, ok)
+		assert.Equal(t, c.want, got)
+	}
+}
+
+func TestPrev(t *testing.T) {
+	cases := []struct {
+		in, want Posn
+	}{
+		{Posn{Major: "aab"}, Posn{Major: "aaa"}},
+		{Posn{Major: "ab0"}, Posn{Major: "aaz"}},
+		{Posn{Major: "aab", Minor: "00"}, Posn{Major: "aaa", Minor: "zz"}},
+		{Posn{Major: "aab"}, Posn{Major: "aaa"}},
+	}
+	for _, c := range cases {
+		got, ok := c.in.Prev()
+		assert.Equal(t, true, ok)
+		assert.Equal(t, c.want, got)
+	}
+
+	_, ok := Posn{Major: "000", Minor: "0"}.Prev()
+	assert.Equal(t, false, ok)
+	_, ok = Posn{Major: "a-b"}.Prev()
+	assert.Equal(t, false, ok)
+}
+
+func TestNextPrevRoundTrip(t *testing.T) {
+	p := Posn{Major: "hzzzzz"}
+	n, _ := p.Next()
+	back, _ := n.Prev()
+	assert.Equal(t, p, back)
+}
+
+func TestNextAlphabet(t *testing.T) {
+	got, ok := Generator{Alphabet: Base36}.Next(Posn{Major: "0z"})
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "10", got.Major)
+}
+
+func TestNth(t *testing.T) {
+	p := Posn{Major: "a00000"}
+	q, ok := p.NthAfter(3)
+	assert.True(t, ok)
+	assert.Equal(t, Posn{Major: "a00003"}, q)
+	q, ok = p.NthBefore(1)
+	assert.True(t, ok)
+	assert.Equal(t, Posn{Major: "Zzzzzz"}, q)
+
+	// a minor doesn't count as a slot
+	p = Posn{Bucket: 1, Major: "a00000", Minor: "x"}
+	q, ok = p.NthAfter(1)
+	assert.True(t, ok)
+	assert.Equal(t, Posn{Bucket: 1, Major: "a00001"}, q)
+	q, ok = p.NthBefore(1)
+	assert.True(t, ok)
+	assert.Equal(t, Posn{Bucket: 1, Major: "Zzzzzz"}, q)
+
+	// slots of other widths
+	q, ok = Generator{}.NthAfter(p, 2, 2)
+	assert.True(t, ok)
+	assert.Equal(t, Posn{Bucket: 1, Major: "a2"}, q)
+	q, ok = Generator{}.NthAfter(p, 1, 8)
+	assert.True(t, ok)
+	assert.Equal(t, Posn{Bucket: 1, Major: "a0000001"}, q)
+	q, ok = Generator{Alphabet: Base36}.NthBefore(Posn{Major: "a"}, 9, 0)
+	assert.True(t, ok)
+	assert.Equal(t, Posn{Major: "1"}, q)
+
+	// the ends of the keyspace
+	_, ok = Posn{Major: "zz"}.NthAfter(1)
+	assert.False(t, ok)
+	_, ok = Posn{Major: "02"}.NthBefore(2)
+	assert.False(t, ok)
+	_, ok = Posn{Major: "a!"}.NthAfter(1)
+	assert.False(t, ok)
+}
@@ -0,0 +1,245 @@
+package lexorank
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// An Item is something in a list, with its rank.
+type Item struct {
+	ID   string
+	Rank Posn
+}
+
+// A Store is where the items of many lists, and their ranks, are kept
+// (typically a database table), for the operations that maintain
+// lists on their own, such as a Scheduler's rebalances.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	// Items returns up to limit items of a list (all of them if limit
+	// isn't positive), in rank order, starting with the first ranked
+	// after after (or the first in the list, if after is nil).
+	Items(ctx context.Context, list string, after *Posn, limit int) ([]Item, error)
+
+	// ItemsBefore is like Items, but works backwards: it returns (in
+	// rank order) the last limit items ranked before before (or the
+	// last in the list, if before is nil).
+	ItemsBefore(ctx context.Context, list string, before *Posn, limit int) ([]Item, error)
+
+	// Update sets the ranks of the given items of a list.
+	Update(ctx context.Context, list string, items []Item) error
+
+	// Insert adds an item to a list.  If another item already has its
+	// rank (as a unique index on the rank would tell), it fails with
+	// an error wrapping ErrCollision.
+	Insert(ctx context.Context, list string, item Item) error
+}
+
+// ErrCollision is returned (perhaps wrapped) by a Store when an item
+// is given a rank another item in the list already has.
+var ErrCollision = errors.New("lexorank: rank already taken")
+
+// ErrNotFound is returned when an item isn't in the list it's looked
+// for in.
+var ErrNotFound = errors.New("lexorank: no such item")
+
+// MemStore is a Store that keeps lists in memory, for tests and small
+// programs.  The zero value is ready to use.
+type MemStore struct {
+	mu    sync.Mutex
+	lists map[string][]Item
+	tombs map[itemKey]bool
+}
+
+var (
+	_ TombstoneStore = (*MemStore)(nil)
+	_ Reclaimer      = (*MemStore)(nil)
+)
+
+// Put adds items to a list, or changes their ranks if they're already
+// there.
+func (m *MemStore) Put(list string, items ...Item) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.put(list, items)
+}
+
+func (m *MemStore) put(list string, items []Item) {
+	if m.lists == nil {
+		m.lists = make(map[string][]Item)
+	}
+	l := m.lists[list]
+	for _, it := range items {
+		if i := slices.IndexFunc(l, func(x Item) bool { return x.ID == it.ID }); i >= 0 {
+			l = slices.Delete(l, i, i+1)
+		}
+		i, _ := slices.BinarySearchFunc(l, it.Rank, func(x Item, p Posn) int {
+			return x.Rank.Compare(p)
+		})
+		l = slices.Insert(l, i, it)
+	}
+	m.lists[list] = l
+}
+
+// List returns the items of a list, in rank order.
+func (m *MemStore) List(list string) []Item {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.lists[list])
+}
+
+func (m *MemStore) Items(ctx context.Context, list string, after *Posn, limit int) ([]Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l := m.lists[list]
+	if after != nil {
+		i, _ := slices.BinarySearchFunc(l, *after, func(x Item, p Posn) int {
+			if x.Rank.Compare(p) <= 0 {
+				return -1
+			}
+			return 1
+		})
+		l = l[i:]
+	}
+	if limit > 0 && len(l) > limit {
+		l = l[:limit]
+	}
+	return slices.Clone(l), nil
+}
+
+func (m *MemStore) ItemsBefore(ctx context.Context, list string, before *Posn, limit int) ([]Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l := m.lists[list]
+	if before != nil {
+		i, _ := slices.BinarySearchFunc(l, *before, func(x Item, p Posn) int {
+			return x.Rank.Compare(p)
+		})
+		l = l[:i]
+	}
+	if limit > 0 && len(l) > limit {
+		l = l[len(l)-limit:]
+	}
+	return slices.Clone(l), nil
+}
+
+func (m *MemStore) Update(ctx context.Context, list string, items []Item) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.Put(list, items...)
+	return nil
+}
+
+func (m *MemStore) Insert(ctx context.Context, list string, item Item) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, taken := slices.BinarySearchFunc(m.lists[list], item.Rank, func(x Item, p Posn) int {
+		return x.Rank.Compare(p)
+	})
+	if taken {
+		return &wrapError{ErrCollision.Error() + ": " + item.Rank.String(), ErrCollision}
+	}
+	m.put(list, []Item{item})
+	return nil
+}
+
+// SoftDelete marks items of a list as soft-deleted, leaving them in
+// the list, with their ranks, as a table with a deleted_at column
+// would.
+func (m *MemStore) SoftDelete(list string, ids ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.tombs == nil {
+		m.tombs = make(map[itemKey]bool)
+	}
+	for _, id := range ids {
+		m.tombs[itemKey{list, id}] = true
+	}
+}
+
+func (m *MemStore) Tombstoned(ctx context.Context, list string, items []Item) ([]bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	dead := make([]bool, len(items))
+	for i, it := range items {
+		dead[i] = m.tombs[itemKey{list, it.ID}]
+	}
+	return dead, ctx.Err()
+}
+
+// Reclaim removes soft-deleted items from a list for good.
+func (m *MemStore) Reclaim(ctx context.Context, list string, ids []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, id := range ids {
+		if !m.tombs[itemKey{list, id}] {
+			return newError("reclaiming " + strconv.Quote(id) + ", which isn't soft-deleted")
+		}
+	}
+	for _, id := range ids {
+		delete(m.tombs, itemKey{list, id})
+		m.lists[list] = slices.DeleteFunc(m.lists[list], func(it Item) bool { return it.ID == id })
+	}
+	return nil
+}
+
+// RebalanceStore gives a whole list in s fresh ranks (as Rebalance
+// does), in the bucket it's in now.  (A Generator can be told to set
+// soft-deleted items aside first; see TombstoneMode.)
+func RebalanceStore(ctx context.Context, s Store, list string) error {
+	return Generator{}.RebalanceStore(ctx, s, list)
+}
+
+// RebalanceStore is like the package-level RebalanceStore, but uses
+// the generator's configuration.
+func (g Generator) RebalanceStore(ctx context.Context, s Store, list string) (err error) {
+	ctx, end := g.startSpan(ctx, "RebalanceStore", 0)
+	touched := 0
+	defer func() { end(touched, err) }()
+	items, err := s.Items(ctx, list, nil, 0)
+	if err != nil || len(items) == 0 {
+		return err
+	}
+	bucket := items[0].Rank.Bucket
+	if g.Tombstones != TombstonesLive {
+		if items, err = g.setTombstonesAside(ctx, s, list, items); err != nil || len(items) == 0 {
+			return err
+		}
+	}
+	var (
+		updates []Item
+		old     []Posn
+	)
+	err = g.RebalanceContext(ctx, len(items), bucket, func(i int, p Posn) error {
+		if !items[i].Rank.Equal(p) {
+			updates = append(updates, Item{ID: items[i].ID, Rank: p})
+			old = append(old, items[i].Rank)
+		}
+		return nil
+	})
+	if err != nil || len(updates) == 0 {
+		return err
+	}
+	if err := s.Update(ctx, list, updates); err != nil {
+		return err
+	}
+	touched = len(updates)
+	g.audit(ctx, list, AuditRebalance, old, updates)
+	return nil
+}
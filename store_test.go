@@ -0,0 +1,53 @@
+package lexorank
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemStore(t *testing.T) {
+	ctx := context.Background()
+	m := &MemStore{}
+	m.Put("a", Item{"x", Posn{Major: "c"}}, Item{"y", Posn{Major: "a"}}, Item{"z", Posn{Major: "b"}})
+	m.Put("b", Item{"w", Posn{Major: "a"}})
+
+	items, err := m.Items(ctx, "a", nil, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []Item{{"y", Posn{Major: "a"}}, {"z", Posn{Major: "b"}}, {"x", Posn{Major: "c"}}}, items)
+
+	items, err = m.Items(ctx, "a", &Posn{Major: "a"}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []Item{{"z", Posn{Major: "b"}}}, items)
+
+	items, err = m.ItemsBefore(ctx, "a", nil, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []Item{{"z", Posn{Major: "b"}}, {"x", Posn{Major: "c"}}}, items)
+	items, err = m.ItemsBefore(ctx, "a", &Posn{Major: "b"}, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []Item{{"y", Posn{Major: "a"}}}, items)
+
+	assert.NoError(t, m.Update(ctx, "a", []Item{{"y", Posn{Major: "d"}}}))
+	assert.Equal(t, []Item{{"z", Posn{Major: "b"}}, {"x", Posn{Major: "c"}}, {"y", Posn{Major: "d"}}}, m.List("a"))
+	assert.Len(t, m.List("b"), 1)
+	assert.Empty(t, m.List("c"))
+}
+
+func TestRebalanceStore(t *testing.T) {
+	ctx := context.Background()
+	m := &MemStore{}
+	m.Put("a", Item{"x", Posn{Bucket: 1, Major: "a"}}, Item{"y", Posn{Bucket: 1, Major: "a0"}}, Item{"z", Posn{Bucket: 1, Major: "a00001"}})
+	counts := &MetricsCounters{}
+	assert.NoError(t, Generator{Metrics: counts}.RebalanceStore(ctx, m, "a"))
+	assert.Equal(t, int64(1), counts.Rebalances)
+
+	want, err := Rebalance(3, 1)
+	assert.NoError(t, err)
+	items := m.List("a")
+	for i, id := range []string{"x", "y", "z"} {
+		assert.Equal(t, Item{id, want[i]}, items[i])
+	}
+
+	assert.NoError(t, RebalanceStore(ctx, m, "empty"))
+}
@@ -0,0 +1,127 @@
+// Package timeline builds keys for lists that are mostly in
+// chronological order, but which people occasionally reorder by hand.
+// A key is a sortable timestamp followed by a lexorank rank:
+//
+//	<time><rank>
+//
+// where <time> is 16 hex digits giving the start of the item's time
+// bucket (see Format.Granularity).  Items in different buckets stay
+// in time order; within a bucket, their ranks order them, so they can
+// be moved around freely.
+package timeline
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dkolbly/lexorank"
+)
+
+const timeLen = 16
+
+// A Format describes how keys are made.  The zero Format puts each
+// second in a bucket of its own.
+type Format struct {
+	// Granularity is the size of the time buckets.  Items can only
+	// be reordered by hand within a bucket, so it should be about as
+	// coarse as the ordering people care about.
+	Granularity time.Duration
+}
+
+func (f Format) granularity() time.Duration {
+	if f.Granularity <= 0 {
+		return time.Second
+	}
+	return f.Granularity
+}
+
+// Key returns the key for an item at time t with the given rank
+// within its bucket.
+func (f Format) Key(t time.Time, rank string) (string, error) {
+	for i := 0; i < len(rank); i++ {
+		if _, err := lexorank.OrderOf(rank[i]); err != nil {
+			return "", fmt.Errorf("timeline: rank %q: %w", rank, err)
+		}
+	}
+	bucket := uint64(t.Truncate(f.granularity()).UnixNano()) ^ (1 << 63)
+	b := make([]byte, 0, timeLen+len(rank))
+	s := strconv.FormatUint(bucket, 16)
+	for i := len(s); i < timeLen; i++ {
+		b = append(b, '0')
+	}
+	b = append(b, s...)
+	return string(append(b, rank...)), nil
+}
+
+// Split takes a key apart into the start of its time bucket and its
+// rank within the bucket.
+func (f Format) Split(key string) (time.Time, string, error) {
+	if len(key) < timeLen {
+		return time.Time{}, "", fmt.Errorf("timeline: key %q too short", key)
+	}
+	bucket, err := strconv.ParseUint(key[:timeLen], 16, 64)
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("timeline: bad time in key %q", key)
+	}
+	return time.Unix(0, int64(bucket^(1<<63))), key[timeLen:], nil
+}
+
+// Append returns the key for a new item at time t, placed after last
+// (the key of the latest item so far, or "" if there are none) if
+// that is in the same bucket.
+func (f Format) Append(t time.Time, last string) (string, error) {
+	prev := ""
+	if last != "" {
+		lt, rank, err := f.Split(last)
+		if err != nil {
+			return "", err
+		}
+		if lt.Equal(t.Truncate(f.granularity())) {
+			prev = rank
+		}
+	}
+	rank, ok := lexorank.Rank(prev, "")
+	if !ok {
+		return "", fmt.Errorf("timeline: no room after %q", last)
+	}
+	return f.Key(t, rank)
+}
+
+// Between returns a key for an item moved by hand to between prev and
+// next (either of which may be "", for the start or end of the list).
+// The item takes on the time bucket of prev, or of next if it's at
+// the start, since it can't be placed between buckets without being
+// in one of them.
+func (f Format) Between(prev, next string) (string, error) {
+	if prev == "" && next == "" {
+		return "", fmt.Errorf("timeline: no neighbours to place between")
+	}
+	var pt, nt time.Time
+	var pr, nr string
+	var err error
+	if prev != "" {
+		if pt, pr, err = f.Split(prev); err != nil {
+			return "", err
+		}
+	}
+	if next != "" {
+		if nt, nr, err = f.Split(next); err != nil {
+			return "", err
+		}
+	}
+
+	t := pt
+	switch {
+	case prev == "":
+		t = nt
+	case next == "" || !nt.Equal(pt):
+		// the rest of prev's bucket is free
+		nr = ""
+	}
+	rank, ok := lexorank.Rank(pr, nr)
+	if !ok {
+		return "", fmt.Errorf("timeline: no room between %q and %q", prev, next)
+	}
+	return f.Key(t, rank)
+}
@@ -0,0 +1,70 @@
+package timeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyOrder(t *testing.T) {
+	var f Format
+	t0 := time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC)
+	t1 := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	a, err := f.Key(t0, "z")
+	assert.NoError(t, err)
+	b, err := f.Key(t1, "0")
+	assert.NoError(t, err)
+	c, err := f.Key(t1.Add(time.Second), "0")
+	assert.NoError(t, err)
+	assert.True(t, a < b && b < c)
+
+	tm, rank, err := f.Split(b)
+	assert.NoError(t, err)
+	assert.True(t, tm.Equal(t1))
+	assert.Equal(t, "0", rank)
+
+	_, err = f.Key(t0, "a_")
+	assert.Error(t, err)
+	_, _, err = f.Split("abc")
+	assert.Error(t, err)
+	_, _, err = f.Split("xxxxxxxxxxxxxxxxa")
+	assert.Error(t, err)
+}
+
+func TestAppend(t *testing.T) {
+	f := Format{Granularity: time.Minute}
+	t1 := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	a, err := f.Append(t1, "")
+	assert.NoError(t, err)
+	b, err := f.Append(t1.Add(10*time.Second), a)
+	assert.NoError(t, err)
+	assert.True(t, a < b)
+	assert.Equal(t, a[:timeLen], b[:timeLen])
+
+	c, err := f.Append(t1.Add(time.Minute), b)
+	assert.NoError(t, err)
+	assert.True(t, b < c)
+	assert.NotEqual(t, b[:timeLen], c[:timeLen])
+}
+
+func TestBetween(t *testing.T) {
+	var f Format
+	t1 := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	a, _ := f.Key(t1, "a")
+	b, _ := f.Key(t1, "b")
+	c, _ := f.Key(t1.Add(time.Hour), "0")
+
+	for _, pair := range [][2]string{{a, b}, {b, c}, {"", a}, {c, ""}} {
+		k, err := f.Between(pair[0], pair[1])
+		assert.NoError(t, err)
+		if pair[0] != "" {
+			assert.True(t, pair[0] < k, "%s !< %s", pair[0], k)
+		}
+		if pair[1] != "" {
+			assert.True(t, k < pair[1], "%s !< %s", k, pair[1])
+		}
+	}
+	_, err := f.Between("", "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,166 @@
+package lexorank
+
+import (
+	"context"
+	"math/big"
+	"strings"
+)
+
+// A TombstoneMode says what operations that work through a Store make
+// of soft-deleted items ("tombstones"), which are still in their
+// lists, with ranks, but aren't shown.
+type TombstoneMode byte
+
+const (
+	// TombstonesLive treats soft-deleted items like any other.
+	TombstonesLive TombstoneMode = iota
+
+	// TombstonesSkip looks past soft-deleted items: inserting after
+	// an item (with AllocateBetween) goes in the roomiest gap before
+	// the next item that isn't deleted, rather than squeezing in
+	// before the first tombstone, and RebalanceStore puts the
+	// tombstones after everything else, so they no longer take up
+	// room between live items.
+	TombstonesSkip
+
+	// TombstonesReclaim is TombstonesSkip, except that RebalanceStore
+	// hands the tombstones to the Store's Reclaim (it must be a
+	// Reclaimer) to take their ranks back, and then gives the whole
+	// list over to the live items.
+	TombstonesReclaim
+)
+
+// A TombstoneStore is a Store that keeps soft-deleted items in its
+// lists, and can say which they are.  A Generator's Tombstoned
+// predicate, if set, is used instead.
+type TombstoneStore interface {
+	Store
+
+	// Tombstoned reports which of the given items of a list are
+	// soft-deleted.
+	Tombstoned(ctx context.Context, list string, items []Item) ([]bool, error)
+}
+
+// A Reclaimer is a Store that can take back the ranks of soft-deleted
+// items, by clearing them or deleting the items for good, so that
+// they're no longer in the list.
+type Reclaimer interface {
+	Store
+
+	Reclaim(ctx context.Context, list string, ids []string) error
+}
+
+// maxTombstoneRun is the most soft-deleted items in a row that
+// AllocateBetween looks through for a gap
+const maxTombstoneRun = 256
+
+// tombstoned reports which of items are soft-deleted, as a nil slice
+// if none are
+func (g Generator) tombstoned(ctx context.Context, s Store, list string, items []Item) ([]bool, error) {
+	if g.Tombstoned != nil {
+		dead := make([]bool, len(items))
+		for i, it := range items {
+			dead[i] = g.Tombstoned(list, it)
+		}
+		return dead, nil
+	}
+	if ts, ok := s.(TombstoneStore); ok {
+		return ts.Tombstoned(ctx, list, items)
+	}
+	return nil, nil
+}
+
+// skipTombstones widens the gap between prev and next, if next is
+// soft-deleted, to run up to the next item that isn't, and returns
+// the roomiest gap along the way
+func (g Generator) skipTombstones(ctx context.Context, s Store, list string, prev, next *Posn) (*Posn, *Posn, error) {
+	if g.Tombstones == TombstonesLive || next == nil {
+		return prev, next, nil
+	}
+	bounds := []*Posn{prev}
+	after := prev
+	for len(bounds) < maxTombstoneRun {
+		items, err := s.Items(ctx, list, after, 100)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(items) == 0 {
+			bounds = append(bounds, nil)
+			break
+		}
+		dead, err := g.tombstoned(ctx, s, list, items)
+		if err != nil {
+			return nil, nil, err
+		}
+		live := false
+		for i := range items {
+			bounds = append(bounds, &items[i].Rank)
+			if dead == nil || !dead[i] {
+				live = true
+				break
+			}
+		}
+		if live {
+			break
+		}
+		after = &items[len(items)-1].Rank
+	}
+
+	// the room in each gap is counted to a couple of digits past the
+	// longest bound, which is enough to tell a gap that's nearly full
+	// from one that isn't
+	a := g.alphabet()
+	depth := 0
+	for _, b := range bounds {
+		if b != nil {
+			depth = max(depth, len(b.digits()))
+		}
+	}
+	depth += 2
+	var best *big.Int
+	for i := 1; i < len(bounds); i++ {
+		lo, hi := bounds[i-1], bounds[i]
+		if lo == nil {
+			lo = &Posn{Major: string(a.min())}
+		}
+		if hi == nil {
+			hi = &Posn{Major: strings.Repeat(string(a.max()), depth)}
+		}
+		room, err := g.Capacity(*lo, *hi, depth)
+		if err == nil && (best == nil || room.Cmp(best) > 0) {
+			best = room
+			prev, next = bounds[i-1], bounds[i]
+		}
+	}
+	return prev, next, nil
+}
+
+// setTombstonesAside returns the items of a list that RebalanceStore
+// should spread, in order: the live ones followed by the tombstones,
+// or (after reclaiming the tombstones) just the live ones
+func (g Generator) setTombstonesAside(ctx context.Context, s Store, list string, items []Item) ([]Item, error) {
+	dead, err := g.tombstoned(ctx, s, list, items)
+	if err != nil || dead == nil {
+		return items, err
+	}
+	var live, tombs []Item
+	for i, it := range items {
+		if dead[i] {
+			tombs = append(tombs, it)
+		} else {
+			live = append(live, it)
+		}
+	}
+	if g.Tombstones == TombstonesSkip || len(tombs) == 0 {
+		return append(live, tombs...), nil
+	}
+	r, ok := s.(Reclaimer)
+	if !ok {
+		return nil, newError("store can't reclaim the ranks of soft-deleted items")
+	}
+	ids := make([]string, len(tombs))
+	for i, it := range tombs {
+		ids[i] = it.ID
+	}
+	return live, r.Reclaim(ctx, list, ids)
+}
@@ -0,0 +1,87 @@
+package lexorank
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllocateBetweenTombstones(t *testing.T) {
+	ctx := context.Background()
+	fill := func() *MemStore {
+		m := &MemStore{}
+		m.Put("l",
+			Item{"x", Posn{Major: "a"}},
+			Item{"t1", Posn{Major: "a0001"}},
+			Item{"t2", Posn{Major: "a0002"}},
+			Item{"y", Posn{Major: "z"}})
+		m.SoftDelete("l", "t1", "t2")
+		return m
+	}
+
+	// counted as live, the tombstones leave hardly any room
+	m := fill()
+	_, err := AllocateBetween(ctx, m, "l", "x", "n")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"x", "n", "t1", "t2", "y"}, ids(m.List("l")))
+
+	m = fill()
+	g := Generator{Tombstones: TombstonesSkip}
+	_, err = g.AllocateBetween(ctx, m, "l", "x", "n")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"x", "t1", "t2", "n", "y"}, ids(m.List("l")))
+
+	// a predicate works for stores that can't tell
+	m = fill()
+	g.Tombstoned = func(list string, it Item) bool { return it.ID[0] == 't' }
+	_, err = g.AllocateBetween(ctx, struct{ Store }{m}, "l", "x", "n")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"x", "t1", "t2", "n", "y"}, ids(m.List("l")))
+
+	// nothing live after the tombstones
+	m = fill()
+	m.SoftDelete("l", "y")
+	_, err = Generator{Tombstones: TombstonesSkip}.AllocateBetween(ctx, m, "l", "x", "n")
+	assert.NoError(t, err)
+	assert.Equal(t, "x", m.List("l")[0].ID)
+}
+
+func TestRebalanceStoreTombstones(t *testing.T) {
+	ctx := context.Background()
+	fill := func() *MemStore {
+		m := &MemStore{}
+		m.Put("l",
+			Item{"x", Posn{Major: "a"}},
+			Item{"t1", Posn{Major: "a0"}},
+			Item{"y", Posn{Major: "a00"}},
+			Item{"t2", Posn{Major: "a000"}},
+			Item{"z", Posn{Major: "a0000"}})
+		m.SoftDelete("l", "t1", "t2")
+		return m
+	}
+
+	m := fill()
+	assert.NoError(t, RebalanceStore(ctx, m, "l"))
+	assert.Equal(t, []string{"x", "t1", "y", "t2", "z"}, ids(m.List("l")))
+
+	m = fill()
+	assert.NoError(t, Generator{Tombstones: TombstonesSkip}.RebalanceStore(ctx, m, "l"))
+	assert.Equal(t, []string{"x", "y", "z", "t1", "t2"}, ids(m.List("l")))
+
+	m = fill()
+	assert.NoError(t, Generator{Tombstones: TombstonesReclaim}.RebalanceStore(ctx, m, "l"))
+	assert.Equal(t, []string{"x", "y", "z"}, ids(m.List("l")))
+	want, _ := Rebalance(3, 0)
+	for i, it := range m.List("l") {
+		assert.Equal(t, want[i], it.Rank)
+	}
+
+	// a store has to be able to reclaim
+	m = fill()
+	g := Generator{Tombstones: TombstonesReclaim, Tombstoned: func(_ string, it Item) bool { return it.ID[0] == 't' }}
+	assert.Error(t, g.RebalanceStore(ctx, struct{ Store }{m}, "l"))
+	assert.Len(t, m.List("l"), 5)
+
+	assert.Error(t, m.Reclaim(ctx, "l", []string{"x"}))
+}
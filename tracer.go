@@ -0,0 +1,32 @@
+package lexorank
+
+import "context"
+
+// A Tracer is told when a generator's long-running operations (such
+// as RebalanceContext) start and finish, for wiring into a tracing
+// system; see the lexorankotel package for OpenTelemetry.
+// Implementations must be safe for concurrent use.
+type Tracer interface {
+	// Start is called as operation op starts, with the number of items
+	// it is working on.  The context it returns is used for the
+	// operation, so that anything it calls out to (fn, say) is traced
+	// as part of it.
+	Start(ctx context.Context, op string, size int) (context.Context, TraceSpan)
+}
+
+// A TraceSpan is an operation being traced.
+type TraceSpan interface {
+	// End is called when the operation finishes, with the number of
+	// items it touched and the error it failed with, if any.
+	End(touched int, err error)
+}
+
+// startSpan starts tracing op, if the generator has a Tracer; end
+// must be called when op finishes
+func (g Generator) startSpan(ctx context.Context, op string, size int) (context.Context, func(touched int, err error)) {
+	if g.Tracer == nil {
+		return ctx, func(int, error) {}
+	}
+	ctx, span := g.Tracer.Start(ctx, op, size)
+	return ctx, span.End
+}
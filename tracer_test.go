@@ -0,0 +1,90 @@
+package lexorank
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type spanRecord struct {
+	op            string
+	size, touched int
+	err           error
+}
+
+type testTracer struct {
+	mu    sync.Mutex
+	spans []*spanRecord
+}
+
+func (tr *testTracer) Start(ctx context.Context, op string, size int) (context.Context, TraceSpan) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	s := &spanRecord{op: op, size: size}
+	tr.spans = append(tr.spans, s)
+	return ctx, s
+}
+
+func (s *spanRecord) End(touched int, err error) {
+	s.touched, s.err = touched, err
+}
+
+func TestTracer(t *testing.T) {
+	tr := &testTracer{}
+	g := Generator{Tracer: tr}
+	ctx := context.Background()
+
+	assert.NoError(t, g.RebalanceContext(ctx, 10, 0, func(int, Posn) error { return nil }))
+	assert.NoError(t, g.RanksContext(ctx, 5, nil, nil, func(int, Posn) error { return nil }))
+	boom := errors.New("boom")
+	err := g.RebalanceParallelContext(ctx, 20000, 1, 2, func(i int, _ Posn) error {
+		if i == 15000 {
+			return boom
+		}
+		return nil
+	})
+	assert.Equal(t, boom, err)
+
+	assert.Equal(t, []*spanRecord{
+		{op: "Rebalance", size: 10, touched: 10},
+		{op: "Ranks", size: 5, touched: 5},
+		{op: "RebalanceParallel", size: 20000, touched: 15000, err: boom},
+	}, tr.spans)
+}
+
+func TestTracerStore(t *testing.T) {
+	tr := &testTracer{}
+	g := Generator{Tracer: tr}
+	ctx := context.Background()
+	m := &MemStore{}
+	m.Put("list", Item{"a", Posn{Major: "a"}})
+	m.Put("list", Item{"b", Posn{Major: "a1"}})
+
+	assert.NoError(t, g.RebalanceStore(ctx, m, "list"))
+	_, err := g.InsertStore(ctx, m, "list", "c", nil, nil)
+	assert.NoError(t, err)
+	items := m.List("list")
+	_, err = g.InsertStore(ctx, m, "list", "d", &items[0].Rank, &items[0].Rank)
+	assert.Equal(t, ErrNoRoom, err)
+
+	var store []spanRecord
+	for _, s := range tr.spans {
+		if s.op == "RebalanceStore" || s.op == "InsertStore" {
+			store = append(store, *s)
+		}
+	}
+	assert.Equal(t, []spanRecord{
+		{op: "RebalanceStore", touched: 2},
+		{op: "InsertStore", size: 1, touched: 1},
+		{op: "InsertStore", size: 1, err: ErrNoRoom},
+	}, store)
+}
+
+func TestNoTracer(t *testing.T) {
+	ranks, err := Generator{}.Rebalance(3, 0)
+	assert.NoError(t, err)
+	assert.Len(t, ranks, 3)
+}
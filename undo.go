@@ -0,0 +1,93 @@
+package lexorank
+
+// Inverse returns the operation that undoes op, given the list as it
+// is before op is applied: deleting what op inserts, putting back
+// what it deletes, and moving what it moves back to where it was.
+// The ranks in the inverse are the very ones the items had, not new
+// ranks in the same places, so undoing leaves the list exactly as it
+// was.  It fails if op can't be applied to the list.
+func (op Op) Inverse(list map[string]Posn) (Op, error) {
+	if err := op.check(list); err != nil {
+		return Op{}, err
+	}
+	switch op.Kind {
+	case OpInsert:
+		return DeleteOp(op.Entries[0].ID, op.Entries[0].Rank), nil
+	case OpDelete:
+		id := op.Entries[0].ID
+		return InsertOp(id, list[id]), nil
+	}
+	entries := make([]Entry, len(op.Entries))
+	for i, e := range op.Entries {
+		entries[i] = Entry{e.ID, list[e.ID]}
+	}
+	return Op{Kind: op.Kind, Entries: entries}, nil
+}
+
+// An UndoLog applies operations to a list, remembering how to undo
+// them, so that an application can undo and redo reordering exactly.
+// The zero value is an empty log that remembers everything.
+type UndoLog struct {
+	// Limit is the most operations to remember for undoing (all of
+	// them, if zero); older ones are forgotten
+	Limit int
+
+	undo, redo []undoStep
+}
+
+// an undoStep is an operation and its inverse
+type undoStep struct {
+	do, undo Op
+}
+
+// Do applies op to list, and remembers it for Undo.  Anything that
+// was undone can't be redone after.
+func (u *UndoLog) Do(list map[string]Posn, op Op) error {
+	inv, err := op.Inverse(list)
+	if err != nil {
+		return err
+	}
+	if err := op.Apply(list); err != nil {
+		return err
+	}
+	u.undo = append(u.undo, undoStep{op, inv})
+	if u.Limit > 0 && len(u.undo) > u.Limit {
+		u.undo = u.undo[len(u.undo)-u.Limit:]
+	}
+	u.redo = u.redo[:0]
+	return nil
+}
+
+// Undo undoes the last operation done (or redone) to list, and
+// returns the operation it applied to do that, for persisting.  It
+// returns false if there's nothing to undo, and an error if the list
+// has changed in a way that means the operation can't be undone, in
+// which case the log is left as it was.
+func (u *UndoLog) Undo(list map[string]Posn) (Op, bool, error) {
+	return u.step(list, &u.undo, &u.redo, func(s undoStep) Op { return s.undo })
+}
+
+// Redo does again the last operation undone, as for Undo.
+func (u *UndoLog) Redo(list map[string]Posn) (Op, bool, error) {
+	return u.step(list, &u.redo, &u.undo, func(s undoStep) Op { return s.do })
+}
+
+// step applies the op of the last step in from, and moves the step to
+// to
+func (u *UndoLog) step(list map[string]Posn, from, to *[]undoStep, op func(undoStep) Op) (Op, bool, error) {
+	if len(*from) == 0 {
+		return Op{}, false, nil
+	}
+	s := (*from)[len(*from)-1]
+	if err := op(s).Apply(list); err != nil {
+		return Op{}, true, err
+	}
+	*from = (*from)[:len(*from)-1]
+	*to = append(*to, s)
+	return op(s), true, nil
+}
+
+// CanUndo and CanRedo report whether there is anything to undo or
+// redo.
+func (u *UndoLog) CanUndo() bool { return len(u.undo) > 0 }
+func (u *UndoLog) CanRedo() bool { return len(u.redo) > 0 }
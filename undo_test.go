@@ -0,0 +1,101 @@
+package lexorank
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInverse(t *testing.T) {
+	a, b := Posn{Major: "a"}, Posn{Major: "b", Minor: ":U"}
+	list := map[string]Posn{"x": a, "y": b}
+
+	inv, err := InsertOp("z", Posn{Major: "c"}).Inverse(list)
+	assert.NoError(t, err)
+	assert.Equal(t, DeleteOp("z", Posn{Major: "c"}), inv)
+
+	inv, err = DeleteOp("y", Posn{}).Inverse(list)
+	assert.NoError(t, err)
+	// the rank it had, written as it was
+	assert.Equal(t, InsertOp("y", b), inv)
+
+	inv, err = MoveOp("x", Posn{Major: "c"}).Inverse(list)
+	assert.NoError(t, err)
+	assert.Equal(t, MoveOp("x", a), inv)
+
+	inv, err = RebalanceOp([]Entry{{"x", Posn{Major: "U"}}, {"y", Posn{Major: "k"}}}).Inverse(list)
+	assert.NoError(t, err)
+	assert.Equal(t, RebalanceOp([]Entry{{"x", a}, {"y", b}}), inv)
+
+	_, err = MoveOp("z", a).Inverse(list)
+	assert.Error(t, err)
+	_, err = InsertOp("x", a).Inverse(list)
+	assert.Error(t, err)
+}
+
+func TestUndoLog(t *testing.T) {
+	list := map[string]Posn{}
+	var u UndoLog
+	_, ok, err := u.Undo(list)
+	assert.False(t, ok)
+	assert.NoError(t, err)
+
+	var states []map[string]Posn
+	for _, op := range []Op{
+		InsertOp("x", Posn{Major: "U"}),
+		InsertOp("y", Posn{Major: "k"}),
+		MoveOp("x", Posn{Major: "kU"}),
+		DeleteOp("y", Posn{Major: "k"}),
+		RebalanceOp([]Entry{{"x", Posn{Major: "U"}}}),
+	} {
+		states = append(states, maps.Clone(list))
+		assert.NoError(t, u.Do(list, op))
+	}
+	final := maps.Clone(list)
+	assert.Error(t, u.Do(list, MoveOp("y", Posn{})))
+
+	// undo all the way back, and then redo
+	for i := len(states) - 1; i >= 0; i-- {
+		_, ok, err := u.Undo(list)
+		assert.True(t, ok)
+		assert.NoError(t, err)
+		assert.Equal(t, states[i], list)
+	}
+	assert.False(t, u.CanUndo())
+	for i := 1; i < len(states); i++ {
+		op, ok, err := u.Redo(list)
+		assert.True(t, ok)
+		assert.NoError(t, err)
+		assert.Equal(t, states[i], list)
+		assert.NotZero(t, op.Kind)
+	}
+	_, _, err = u.Redo(list)
+	assert.NoError(t, err)
+	assert.Equal(t, final, list)
+	assert.False(t, u.CanRedo())
+
+	// doing something new forgets what could be redone
+	_, _, err = u.Undo(list)
+	assert.NoError(t, err)
+	assert.NoError(t, u.Do(list, InsertOp("z", Posn{Major: "z"})))
+	assert.False(t, u.CanRedo())
+
+	// an undo that no longer makes sense fails, and stays put
+	delete(list, "z")
+	_, ok, err = u.Undo(list)
+	assert.True(t, ok)
+	assert.Error(t, err)
+	assert.True(t, u.CanUndo())
+
+	u = UndoLog{Limit: 2}
+	list = map[string]Posn{}
+	for _, id := range []string{"a", "b", "c"} {
+		assert.NoError(t, u.Do(list, InsertOp(id, Posn{Major: id})))
+	}
+	for u.CanUndo() {
+		_, _, err := u.Undo(list)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, map[string]Posn{"a": {Major: "a"}}, list)
+}
@@ -0,0 +1,199 @@
+package lexorank
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A UnicodeAlphabet is a numeral system whose digits are Unicode code
+// points, which may take more than one byte each, for storage that
+// compares strings by code point.  Since UTF-8 sorts byte-wise the
+// same way its code points do, ranks written in it sort the same way
+// whether compared as bytes or as code points, so Posn.Compare works
+// on them as it is.
+//
+// Generating and parsing ranks in one is done by a UnicodeGenerator,
+// which works out the ranks with a byte alphabet of the same base
+// standing in for it.
+type UnicodeAlphabet struct {
+	digits []rune
+	values map[rune]int
+
+	// inner has a byte digit for each of digits
+	inner Alphabet
+}
+
+// innerDigits are the bytes that stand in for the digits of a
+// UnicodeAlphabet: printable ASCII, but for ':' and '|', which mean
+// something in a Posn
+const innerDigits = "!\"#$%&'()*+,-./0123456789;<=>?@ABCDEFGHIJKLMNOPQRSTUVWXYZ[\\]^_`abcdefghijklmnopqrstuvwxyz{}~"
+
+// maxUnicodeBase is the most digits a UnicodeAlphabet can have
+const maxUnicodeBase = len(innerDigits)
+
+// NewUnicodeAlphabet creates an alphabet whose digits, in order, are
+// the code points of chars.  They must be strictly ascending (so
+// unique) and printable, and can't include '|' or ':', which go
+// between the parts of a position.  There can be at most 92 of them.
+func NewUnicodeAlphabet(chars string) (UnicodeAlphabet, error) {
+	if !utf8.ValidString(chars) {
+		return UnicodeAlphabet{}, newError("alphabet " + strconv.Quote(chars) + " is not valid UTF-8")
+	}
+	digits := []rune(chars)
+	if len(digits) < 2 || len(digits) > maxUnicodeBase {
+		return UnicodeAlphabet{}, newError("alphabet " + strconv.Quote(chars) + " needs between 2 and " +
+			strconv.Itoa(maxUnicodeBase) + " digits")
+	}
+	u := UnicodeAlphabet{digits: digits, values: make(map[rune]int, len(digits))}
+	for i, r := range digits {
+		q := strconv.QuoteRune(r)
+		switch {
+		case !unicode.IsPrint(r) || r == ' ':
+			return UnicodeAlphabet{}, newError("alphabet digit " + q + " is not printable")
+		case r == '|' || r == ':':
+			return UnicodeAlphabet{}, newError("alphabet digit " + q + " separates the parts of a position")
+		case i > 0 && r == digits[i-1]:
+			return UnicodeAlphabet{}, newError("alphabet digit " + q + " is repeated")
+		case i > 0 && r < digits[i-1]:
+			return UnicodeAlphabet{}, newError("alphabet digit " + q + " is out of order after " + strconv.QuoteRune(digits[i-1]))
+		}
+		u.values[r] = i
+	}
+	u.inner = mustAlphabet(innerDigits[:len(digits)], nil)
+	return u, nil
+}
+
+// Digits returns the alphabet's digits, in ascending order.
+func (u UnicodeAlphabet) Digits() string {
+	return string(u.digits)
+}
+
+// Base returns the number of digits in the alphabet.
+func (u UnicodeAlphabet) Base() int {
+	return len(u.digits)
+}
+
+// Valid reports whether s is made only of the alphabet's digits.
+func (u UnicodeAlphabet) Valid(s string) bool {
+	_, ok := u.encode(s)
+	return ok
+}
+
+// encode translates digits of u into the digits of its inner
+// alphabet, if they are all digits of u
+func (u UnicodeAlphabet) encode(s string) (string, bool) {
+	if u.values == nil {
+		return "", false
+	}
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		v, ok := u.values[r]
+		if !ok {
+			return "", false
+		}
+		out = append(out, u.inner.digit(v))
+	}
+	return string(out), true
+}
+
+// decode translates digits of the inner alphabet back into u's
+func (u UnicodeAlphabet) decode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		b.WriteRune(u.digits[u.inner.order(s[i])])
+	}
+	return b.String()
+}
+
+func (u UnicodeAlphabet) encodePosn(p Posn) (Posn, bool) {
+	major, ok := u.encode(p.Major)
+	if !ok {
+		return Posn{}, false
+	}
+	minor, ok := u.encode(p.MinorValue())
+	return Posn{Bucket: p.Bucket, Major: major, Minor: minor}, ok
+}
+
+func (u UnicodeAlphabet) decodePosn(p Posn) Posn {
+	return Posn{Bucket: p.Bucket, Major: u.decode(p.Major), Minor: u.decode(p.MinorValue())}
+}
+
+// A UnicodeGenerator generates and parses ranks in a UnicodeAlphabet.
+type UnicodeGenerator struct {
+	Alphabet UnicodeAlphabet
+
+	// Generator makes the ranks; its Alphabet and Profile are
+	// ignored, since the ranks are in Alphabet, written the default
+	// way.
+	Generator Generator
+}
+
+// generator returns the Generator that works out ranks in the inner
+// alphabet
+func (ug UnicodeGenerator) generator() Generator {
+	g := ug.Generator
+	g.Alphabet = ug.Alphabet.inner
+	g.Profile = Profile{}
+	return g
+}
+
+// Rank is like the package-level Rank, but in the generator's
+// alphabet, where the lowest and highest ranks are its smallest and
+// largest digits.
+func (ug UnicodeGenerator) Rank(prev, next string) (string, bool) {
+	u := ug.Alphabet
+	lo, ok1 := u.encode(prev)
+	hi, ok2 := u.encode(next)
+	if !ok1 || !ok2 {
+		return prev, false
+	}
+	r, ok := ug.generator().Rank(lo, hi)
+	if !ok {
+		return prev, false
+	}
+	return u.decode(r), true
+}
+
+// Ranks is like the package-level Ranks, but in the generator's
+// alphabet.  It fails if prev or next has digits outside it.
+func (ug UnicodeGenerator) Ranks(n int, prev, next *Posn) ([]Posn, bool) {
+	u := ug.Alphabet
+	var bounds [2]*Posn
+	for i, p := range []*Posn{prev, next} {
+		if p == nil {
+			continue
+		}
+		q, ok := u.encodePosn(*p)
+		if !ok {
+			return nil, false
+		}
+		bounds[i] = &q
+	}
+	ranks, ok := ug.generator().Ranks(n, bounds[0], bounds[1])
+	if !ok {
+		return nil, false
+	}
+	for i, p := range ranks {
+		ranks[i] = u.decodePosn(p)
+	}
+	return ranks, true
+}
+
+// Parse is like the package-level Parse, but reads digits of the
+// generator's alphabet.
+func (ug UnicodeGenerator) Parse(s string) (Posn, error) {
+	bad := newError("invalid position " + strconv.Quote(s))
+	if len(s) < 3 || s[0] < '0' || s[0] > '0'+MaxBucket || s[1] != '|' {
+		return Posn{}, bad
+	}
+	p := Posn{Bucket: s[0] - '0', Major: s[2:]}
+	if i := strings.IndexByte(p.Major, ':'); i >= 0 {
+		p.Major, p.Minor = p.Major[:i], p.Major[i+1:]
+	}
+	if p.Major == "" || !ug.Alphabet.Valid(p.Major) || !ug.Alphabet.Valid(p.Minor) {
+		return Posn{}, bad
+	}
+	return p, nil
+}
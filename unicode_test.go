@@ -0,0 +1,96 @@
+package lexorank
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewUnicodeAlphabet(t *testing.T) {
+	u, err := NewUnicodeAlphabet("αβγδεζηθικλμνξοπρστυφχψω")
+	assert.NoError(t, err)
+	assert.Equal(t, 24, u.Base())
+	assert.Equal(t, "αβγδεζηθικλμνξοπρστυφχψω", u.Digits())
+	assert.True(t, u.Valid("αω"))
+	assert.False(t, u.Valid("αa"))
+
+	for _, s := range []string{"α", "βα", "αα", "α:β", "α β", "\xff\xfe", ""} {
+		_, err = NewUnicodeAlphabet(s)
+		assert.Error(t, err, s)
+	}
+	assert.False(t, UnicodeAlphabet{}.Valid("a"))
+}
+
+func TestUnicodeGenerator(t *testing.T) {
+	// digits of one to four bytes each, so byte and code point
+	// lengths don't line up
+	u, err := NewUnicodeAlphabet("0123456789é€𝄞")
+	assert.NoError(t, err)
+	ug := UnicodeGenerator{Alphabet: u}
+
+	r, ok := ug.Rank("", "")
+	assert.True(t, ok)
+	assert.Equal(t, "6", r)
+	r, ok = ug.Rank("9", "é")
+	assert.True(t, ok)
+	assert.Equal(t, "96", r)
+	r, ok = ug.Rank("€𝄞", "")
+	assert.True(t, ok)
+	assert.Equal(t, "€𝄞6", r)
+	_, ok = ug.Rank("a", "")
+	assert.False(t, ok)
+
+	// ranks inserted over and over at the start, middle and end stay
+	// in order, compared both as bytes and as code points
+	list := []string{}
+	for i := 0; i < 300; i++ {
+		at := []int{0, len(list) / 2, len(list)}[i%3]
+		var prev, next string
+		if at > 0 {
+			prev = list[at-1]
+		}
+		if at < len(list) {
+			next = list[at]
+		}
+		r, ok := ug.Rank(prev, next)
+		assert.True(t, ok)
+		list = slices.Insert(list, at, r)
+	}
+	assert.True(t, slices.IsSorted(list))
+	assert.True(t, slices.IsSortedFunc(list, func(a, b string) int {
+		return slices.Compare([]rune(a), []rune(b))
+	}))
+}
+
+func TestUnicodeGeneratorRanks(t *testing.T) {
+	u, err := NewUnicodeAlphabet("αβγδεζηθικλμνξοπρστυφχψω")
+	assert.NoError(t, err)
+	ug := UnicodeGenerator{Alphabet: u}
+
+	prev, err := ug.Parse("0|βγδ:")
+	assert.NoError(t, err)
+	next, err := ug.Parse("0|βγε:α")
+	assert.NoError(t, err)
+	ranks, ok := ug.Ranks(3, &prev, &next)
+	assert.True(t, ok)
+	last := prev
+	for _, p := range append(ranks, next) {
+		assert.Equal(t, -1, last.Compare(p))
+		_, err := ug.Parse(p.String())
+		assert.NoError(t, err, p.String())
+		last = p
+	}
+
+	ranks, ok = ug.Ranks(2, nil, nil)
+	assert.True(t, ok)
+	assert.Len(t, ranks, 2)
+	assert.True(t, u.Valid(ranks[0].Major))
+
+	_, ok = ug.Ranks(1, &Posn{Major: "a"}, nil)
+	assert.False(t, ok)
+	for _, s := range []string{"0|", "0|a:", "3|β:", "0|β:a", "0|:β"} {
+		_, err = ug.Parse(s)
+		assert.Error(t, err, s)
+	}
+}
@@ -0,0 +1,91 @@
+package lexorank
+
+import (
+	"errors"
+	"strconv"
+)
+
+// A Versioned is a rank along with a version number (or etag) that
+// changes whenever the rank does, for optimistic concurrency control
+// of reorders.
+type Versioned struct {
+	Rank    Posn
+	Version int64
+}
+
+// Bump returns the versioned rank after changing the rank to r.
+func (v Versioned) Bump(r Posn) Versioned {
+	return Versioned{Rank: r, Version: v.Version + 1}
+}
+
+// ErrConflict is returned (wrapped in a *ConflictError) when the
+// neighbours of a reorder have changed since they were observed.
+var ErrConflict = errors.New("lexorank: neighbour changed")
+
+// A ConflictError reports a neighbour that wasn't what the caller
+// thought it was.  Observed or Current is nil if the caller thought
+// there was no neighbour and there is one now, or vice versa.
+type ConflictError struct {
+	Observed, Current *Versioned
+}
+
+func (e *ConflictError) Error() string {
+	describe := func(v *Versioned) string {
+		if v == nil {
+			return "none"
+		}
+		return v.Rank.String() + " (version " + strconv.FormatInt(v.Version, 10) + ")"
+	}
+	return ErrConflict.Error() + ": observed " + describe(e.Observed) + ", now " + describe(e.Current)
+}
+
+func (e *ConflictError) Unwrap() error {
+	return ErrConflict
+}
+
+// RankVersioned returns a rank between two neighbours, but only if
+// they are still the ones the client saw: prev and next are the
+// neighbours as observed (typically sent along with the reorder
+// request), and curPrev and curNext are what's there now (typically
+// read in the transaction doing the update).  If either has changed,
+// the error is a *ConflictError, and the client should refresh and
+// try again.
+func RankVersioned(prev, next, curPrev, curNext *Versioned) (Posn, error) {
+	return Generator{}.RankVersioned(prev, next, curPrev, curNext)
+}
+
+// RankVersioned is like the package-level RankVersioned, but uses the
+// generator's configuration.
+func (g Generator) RankVersioned(prev, next, curPrev, curNext *Versioned) (Posn, error) {
+	if err := checkVersion(prev, curPrev); err != nil {
+		return Posn{}, err
+	}
+	if err := checkVersion(next, curNext); err != nil {
+		return Posn{}, err
+	}
+	var p, n *Posn
+	if curPrev != nil {
+		p = &curPrev.Rank
+	}
+	if curNext != nil {
+		n = &curNext.Rank
+	}
+	if p != nil && n != nil {
+		if err := CheckBounds(*p, *n); err != nil {
+			return Posn{}, err
+		}
+	}
+	out, ok := g.Ranks(1, p, n)
+	if !ok {
+		return Posn{}, newError("no room between neighbours")
+	}
+	return out[0], nil
+}
+
+func checkVersion(observed, current *Versioned) error {
+	if (observed == nil) != (current == nil) ||
+		(observed != nil && (observed.Version != current.Version || !observed.Rank.Equal(current.Rank))) {
+		return &ConflictError{Observed: observed, Current: current}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package lexorank
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRankVersioned(t *testing.T) {
+	prev := Versioned{Rank: Posn{Major: "a00000"}, Version: 3}
+	next := Versioned{Rank: Posn{Major: "b00000"}, Version: 7}
+
+	r, err := RankVersioned(&prev, &next, &prev, &next)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, r.Compare(prev.Rank))
+	assert.Equal(t, -1, r.Compare(next.Rank))
+
+	r, err = RankVersioned(nil, &next, nil, &next)
+	assert.NoError(t, err)
+	assert.Equal(t, -1, r.Compare(next.Rank))
+}
+
+func TestRankVersionedConflict(t *testing.T) {
+	prev := Versioned{Rank: Posn{Major: "a00000"}, Version: 3}
+	next := Versioned{Rank: Posn{Major: "b00000"}, Version: 7}
+	moved := next.Bump(Posn{Major: "c00000"})
+	assert.Equal(t, int64(8), moved.Version)
+
+	_, err := RankVersioned(&prev, &next, &prev, &moved)
+	assert.True(t, errors.Is(err, ErrConflict))
+	var cerr *ConflictError
+	assert.True(t, errors.As(err, &cerr))
+	assert.Equal(t, &next, cerr.Observed)
+	assert.Equal(t, &moved, cerr.Current)
+	assert.Equal(t, "lexorank: neighbour changed: observed 0|b00000: (version 7), now 0|c00000: (version 8)", err.Error())
+
+	// something was inserted at the top in the meantime
+	_, err = RankVersioned(nil, &next, &prev, &next)
+	assert.True(t, errors.As(err, &cerr))
+	assert.Nil(t, cerr.Observed)
+
+	_, err = RankVersioned(&next, &prev, &next, &prev)
+	assert.True(t, errors.Is(err, ErrInvertedBounds))
+}
@@ -0,0 +1,87 @@
+package lexorank
+
+import (
+	"context"
+	"strconv"
+)
+
+// A Window is the part of a list that a client can see, as in a
+// virtualized list that only renders what's on screen: the ranks of a
+// run of items next to each other in the list, in order.
+type Window struct {
+	Ranks []Posn
+
+	// AtStart and AtEnd say whether the window reaches the start or
+	// the end of the whole list
+	AtStart, AtEnd bool
+}
+
+// A NeighbourFunc returns the rank of the item next to the one with
+// rank p in the whole list: the one before it if before is set, and
+// the one after it otherwise.  It returns nil if p is at that end of
+// the list.
+type NeighbourFunc func(ctx context.Context, p Posn, before bool) (*Posn, error)
+
+// StoreNeighbours returns a NeighbourFunc that looks neighbours up in
+// a list in s.
+func StoreNeighbours(s Store, list string) NeighbourFunc {
+	return func(ctx context.Context, p Posn, before bool) (*Posn, error) {
+		var items []Item
+		var err error
+		if before {
+			items, err = s.ItemsBefore(ctx, list, &p, 1)
+		} else {
+			items, err = s.Items(ctx, list, &p, 1)
+		}
+		if err != nil || len(items) == 0 {
+			return nil, err
+		}
+		return &items[0].Rank, nil
+	}
+}
+
+// RankInWindow returns a rank for an item dropped at index to of a
+// window of a list (0 for the top of the window, len(w.Ranks) for the
+// bottom), for clients that only have the window.  Between two items
+// of the window, the window is all it takes; at the top or bottom,
+// the item on the other side of the drop is off screen, so it is
+// looked up with neighbour (unless the window reaches that end of
+// the list), and the rank goes between the two.  It fails with
+// ErrNoRoom if there's no room there.
+func RankInWindow(ctx context.Context, w Window, to int, neighbour NeighbourFunc) (Posn, error) {
+	return Generator{}.RankInWindow(ctx, w, to, neighbour)
+}
+
+// RankInWindow is like the package-level RankInWindow, but uses the
+// generator's configuration.
+func (g Generator) RankInWindow(ctx context.Context, w Window, to int, neighbour NeighbourFunc) (Posn, error) {
+	n := len(w.Ranks)
+	if to < 0 || to > n {
+		return Posn{}, newError("index " + strconv.Itoa(to) + " out of range")
+	}
+	var prev, next *Posn
+	if to > 0 {
+		prev = &w.Ranks[to-1]
+	}
+	if to < n {
+		next = &w.Ranks[to]
+	}
+
+	var err error
+	switch {
+	case n == 0 && !(w.AtStart && w.AtEnd):
+		return Posn{}, newError("empty window with more of the list either side")
+	case to == 0 && !w.AtStart:
+		prev, err = neighbour(ctx, *next, true)
+	case to == n && !w.AtEnd:
+		next, err = neighbour(ctx, *prev, false)
+	}
+	if err != nil {
+		return Posn{}, err
+	}
+	r, ok := g.Ranks(1, prev, next)
+	if !ok {
+		return Posn{}, ErrNoRoom
+	}
+	return r[0], nil
+}
@@ -0,0 +1,63 @@
+package lexorank
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRankInWindow(t *testing.T) {
+	ctx := context.Background()
+	ranks, err := Rebalance(10, 0)
+	assert.NoError(t, err)
+	m := &MemStore{}
+	for i, p := range ranks {
+		m.Put("l", Item{strconv.Itoa(i), p})
+	}
+	nb := StoreNeighbours(m, "l")
+	w := Window{Ranks: ranks[3:6]}
+
+	between := func(p Posn, i, j int) {
+		t.Helper()
+		assert.Equal(t, 1, p.Compare(ranks[i]))
+		assert.Equal(t, -1, p.Compare(ranks[j]))
+	}
+
+	// inside the window
+	p, err := RankInWindow(ctx, w, 1, nb)
+	assert.NoError(t, err)
+	between(p, 3, 4)
+
+	// at the edges, the neighbours off screen are looked up
+	p, err = RankInWindow(ctx, w, 0, nb)
+	assert.NoError(t, err)
+	between(p, 2, 3)
+	p, err = RankInWindow(ctx, w, 3, nb)
+	assert.NoError(t, err)
+	between(p, 5, 6)
+
+	// unless the window goes to the end of the list
+	p, err = RankInWindow(ctx, Window{Ranks: ranks[7:], AtEnd: true}, 3, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, p.Compare(ranks[9]))
+	p, err = RankInWindow(ctx, Window{Ranks: ranks[:2], AtStart: true}, 0, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, -1, p.Compare(ranks[0]))
+	p, err = RankInWindow(ctx, Window{Ranks: ranks[:2]}, 0, nb)
+	assert.NoError(t, err)
+	assert.Equal(t, -1, p.Compare(ranks[0]))
+
+	_, err = RankInWindow(ctx, Window{AtStart: true, AtEnd: true}, 0, nil)
+	assert.NoError(t, err)
+	_, err = RankInWindow(ctx, Window{}, 0, nb)
+	assert.Error(t, err)
+	_, err = RankInWindow(ctx, w, 4, nb)
+	assert.Error(t, err)
+
+	oops := errors.New("oops")
+	_, err = RankInWindow(ctx, w, 0, func(context.Context, Posn, bool) (*Posn, error) { return nil, oops })
+	assert.ErrorIs(t, err, oops)
+}
@@ -0,0 +1,42 @@
+package lexorank
+
+import "math/big"
+
+// writerRange narrows the gap between the canonical bounds lo and hi
+// (with lo < hi) down to this generator's writer's share of it.  The
+// shares are disjoint and together cover the gap, so whatever one
+// writer picks from its share can't be picked by another.
+func (g Generator) writerRange(a Alphabet, lo, hi string) (string, string, bool) {
+	if g.Writer < 0 || g.Writer >= g.Writers {
+		return lo, hi, false
+	}
+	writers := big.NewInt(int64(g.Writers))
+
+	// read the bounds as numbers with enough digits that each share
+	// has some room in it
+	base := big.NewInt(int64(a.base()))
+	width := max(len(lo), len(hi))
+	vlo, vhi := digitsValue(a, lo, width), digitsValue(a, hi, width)
+	span := new(big.Int).Sub(vhi, vlo)
+	limit := new(big.Int).Mul(writers, big.NewInt(4))
+	for span.Cmp(limit) < 0 {
+		width++
+		vlo.Mul(vlo, base)
+		vhi.Mul(vhi, base)
+		span.Mul(span, base)
+	}
+
+	share := func(w int) string {
+		v := new(big.Int).Mul(span, big.NewInt(int64(w)))
+		v.Quo(v, writers)
+		return digitsString(a, v.Add(v, vlo), width)
+	}
+	sublo, subhi := lo, hi
+	if g.Writer > 0 {
+		sublo = share(g.Writer)
+	}
+	if g.Writer < g.Writers-1 {
+		subhi = share(g.Writer + 1)
+	}
+	return sublo, subhi, true
+}
@@ -0,0 +1,41 @@
+package lexorank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriters(t *testing.T) {
+	w0 := Generator{Writer: 0, Writers: 3}
+	w1 := Generator{Writer: 1, Writers: 3}
+	w2 := Generator{Writer: 2, Writers: 3}
+
+	r0, ok := w0.Rank("", "")
+	assert.Equal(t, true, ok)
+	r1, ok := w1.Rank("", "")
+	assert.Equal(t, true, ok)
+	r2, ok := w2.Rank("", "")
+	assert.Equal(t, true, ok)
+	assert.True(t, r0 < r1 && r1 < r2, "%s %s %s", r0, r1, r2)
+
+	// even in a tight spot, the writers keep apart, and stay
+	// between the bounds
+	for _, gap := range [][2]string{{"a", "b"}, {"a", "a1"}, {"azzz", "b"}, {"a0", "a00001"}} {
+		var got []string
+		for _, g := range []Generator{w0, w1, w2} {
+			r, ok := g.Rank(gap[0], gap[1])
+			assert.Equal(t, true, ok, gap)
+			assert.True(t, gap[0] < r && r < gap[1], "%s not in %v", r, gap)
+			got = append(got, r)
+		}
+		assert.True(t, got[0] < got[1] && got[1] < got[2], "%v", got)
+
+		b, ok := w1.AppendRank(nil, []byte(gap[0]), []byte(gap[1]))
+		assert.Equal(t, true, ok)
+		assert.Equal(t, got[1], string(b))
+	}
+
+	_, ok = Generator{Writer: 3, Writers: 3}.Rank("a", "b")
+	assert.Equal(t, false, ok)
+}